@@ -1,6 +1,8 @@
 package errors
 
 import (
+	"net/http"
+
 	"github.com/tidwall/gjson"
 )
 
@@ -17,7 +19,7 @@ import (
 type AntigravityParser struct{}
 
 // Parse implements ErrorParser for Antigravity/Google Cloud API
-func (p *AntigravityParser) Parse(statusCode int, body []byte) *ParsedError {
+func (p *AntigravityParser) Parse(statusCode int, body []byte, headers http.Header) *ParsedError {
 	errorStatus := gjson.GetBytes(body, "error.status").String()
 	message := gjson.GetBytes(body, "error.message").String()
 	reason := p.extractReason(body)
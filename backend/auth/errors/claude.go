@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/tidwall/gjson"
@@ -17,7 +18,7 @@ import (
 type ClaudeParser struct{}
 
 // Parse implements ErrorParser for Claude/Anthropic API
-func (p *ClaudeParser) Parse(statusCode int, body []byte) *ParsedError {
+func (p *ClaudeParser) Parse(statusCode int, body []byte, headers http.Header) *ParsedError {
 	errorType := gjson.GetBytes(body, "error.type").String()
 	message := gjson.GetBytes(body, "error.message").String()
 
@@ -40,6 +41,9 @@ func (p *ClaudeParser) Parse(statusCode int, body []byte) *ParsedError {
 		p.overrideByErrorType(parsed, errorType)
 	}
 
+	// Server-specified wait beats the fixed cooldowns
+	applyRetryAfter(parsed, headers)
+
 	return parsed
 }
 
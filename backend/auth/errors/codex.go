@@ -1,6 +1,8 @@
 package errors
 
 import (
+	"net/http"
+
 	"github.com/tidwall/gjson"
 )
 
@@ -16,7 +18,7 @@ import (
 type CodexParser struct{}
 
 // Parse implements ErrorParser for Codex/OpenAI API
-func (p *CodexParser) Parse(statusCode int, body []byte) *ParsedError {
+func (p *CodexParser) Parse(statusCode int, body []byte, headers http.Header) *ParsedError {
 	errorType := gjson.GetBytes(body, "error.type").String()
 	errorCode := gjson.GetBytes(body, "error.code").String()
 	message := gjson.GetBytes(body, "error.message").String()
@@ -41,6 +43,9 @@ func (p *CodexParser) Parse(statusCode int, body []byte) *ParsedError {
 		p.handle429(parsed, errorCode, errorType, message)
 	}
 
+	// Server-specified wait beats the fixed cooldowns
+	applyRetryAfter(parsed, headers)
+
 	return parsed
 }
 
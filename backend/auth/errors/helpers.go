@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +19,9 @@ const (
 	CooldownNotFound     = 12 * time.Hour
 )
 
+// MaxRetryAfter caps server-specified waits so a bogus header cannot park an account for days
+const MaxRetryAfter = 24 * time.Hour
+
 // parseByStatusCode creates ParsedError based on status code only (fallback)
 func parseByStatusCode(statusCode int, body []byte) *ParsedError {
 	parsed := &ParsedError{
@@ -83,8 +87,28 @@ func extractMessage(body []byte) string {
 	return ""
 }
 
+// applyRetryAfter sets the cooldown from a Retry-After header on throttling/quota errors
+func applyRetryAfter(parsed *ParsedError, headers http.Header) {
+	if headers == nil || (!parsed.Retryable && parsed.Type != ErrTypeQuotaExceeded) {
+		return
+	}
+
+	wait := parseRetryAfterHeader(headers.Get("Retry-After"), time.Now())
+	if wait <= 0 {
+		return
+	}
+	if wait > MaxRetryAfter {
+		wait = MaxRetryAfter
+	}
+
+	parsed.RetryAfter = wait
+	parsed.CooldownDur = wait
+}
+
 // parseRetryAfterHeader parses Retry-After header value to duration
-func parseRetryAfterHeader(value string) time.Duration {
+// Accepts delay-seconds ("120") or an HTTP-date ("Wed, 21 Oct 2015 07:28:00 GMT");
+// dates in the past yield 0.
+func parseRetryAfterHeader(value string, now time.Time) time.Duration {
 	value = strings.TrimSpace(value)
 	if value == "" {
 		return 0
@@ -92,10 +116,18 @@ func parseRetryAfterHeader(value string) time.Duration {
 
 	// Try parsing as seconds
 	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
 		return time.Duration(seconds) * time.Second
 	}
 
-	// Try parsing as HTTP date (not commonly used, skip for simplicity)
+	// Try parsing as HTTP date
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait
+		}
+	}
 	return 0
 }
 
@@ -0,0 +1,49 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// retryableNetMessages matches transport failures that were flattened to strings
+// (e.g. by proxy dialers that don't wrap the underlying syscall error)
+var retryableNetMessages = []string{
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"unexpected EOF",
+}
+
+// IsRetryableNetError checks if a transport error (no HTTP status) is worth retrying
+// Timeouts, refused/reset connections and truncated responses are retryable;
+// caller cancellation is not.
+func IsRetryableNetError(err error) bool {
+	if err == nil || stderrors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	if stderrors.Is(err, syscall.ECONNRESET) ||
+		stderrors.Is(err, syscall.ECONNREFUSED) ||
+		stderrors.Is(err, syscall.EPIPE) ||
+		stderrors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, pattern := range retryableNetMessages {
+		if containsIgnoreCase(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,9 +1,12 @@
 package errors
 
+import "net/http"
+
 // ErrorParser parses API error responses into structured ParsedError
 type ErrorParser interface {
 	// Parse extracts error information from HTTP response
-	Parse(statusCode int, body []byte) *ParsedError
+	// headers may be nil when the response headers are unavailable
+	Parse(statusCode int, body []byte, headers http.Header) *ParsedError
 }
 
 // GetParser returns appropriate error parser for provider
@@ -24,6 +27,8 @@ func GetParser(providerID string) ErrorParser {
 type DefaultParser struct{}
 
 // Parse implements ErrorParser for unknown providers
-func (p *DefaultParser) Parse(statusCode int, body []byte) *ParsedError {
-	return parseByStatusCode(statusCode, body)
+func (p *DefaultParser) Parse(statusCode int, body []byte, headers http.Header) *ParsedError {
+	parsed := parseByStatusCode(statusCode, body)
+	applyRetryAfter(parsed, headers)
+	return parsed
 }
@@ -48,6 +48,14 @@ func IsRetryableStatus(code int) bool {
 	return false
 }
 
+// StatusOverloaded is Anthropic's non-standard "overloaded" status
+const StatusOverloaded = 529
+
+// IsOverloadedStatus checks if the upstream is overloaded as a whole rather than the account failing
+func IsOverloadedStatus(code int) bool {
+	return code == StatusOverloaded
+}
+
 // IsAuthError checks if the status code indicates an authentication error
 func IsAuthError(code int) bool {
 	return code == 401 || code == 403
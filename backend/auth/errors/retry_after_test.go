@@ -0,0 +1,91 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfterHeader(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"seconds", "120", 120 * time.Second},
+		{"seconds with spaces", " 7 ", 7 * time.Second},
+		{"http date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"http date in past", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"negative", "-5", 0},
+		{"empty", "", 0},
+		{"garbage", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfterHeader(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfterHeader(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaudeParser_RetryAfterSeconds(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Retry-After", "42")
+	body := []byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+
+	parsed := (&ClaudeParser{}).Parse(429, body, headers)
+
+	if parsed.Type != ErrTypeRateLimit {
+		t.Fatalf("Type = %s, want rate_limit", parsed.Type)
+	}
+	if parsed.CooldownDur != 42*time.Second || parsed.RetryAfter != 42*time.Second {
+		t.Errorf("CooldownDur = %v, RetryAfter = %v, want 42s", parsed.CooldownDur, parsed.RetryAfter)
+	}
+}
+
+func TestCodexParser_RetryAfterHTTPDate(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Retry-After", time.Now().Add(10*time.Minute).UTC().Format(http.TimeFormat))
+	body := []byte(`{"error":{"code":"insufficient_quota","message":"You exceeded your current quota"}}`)
+
+	parsed := (&CodexParser{}).Parse(429, body, headers)
+
+	if parsed.Type != ErrTypeQuotaExceeded {
+		t.Fatalf("Type = %s, want quota_exceeded", parsed.Type)
+	}
+	// HTTP-date has second precision, so allow for rounding and elapsed time
+	if parsed.RetryAfter < 9*time.Minute || parsed.RetryAfter > 10*time.Minute {
+		t.Errorf("RetryAfter = %v, want ~10m", parsed.RetryAfter)
+	}
+}
+
+func TestParsers_RetryAfterIgnoredWithoutHeader(t *testing.T) {
+	body := []byte(`{"error":{"code":"rate_limit_exceeded","message":"rate limit"}}`)
+
+	parsed := (&CodexParser{}).Parse(429, body, nil)
+	if parsed.CooldownDur != CooldownRateLimit || parsed.RetryAfter != 0 {
+		t.Errorf("CooldownDur = %v, RetryAfter = %v, want default cooldown", parsed.CooldownDur, parsed.RetryAfter)
+	}
+
+	// Non-retryable errors keep their own handling even if the header is present
+	headers := http.Header{}
+	headers.Set("Retry-After", "5")
+	parsed = (&ClaudeParser{}).Parse(401, nil, headers)
+	if parsed.CooldownDur != CooldownAuthFailure {
+		t.Errorf("401 CooldownDur = %v, want %v", parsed.CooldownDur, CooldownAuthFailure)
+	}
+}
+
+func TestRetryAfter_Capped(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Retry-After", "31536000")
+
+	parsed := (&DefaultParser{}).Parse(429, nil, headers)
+	if parsed.CooldownDur != MaxRetryAfter {
+		t.Errorf("CooldownDur = %v, want cap %v", parsed.CooldownDur, MaxRetryAfter)
+	}
+}
@@ -24,6 +24,7 @@ type ParsedError struct {
 	Message     string        // Error message from API
 	Retryable   bool          // Whether request can be retried
 	CooldownDur time.Duration // Suggested cooldown before retry
+	RetryAfter  time.Duration // Server-specified wait from Retry-After header, 0 if absent
 	RawBody     []byte        // Original response body
 	RawType     string        // Original error type from API (e.g., "rate_limit_error")
 	RawCode     string        // Original error code from API (e.g., "insufficient_quota")
@@ -2,6 +2,7 @@ package manager
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"aigateway-backend/auth/errors"
@@ -21,6 +22,12 @@ type AccountState struct {
 	LastRefreshedAt  time.Time // When token was last refreshed
 	NextRefreshAfter time.Time // Backoff for refresh failures
 
+	inFlight       int64 // Requests selected but not yet marked (atomic)
+	lastSelectedAt int64 // Unix nanos of the last Select pick (atomic)
+	authFailures   int64 // Consecutive authentication failures (atomic)
+
+	events eventRing // Health transition timeline
+
 	mu sync.RWMutex // Protects state mutations
 }
 
@@ -48,6 +55,30 @@ func (a *AccountState) GetModelState(model string) *ModelState {
 	return ms
 }
 
+// InFlight returns the number of requests currently executing on this account
+func (a *AccountState) InFlight() int64 {
+	return atomic.LoadInt64(&a.inFlight)
+}
+
+// acquireInFlight increments the in-flight counter and returns the new value
+func (a *AccountState) acquireInFlight() int64 {
+	return atomic.AddInt64(&a.inFlight, 1)
+}
+
+// releaseInFlight decrements the in-flight counter without going below zero
+// Results can be marked for accounts that were never selected (e.g. fallback switches).
+func (a *AccountState) releaseInFlight() int64 {
+	for {
+		cur := atomic.LoadInt64(&a.inFlight)
+		if cur <= 0 {
+			return 0
+		}
+		if atomic.CompareAndSwapInt64(&a.inFlight, cur, cur-1) {
+			return cur - 1
+		}
+	}
+}
+
 // IsBlockedFor checks if account is blocked for specific model
 func (a *AccountState) IsBlockedFor(model string, now time.Time) (bool, BlockReason) {
 	a.mu.RLock()
@@ -85,8 +116,9 @@ func (a *AccountState) MarkSuccess(model string, now time.Time) {
 	ms.SuccessCount++
 	ms.ClearBlock()
 
-	// Reset quota backoff on success
+	// Reset quota backoff and the auth failure streak on success
 	a.QuotaState.Reset()
+	atomic.StoreInt64(&a.authFailures, 0)
 	a.UpdatedAt = now
 }
 
@@ -109,13 +141,20 @@ func (a *AccountState) MarkFailure(model string, err *errors.ParsedError, now ti
 	case errors.ErrTypeQuotaExceeded:
 		ms.BlockReason = BlockReasonQuota
 		a.QuotaState.Increment()
-		ms.NextRetryAfter = now.Add(a.QuotaState.NextBackoff())
+		if err.RetryAfter > 0 {
+			ms.NextRetryAfter = now.Add(err.RetryAfter)
+		} else {
+			ms.NextRetryAfter = now.Add(a.QuotaState.NextBackoff())
+		}
 
 	case errors.ErrTypeRateLimit:
 		ms.BlockReason = BlockReasonCooldown
 		ms.NextRetryAfter = now.Add(err.CooldownDur)
 
-	case errors.ErrTypeOverloaded, errors.ErrTypeTransient:
+	case errors.ErrTypeOverloaded:
+		// Provider-wide overload isn't the account's fault; the router backs off on the same account
+
+	case errors.ErrTypeTransient:
 		ms.BlockReason = BlockReasonCooldown
 		ms.NextRetryAfter = now.Add(err.CooldownDur)
 
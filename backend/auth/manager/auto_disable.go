@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"aigateway-backend/auth/errors"
+	"aigateway-backend/models"
+)
+
+// SetAuthFailureThreshold sets how many consecutive authentication failures (401 or
+// invalid_grant) permanently deactivate an account (0 = disabled)
+// Below the threshold an auth failure only cools the account down, so a transient 401
+// doesn't take it out of rotation; at the threshold it is disabled and IsActive=false is persisted.
+func (m *Manager) SetAuthFailureThreshold(threshold int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.authFailureThreshold = threshold
+}
+
+// SetOnAccountDisabled registers a callback fired when an account is auto-disabled
+// Runs synchronously on the request path; alerting hooks should not block.
+func (m *Manager) SetOnAccountDisabled(fn func(account *models.Account, reason string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onAccountDisabled = fn
+}
+
+// AuthFailures returns the account's current streak of consecutive authentication failures
+func (a *AccountState) AuthFailures() int64 {
+	return atomic.LoadInt64(&a.authFailures)
+}
+
+// isRevokedCredential reports whether a failure means the account's credentials were rejected
+func isRevokedCredential(parsed *errors.ParsedError, body []byte) bool {
+	return parsed.Type == errors.ErrTypeAuthentication || bytes.Contains(body, []byte("invalid_grant"))
+}
+
+// trackAuthFailure counts an authentication failure and deactivates the account at the threshold
+func (m *Manager) trackAuthFailure(acc *AccountState, parsed *errors.ParsedError, body []byte) {
+	m.mu.RLock()
+	threshold := m.authFailureThreshold
+	onDisabled := m.onAccountDisabled
+	m.mu.RUnlock()
+
+	if threshold <= 0 || !isRevokedCredential(parsed, body) {
+		return
+	}
+
+	streak := atomic.AddInt64(&acc.authFailures, 1)
+	if streak < int64(threshold) {
+		// Keep the account selectable once its auth cooldown expires
+		acc.mu.Lock()
+		acc.Disabled = false
+		acc.mu.Unlock()
+		return
+	}
+	if streak > int64(threshold) {
+		return // Already deactivated
+	}
+
+	acc.mu.Lock()
+	acc.Disabled = true
+	acc.Account.IsActive = false
+	acc.mu.Unlock()
+
+	if m.accountRepo != nil {
+		if err := m.accountRepo.UpdateActive(acc.Account.ID, false); err != nil {
+			log.Printf("%s Failed to deactivate account %s: %v", m.logger.prefix, acc.Account.ID, err)
+		}
+	}
+
+	// MarkResult logs the disable; the hook gets the threshold as context
+	if onDisabled != nil {
+		onDisabled(acc.Account, fmt.Sprintf("%d consecutive authentication failures", streak))
+	}
+}
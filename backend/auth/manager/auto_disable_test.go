@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+func TestAuthFailureThreshold_DisablesAtThreshold(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true})
+	m.SetAuthFailureThreshold(3)
+
+	var disabled []string
+	m.SetOnAccountDisabled(func(account *models.Account, reason string) {
+		disabled = append(disabled, account.ID)
+	})
+
+	acc := m.GetAccount("acc-1")
+	for i := 1; i <= 2; i++ {
+		m.MarkResult("acc-1", "gemini-2.5-pro", 401, nil, nil)
+		if acc.Disabled || !acc.Account.IsActive {
+			t.Fatalf("after %d failures: disabled = %v, active = %v, want still enabled", i, acc.Disabled, acc.Account.IsActive)
+		}
+	}
+
+	m.MarkResult("acc-1", "gemini-2.5-pro", 401, nil, nil)
+	if !acc.Disabled || acc.Account.IsActive {
+		t.Fatalf("after 3 failures: disabled = %v, active = %v, want disabled and inactive", acc.Disabled, acc.Account.IsActive)
+	}
+
+	// Further failures don't re-fire the hook
+	m.MarkResult("acc-1", "gemini-2.5-pro", 401, nil, nil)
+	if len(disabled) != 1 || disabled[0] != "acc-1" {
+		t.Errorf("OnAccountDisabled calls = %v, want exactly one for acc-1", disabled)
+	}
+
+	if _, err := m.Select(context.Background(), "antigravity", "claude-sonnet-4-5"); err == nil {
+		t.Error("Select() should fail once the only account is disabled")
+	}
+}
+
+func TestAuthFailureThreshold_SuccessResetsStreak(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true})
+	m.SetAuthFailureThreshold(2)
+
+	m.MarkResult("acc-1", "gemini-2.5-pro", 401, nil, nil)
+	m.MarkResult("acc-1", "gemini-2.5-pro", 200, nil, nil)
+	m.MarkResult("acc-1", "gemini-2.5-pro", 401, nil, nil)
+
+	if acc := m.GetAccount("acc-1"); acc.Disabled || acc.AuthFailures() != 1 {
+		t.Errorf("disabled = %v, streak = %d, want enabled with a streak of 1", acc.Disabled, acc.AuthFailures())
+	}
+}
+
+func TestAuthFailureThreshold_CountsInvalidGrant(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true})
+	m.SetAuthFailureThreshold(1)
+
+	m.MarkResult("acc-1", "gemini-2.5-pro", 400, []byte(`{"error":"invalid_grant","error_description":"Token has been revoked."}`), nil)
+
+	if acc := m.GetAccount("acc-1"); !acc.Disabled || acc.Account.IsActive {
+		t.Errorf("disabled = %v, active = %v, want an invalid_grant to deactivate the account", acc.Disabled, acc.Account.IsActive)
+	}
+}
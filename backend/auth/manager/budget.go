@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// BudgetStatus reports daily request budget usage for an account
+type BudgetStatus struct {
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// SetDailyRequestBudget sets the default per-account daily request budget (0 = unlimited)
+// Accounts can override it with "daily_request_budget" in their metadata.
+func (m *Manager) SetDailyRequestBudget(limit int64) {
+	atomic.StoreInt64(&m.dailyBudget, limit)
+}
+
+// GetBudgetStatus returns daily budget usage for an account, or nil when unlimited
+func (m *Manager) GetBudgetStatus(accountID string) *BudgetStatus {
+	m.mu.RLock()
+	acc, exists := m.accounts[accountID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+
+	limit := m.budgetLimit(acc)
+	if limit <= 0 || m.redis == nil {
+		return nil
+	}
+
+	used := m.budgetUsed(accountID)
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &BudgetStatus{
+		Limit:     limit,
+		Used:      used,
+		Remaining: remaining,
+		ResetsAt:  nextBudgetReset(time.Now()),
+	}
+}
+
+// budgetLimit returns the effective daily budget for an account (0 = unlimited)
+func (m *Manager) budgetLimit(acc *AccountState) int64 {
+	if override := gjson.Get(acc.Account.Metadata, "daily_request_budget"); override.Exists() {
+		return override.Int()
+	}
+	return atomic.LoadInt64(&m.dailyBudget)
+}
+
+// isOverBudget reports whether account has used its daily request budget
+// Fails open on Redis errors so a Redis outage never blocks all traffic.
+func (m *Manager) isOverBudget(acc *AccountState) bool {
+	limit := m.budgetLimit(acc)
+	if limit <= 0 || m.redis == nil {
+		return false
+	}
+	return m.budgetUsed(acc.Account.ID) >= limit
+}
+
+// budgetUsed returns today's request count for an account
+func (m *Manager) budgetUsed(accountID string) int64 {
+	used, err := m.redis.Get(context.Background(), budgetKey(accountID)).Int64()
+	if err != nil {
+		return 0
+	}
+	return used
+}
+
+// reserveBudget counts one request against the account's daily budget when it is selected
+// The increment itself decides, so concurrent selections can't take the account past its
+// budget; a request that doesn't fit is given back and false returned. Fails open on Redis errors.
+func (m *Manager) reserveBudget(acc *AccountState) bool {
+	limit := m.budgetLimit(acc)
+	if m.redis == nil || limit <= 0 {
+		return true
+	}
+
+	ctx := context.Background()
+	key := budgetKey(acc.Account.ID)
+
+	count, err := m.redis.Incr(ctx, key).Result()
+	if err != nil {
+		log.Printf("%s Failed to reserve budget for %s: %v", m.logger.prefix, acc.Account.ID, err)
+		return true
+	}
+
+	// First request of the day starts the window
+	if count == 1 {
+		m.redis.ExpireAt(ctx, key, nextBudgetReset(time.Now()))
+	}
+
+	if count > limit {
+		m.refundBudget(acc)
+		return false
+	}
+	return true
+}
+
+// refundBudget gives back a request reserved by reserveBudget that was never sent
+func (m *Manager) refundBudget(acc *AccountState) {
+	if m.redis == nil || m.budgetLimit(acc) <= 0 {
+		return
+	}
+	if err := m.redis.Decr(context.Background(), budgetKey(acc.Account.ID)).Err(); err != nil {
+		log.Printf("%s Failed to refund budget for %s: %v", m.logger.prefix, acc.Account.ID, err)
+	}
+}
+
+// budgetExhaustedError is returned when every remaining candidate lost its last budgeted request to a concurrent selection
+func budgetExhaustedError(now time.Time) *AllBlockedError {
+	resetAt := nextBudgetReset(now)
+	return &AllBlockedError{
+		WaitDuration: resetAt,
+		Message:      fmt.Sprintf("all accounts over daily budget, retry at %v", resetAt),
+	}
+}
+
+// budgetKey returns the Redis key for an account's daily request count
+// Format: auth:budget:{account_id}
+func budgetKey(accountID string) string {
+	return fmt.Sprintf("auth:budget:%s", accountID)
+}
+
+// nextBudgetReset returns the next UTC midnight after now
+func nextBudgetReset(now time.Time) time.Time {
+	y, mo, d := now.UTC().Date()
+	return time.Date(y, mo, d+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// setupBudgetManager creates a manager backed by miniredis with a single account
+func setupBudgetManager(t *testing.T, metadata string) (*miniredis.Miniredis, *Manager) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	m := NewManager(nil, client)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{
+		ID:         "acc-1",
+		ProviderID: "antigravity",
+		Metadata:   metadata,
+		IsActive:   true,
+	})
+
+	return mr, m
+}
+
+func TestDailyBudget_SkipsAccountAtBudget(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	m.SetDailyRequestBudget(2)
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	for i := 0; i < 2; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() #%d error = %v", i+1, err)
+		}
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+	}
+
+	_, err := m.Select(ctx, "antigravity", model)
+	allBlocked, ok := err.(*AllBlockedError)
+	if !ok {
+		t.Fatalf("Select() error = %v, want AllBlockedError", err)
+	}
+	if !allBlocked.WaitDuration.Equal(nextBudgetReset(time.Now())) {
+		t.Errorf("WaitDuration = %v, want next UTC midnight", allBlocked.WaitDuration)
+	}
+
+	status := m.GetBudgetStatus("acc-1")
+	if status == nil || status.Used != 2 || status.Remaining != 0 {
+		t.Errorf("GetBudgetStatus() = %+v, want used=2 remaining=0", status)
+	}
+}
+
+func TestDailyBudget_AvailableAfterReset(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	m.SetDailyRequestBudget(1)
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	acc, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.MarkResult(acc.Account.ID, model, 429, nil, nil)
+	acc.GetModelState(model).ClearBlock()
+
+	if _, err := m.Select(ctx, "antigravity", model); err == nil {
+		t.Fatal("Select() should fail while account is at its daily budget")
+	}
+
+	// Budget key expires at the next UTC midnight
+	mr.FastForward(24 * time.Hour)
+
+	if status := m.GetBudgetStatus("acc-1"); status.Remaining != 1 {
+		t.Errorf("Remaining after reset = %d, want 1", status.Remaining)
+	}
+	if _, err := m.Select(ctx, "antigravity", model); err != nil {
+		t.Fatalf("Select() after reset error = %v", err)
+	}
+}
+
+func TestDailyBudget_ConcurrentSelectsDontOvershoot(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	m.SetDailyRequestBudget(3)
+	model := "gemini-2.5-pro"
+
+	var wg sync.WaitGroup
+	var selected atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Select(context.Background(), "antigravity", model); err == nil {
+				selected.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := selected.Load(); got != 3 {
+		t.Errorf("selected %d times, want the budget of 3", got)
+	}
+	if status := m.GetBudgetStatus("acc-1"); status.Used != 3 {
+		t.Errorf("Used = %d, want 3", status.Used)
+	}
+}
+
+func TestDailyBudget_CountsEachRequestOnce(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	m.SetDailyRequestBudget(10)
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	acc, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+
+	acc, err = m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.Release(acc.Account.ID)
+
+	if status := m.GetBudgetStatus("acc-1"); status.Used != 2 {
+		t.Errorf("Used = %d after two requests, want 2", status.Used)
+	}
+}
+
+func TestDailyBudget_MetadataOverride(t *testing.T) {
+	mr, m := setupBudgetManager(t, `{"daily_request_budget": 5}`)
+	defer mr.Close()
+
+	if status := m.GetBudgetStatus("acc-1"); status == nil || status.Limit != 5 {
+		t.Fatalf("GetBudgetStatus() = %+v, want limit=5 from metadata", status)
+	}
+
+	m.SetDailyRequestBudget(0)
+	if status := m.GetBudgetStatus("acc-1"); status == nil || status.Limit != 5 {
+		t.Errorf("metadata budget should apply even when default is unlimited")
+	}
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"sort"
+	"time"
+)
+
+// Exclusion reasons reported by Candidates beyond the per-model BlockReason values
+const (
+	ExclusionDailyBudget    = "daily_budget"
+	ExclusionQuotaExhausted = "quota_exhausted"
+	ExclusionBlocked        = "blocked"
+	ExclusionModelPolicy    = "model_policy"
+)
+
+// CandidateStatus describes whether an account is selectable for a provider+model and why not
+type CandidateStatus struct {
+	AccountID        string     `json:"account_id"`
+	Label            string     `json:"label"`
+	Eligible         bool       `json:"eligible"`
+	Reason           string     `json:"reason,omitempty"`
+	RetryAt          *time.Time `json:"retry_at,omitempty"`
+	InFlight         int64      `json:"in_flight"`
+	RotationCooldown bool       `json:"rotation_cooldown"` // Eligible but deprioritized after a recent pick
+}
+
+// Candidates reports every account of a provider with its eligibility for model
+// Mirrors the filters applied by selectAvailable so operators can see why an account is skipped.
+func (m *Manager) Candidates(providerID, model string) []CandidateStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.clock()
+	result := make([]CandidateStatus, 0)
+
+	for _, acc := range m.accounts {
+		if acc.Account.ProviderID != providerID {
+			continue
+		}
+
+		status := CandidateStatus{
+			AccountID: acc.Account.ID,
+			Label:     acc.Account.Label,
+			InFlight:  acc.InFlight(),
+		}
+
+		if !acc.Account.AllowsModel(model) {
+			status.Reason = ExclusionModelPolicy
+		} else if blocked, reason := acc.IsBlockedFor(model, now); blocked {
+			status.Reason = string(reason)
+			if reason == BlockReasonNone {
+				status.Reason = ExclusionBlocked
+			}
+			if retryAt := acc.GetNextRetryTime(model); !retryAt.IsZero() {
+				status.RetryAt = &retryAt
+			}
+		} else if m.isOverBudget(acc) {
+			status.Reason = ExclusionDailyBudget
+			resetAt := nextBudgetReset(now)
+			status.RetryAt = &resetAt
+		} else if m.quotaTracker != nil && !m.quotaTracker.IsAvailable(acc.Account.ID, model) {
+			status.Reason = ExclusionQuotaExhausted
+			status.RetryAt = m.quotaTracker.GetEarliestReset([]string{acc.Account.ID}, model)
+		} else {
+			status.Eligible = true
+			status.RotationCooldown = m.inRotationCooldown(acc, now)
+		}
+
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].AccountID < result[j].AccountID
+	})
+
+	return result
+}
@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"sync"
+	"time"
+)
+
+// maxAccountEvents bounds the per-account timeline kept in memory
+const maxAccountEvents = 100
+
+// AccountEventType identifies a health transition of an account
+type AccountEventType string
+
+const (
+	AccountEventBlocked   AccountEventType = "blocked"
+	AccountEventUnblocked AccountEventType = "unblocked"
+	AccountEventDisabled  AccountEventType = "disabled"
+)
+
+// AccountEvent is one entry of an account's health timeline
+type AccountEvent struct {
+	Type   AccountEventType `json:"type"`
+	Model  string           `json:"model,omitempty"`
+	Reason string           `json:"reason"`
+	Until  *time.Time       `json:"until,omitempty"` // Block expiry, blocked events only
+	At     time.Time        `json:"at"`
+}
+
+// eventRing is a fixed-size ring buffer of account events (oldest overwritten first)
+type eventRing struct {
+	mu     sync.Mutex
+	events []AccountEvent
+	next   int
+}
+
+func (r *eventRing) add(event AccountEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) < maxAccountEvents {
+		r.events = append(r.events, event)
+		return
+	}
+	r.events[r.next] = event
+	r.next = (r.next + 1) % maxAccountEvents
+}
+
+// snapshot returns the events oldest first
+func (r *eventRing) snapshot() []AccountEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]AccountEvent, 0, len(r.events))
+	result = append(result, r.events[r.next:]...)
+	return append(result, r.events[:r.next]...)
+}
+
+// GetAccountEvents returns the health timeline of an account, oldest first
+// The second return value is false when the account is unknown.
+func (m *Manager) GetAccountEvents(accountID string) ([]AccountEvent, bool) {
+	acc := m.GetAccount(accountID)
+	if acc == nil {
+		return nil, false
+	}
+	return acc.events.snapshot(), true
+}
+
+// blockReasonFor returns the model's current block reason without creating its state
+func (a *AccountState) blockReasonFor(model string) BlockReason {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if ms, exists := a.ModelStates[model]; exists {
+		return ms.BlockReason
+	}
+	return BlockReasonNone
+}
+
+// isDisabled reports the account-level disable flag under the state lock
+func (a *AccountState) isDisabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.Disabled
+}
+
+// recordEvent appends a transition to the account's timeline
+func (a *AccountState) recordEvent(eventType AccountEventType, model, reason string, until time.Time, now time.Time) {
+	event := AccountEvent{
+		Type:   eventType,
+		Model:  model,
+		Reason: reason,
+		At:     now,
+	}
+	if !until.IsZero() {
+		event.Until = &until
+	}
+	a.events.add(event)
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"aigateway-backend/auth/errors"
+)
+
+func TestAccountEvents_BlockThenRecover(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.RegisterParser("antigravity", &errors.ClaudeParser{})
+
+	model := "claude-sonnet-4-5"
+	acc, err := m.Select(context.Background(), "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.MarkResult(acc.Account.ID, model, 429, []byte(`{"error":{"type":"rate_limit_error"}}`), nil)
+	m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+
+	events, ok := m.GetAccountEvents(acc.Account.ID)
+	if !ok {
+		t.Fatalf("GetAccountEvents(%q) reported unknown account", acc.Account.ID)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(events), events)
+	}
+
+	blocked, unblocked := events[0], events[1]
+	if blocked.Type != AccountEventBlocked || blocked.Model != model || blocked.Reason != string(BlockReasonCooldown) {
+		t.Errorf("first event = %+v, want blocked on %s with reason %s", blocked, model, BlockReasonCooldown)
+	}
+	if blocked.Until == nil || !blocked.Until.After(blocked.At) {
+		t.Errorf("blocked event Until = %v, want a time after %v", blocked.Until, blocked.At)
+	}
+	if unblocked.Type != AccountEventUnblocked || unblocked.Model != model || unblocked.Reason != "success" {
+		t.Errorf("second event = %+v, want unblocked on %s with reason success", unblocked, model)
+	}
+}
+
+func TestAccountEvents_UnknownAccount(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	if _, ok := m.GetAccountEvents("missing"); ok {
+		t.Error("GetAccountEvents() ok = true for an unknown account")
+	}
+}
+
+func TestEventRing_KeepsNewestOldestFirst(t *testing.T) {
+	var r eventRing
+	for i := 0; i < maxAccountEvents+5; i++ {
+		r.add(AccountEvent{Reason: string(rune('a' + i%26))})
+	}
+
+	events := r.snapshot()
+	if len(events) != maxAccountEvents {
+		t.Fatalf("len = %d, want %d", len(events), maxAccountEvents)
+	}
+	if got, want := events[0].Reason, string(rune('a'+5%26)); got != want {
+		t.Errorf("oldest reason = %q, want %q", got, want)
+	}
+	if got, want := events[len(events)-1].Reason, string(rune('a'+(maxAccountEvents+4)%26)); got != want {
+		t.Errorf("newest reason = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"context"
+	"strings"
+)
+
+// ExcludeAccountsHeader lets admins keep specific accounts out of selection for one request
+const ExcludeAccountsHeader = "X-Exclude-Accounts"
+
+type excludedAccountsKey struct{}
+
+// ParseExcludedAccounts splits a comma-separated X-Exclude-Accounts value into account IDs
+func ParseExcludedAccounts(header string) []string {
+	var ids []string
+	for _, id := range strings.Split(header, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// WithExcludedAccounts returns a context whose Select calls skip the given account IDs
+// in addition to any already excluded by ctx
+func WithExcludedAccounts(ctx context.Context, accountIDs []string) context.Context {
+	if len(accountIDs) == 0 {
+		return ctx
+	}
+	inherited := excludedAccounts(ctx)
+	excluded := make(map[string]bool, len(inherited)+len(accountIDs))
+	for id := range inherited {
+		excluded[id] = true
+	}
+	for _, id := range accountIDs {
+		excluded[id] = true
+	}
+	return context.WithValue(ctx, excludedAccountsKey{}, excluded)
+}
+
+// excludedAccounts returns the per-request exclusion set carried by ctx (nil when none)
+func excludedAccounts(ctx context.Context) map[string]bool {
+	if ctx == nil {
+		return nil
+	}
+	excluded, _ := ctx.Value(excludedAccountsKey{}).(map[string]bool)
+	return excluded
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+func TestSelect_SkipsExcludedAccounts(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	// acc-1 has the highest priority, so it is picked whenever it isn't excluded
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", Metadata: `{"priority":1}`, IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-3", ProviderID: "antigravity", IsActive: true})
+
+	ctx := WithExcludedAccounts(context.Background(), ParseExcludedAccounts(" acc-1, acc-3 ,"))
+	for i := 0; i < 4; i++ {
+		acc, err := m.Select(ctx, "antigravity", "gemini-2.5-pro")
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		if acc.Account.ID != "acc-2" {
+			t.Fatalf("Select() = %s, want acc-2 (acc-1 and acc-3 excluded)", acc.Account.ID)
+		}
+		m.MarkResult(acc.Account.ID, "gemini-2.5-pro", 200, nil, nil)
+	}
+
+	// Exclusion is scoped to the context; other requests get acc-1 back
+	acc, err := m.Select(context.Background(), "antigravity", "gemini-2.5-pro")
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if acc.Account.ID != "acc-1" {
+		t.Errorf("Select() without exclusion = %s, want acc-1", acc.Account.ID)
+	}
+}
+
+func TestWithExcludedAccounts_AddsToInheritedSet(t *testing.T) {
+	ctx := WithExcludedAccounts(context.Background(), []string{"acc-1"})
+	ctx = WithExcludedAccounts(ctx, []string{"acc-2"})
+
+	excluded := excludedAccounts(ctx)
+	if !excluded["acc-1"] || !excluded["acc-2"] {
+		t.Errorf("excluded = %v, want acc-1 and acc-2", excluded)
+	}
+}
+
+func TestSelect_AllAccountsExcluded(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true})
+
+	ctx := WithExcludedAccounts(context.Background(), []string{"acc-1"})
+	if acc, err := m.Select(ctx, "antigravity", "gemini-2.5-pro"); err == nil {
+		t.Fatalf("Select() = %s, want an error when every account is excluded", acc.Account.ID)
+	}
+}
+
+func TestParseExcludedAccounts(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"acc-1", []string{"acc-1"}},
+		{"acc-1, acc-2,,", []string{"acc-1", "acc-2"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseExcludedAccounts(tt.header); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseExcludedAccounts(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+)
+
+// fakeQuotaTracker reports fixed headroom per account; accounts without an entry are unlearned
+type fakeQuotaTracker struct {
+	headroom map[string]int
+}
+
+func (f *fakeQuotaTracker) RecordUsage(accountID, model string, tokens int64) {}
+func (f *fakeQuotaTracker) MarkExhausted(accountID, model string)             {}
+func (f *fakeQuotaTracker) IsAvailable(accountID, model string) bool          { return true }
+
+func (f *fakeQuotaTracker) GetEarliestReset(accountIDs []string, model string) *time.Time {
+	return nil
+}
+
+func (f *fakeQuotaTracker) RemainingHeadroom(accountID, model string) (int, bool) {
+	h, ok := f.headroom[accountID]
+	return h, ok
+}
+
+func TestSelect_AvoidsAccountNearLearnedLimit(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+
+	// acc-1 has 2 requests left, acc-2 has 80
+	m.SetQuotaTracker(&fakeQuotaTracker{headroom: map[string]int{"acc-1": 2, "acc-2": 80}}, nil)
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	for i := 0; i < 5; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+		if acc.Account.ID != "acc-2" {
+			t.Fatalf("Select() #%d = %s, want acc-2 (most headroom)", i+1, acc.Account.ID)
+		}
+	}
+}
+
+func TestSelect_RoundRobinWhenLimitsUnlearned(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+	m.SetQuotaTracker(&fakeQuotaTracker{}, nil)
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+		seen[acc.Account.ID] = true
+	}
+
+	if len(seen) != 2 {
+		t.Errorf("selected accounts = %v, want both accounts via round-robin", seen)
+	}
+}
+
+func TestSelect_LoadBeforeHeadroom(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+	m.SetQuotaTracker(&fakeQuotaTracker{headroom: map[string]int{"acc-1": 2, "acc-2": 80}}, nil)
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	// acc-2 has the most headroom but is busy, so the idle acc-1 takes the next request
+	first, err := m.Select(ctx, "antigravity", model)
+	if err != nil || first.Account.ID != "acc-2" {
+		t.Fatalf("Select() = %v, %v, want acc-2", first, err)
+	}
+	second, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if second.Account.ID != "acc-1" {
+		t.Errorf("Select() = %s, want the idle acc-1", second.Account.ID)
+	}
+}
+
+func TestSelect_UnlearnedAccountsShareWithBestLearned(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-3", ProviderID: "antigravity", IsActive: true})
+
+	// acc-3 is unlearned: it neither always wins nor is avoided; acc-1 is near its limit
+	m.SetQuotaTracker(&fakeQuotaTracker{headroom: map[string]int{"acc-1": 2, "acc-2": 80}}, nil)
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	seen := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+		seen[acc.Account.ID] = true
+	}
+
+	if seen["acc-1"] || !seen["acc-2"] || !seen["acc-3"] {
+		t.Errorf("selected accounts = %v, want acc-2 and acc-3 only", seen)
+	}
+}
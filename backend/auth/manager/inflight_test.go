@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+func TestSelect_SpreadsConcurrentLoad(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	for _, id := range []string{"acc-2", "acc-3"} {
+		m.AddAccount(&models.Account{ID: id, ProviderID: "antigravity", IsActive: true})
+	}
+
+	model := "gemini-2.5-pro"
+	const requests = 30
+
+	var wg sync.WaitGroup
+	selected := make(chan string, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			acc, err := m.Select(context.Background(), "antigravity", model)
+			if err != nil {
+				t.Errorf("Select() error = %v", err)
+				return
+			}
+			selected <- acc.Account.ID
+		}()
+	}
+	wg.Wait()
+	close(selected)
+
+	// Requests are still in flight, so each account should carry an equal share
+	for _, id := range []string{"acc-1", "acc-2", "acc-3"} {
+		if got := m.GetAccount(id).InFlight(); got != requests/3 {
+			t.Errorf("%s in-flight = %d, want %d", id, got, requests/3)
+		}
+	}
+
+	gauge := m.metrics.Summary()["in_flight"].(map[string]int64)
+	if gauge["acc-1"] != requests/3 {
+		t.Errorf("in_flight gauge acc-1 = %d, want %d", gauge["acc-1"], requests/3)
+	}
+
+	for id := range selected {
+		m.MarkResult(id, model, 200, nil, nil)
+	}
+	for _, id := range []string{"acc-1", "acc-2", "acc-3"} {
+		if got := m.GetAccount(id).InFlight(); got != 0 {
+			t.Errorf("%s in-flight after MarkResult = %d, want 0", id, got)
+		}
+	}
+}
+
+func TestSelect_PrefersLeastLoaded(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	first, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	// The busy account is skipped until its request completes
+	for i := 0; i < 3; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		if i == 0 && acc.Account.ID == first.Account.ID {
+			t.Errorf("Select() picked busy account %s", first.Account.ID)
+		}
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+	}
+
+	m.MarkResult(first.Account.ID, model, 200, nil, nil)
+	if got := first.InFlight(); got != 0 {
+		t.Errorf("in-flight = %d, want 0", got)
+	}
+}
+
+func TestMarkResult_InFlightNeverNegative(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	m.MarkResult("acc-1", "gemini-2.5-pro", 200, nil, nil)
+	if got := m.GetAccount("acc-1").InFlight(); got != 0 {
+		t.Errorf("in-flight = %d, want 0", got)
+	}
+}
@@ -0,0 +1,42 @@
+package manager
+
+import (
+	"math/rand"
+	"time"
+
+	"aigateway-backend/auth/errors"
+)
+
+// DefaultCooldownJitter is the largest extra share of a computed cooldown added at random
+const DefaultCooldownJitter = 0.2
+
+// SetCooldownJitter sets the random extra share added to computed cooldowns (0 = disabled)
+// Accounts blocked by the same provider-wide limit then recover staggered instead of all at
+// once. Jitter only lengthens a cooldown and never applies to an upstream Retry-After.
+func (m *Manager) SetCooldownJitter(fraction float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cooldownJitter = fraction
+}
+
+// applyCooldownJitter pushes a fresh rate-limit or quota block back by a random fraction of its length
+func (m *Manager) applyCooldownJitter(acc *AccountState, model string, parsed *errors.ParsedError, now time.Time) {
+	m.mu.RLock()
+	fraction := m.cooldownJitter
+	m.mu.RUnlock()
+	if fraction <= 0 || parsed.RetryAfter > 0 {
+		return
+	}
+
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	ms, exists := acc.ModelStates[model]
+	if !exists || (ms.BlockReason != BlockReasonCooldown && ms.BlockReason != BlockReasonQuota) {
+		return
+	}
+	cooldown := ms.NextRetryAfter.Sub(now)
+	if maxJitter := int64(float64(cooldown) * fraction); maxJitter > 0 {
+		ms.NextRetryAfter = ms.NextRetryAfter.Add(time.Duration(rand.Int63n(maxJitter + 1)))
+	}
+}
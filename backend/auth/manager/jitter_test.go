@@ -0,0 +1,59 @@
+package manager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"aigateway-backend/auth/errors"
+	"aigateway-backend/models"
+)
+
+func TestCooldownJitter_StaggersSimultaneousBlocks(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.RegisterParser("antigravity", &errors.ClaudeParser{})
+
+	model := "claude-sonnet-4-5"
+	ids := []string{"acc-1"}
+	for i := 2; i <= 6; i++ {
+		id := fmt.Sprintf("acc-%d", i)
+		m.AddAccount(&models.Account{ID: id, ProviderID: "antigravity", IsActive: true})
+		ids = append(ids, id)
+	}
+
+	// Provider-wide rate limit hits every account in the same tick
+	before := time.Now()
+	for _, id := range ids {
+		m.MarkResult(id, model, 429, []byte(`{"type":"error","error":{"type":"rate_limit_error"}}`), nil)
+	}
+	after := time.Now()
+
+	distinct := make(map[time.Time]bool)
+	maxJitter := time.Duration(float64(errors.CooldownRateLimit) * DefaultCooldownJitter)
+	for _, id := range ids {
+		next := m.GetAccount(id).GetNextRetryTime(model)
+		if next.Before(before.Add(errors.CooldownRateLimit)) || next.After(after.Add(errors.CooldownRateLimit+maxJitter)) {
+			t.Errorf("%s NextRetryAfter is %v after the 429, want within [%v, %v]", id, next.Sub(before), errors.CooldownRateLimit, errors.CooldownRateLimit+maxJitter)
+		}
+		distinct[next] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("all %d accounts recover at the same instant, want staggered NextRetryAfter", len(ids))
+	}
+}
+
+func TestCooldownJitter_Disabled(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.RegisterParser("antigravity", &errors.ClaudeParser{})
+	m.SetCooldownJitter(0)
+
+	model := "claude-sonnet-4-5"
+	before := time.Now()
+	m.MarkResult("acc-1", model, 429, []byte(`{"type":"error","error":{"type":"rate_limit_error"}}`), nil)
+
+	if wait := m.GetAccount("acc-1").GetNextRetryTime(model).Sub(before); wait > errors.CooldownRateLimit+time.Second {
+		t.Errorf("NextRetryAfter is %v after the 429 with jitter disabled, want ~%v", wait, errors.CooldownRateLimit)
+	}
+}
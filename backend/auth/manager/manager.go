@@ -3,6 +3,8 @@ package manager
 import (
 	"context"
 	"fmt"
+	"log"
+	"net/http"
 	"sync"
 	"time"
 
@@ -19,6 +21,7 @@ type QuotaTracker interface {
 	MarkExhausted(accountID, model string)
 	IsAvailable(accountID, model string) bool
 	GetEarliestReset(accountIDs []string, model string) *time.Time
+	RemainingHeadroom(accountID, model string) (int, bool) // false when the limit is unlearned
 }
 
 // TokenExtractor interface for extracting tokens from response
@@ -26,6 +29,11 @@ type TokenExtractor interface {
 	ExtractTokens(providerID string, payload []byte) int64
 }
 
+// TokenWarmth reports whether an account's access token is cached and valid (avoid circular import)
+type TokenWarmth interface {
+	HasWarmToken(account *models.Account) bool
+}
+
 // Manager manages account states for all providers
 type Manager struct {
 	accounts map[string]*AccountState // key: account ID
@@ -45,12 +53,44 @@ type Manager struct {
 	quotaTracker   QuotaTracker
 	tokenExtractor TokenExtractor
 
+	// Default per-account daily request budget (0 = unlimited)
+	dailyBudget int64
+
+	// Minimum interval between selections of the same account (0 = disabled)
+	rotationCooldown time.Duration
+
+	// Route requests to priority/standard pools by Claude service_tier
+	serviceTierRouting bool
+
+	// Prefer accounts with a cached access token among otherwise equal ones (nil = disabled)
+	tokenWarmth TokenWarmth
+
+	// Random extra share added to computed cooldowns (0 = disabled)
+	cooldownJitter float64
+
+	// Consecutive auth failures before an account is deactivated (0 = disabled)
+	authFailureThreshold int
+	onAccountDisabled    func(account *models.Account, reason string)
+
+	// Serializes account pick + in-flight acquire across concurrent selects
+	selectMu sync.Mutex
+
+	// Traffic ramp after recovering from all-blocked
+	slowStart *slowStart
+	clock     func() time.Time
+
 	// Background refresh control
 	refreshCancel context.CancelFunc
 
 	// Background reconciliation control
 	reconcileCancel context.CancelFunc
 
+	// Background metrics snapshot control
+	metricsCancel context.CancelFunc
+
+	// Background account state snapshot control (non-nil = persistence enabled)
+	stateCancel context.CancelFunc
+
 	// Observability
 	metrics *Metrics
 	logger  *StateLogger
@@ -69,6 +109,10 @@ func NewManager(
 		refreshers:   make(map[string]TokenRefresher),
 		metrics:      NewMetrics(),
 		logger:       NewStateLogger(true),
+		slowStart:    newSlowStart(),
+		clock:        time.Now,
+
+		cooldownJitter: DefaultCooldownJitter,
 	}
 
 	// Register default error parsers
@@ -131,23 +175,66 @@ func (m *Manager) LoadAccounts(ctx context.Context, providerIDs ...string) error
 		m.logger.LogAccountLoaded(providerID, len(accounts))
 	}
 
+	// Carry blocks and cooldowns over from before a restart
+	if m.stateCancel != nil {
+		if err := m.restoreStates(ctx); err != nil {
+			log.Printf("%s Failed to restore account states: %v", m.logger.prefix, err)
+		}
+	}
+
 	return nil
 }
 
 // Select picks best available account for provider and model
+// Accounts excluded via WithExcludedAccounts on ctx are never picked.
 func (m *Manager) Select(ctx context.Context, providerID, model string) (*AccountState, error) {
+	return m.SelectForTier(ctx, providerID, model, "")
+}
+
+// SelectForTier picks best available account, preferring the pool that matches the
+// requested Claude service_tier when tier routing is enabled ("" = no preference)
+func (m *Manager) SelectForTier(ctx context.Context, providerID, model, tier string) (*AccountState, error) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	candidates := m.getCandidates(providerID, model, excludedAccounts(ctx))
+	m.mu.RUnlock()
 
-	candidates := m.getCandidates(providerID)
 	if len(candidates) == 0 {
 		m.metrics.RecordSelect(false, false)
-		return nil, fmt.Errorf("no accounts for provider %s", providerID)
+		return nil, fmt.Errorf("no accounts for provider %s (model %s)", providerID, model)
+	}
+
+	rampKey := slowStartKey(providerID, model)
+
+	// Redis and quota reads happen here, outside both locks
+	available, err := m.selectAvailable(candidates, model, tier)
+	var acc *AccountState
+	if err == nil {
+		hints := m.loadSelectionHints(available, model)
+
+		for {
+			// Serialize pick+acquire so concurrent selects see each other's in-flight load
+			m.selectMu.Lock()
+			acc = pickBest(available, hints)
+			m.metrics.SetInFlight(acc.Account.ID, acc.acquireInFlight())
+			m.selectMu.Unlock()
+
+			if m.reserveBudget(acc) {
+				break
+			}
+
+			// A concurrent selection took the account's last budgeted request
+			m.metrics.SetInFlight(acc.Account.ID, acc.releaseInFlight())
+			available = withoutAccount(available, acc)
+			if len(available) == 0 {
+				acc, err = nil, budgetExhaustedError(time.Now())
+				break
+			}
+		}
 	}
 
-	acc, err := m.selectBest(candidates, model)
 	if err != nil {
 		if _, ok := err.(*AllBlockedError); ok {
+			m.slowStart.markAllBlocked(rampKey)
 			m.metrics.RecordSelect(false, true)
 			m.logger.LogAllBlocked(providerID, model, time.Now())
 		} else {
@@ -156,6 +243,19 @@ func (m *Manager) Select(ctx context.Context, providerID, model string) (*Accoun
 		return nil, err
 	}
 
+	// Throttle traffic while recently-recovered accounts ramp back up
+	now := m.clock()
+	if !m.slowStart.admit(rampKey, now) {
+		m.refundBudget(acc)
+		m.metrics.SetInFlight(acc.Account.ID, acc.releaseInFlight())
+		m.metrics.RecordSelect(false, true)
+		return nil, &AllBlockedError{
+			WaitDuration: now.Add(slowStartRetryDelay),
+			Message:      fmt.Sprintf("slow-start: throttling %s/%s after recovery", providerID, model),
+		}
+	}
+
+	acc.markSelected(now)
 	m.metrics.RecordSelect(true, false)
 	m.metrics.RecordRotation(providerID)
 	m.logger.LogAccountSelected(acc.Account.ID, providerID, model)
@@ -163,8 +263,24 @@ func (m *Manager) Select(ctx context.Context, providerID, model string) (*Accoun
 	return acc, nil
 }
 
+// Release ends a request that finished without a result to judge the account by, such as a
+// stream the client abandoned: the in-flight slot is freed and the request keeps its reserved
+// daily budget, but health, cooldowns and quota are left untouched
+func (m *Manager) Release(accountID string) {
+	m.mu.RLock()
+	acc, exists := m.accounts[accountID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	m.metrics.SetInFlight(accountID, acc.releaseInFlight())
+}
+
 // MarkResult updates account state based on execution result
-func (m *Manager) MarkResult(accountID, model string, statusCode int, body []byte) {
+// headers are the upstream response headers (may be nil); Retry-After sets the cooldown.
+func (m *Manager) MarkResult(accountID, model string, statusCode int, body []byte, headers http.Header) {
 	m.mu.RLock()
 	acc, exists := m.accounts[accountID]
 	m.mu.RUnlock()
@@ -174,12 +290,20 @@ func (m *Manager) MarkResult(accountID, model string, statusCode int, body []byt
 	}
 
 	now := time.Now()
+	prevReason := acc.blockReasonFor(model)
+	wasDisabled := acc.isDisabled()
+
+	// Request finished executing on this account; its daily budget was reserved at selection
+	m.metrics.SetInFlight(accountID, acc.releaseInFlight())
 
 	// Success case
 	if statusCode >= 200 && statusCode < 300 {
 		acc.MarkSuccess(model, now)
 		m.logger.LogSuccess(accountID, model)
 		m.metrics.UpdateAccountHealth(acc)
+		if prevReason != BlockReasonNone {
+			acc.recordEvent(AccountEventUnblocked, model, "success", time.Time{}, now)
+		}
 
 		// Track quota usage (extract tokens from response)
 		if m.quotaTracker != nil && m.tokenExtractor != nil {
@@ -191,8 +315,10 @@ func (m *Manager) MarkResult(accountID, model string, statusCode int, body []byt
 
 	// Parse error
 	parser := m.getParser(acc.Account.ProviderID)
-	parsed := parser.Parse(statusCode, body)
+	parsed := parser.Parse(statusCode, body, headers)
 	acc.MarkFailure(model, parsed, now)
+	m.applyCooldownJitter(acc, model, parsed, now)
+	m.trackAuthFailure(acc, parsed, body)
 
 	// Check for quota exhaustion
 	if parsed.Type == errors.ErrTypeQuotaExceeded && m.quotaTracker != nil {
@@ -209,11 +335,15 @@ func (m *Manager) MarkResult(accountID, model string, statusCode int, body []byt
 	if ms.BlockReason != BlockReasonNone {
 		m.metrics.RecordCooldown(ms.BlockReason)
 		m.logger.LogAccountBlocked(accountID, model, ms.BlockReason, ms.NextRetryAfter)
+		acc.recordEvent(AccountEventBlocked, model, string(ms.BlockReason), ms.NextRetryAfter, now)
 	}
 
 	// Check if account was disabled
-	if acc.Disabled {
+	if acc.isDisabled() {
 		m.logger.LogAccountDisabled(accountID, string(parsed.Type))
+		if !wasDisabled {
+			acc.recordEvent(AccountEventDisabled, "", string(parsed.Type), time.Time{}, now)
+		}
 	}
 }
 
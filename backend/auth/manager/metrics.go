@@ -17,6 +17,9 @@ type Metrics struct {
 	// Account health snapshots
 	healthSnapshots sync.Map // map[string]*AccountHealth
 
+	// In-flight request gauge per account
+	inFlight sync.Map // map[string]*int64
+
 	// Retry counts
 	retryTotal   int64
 	retrySuccess int64
@@ -78,6 +81,22 @@ func (m *Metrics) RecordSelect(success bool, allBlocked bool) {
 	}
 }
 
+// SetInFlight updates the in-flight gauge for an account
+func (m *Metrics) SetInFlight(accountID string, count int64) {
+	val, _ := m.inFlight.LoadOrStore(accountID, new(int64))
+	atomic.StoreInt64(val.(*int64), count)
+}
+
+// GetInFlight returns current in-flight requests per account
+func (m *Metrics) GetInFlight() map[string]int64 {
+	result := make(map[string]int64)
+	m.inFlight.Range(func(key, value interface{}) bool {
+		result[key.(string)] = atomic.LoadInt64(value.(*int64))
+		return true
+	})
+	return result
+}
+
 // RecordRetry records a retry attempt
 func (m *Metrics) RecordRetry(success bool) {
 	atomic.AddInt64(&m.retryTotal, 1)
@@ -178,6 +197,7 @@ func (m *Metrics) Summary() map[string]interface{} {
 		"cooldown_events":  m.GetCooldownEvents(),
 		"selection_stats":  m.GetSelectionStats(),
 		"retry_stats":      m.GetRetryStats(),
+		"in_flight":        m.GetInFlight(),
 		"account_count":    len(m.GetAccountHealths()),
 	}
 }
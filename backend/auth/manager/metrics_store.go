@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// metricsKey is the Redis hash holding the last persisted counter snapshot
+// Format: auth:metrics -> {rotation:{provider}, cooldown:{reason}, select_total, ...}
+const metricsKey = "auth:metrics"
+
+// Hash field prefixes for per-provider and per-reason counters
+const (
+	rotationFieldPrefix = "rotation:"
+	cooldownFieldPrefix = "cooldown:"
+)
+
+// scalarCounters maps hash fields to the fixed counters of m
+func (m *Metrics) scalarCounters() map[string]*int64 {
+	return map[string]*int64{
+		"select_total":   &m.selectTotal,
+		"select_success": &m.selectSuccess,
+		"select_blocked": &m.selectBlocked,
+		"retry_total":    &m.retryTotal,
+		"retry_success":  &m.retrySuccess,
+	}
+}
+
+// Snapshot returns all cumulative counters keyed by their Redis hash field
+// Gauges (in-flight, account health) describe current state and are not included.
+func (m *Metrics) Snapshot() map[string]int64 {
+	result := make(map[string]int64)
+	for provider, count := range m.GetRotationCounts() {
+		result[rotationFieldPrefix+provider] = count
+	}
+	for reason, count := range m.GetCooldownEvents() {
+		result[cooldownFieldPrefix+reason] = count
+	}
+	for field, counter := range m.scalarCounters() {
+		result[field] = atomic.LoadInt64(counter)
+	}
+	return result
+}
+
+// Restore adds a snapshot's counters onto m
+// Call before serving traffic so restored totals continue where the previous process stopped.
+func (m *Metrics) Restore(snapshot map[string]int64) {
+	scalars := m.scalarCounters()
+	for field, count := range snapshot {
+		switch {
+		case strings.HasPrefix(field, rotationFieldPrefix):
+			val, _ := m.rotationCounts.LoadOrStore(strings.TrimPrefix(field, rotationFieldPrefix), new(int64))
+			atomic.AddInt64(val.(*int64), count)
+		case strings.HasPrefix(field, cooldownFieldPrefix):
+			val, _ := m.cooldownEvents.LoadOrStore(BlockReason(strings.TrimPrefix(field, cooldownFieldPrefix)), new(int64))
+			atomic.AddInt64(val.(*int64), count)
+		default:
+			if counter, ok := scalars[field]; ok {
+				atomic.AddInt64(counter, count)
+			}
+		}
+	}
+}
+
+// SaveMetrics writes the current counters to Redis, replacing the previous snapshot
+func (m *Manager) SaveMetrics(ctx context.Context) error {
+	if m.redis == nil {
+		return nil
+	}
+
+	values := make(map[string]interface{})
+	for field, count := range m.metrics.Snapshot() {
+		values[field] = count
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return m.redis.HSet(ctx, metricsKey, values).Err()
+}
+
+// RestoreMetrics loads the persisted counters from Redis into the metrics collector
+func (m *Manager) RestoreMetrics(ctx context.Context) error {
+	if m.redis == nil {
+		return nil
+	}
+
+	fields, err := m.redis.HGetAll(ctx, metricsKey).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	snapshot := make(map[string]int64, len(fields))
+	for field, raw := range fields {
+		count, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			continue
+		}
+		snapshot[field] = count
+	}
+	m.metrics.Restore(snapshot)
+	return nil
+}
+
+// StartMetricsSnapshot periodically persists metric counters to Redis
+// The snapshot is shared by key, so with several replicas the last writer wins.
+func (m *Manager) StartMetricsSnapshot(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || m.redis == nil {
+		return
+	}
+
+	// Cancel previous loop if exists
+	if m.metricsCancel != nil {
+		m.metricsCancel()
+	}
+
+	snapshotCtx, cancel := context.WithCancel(ctx)
+	m.metricsCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-snapshotCtx.Done():
+				return
+			case <-ticker.C:
+				if err := m.SaveMetrics(snapshotCtx); err != nil {
+					log.Printf("%s Failed to snapshot metrics: %v", m.logger.prefix, err)
+				}
+			}
+		}
+	}()
+}
+
+// StopMetricsSnapshot stops the snapshot loop and persists a final snapshot
+func (m *Manager) StopMetricsSnapshot() {
+	if m.metricsCancel == nil {
+		return
+	}
+	m.metricsCancel()
+	m.metricsCancel = nil
+
+	if err := m.SaveMetrics(context.Background()); err != nil {
+		log.Printf("%s Failed to snapshot metrics: %v", m.logger.prefix, err)
+	}
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMetrics_SnapshotRestoresIntoNewManager(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	ctx := context.Background()
+
+	before := NewManager(nil, client)
+	before.SetLogging(false)
+	metrics := before.GetMetrics()
+	metrics.RecordRotation("antigravity")
+	metrics.RecordRotation("antigravity")
+	metrics.RecordCooldown(BlockReasonCooldown)
+	metrics.RecordSelect(true, false)
+	metrics.RecordSelect(false, true)
+	metrics.RecordRetry(true)
+
+	if err := before.SaveMetrics(ctx); err != nil {
+		t.Fatalf("SaveMetrics() error = %v", err)
+	}
+	if got := mr.HGet(metricsKey, "rotation:antigravity"); got != "2" {
+		t.Errorf("persisted rotation:antigravity = %q, want 2", got)
+	}
+
+	// Simulated restart: a fresh manager restores, then keeps counting on top
+	after := NewManager(nil, client)
+	after.SetLogging(false)
+	if err := after.RestoreMetrics(ctx); err != nil {
+		t.Fatalf("RestoreMetrics() error = %v", err)
+	}
+	after.GetMetrics().RecordRotation("antigravity")
+
+	restored := after.GetMetrics()
+	if got := restored.GetRotationCounts()["antigravity"]; got != 3 {
+		t.Errorf("rotation count = %d, want 3", got)
+	}
+	if got := restored.GetCooldownEvents()[string(BlockReasonCooldown)]; got != 1 {
+		t.Errorf("cooldown events = %d, want 1", got)
+	}
+	if got := restored.GetSelectionStats(); got["total"] != 2 || got["success"] != 1 || got["blocked"] != 1 {
+		t.Errorf("selection stats = %v, want total 2, success 1, blocked 1", got)
+	}
+	if got := restored.GetRetryStats(); got["total"] != 1 || got["success"] != 1 {
+		t.Errorf("retry stats = %v, want total 1, success 1", got)
+	}
+}
+
+func TestMetrics_RestoreWithoutSnapshot(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	m := NewManager(nil, client)
+	m.SetLogging(false)
+	if err := m.RestoreMetrics(context.Background()); err != nil {
+		t.Fatalf("RestoreMetrics() error = %v", err)
+	}
+	if got := m.GetMetrics().GetSelectionStats()["total"]; got != 0 {
+		t.Errorf("select total = %d, want 0 with no persisted snapshot", got)
+	}
+}
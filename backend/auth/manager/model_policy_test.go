@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+func TestSelect_SkipsAccountDenyingModel(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true, DeniedModels: models.StringArray{"claude-opus-*"}})
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		acc, err := m.Select(ctx, "antigravity", "claude-opus-4-5")
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		if acc.Account.ID != "acc-2" {
+			t.Fatalf("Select() = %s, want acc-2 (acc-1 denies opus)", acc.Account.ID)
+		}
+		m.MarkResult(acc.Account.ID, "claude-opus-4-5", 200, nil, nil)
+	}
+
+	statuses := m.Candidates("antigravity", "claude-opus-4-5")
+	if statuses[0].AccountID != "acc-1" || statuses[0].Eligible || statuses[0].Reason != ExclusionModelPolicy {
+		t.Errorf("Candidates()[0] = %+v, want acc-1 excluded by model policy", statuses[0])
+	}
+}
+
+func TestSelect_AllowListRestrictsModels(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true, AllowedModels: models.StringArray{"gemini-2.5-pro"}})
+
+	ctx := context.Background()
+	if _, err := m.Select(ctx, "antigravity", "gemini-2.5-pro"); err != nil {
+		t.Fatalf("Select() allowed model error = %v", err)
+	}
+	if _, err := m.Select(ctx, "antigravity", "claude-sonnet-4-5"); err == nil {
+		t.Error("Select() should fail for a model outside the allow list")
+	}
+}
+
+func TestAccountAllowsModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed models.StringArray
+		denied  models.StringArray
+		model   string
+		want    bool
+	}{
+		{"empty lists allow all", nil, nil, "claude-opus-4-5", true},
+		{"empty arrays allow all", models.StringArray{}, models.StringArray{}, "gemini-2.5-pro", true},
+		{"exact deny", nil, models.StringArray{"claude-opus-4-5"}, "claude-opus-4-5", false},
+		{"prefix deny", nil, models.StringArray{"claude-opus-*"}, "claude-opus-4-5-thinking", false},
+		{"deny wins over allow", models.StringArray{"claude-*"}, models.StringArray{"claude-opus-*"}, "claude-opus-4-5", false},
+		{"allow list match", models.StringArray{"claude-*"}, nil, "claude-sonnet-4-5", true},
+		{"allow list miss", models.StringArray{"claude-*"}, nil, "gemini-2.5-pro", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := &models.Account{AllowedModels: tt.allowed, DeniedModels: tt.denied}
+			if got := acc.AllowsModel(tt.model); got != tt.want {
+				t.Errorf("AllowsModel(%q) = %v, want %v", tt.model, got, tt.want)
+			}
+		})
+	}
+}
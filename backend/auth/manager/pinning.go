@@ -0,0 +1,43 @@
+package manager
+
+import "fmt"
+
+// SelectPinned returns a specific account, bypassing load balancing
+// Used to reproduce account-specific behavior: the account must belong to providerID, not be
+// disabled and allow model, and it must not be cooling down, over budget or out of quota, so a
+// pin never sends traffic to an account selection would have kept out.
+func (m *Manager) SelectPinned(providerID, accountID, model string) (*AccountState, error) {
+	m.mu.RLock()
+	acc, ok := m.accounts[accountID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("pinned account %s not found", accountID)
+	}
+	if acc.Account.ProviderID != providerID {
+		return nil, fmt.Errorf("pinned account %s belongs to provider %s, not %s", accountID, acc.Account.ProviderID, providerID)
+	}
+	if acc.Disabled {
+		return nil, fmt.Errorf("pinned account %s is disabled", accountID)
+	}
+	if !acc.Account.AllowsModel(model) {
+		return nil, fmt.Errorf("pinned account %s does not allow model %s", accountID, model)
+	}
+
+	// Same cooldown, budget and quota checks as load-balanced selection
+	if _, err := m.selectAvailable([]*AccountState{acc}, model, ""); err != nil {
+		return nil, err
+	}
+
+	if !m.reserveBudget(acc) {
+		return nil, budgetExhaustedError(m.clock())
+	}
+
+	m.selectMu.Lock()
+	m.metrics.SetInFlight(acc.Account.ID, acc.acquireInFlight())
+	m.selectMu.Unlock()
+
+	acc.markSelected(m.clock())
+	m.logger.LogAccountSelected(acc.Account.ID, providerID, model)
+	return acc, nil
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+func TestSelectPinned(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-3", ProviderID: "openai", IsActive: true})
+
+	acc, err := m.SelectPinned("antigravity", "acc-2", "gemini-2.5-pro")
+	if err != nil || acc.Account.ID != "acc-2" {
+		t.Fatalf("SelectPinned() = %v, %v, want acc-2", acc, err)
+	}
+	if acc.InFlight() != 1 {
+		t.Errorf("InFlight = %d, want 1 until MarkResult", acc.InFlight())
+	}
+
+	// A cooling-down account is not handed out even when pinned
+	m.MarkResult("acc-2", "gemini-2.5-pro", 429, nil, nil)
+	if _, err := m.SelectPinned("antigravity", "acc-2", "gemini-2.5-pro"); err == nil {
+		t.Error("pinning a rate-limited account should fail")
+	} else if _, ok := err.(*AllBlockedError); !ok {
+		t.Errorf("err = %T, want *AllBlockedError", err)
+	}
+
+	if _, err := m.SelectPinned("antigravity", "acc-3", "gemini-2.5-pro"); err == nil {
+		t.Error("pinning an account of another provider should fail")
+	}
+	if _, err := m.SelectPinned("antigravity", "missing", "gemini-2.5-pro"); err == nil {
+		t.Error("pinning an unknown account should fail")
+	}
+
+	m.GetAccount("acc-1").Disabled = true
+	if _, err := m.SelectPinned("antigravity", "acc-1", "gemini-2.5-pro"); err == nil {
+		t.Error("pinning a disabled account should fail")
+	}
+}
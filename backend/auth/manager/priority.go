@@ -0,0 +1,35 @@
+package manager
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// accountPriority returns the account's selection priority from metadata "priority" (default 0)
+// Operators give cheaper accounts (e.g. free tier) a higher priority so they are used first.
+func accountPriority(acc *AccountState) int64 {
+	return gjson.Get(acc.Account.Metadata, "priority").Int()
+}
+
+// highestPriority narrows available accounts to those in the highest priority tier
+// Blocked, exhausted and over-budget accounts are already filtered out, so lower tiers
+// are only reached once every account in the preferred tier is unavailable.
+func highestPriority(accounts []*AccountState) []*AccountState {
+	if len(accounts) < 2 {
+		return accounts
+	}
+
+	best := accountPriority(accounts[0])
+	for _, acc := range accounts[1:] {
+		if p := accountPriority(acc); p > best {
+			best = p
+		}
+	}
+
+	result := make([]*AccountState, 0, len(accounts))
+	for _, acc := range accounts {
+		if accountPriority(acc) == best {
+			result = append(result, acc)
+		}
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+func TestPriorityTiers_FreeAccountsUsedUntilExhausted(t *testing.T) {
+	// acc-1 is the paid account (default priority 0); free accounts allow one request a day each
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-free-1", ProviderID: "antigravity", Metadata: `{"priority":10,"daily_request_budget":1}`, IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-free-2", ProviderID: "antigravity", Metadata: `{"priority":10,"daily_request_budget":1}`, IsActive: true})
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	picked := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() #%d error = %v", i+1, err)
+		}
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+		picked = append(picked, acc.Account.ID)
+	}
+
+	free := map[string]bool{picked[0]: true, picked[1]: true}
+	if !free["acc-free-1"] || !free["acc-free-2"] {
+		t.Errorf("first picks = %v, want both free accounts before the paid one", picked[:2])
+	}
+	if picked[2] != "acc-1" || picked[3] != "acc-1" {
+		t.Errorf("later picks = %v, want the paid account once free budgets are used", picked[2:])
+	}
+}
+
+func TestPriorityTiers_FallsBackWhenPreferredTierBlocked(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-free", ProviderID: "antigravity", Metadata: `{"priority":1}`, IsActive: true})
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	acc, err := m.Select(ctx, "antigravity", model)
+	if err != nil || acc.Account.ID != "acc-free" {
+		t.Fatalf("Select() = %v, %v; want acc-free", acc, err)
+	}
+
+	m.MarkResult("acc-free", model, 429, nil, nil)
+	acc, err = m.Select(ctx, "antigravity", model)
+	if err != nil || acc.Account.ID != "acc-1" {
+		t.Fatalf("Select() after 429 = %v, %v; want paid fallback acc-1", acc, err)
+	}
+}
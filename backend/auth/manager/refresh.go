@@ -2,6 +2,8 @@ package manager
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
@@ -130,7 +132,13 @@ func (m *Manager) refreshAccount(ctx context.Context, acc *AccountState, refresh
 	}
 
 	// Update account with new token
-	m.updateAccountToken(acc, result, now)
+	if err := m.updateAccountToken(acc, result, now); err != nil {
+		log.Printf("Token refresh for %s could not be saved: %v", acc.Account.ID, err)
+		acc.mu.Lock()
+		acc.NextRefreshAfter = now.Add(RefreshFailureBackoff)
+		acc.mu.Unlock()
+		return
+	}
 	log.Printf("Token refreshed for %s", acc.Account.ID)
 }
 
@@ -140,21 +148,75 @@ func (m *Manager) getRefresher(providerID string) TokenRefresher {
 	return m.refreshers[providerID]
 }
 
-func (m *Manager) updateAccountToken(acc *AccountState, result *TokenResult, now time.Time) {
+// updateAccountToken merges a refresh result into the account's auth data and persists it
+// Providers that rotate refresh tokens return a new one with every refresh; it must be saved,
+// otherwise the next refresh presents the stale token and fails permanently.
+// An empty RefreshToken keeps the current one.
+func (m *Manager) updateAccountToken(acc *AccountState, result *TokenResult, now time.Time) error {
 	acc.mu.Lock()
 	defer acc.mu.Unlock()
 
+	authData, err := mergeTokenResult(acc.Account.AuthData, result)
+	if err != nil {
+		return err
+	}
+
+	// Swap in an updated copy so readers holding the old pointer never see a partial update
+	updated := *acc.Account
+	updated.AuthData = authData
+	expiresAt := result.ExpiresAt
+	updated.ExpiresAt = &expiresAt
+	acc.Account = &updated
+
 	acc.LastRefreshedAt = now
 	acc.NextRefreshAfter = time.Time{}
 
-	// Note: Actual auth data update should be done via repository
-	// This is a placeholder for the integration
+	if m.accountRepo == nil {
+		return nil
+	}
+	if err := m.accountRepo.UpdateAuthDataWithExpiry(updated.ID, authData, expiresAt); err != nil {
+		return fmt.Errorf("failed to persist refreshed token: %w", err)
+	}
+	return nil
+}
+
+// mergeTokenResult writes refreshed tokens into the auth data JSON, keeping all other fields
+func mergeTokenResult(authData string, result *TokenResult) (string, error) {
+	data := make(map[string]interface{})
+	if authData != "" {
+		if err := json.Unmarshal([]byte(authData), &data); err != nil {
+			return "", fmt.Errorf("failed to parse auth data: %w", err)
+		}
+	}
+
+	data["access_token"] = result.AccessToken
+	if result.RefreshToken != "" {
+		data["refresh_token"] = result.RefreshToken
+	}
+	data["expires_at"] = result.ExpiresAt.Format(time.RFC3339)
+
+	merged, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode auth data: %w", err)
+	}
+	return string(merged), nil
 }
 
-// getExpiryFromAccount extracts token expiry from account auth data
+// getExpiryFromAccount returns the token expiry from the account column, falling back to auth data
 func getExpiryFromAccount(account *models.Account) time.Time {
-	// Parse auth data to get expires_at
-	// Implementation depends on auth data format
-	// This is a simplified version
-	return time.Time{}
+	if account.ExpiresAt != nil {
+		return *account.ExpiresAt
+	}
+
+	var data struct {
+		ExpiresAt string `json:"expires_at"`
+	}
+	if err := json.Unmarshal([]byte(account.AuthData), &data); err != nil || data.ExpiresAt == "" {
+		return time.Time{}
+	}
+	expiresAt, err := time.Parse(time.RFC3339, data.ExpiresAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return expiresAt
 }
@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// rotatingRefresher returns a fixed refresh result, like a provider that rotates refresh tokens
+type rotatingRefresher struct {
+	result *TokenResult
+	seen   []string // refresh_token values presented on each refresh
+}
+
+func (r *rotatingRefresher) RefreshLead() time.Duration { return 5 * time.Minute }
+
+func (r *rotatingRefresher) Refresh(ctx context.Context, account *models.Account) (*TokenResult, error) {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(account.AuthData), &data)
+	token, _ := data["refresh_token"].(string)
+	r.seen = append(r.seen, token)
+	return r.result, nil
+}
+
+func setupRefreshManager(t *testing.T, authData string) (*Manager, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to test db: %v", err)
+	}
+	if err := db.Exec(`
+		CREATE TABLE accounts (
+			id TEXT PRIMARY KEY,
+			provider_id TEXT NOT NULL,
+			label TEXT NOT NULL,
+			auth_data TEXT NOT NULL,
+			metadata TEXT,
+			is_active BOOLEAN DEFAULT 1,
+			expires_at DATETIME,
+			updated_at DATETIME
+		)
+	`).Error; err != nil {
+		t.Fatalf("failed to create accounts table: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO accounts (id, provider_id, label, auth_data, is_active) VALUES (?, ?, ?, ?, 1)`,
+		"acc-1", "claude", "acc-1", authData).Error; err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+
+	m := NewManager(repositories.NewAccountRepository(db), nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "claude", AuthData: authData, IsActive: true})
+	return m, db
+}
+
+func storedAuthData(t *testing.T, db *gorm.DB) map[string]interface{} {
+	var authData string
+	if err := db.Raw(`SELECT auth_data FROM accounts WHERE id = ?`, "acc-1").Scan(&authData).Error; err != nil {
+		t.Fatalf("failed to load account: %v", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(authData), &data); err != nil {
+		t.Fatalf("stored auth data is not JSON: %v", err)
+	}
+	return data
+}
+
+func TestRefreshAccount_PersistsRotatedRefreshToken(t *testing.T) {
+	m, db := setupRefreshManager(t, `{"access_token":"old-access","refresh_token":"old-refresh","email":"a@example.com"}`)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	refresher := &rotatingRefresher{result: &TokenResult{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresAt: expiresAt}}
+	acc := m.GetAccount("acc-1")
+
+	m.refreshAccount(context.Background(), acc, refresher)
+
+	data := storedAuthData(t, db)
+	if data["refresh_token"] != "new-refresh" || data["access_token"] != "new-access" {
+		t.Errorf("stored tokens = %v / %v, want new-access / new-refresh", data["access_token"], data["refresh_token"])
+	}
+	if data["email"] != "a@example.com" {
+		t.Errorf("email = %v, other auth data fields should be kept", data["email"])
+	}
+	if got := getExpiryFromAccount(m.GetAccount("acc-1").Account); !got.Equal(expiresAt) {
+		t.Errorf("in-memory expiry = %v, want %v", got, expiresAt)
+	}
+
+	// The next refresh must present the rotated token, not the stale one
+	m.refreshAccount(context.Background(), m.GetAccount("acc-1"), refresher)
+	if len(refresher.seen) != 2 || refresher.seen[1] != "new-refresh" {
+		t.Errorf("refresh tokens presented = %v, want [old-refresh new-refresh]", refresher.seen)
+	}
+}
+
+func TestRefreshAccount_KeepsRefreshTokenWhenNotRotated(t *testing.T) {
+	m, db := setupRefreshManager(t, `{"access_token":"old-access","refresh_token":"old-refresh"}`)
+
+	refresher := &rotatingRefresher{result: &TokenResult{AccessToken: "new-access", ExpiresAt: time.Now().Add(time.Hour)}}
+	m.refreshAccount(context.Background(), m.GetAccount("acc-1"), refresher)
+
+	if data := storedAuthData(t, db); data["refresh_token"] != "old-refresh" || data["access_token"] != "new-access" {
+		t.Errorf("stored tokens = %v / %v, want new-access / old-refresh", data["access_token"], data["refresh_token"])
+	}
+}
+
+func TestShouldRefresh_UsesAuthDataExpiry(t *testing.T) {
+	m := NewManager(nil, nil)
+	m.SetLogging(false)
+	soon := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "claude", AuthData: `{"expires_at":"` + soon + `"}`, IsActive: true})
+
+	if !m.shouldRefresh(m.GetAccount("acc-1"), &rotatingRefresher{}, time.Now()) {
+		t.Error("token expiring within the refresh lead should be refreshed")
+	}
+}
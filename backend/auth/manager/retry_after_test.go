@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"aigateway-backend/auth/errors"
+)
+
+func TestMarkResult_RetryAfterSetsCooldown(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.RegisterParser("antigravity", &errors.ClaudeParser{})
+
+	model := "gemini-2.5-pro"
+	acc, err := m.Select(context.Background(), "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	headers := http.Header{}
+	headers.Set("Retry-After", "120")
+	before := time.Now()
+	m.MarkResult(acc.Account.ID, model, 429, []byte(`{"error":{"type":"rate_limit_error"}}`), headers)
+
+	wait := acc.GetNextRetryTime(model).Sub(before)
+	if wait < 119*time.Second || wait > 121*time.Second {
+		t.Errorf("NextRetryAfter is %v after the 429, want ~120s from Retry-After", wait)
+	}
+}
+
+func TestMarkResult_RetryAfterOverridesQuotaBackoff(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.RegisterParser("antigravity", &errors.CodexParser{})
+
+	model := "gemini-2.5-pro"
+	acc, err := m.Select(context.Background(), "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	headers := http.Header{}
+	headers.Set("Retry-After", "30")
+	before := time.Now()
+	m.MarkResult(acc.Account.ID, model, 429, []byte(`{"error":{"code":"insufficient_quota"}}`), headers)
+
+	wait := acc.GetNextRetryTime(model).Sub(before)
+	if wait < 29*time.Second || wait > 31*time.Second {
+		t.Errorf("NextRetryAfter is %v after quota 429, want ~30s from Retry-After", wait)
+	}
+}
+
+func TestMarkResult_OverloadedDoesNotBlockAccount(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.RegisterParser("antigravity", &errors.ClaudeParser{})
+
+	model := "claude-sonnet-4-5"
+	m.MarkResult("acc-1", model, errors.StatusOverloaded, []byte(`{"type":"error","error":{"type":"overloaded_error"}}`), nil)
+
+	acc := m.GetAccount("acc-1")
+	if blocked, reason := acc.IsBlockedFor(model, time.Now()); blocked || acc.Disabled {
+		t.Errorf("blocked = %v (%s), disabled = %v after a 529, want the account to stay selectable", blocked, reason, acc.Disabled)
+	}
+	if got := acc.GetModelState(model).LastError; got == nil || got.Type != errors.ErrTypeOverloaded {
+		t.Errorf("LastError = %+v, want an overloaded error", got)
+	}
+}
@@ -0,0 +1,57 @@
+package manager
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SetRotationCooldown sets the minimum interval between selections of the same account (0 = disabled)
+// Accounts used within the cooldown are skipped while another account is available.
+func (m *Manager) SetRotationCooldown(cooldown time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rotationCooldown = cooldown
+}
+
+// LastSelectedAt returns when the account was last handed out by Select (zero if never)
+func (a *AccountState) LastSelectedAt() time.Time {
+	nanos := atomic.LoadInt64(&a.lastSelectedAt)
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// markSelected records the selection time for rotation cooldown
+func (a *AccountState) markSelected(now time.Time) {
+	atomic.StoreInt64(&a.lastSelectedAt, now.UnixNano())
+}
+
+// inRotationCooldown reports whether the account was selected within the cooldown window
+func (m *Manager) inRotationCooldown(acc *AccountState, now time.Time) bool {
+	if m.rotationCooldown <= 0 {
+		return false
+	}
+	last := acc.LastSelectedAt()
+	return !last.IsZero() && now.Sub(last) < m.rotationCooldown
+}
+
+// rested returns the accounts outside their rotation cooldown, or all accounts if none are
+// The cooldown only spreads traffic; it never leaves a request without an account.
+func (m *Manager) rested(accounts []*AccountState, now time.Time) []*AccountState {
+	if m.rotationCooldown <= 0 {
+		return accounts
+	}
+
+	result := make([]*AccountState, 0, len(accounts))
+	for _, acc := range accounts {
+		if !m.inRotationCooldown(acc, now) {
+			result = append(result, acc)
+		}
+	}
+
+	if len(result) == 0 {
+		return accounts
+	}
+	return result
+}
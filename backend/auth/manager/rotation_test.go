@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+)
+
+func TestRotationCooldown_SkipsRecentlyUsedAccount(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.clock = func() time.Time { return now }
+	m.SetRotationCooldown(500 * time.Millisecond)
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	first, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.MarkResult(first.Account.ID, model, 200, nil, nil)
+
+	// Within the cooldown the other account is picked
+	now = now.Add(100 * time.Millisecond)
+	second, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if second.Account.ID == first.Account.ID {
+		t.Fatalf("Select() = %s, want the other account during cooldown", second.Account.ID)
+	}
+}
+
+func TestRotationCooldown_EligibleAfterCooldown(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.clock = func() time.Time { return now }
+	m.SetRotationCooldown(500 * time.Millisecond)
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	acc, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+
+	// The only account is still handed out during its cooldown
+	now = now.Add(100 * time.Millisecond)
+	if _, err := m.Select(ctx, "antigravity", model); err != nil {
+		t.Fatalf("Select() with single account in cooldown error = %v", err)
+	}
+
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+
+	// Once acc-1's cooldown elapses it is eligible again while acc-2 rests
+	now = now.Add(600 * time.Millisecond)
+	m.accounts["acc-2"].markSelected(now.Add(-10 * time.Millisecond))
+	got, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if got.Account.ID != "acc-1" {
+		t.Errorf("Select() = %s, want acc-1 after its cooldown", got.Account.ID)
+	}
+}
@@ -41,12 +41,16 @@ func (e *AllExhaustedError) WaitDuration() time.Duration {
 	return dur
 }
 
-// getCandidates returns accounts for given provider
-func (m *Manager) getCandidates(providerID string) []*AccountState {
+// getCandidates returns accounts for given provider whose model policy permits model
+// Accounts in excluded (a per-request X-Exclude-Accounts list) are skipped.
+func (m *Manager) getCandidates(providerID, model string, excluded map[string]bool) []*AccountState {
 	candidates := make([]*AccountState, 0)
 
 	for _, acc := range m.accounts {
-		if acc.Account.ProviderID == providerID && !acc.Disabled {
+		if excluded[acc.Account.ID] {
+			continue
+		}
+		if acc.Account.ProviderID == providerID && !acc.Disabled && acc.Account.AllowsModel(model) {
 			candidates = append(candidates, acc)
 		}
 	}
@@ -54,8 +58,21 @@ func (m *Manager) getCandidates(providerID string) []*AccountState {
 	return candidates
 }
 
-// selectBest selects best available account for model
-func (m *Manager) selectBest(candidates []*AccountState, model string) (*AccountState, error) {
+// withoutAccount returns accounts minus acc
+func withoutAccount(accounts []*AccountState, acc *AccountState) []*AccountState {
+	rest := make([]*AccountState, 0, len(accounts))
+	for _, a := range accounts {
+		if a != acc {
+			rest = append(rest, a)
+		}
+	}
+	return rest
+}
+
+// selectAvailable returns the candidates that may take a request for model, narrowed to the
+// preferred pool. Runs before the serialized pick since the budget and quota checks read Redis.
+// tier is the requested Claude service_tier used for pool preference ("" = none).
+func (m *Manager) selectAvailable(candidates []*AccountState, model, tier string) ([]*AccountState, error) {
 	now := time.Now()
 	available := make([]*AccountState, 0)
 	quotaExhausted := make([]string, 0) // Track exhausted account IDs for reset time
@@ -75,6 +92,15 @@ func (m *Manager) selectBest(candidates []*AccountState, model string) (*Account
 			continue
 		}
 
+		// Check daily request budget (resets at UTC midnight)
+		if m.isOverBudget(acc) {
+			resetAt := nextBudgetReset(now)
+			if earliestRetry.IsZero() || resetAt.Before(earliestRetry) {
+				earliestRetry = resetAt
+			}
+			continue
+		}
+
 		// Check if quota exhausted (if quota tracker is configured)
 		if m.quotaTracker != nil && !m.quotaTracker.IsAvailable(acc.Account.ID, model) {
 			quotaExhausted = append(quotaExhausted, acc.Account.ID)
@@ -100,25 +126,102 @@ func (m *Manager) selectBest(candidates []*AccountState, model string) (*Account
 		}
 	}
 
-	// Round-robin selection
-	return m.roundRobinSelect(available, model)
+	// Keep to the requested service tier's pool while it has available accounts
+	available = m.tierPool(available, tier)
+
+	// Use the highest account priority tier until all of its accounts are unavailable
+	available = highestPriority(available)
+
+	// Skip accounts used within the rotation cooldown while others are available
+	return m.rested(available, m.clock()), nil
 }
 
-// roundRobinSelect picks next account using round-robin
-func (m *Manager) roundRobinSelect(available []*AccountState, model string) (*AccountState, error) {
-	if len(available) == 0 {
-		return nil, fmt.Errorf("no available accounts")
+// selectionHints holds the tie-breaking inputs that need Redis or the quota tracker,
+// read before the serialized pick so it does no I/O
+type selectionHints struct {
+	headroom map[string]int  // Requests left before the learned limit; unlearned accounts are absent
+	warm     map[string]bool // Accounts with a cached access token (nil = preference disabled)
+	counter  int64           // Round-robin counter for this selection
+}
+
+// loadSelectionHints reads the tie-breakers for available; nothing is read for a single account
+func (m *Manager) loadSelectionHints(available []*AccountState, model string) selectionHints {
+	var hints selectionHints
+	if len(available) < 2 {
+		return hints
 	}
 
-	if len(available) == 1 {
-		return available[0], nil
+	if m.quotaTracker != nil {
+		hints.headroom = make(map[string]int, len(available))
+		for _, acc := range available {
+			if headroom, learned := m.quotaTracker.RemainingHeadroom(acc.Account.ID, model); learned {
+				hints.headroom[acc.Account.ID] = headroom
+			}
+		}
 	}
 
-	// Get counter from Redis for fair distribution
-	counter := m.getCounter(model)
-	idx := int(counter) % len(available)
+	if m.tokenWarmth != nil {
+		hints.warm = make(map[string]bool, len(available))
+		for _, acc := range available {
+			hints.warm[acc.Account.ID] = m.tokenWarmth.HasWarmToken(acc.Account)
+		}
+	}
+
+	hints.counter = m.getCounter(model)
+	return hints
+}
+
+// pickBest chooses among available accounts: least-loaded first, then the most learned quota
+// headroom, then a warm token cache, round-robin among ties. Called with selectMu held.
+func pickBest(available []*AccountState, hints selectionHints) *AccountState {
+	ranked := warmTokens(mostHeadroom(leastLoaded(available), hints.headroom), hints.warm)
+	return ranked[int(hints.counter%int64(len(ranked)))]
+}
+
+// mostHeadroom drops accounts with less learned headroom than the best learned account
+// Accounts without a learned limit have unknown headroom, so they are kept rather than
+// preferred; when no limits are learned every account is kept.
+func mostHeadroom(accounts []*AccountState, headroom map[string]int) []*AccountState {
+	if len(accounts) < 2 || len(headroom) == 0 {
+		return accounts
+	}
+
+	best := -1
+	for _, acc := range accounts {
+		if h, learned := headroom[acc.Account.ID]; learned && h > best {
+			best = h
+		}
+	}
+	if best < 0 {
+		return accounts
+	}
+
+	result := make([]*AccountState, 0, len(accounts))
+	for _, acc := range accounts {
+		if h, learned := headroom[acc.Account.ID]; !learned || h == best {
+			result = append(result, acc)
+		}
+	}
+	return result
+}
+
+// leastLoaded returns the accounts with the fewest in-flight requests
+func leastLoaded(accounts []*AccountState) []*AccountState {
+	minLoad := int64(-1)
+	result := make([]*AccountState, 0, len(accounts))
+
+	for _, acc := range accounts {
+		load := acc.InFlight()
+		switch {
+		case minLoad < 0 || load < minLoad:
+			minLoad = load
+			result = append(result[:0], acc)
+		case load == minLoad:
+			result = append(result, acc)
+		}
+	}
 
-	return available[idx], nil
+	return result
 }
 
 // getCounter gets and increments round-robin counter from Redis
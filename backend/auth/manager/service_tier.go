@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// Claude service_tier values that influence pool selection
+const (
+	serviceTierAuto         = "auto"
+	serviceTierStandardOnly = "standard_only"
+)
+
+// PriorityPool is the account metadata "pool" value marking dedicated priority capacity
+const PriorityPool = "priority"
+
+// SetServiceTierRouting enables routing by Claude service_tier
+// "auto" requests prefer accounts with metadata {"pool":"priority"}; "standard_only"
+// requests prefer the remaining accounts. Either falls back to the other pool when empty.
+func (m *Manager) SetServiceTierRouting(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.serviceTierRouting = enabled
+}
+
+// isPriorityAccount reports whether the account belongs to the priority pool
+func isPriorityAccount(acc *AccountState) bool {
+	return gjson.Get(acc.Account.Metadata, "pool").String() == PriorityPool
+}
+
+// tierPool narrows available accounts to the pool matching tier
+// Returns accounts unchanged when routing is disabled, the tier is unset, or the pool is empty.
+func (m *Manager) tierPool(accounts []*AccountState, tier string) []*AccountState {
+	if !m.serviceTierRouting {
+		return accounts
+	}
+
+	var wantPriority bool
+	switch tier {
+	case serviceTierAuto:
+		wantPriority = true
+	case serviceTierStandardOnly:
+		wantPriority = false
+	default:
+		return accounts
+	}
+
+	pool := make([]*AccountState, 0, len(accounts))
+	for _, acc := range accounts {
+		if isPriorityAccount(acc) == wantPriority {
+			pool = append(pool, acc)
+		}
+	}
+
+	if len(pool) == 0 {
+		return accounts
+	}
+	return pool
+}
@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+// setupTierManager registers one standard and one priority-pool account
+func setupTierManager(t *testing.T) *Manager {
+	mr, m := setupBudgetManager(t, "")
+	t.Cleanup(mr.Close)
+	m.AddAccount(&models.Account{ID: "acc-priority", ProviderID: "antigravity", Metadata: `{"pool":"priority"}`, IsActive: true})
+	return m
+}
+
+// selectN runs n tier selections and returns the picked account IDs
+func selectN(t *testing.T, m *Manager, tier string, n int) map[string]int {
+	t.Helper()
+
+	picked := make(map[string]int)
+	for i := 0; i < n; i++ {
+		acc, err := m.SelectForTier(context.Background(), "antigravity", "gemini-2.5-pro", tier)
+		if err != nil {
+			t.Fatalf("SelectForTier() error = %v", err)
+		}
+		m.MarkResult(acc.Account.ID, "gemini-2.5-pro", 200, nil, nil)
+		picked[acc.Account.ID]++
+	}
+	return picked
+}
+
+func TestServiceTier_SelectsMatchingPool(t *testing.T) {
+	m := setupTierManager(t)
+	m.SetServiceTierRouting(true)
+
+	if picked := selectN(t, m, "auto", 4); picked["acc-priority"] != 4 {
+		t.Errorf("auto picked %v, want only acc-priority", picked)
+	}
+	if picked := selectN(t, m, "standard_only", 4); picked["acc-1"] != 4 {
+		t.Errorf("standard_only picked %v, want only acc-1", picked)
+	}
+	if picked := selectN(t, m, "", 4); len(picked) != 2 {
+		t.Errorf("no tier picked %v, want both pools", picked)
+	}
+}
+
+func TestServiceTier_FallsBackWhenPoolUnavailable(t *testing.T) {
+	m := setupTierManager(t)
+	m.SetServiceTierRouting(true)
+	m.MarkResult("acc-priority", "gemini-2.5-pro", 429, nil, nil)
+
+	if picked := selectN(t, m, "auto", 2); picked["acc-1"] != 2 {
+		t.Errorf("auto picked %v, want standard fallback while priority is blocked", picked)
+	}
+}
+
+func TestServiceTier_IgnoredWhenRoutingDisabled(t *testing.T) {
+	m := setupTierManager(t)
+
+	if picked := selectN(t, m, "auto", 4); len(picked) != 2 {
+		t.Errorf("auto picked %v, want round-robin across both accounts", picked)
+	}
+}
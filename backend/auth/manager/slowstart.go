@@ -0,0 +1,122 @@
+package manager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// slowStartRetryDelay is how long a throttled caller should wait before retrying
+const slowStartRetryDelay = 500 * time.Millisecond
+
+// defaultSlowStartMinFraction is the share of traffic admitted right after recovery
+const defaultSlowStartMinFraction = 0.1
+
+// slowStart ramps traffic up gradually after a provider/model recovers from all-blocked,
+// so queued requests don't re-exhaust the just-recovered accounts at once
+type slowStart struct {
+	window      time.Duration // Ramp duration (0 = disabled)
+	minFraction float64       // Fraction admitted at the start of the ramp
+
+	mu      sync.Mutex
+	blocked map[string]bool       // provider:model keys currently all-blocked
+	ramps   map[string]*rampState // provider:model keys currently ramping
+}
+
+// rampState tracks admissions during a single recovery ramp
+type rampState struct {
+	recoveredAt time.Time
+	bucket      time.Time // Start of the current one-second admission bucket
+	attempts    int64
+	admitted    int64
+}
+
+func newSlowStart() *slowStart {
+	return &slowStart{
+		minFraction: defaultSlowStartMinFraction,
+		blocked:     make(map[string]bool),
+		ramps:       make(map[string]*rampState),
+	}
+}
+
+// SetSlowStart configures the recovery ramp window and initial admitted fraction
+// window <= 0 disables slow-start; minFraction outside (0,1] falls back to the default.
+func (m *Manager) SetSlowStart(window time.Duration, minFraction float64) {
+	m.slowStart.mu.Lock()
+	defer m.slowStart.mu.Unlock()
+
+	if minFraction <= 0 || minFraction > 1 {
+		minFraction = defaultSlowStartMinFraction
+	}
+	m.slowStart.window = window
+	m.slowStart.minFraction = minFraction
+}
+
+// markAllBlocked remembers that provider/model had no available accounts
+func (s *slowStart) markAllBlocked(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.window <= 0 {
+		return
+	}
+	s.blocked[key] = true
+	delete(s.ramps, key)
+}
+
+// admit decides whether a successful selection may proceed during a recovery ramp
+// Returns false when the request should be throttled.
+func (s *slowStart) admit(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.window <= 0 {
+		return true
+	}
+
+	// First success after all-blocked starts the ramp
+	if s.blocked[key] {
+		delete(s.blocked, key)
+		s.ramps[key] = &rampState{recoveredAt: now}
+	}
+
+	ramp, ok := s.ramps[key]
+	if !ok {
+		return true
+	}
+
+	fraction := s.fraction(ramp, now)
+	if fraction >= 1 {
+		delete(s.ramps, key)
+		return true
+	}
+
+	// Counters reset every second so admissions track the current fraction
+	if bucket := now.Truncate(time.Second); !bucket.Equal(ramp.bucket) {
+		ramp.bucket = bucket
+		ramp.attempts = 0
+		ramp.admitted = 0
+	}
+
+	// Deterministic admission: keep admitted/attempts at or below the current fraction
+	ramp.attempts++
+	if float64(ramp.admitted) < fraction*float64(ramp.attempts) {
+		ramp.admitted++
+		return true
+	}
+	return false
+}
+
+// fraction returns the share of traffic allowed at now, ramping linearly to 1 over the window
+func (s *slowStart) fraction(ramp *rampState, now time.Time) float64 {
+	elapsed := now.Sub(ramp.recoveredAt)
+	if elapsed >= s.window {
+		return 1
+	}
+	return s.minFraction + (1-s.minFraction)*float64(elapsed)/float64(s.window)
+}
+
+// slowStartKey identifies a provider/model pair for ramp tracking
+func slowStartKey(providerID, model string) string {
+	return fmt.Sprintf("%s:%s", providerID, model)
+}
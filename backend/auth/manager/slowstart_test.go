@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countAdmitted runs n selections and returns how many were admitted
+func countAdmitted(t *testing.T, m *Manager, model string, n int) int {
+	t.Helper()
+
+	admitted := 0
+	for i := 0; i < n; i++ {
+		_, err := m.Select(context.Background(), "antigravity", model)
+		if err == nil {
+			admitted++
+			continue
+		}
+		if _, ok := err.(*AllBlockedError); !ok {
+			t.Fatalf("Select() error = %v, want AllBlockedError", err)
+		}
+	}
+	return admitted
+}
+
+func TestSlowStart_RampsUpAfterRecovery(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.clock = func() time.Time { return now }
+	m.SetSlowStart(10*time.Minute, 0.1)
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	acc, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.MarkResult(acc.Account.ID, model, 429, nil, nil)
+
+	if _, err := m.Select(ctx, "antigravity", model); err == nil {
+		t.Fatal("Select() should fail while account is blocked")
+	}
+	acc.GetModelState(model).ClearBlock()
+
+	// Right after recovery only ~10% of traffic is admitted
+	if got := countAdmitted(t, m, model, 100); got != 10 {
+		t.Errorf("admitted at recovery = %d, want 10", got)
+	}
+
+	// Halfway through the window the admitted share has grown
+	now = now.Add(5 * time.Minute)
+	if got := countAdmitted(t, m, model, 100); got < 50 || got > 60 {
+		t.Errorf("admitted mid-ramp = %d, want ~55", got)
+	}
+
+	// After the window all traffic is admitted again
+	now = now.Add(5 * time.Minute)
+	if got := countAdmitted(t, m, model, 20); got != 20 {
+		t.Errorf("admitted after ramp = %d, want 20", got)
+	}
+}
+
+func TestSlowStart_DisabledByDefault(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	acc, err := m.Select(ctx, "antigravity", model)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	m.MarkResult(acc.Account.ID, model, 429, nil, nil)
+	if _, err := m.Select(ctx, "antigravity", model); err == nil {
+		t.Fatal("Select() should fail while account is blocked")
+	}
+	acc.GetModelState(model).ClearBlock()
+
+	if got := countAdmitted(t, m, model, 10); got != 10 {
+		t.Errorf("admitted = %d, want 10", got)
+	}
+}
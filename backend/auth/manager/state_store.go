@@ -0,0 +1,182 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// statesKey is the Redis hash holding the last persisted account state snapshot
+// Format: auth:states -> {account_id: persistedAccountState JSON}
+const statesKey = "auth:states"
+
+// persistedAccountState is the part of AccountState that survives a restart
+type persistedAccountState struct {
+	Disabled bool                           `json:"disabled,omitempty"`
+	SavedAt  time.Time                      `json:"saved_at"`
+	Models   map[string]persistedModelState `json:"models,omitempty"`
+}
+
+// persistedModelState is the part of ModelState that survives a restart
+type persistedModelState struct {
+	Disabled       bool        `json:"disabled,omitempty"`
+	BlockReason    BlockReason `json:"block_reason,omitempty"`
+	NextRetryAfter time.Time   `json:"next_retry_after"`
+	SuccessCount   int64       `json:"success_count"`
+	FailureCount   int64       `json:"failure_count"`
+}
+
+// snapshot captures the account's persistable state
+func (a *AccountState) snapshot(now time.Time) persistedAccountState {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	state := persistedAccountState{
+		Disabled: a.Disabled,
+		SavedAt:  now,
+		Models:   make(map[string]persistedModelState, len(a.ModelStates)),
+	}
+	for model, ms := range a.ModelStates {
+		state.Models[model] = persistedModelState{
+			Disabled:       ms.Disabled,
+			BlockReason:    ms.BlockReason,
+			NextRetryAfter: ms.NextRetryAfter,
+			SuccessCount:   ms.SuccessCount,
+			FailureCount:   ms.FailureCount,
+		}
+	}
+	return state
+}
+
+// restore applies a persisted snapshot; blocks that expired while the process was down are dropped
+// The account-level disable is skipped if the account was updated (e.g. re-authenticated) after the snapshot.
+func (a *AccountState) restore(state persistedAccountState, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if state.Disabled && !a.Account.UpdatedAt.After(state.SavedAt) {
+		a.Disabled = true
+	}
+
+	for model, saved := range state.Models {
+		ms := a.getOrCreateModelState(model)
+		ms.SuccessCount = saved.SuccessCount
+		ms.FailureCount = saved.FailureCount
+		if saved.Disabled || now.Before(saved.NextRetryAfter) {
+			ms.Disabled = saved.Disabled
+			ms.BlockReason = saved.BlockReason
+			ms.NextRetryAfter = saved.NextRetryAfter
+		}
+	}
+}
+
+// SaveStates writes block state and counters of all loaded accounts to Redis, replacing the previous snapshot
+// Nothing is written before accounts are loaded, so an early tick can't wipe the last snapshot.
+func (m *Manager) SaveStates(ctx context.Context) error {
+	if m.redis == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	now := m.clock()
+	values := make(map[string]interface{}, len(m.accounts))
+	for id, acc := range m.accounts {
+		data, err := json.Marshal(acc.snapshot(now))
+		if err != nil {
+			continue
+		}
+		values[id] = data
+	}
+	m.mu.RUnlock()
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := m.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, statesKey)
+		pipe.HSet(ctx, statesKey, values)
+		return nil
+	})
+	return err
+}
+
+// restoreStates applies the persisted snapshot to loaded accounts (caller holds m.mu)
+func (m *Manager) restoreStates(ctx context.Context) error {
+	if m.redis == nil {
+		return nil
+	}
+
+	fields, err := m.redis.HGetAll(ctx, statesKey).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	now := m.clock()
+	restored := 0
+	for id, raw := range fields {
+		acc, ok := m.accounts[id]
+		if !ok {
+			continue
+		}
+		var state persistedAccountState
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			continue
+		}
+		acc.restore(state, now)
+		restored++
+	}
+
+	if restored > 0 && m.logger.enabled {
+		log.Printf("%s Restored state for %d accounts", m.logger.prefix, restored)
+	}
+	return nil
+}
+
+// StartStateSnapshot periodically persists account states to Redis and restores them on LoadAccounts
+// The snapshot is shared by key, so with several replicas the last writer wins.
+func (m *Manager) StartStateSnapshot(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || m.redis == nil {
+		return
+	}
+
+	// Cancel previous loop if exists
+	if m.stateCancel != nil {
+		m.stateCancel()
+	}
+
+	snapshotCtx, cancel := context.WithCancel(ctx)
+	m.stateCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-snapshotCtx.Done():
+				return
+			case <-ticker.C:
+				if err := m.SaveStates(snapshotCtx); err != nil {
+					log.Printf("%s Failed to snapshot account states: %v", m.logger.prefix, err)
+				}
+			}
+		}
+	}()
+}
+
+// StopStateSnapshot stops the snapshot loop and persists a final snapshot
+func (m *Manager) StopStateSnapshot() {
+	if m.stateCancel == nil {
+		return
+	}
+	m.stateCancel()
+	m.stateCancel = nil
+
+	if err := m.SaveStates(context.Background()); err != nil {
+		log.Printf("%s Failed to snapshot account states: %v", m.logger.prefix, err)
+	}
+}
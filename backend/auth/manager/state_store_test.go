@@ -0,0 +1,144 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aigateway-backend/repositories"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupStateStoreRepo creates an accounts table with two active antigravity accounts
+func setupStateStoreRepo(t *testing.T) *repositories.AccountRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to test db: %v", err)
+	}
+	if err := db.Exec(`
+		CREATE TABLE accounts (
+			id TEXT PRIMARY KEY,
+			provider_id TEXT NOT NULL,
+			label TEXT NOT NULL,
+			auth_data TEXT NOT NULL,
+			metadata TEXT,
+			is_active BOOLEAN DEFAULT 1,
+			updated_at DATETIME
+		)
+	`).Error; err != nil {
+		t.Fatalf("failed to create accounts table: %v", err)
+	}
+	for _, id := range []string{"acc-1", "acc-2"} {
+		if err := db.Exec(`INSERT INTO accounts (id, provider_id, label, auth_data, is_active) VALUES (?, 'antigravity', ?, '{}', 1)`, id, id).Error; err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+	}
+	return repositories.NewAccountRepository(db)
+}
+
+func newStateStoreManager(t *testing.T, repo *repositories.AccountRepository, client *redis.Client) *Manager {
+	m := NewManager(repo, client)
+	m.SetLogging(false)
+	m.StartStateSnapshot(context.Background(), time.Hour)
+	if err := m.LoadAccounts(context.Background(), "antigravity"); err != nil {
+		t.Fatalf("LoadAccounts() error = %v", err)
+	}
+	return m
+}
+
+func TestStateSnapshot_BlockSurvivesRestart(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	repo := setupStateStoreRepo(t)
+	model := "gemini-2.5-pro"
+
+	before := newStateStoreManager(t, repo, client)
+	before.MarkResult("acc-1", model, 429, nil, nil)
+	before.MarkResult("acc-2", model, 200, nil, nil)
+	if blocked, _ := before.GetAccount("acc-1").IsBlockedFor(model, time.Now()); !blocked {
+		t.Fatal("acc-1 should be blocked after a 429")
+	}
+	retryAt := before.GetAccount("acc-1").GetNextRetryTime(model)
+
+	// Shutdown persists the final snapshot
+	before.StopStateSnapshot()
+
+	after := newStateStoreManager(t, repo, client)
+	defer after.StopStateSnapshot()
+
+	blocked, reason := after.GetAccount("acc-1").IsBlockedFor(model, time.Now())
+	if !blocked || reason != BlockReasonCooldown {
+		t.Errorf("restored acc-1 IsBlockedFor() = %v, %q, want blocked by cooldown", blocked, reason)
+	}
+	if got := after.GetAccount("acc-1").GetNextRetryTime(model); !got.Equal(retryAt) {
+		t.Errorf("restored NextRetryAfter = %v, want %v", got, retryAt)
+	}
+	if blocked, _ := after.GetAccount("acc-2").IsBlockedFor(model, time.Now()); blocked {
+		t.Error("acc-2 should not be blocked after restart")
+	}
+	if got := after.GetAccount("acc-2").GetModelState(model).SuccessCount; got != 1 {
+		t.Errorf("restored acc-2 SuccessCount = %d, want 1", got)
+	}
+
+	acc, err := after.Select(context.Background(), "antigravity", model)
+	if err != nil || acc.Account.ID != "acc-2" {
+		t.Errorf("Select() after restart = %v, %v, want acc-2", acc, err)
+	}
+}
+
+func TestStateSnapshot_ExpiredBlockNotRestored(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	repo := setupStateStoreRepo(t)
+	model := "gemini-2.5-pro"
+
+	before := newStateStoreManager(t, repo, client)
+	before.MarkResult("acc-1", model, 429, nil, nil)
+	before.StopStateSnapshot()
+
+	// Restart after the cooldown has run out
+	after := NewManager(repo, client)
+	after.SetLogging(false)
+	after.clock = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	after.StartStateSnapshot(context.Background(), time.Hour)
+	defer after.StopStateSnapshot()
+	if err := after.LoadAccounts(context.Background(), "antigravity"); err != nil {
+		t.Fatalf("LoadAccounts() error = %v", err)
+	}
+
+	ms := after.GetAccount("acc-1").GetModelState(model)
+	if ms.BlockReason != BlockReasonNone || !ms.NextRetryAfter.IsZero() {
+		t.Errorf("expired block restored: reason %q, retry after %v", ms.BlockReason, ms.NextRetryAfter)
+	}
+	if ms.FailureCount != 1 {
+		t.Errorf("FailureCount = %d, want 1", ms.FailureCount)
+	}
+}
+
+func TestStateSnapshot_NotRestoredWhenDisabled(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	repo := setupStateStoreRepo(t)
+	model := "gemini-2.5-pro"
+
+	before := newStateStoreManager(t, repo, client)
+	before.MarkResult("acc-1", model, 429, nil, nil)
+	before.StopStateSnapshot()
+
+	// Without StartStateSnapshot, LoadAccounts starts from a clean state
+	after := NewManager(repo, client)
+	after.SetLogging(false)
+	if err := after.LoadAccounts(context.Background(), "antigravity"); err != nil {
+		t.Fatalf("LoadAccounts() error = %v", err)
+	}
+	if blocked, _ := after.GetAccount("acc-1").IsBlockedFor(model, time.Now()); blocked {
+		t.Error("acc-1 restored blocked although state persistence is off")
+	}
+}
@@ -0,0 +1,30 @@
+package manager
+
+// SetWarmTokenPreference prefers accounts whose access token is already cached (nil = disabled)
+// Only breaks ties left after headroom and load, so cold accounts still get traffic when
+// they're the better pick, but an equal warm account avoids an on-demand refresh.
+func (m *Manager) SetWarmTokenPreference(warmth TokenWarmth) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokenWarmth = warmth
+}
+
+// warmTokens returns the accounts with a warm cached token, or all accounts if none have one
+// warm is read by loadSelectionHints; nil means the preference is disabled.
+func warmTokens(accounts []*AccountState, warm map[string]bool) []*AccountState {
+	if warm == nil || len(accounts) < 2 {
+		return accounts
+	}
+
+	result := make([]*AccountState, 0, len(accounts))
+	for _, acc := range accounts {
+		if warm[acc.Account.ID] {
+			result = append(result, acc)
+		}
+	}
+
+	if len(result) == 0 {
+		return accounts
+	}
+	return result
+}
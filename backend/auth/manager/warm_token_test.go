@@ -0,0 +1,59 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"aigateway-backend/models"
+)
+
+// staticWarmth reports a fixed set of accounts as having a warm token
+type staticWarmth map[string]bool
+
+func (w staticWarmth) HasWarmToken(account *models.Account) bool {
+	return w[account.ID]
+}
+
+func TestWarmTokenPreference_PrefersCachedToken(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+	m.SetWarmTokenPreference(staticWarmth{"acc-2": true})
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	for i := 0; i < 4; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		if acc.Account.ID != "acc-2" {
+			t.Errorf("Select() #%d = %s, want warm acc-2", i, acc.Account.ID)
+		}
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+	}
+}
+
+func TestWarmTokenPreference_FallsBackWhenNoneWarm(t *testing.T) {
+	mr, m := setupBudgetManager(t, "")
+	defer mr.Close()
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", IsActive: true})
+	m.SetWarmTokenPreference(staticWarmth{})
+
+	ctx := context.Background()
+	model := "gemini-2.5-pro"
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		acc, err := m.Select(ctx, "antigravity", model)
+		if err != nil {
+			t.Fatalf("Select() error = %v", err)
+		}
+		seen[acc.Account.ID] = true
+		m.MarkResult(acc.Account.ID, model, 200, nil, nil)
+	}
+	if len(seen) != 2 {
+		t.Errorf("selected %v, want round-robin over both cold accounts", seen)
+	}
+}
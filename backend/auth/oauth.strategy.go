@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"aigateway-backend/auth/oauth"
 	"aigateway-backend/models"
 	"context"
 	"encoding/json"
@@ -123,7 +124,14 @@ func (s *OAuthStrategy) RefreshToken(ctx context.Context, authData map[string]in
 		return "", fmt.Errorf("failed to parse token response: %w", err)
 	}
 
-	expiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	// Never cache or store an empty token from a 200 response
+	lifetime, err := oauth.ValidateTokenResponse(tokenResp.AccessToken, int(tokenResp.ExpiresIn))
+	if err != nil {
+		return "", fmt.Errorf("refresh failed: %w", err)
+	}
+	tokenResp.ExpiresIn = int64(lifetime.Seconds())
+
+	expiresAt := time.Now().Add(lifetime)
 	s.updateCacheAndDB(ctx, providerID, accountID, authData, &tokenResp, expiresAt)
 	return tokenResp.AccessToken, nil
 }
@@ -138,32 +138,60 @@ func (p *ProviderOAuth) ExchangeCode(ctx context.Context, code string, pkceCodes
 		return nil, fmt.Errorf("PKCE codes are required")
 	}
 
-	var body io.Reader
+	tokenResp, err := p.requestToken(ctx, map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          code,
+		"redirect_uri":  p.RedirectURI,
+		"code_verifier": pkceCodes.CodeVerifier,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("token exchange %w", err)
+	}
+	return tokenResp, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token
+func (p *ProviderOAuth) RefreshToken(ctx context.Context, refreshToken string) (*TokenResponse, error) {
+	params := map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+	}
+	// Codex CLI narrows the refreshed token to the identity scopes
+	if p.ProviderID == "codex" {
+		params["scope"] = "openid profile email"
+	}
+
+	tokenResp, err := p.requestToken(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("token refresh %w", err)
+	}
+	return tokenResp, nil
+}
+
+// newTokenRequest builds a token endpoint request with the provider's client credentials
+// Claude's endpoint takes a JSON body, the others form-encoded.
+func (p *ProviderOAuth) newTokenRequest(ctx context.Context, params map[string]string) (*http.Request, error) {
+	fields := map[string]string{"client_id": p.ClientID}
+	if p.ClientSecret != "" {
+		fields["client_secret"] = p.ClientSecret
+	}
+	for k, v := range params {
+		fields[k] = v
+	}
 
-	// Claude uses JSON, others use form
+	var body io.Reader
+	contentType := "application/x-www-form-urlencoded"
 	if p.ProviderID == "claude" {
-		reqBody := map[string]string{
-			"grant_type":    "authorization_code",
-			"client_id":     p.ClientID,
-			"code":          code,
-			"redirect_uri":  p.RedirectURI,
-			"code_verifier": pkceCodes.CodeVerifier,
-		}
-		jsonBody, err := json.Marshal(reqBody)
+		jsonBody, err := json.Marshal(fields)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
 		body = strings.NewReader(string(jsonBody))
+		contentType = "application/json"
 	} else {
-		data := url.Values{
-			"grant_type":    {"authorization_code"},
-			"code":          {code},
-			"redirect_uri":  {p.RedirectURI},
-			"code_verifier": {pkceCodes.CodeVerifier},
-			"client_id":     {p.ClientID},
-		}
-		if p.ClientSecret != "" {
-			data.Set("client_secret", p.ClientSecret)
+		data := url.Values{}
+		for k, v := range fields {
+			data.Set(k, v)
 		}
 		body = strings.NewReader(data.Encode())
 	}
@@ -172,17 +200,21 @@ func (p *ProviderOAuth) ExchangeCode(ctx context.Context, code string, pkceCodes
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
 
-	if p.ProviderID == "claude" {
-		req.Header.Set("Content-Type", "application/json")
-	} else {
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+// requestToken posts to the token endpoint and parses the token response
+func (p *ProviderOAuth) requestToken(ctx context.Context, params map[string]string) (*TokenResponse, error) {
+	req, err := p.newTokenRequest(ctx, params)
+	if err != nil {
+		return nil, err
 	}
-	req.Header.Set("Accept", "application/json")
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("token exchange request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -192,7 +224,7 @@ func (p *ProviderOAuth) ExchangeCode(ctx context.Context, code string, pkceCodes
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token exchange failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("failed with status %d: %s", resp.StatusCode, string(respBody))
 	}
 
 	var tokenResp TokenResponse
@@ -200,6 +232,12 @@ func (p *ProviderOAuth) ExchangeCode(ctx context.Context, code string, pkceCodes
 		return nil, fmt.Errorf("failed to parse token response: %w", err)
 	}
 
+	lifetime, err := ValidateTokenResponse(tokenResp.AccessToken, tokenResp.ExpiresIn)
+	if err != nil {
+		return nil, fmt.Errorf("failed: %w", err)
+	}
+	tokenResp.ExpiresIn = int(lifetime.Seconds())
+
 	return &tokenResp, nil
 }
 
@@ -2,8 +2,16 @@ package oauth
 
 import (
 	"aigateway-backend/auth/pkce"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetProviderOAuth(t *testing.T) {
@@ -172,3 +180,148 @@ func TestProviderOAuthFields(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshToken_ProviderRequestFormat(t *testing.T) {
+	tests := []struct {
+		providerID      string
+		wantContentType string
+		wantFields      map[string]string
+	}{
+		{
+			providerID:      "antigravity",
+			wantContentType: "application/x-www-form-urlencoded",
+			wantFields: map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": "rt-123",
+				"client_id":     AntigravityClientID,
+				"client_secret": AntigravitySecret,
+			},
+		},
+		{
+			providerID:      "codex",
+			wantContentType: "application/x-www-form-urlencoded",
+			wantFields: map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": "rt-123",
+				"client_id":     CodexClientID,
+				"scope":         "openid profile email",
+			},
+		},
+		{
+			providerID:      "claude",
+			wantContentType: "application/json",
+			wantFields: map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": "rt-123",
+				"client_id":     ClaudeClientID,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.providerID, func(t *testing.T) {
+			var contentType string
+			var fields map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				body, _ := io.ReadAll(r.Body)
+
+				fields = make(map[string]string)
+				if contentType == "application/json" {
+					if err := json.Unmarshal(body, &fields); err != nil {
+						t.Errorf("body is not a JSON object: %s", body)
+					}
+				} else {
+					values, _ := url.ParseQuery(string(body))
+					for k := range values {
+						fields[k] = values.Get(k)
+					}
+				}
+				w.Write([]byte(`{"access_token":"at-new","refresh_token":"rt-new","expires_in":3600}`))
+			}))
+			defer server.Close()
+
+			provider, _ := GetProviderOAuth(tt.providerID, "http://localhost/callback")
+			provider.TokenURL = server.URL
+
+			tokenResp, err := provider.RefreshToken(context.Background(), "rt-123")
+			if err != nil {
+				t.Fatalf("RefreshToken() error = %v", err)
+			}
+			if tokenResp.AccessToken != "at-new" || tokenResp.RefreshToken != "rt-new" {
+				t.Errorf("token response = %+v, want the refreshed tokens", tokenResp)
+			}
+
+			if contentType != tt.wantContentType {
+				t.Errorf("Content-Type = %s, want %s", contentType, tt.wantContentType)
+			}
+			if len(fields) != len(tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+			for k, want := range tt.wantFields {
+				if fields[k] != want {
+					t.Errorf("%s = %q, want %q", k, fields[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshToken_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+	}))
+	defer server.Close()
+
+	provider, _ := GetProviderOAuth("claude", "http://localhost/callback")
+	provider.TokenURL = server.URL
+
+	_, err := provider.RefreshToken(context.Background(), "rt-revoked")
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("RefreshToken() error = %v, want the upstream invalid_grant body", err)
+	}
+}
+
+func TestRefreshToken_EmptyAccessTokenIsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	provider, _ := GetProviderOAuth("codex", "http://localhost/callback")
+	provider.TokenURL = server.URL
+
+	tokenResp, err := provider.RefreshToken(context.Background(), "rt-123")
+	if !errors.Is(err, ErrNoAccessToken) {
+		t.Errorf("RefreshToken() = %+v, %v, want ErrNoAccessToken", tokenResp, err)
+	}
+}
+
+func TestValidateTokenResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		accessToken  string
+		expiresIn    int
+		wantLifetime time.Duration
+		wantErr      bool
+	}{
+		{"valid", "at", 3599, 3599 * time.Second, false},
+		{"missing expiry", "at", 0, DefaultTokenLifetime, false},
+		{"negative expiry", "at", -5, DefaultTokenLifetime, false},
+		{"empty token", "", 3600, 0, true},
+		{"blank token", "  ", 3600, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifetime, err := ValidateTokenResponse(tt.accessToken, tt.expiresIn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if lifetime != tt.wantLifetime {
+				t.Errorf("lifetime = %v, want %v", lifetime, tt.wantLifetime)
+			}
+		})
+	}
+}
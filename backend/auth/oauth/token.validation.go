@@ -0,0 +1,25 @@
+package oauth
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrNoAccessToken means the token endpoint answered 200 without an access token
+// Seen with revoked or malformed grants; retrying won't help, the account needs re-authentication.
+var ErrNoAccessToken = errors.New("token response contained no access_token")
+
+// DefaultTokenLifetime is assumed when a token response omits expires_in
+const DefaultTokenLifetime = time.Hour
+
+// ValidateTokenResponse checks a token endpoint result and returns how long the token lives
+func ValidateTokenResponse(accessToken string, expiresIn int) (time.Duration, error) {
+	if strings.TrimSpace(accessToken) == "" {
+		return 0, ErrNoAccessToken
+	}
+	if expiresIn <= 0 {
+		return DefaultTokenLifetime, nil
+	}
+	return time.Duration(expiresIn) * time.Second, nil
+}
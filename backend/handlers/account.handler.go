@@ -77,6 +77,10 @@ func (h *AccountHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateAccountBody(&account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	account.ID = uuid.New().String()
 
@@ -117,6 +121,10 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateAccountBody(&account); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	account.ID = id
 	account.CreatedBy = existing.CreatedBy // Preserve creator
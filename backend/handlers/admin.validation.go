@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"aigateway-backend/models"
+)
+
+// Column limits from the gorm models; longer values fail or truncate on insert
+const (
+	maxProviderIDLen  = 50
+	maxLabelLen       = 100
+	maxAliasLen       = 100
+	maxModelNameLen   = 100
+	maxDescriptionLen = 255
+	maxURLLen         = 255
+)
+
+// validateProxyBody checks a proxy create/update body before it is stored
+func validateProxyBody(proxy *models.Proxy) error {
+	if err := validateProxyURLField("url", proxy.URL, true); err != nil {
+		return err
+	}
+
+	switch proxy.Protocol {
+	case "", models.ProxyProtocolHTTP, models.ProxyProtocolHTTPS, models.ProxyProtocolSOCKS5:
+	default:
+		return fmt.Errorf("protocol must be one of http, https, socks5")
+	}
+
+	switch proxy.HealthStatus {
+	case "", models.HealthStatusHealthy, models.HealthStatusDegraded, models.HealthStatusDown:
+	default:
+		return fmt.Errorf("health_status must be one of healthy, degraded, down")
+	}
+
+	if proxy.MaxAccounts < 0 {
+		return fmt.Errorf("max_accounts must not be negative (0 = unlimited)")
+	}
+	if proxy.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
+	if proxy.MaxFailures < 0 {
+		return fmt.Errorf("max_failures must not be negative")
+	}
+	return nil
+}
+
+// validateMappingBody checks a model mapping create/update body before it is stored
+func validateMappingBody(req *CreateMappingRequest) error {
+	if strings.ContainsAny(req.Alias, " \t\r\n") {
+		return fmt.Errorf("alias must not contain whitespace")
+	}
+	if len(req.Alias) > maxAliasLen {
+		return fmt.Errorf("alias must be at most %d characters", maxAliasLen)
+	}
+	if len(req.ProviderID) > maxProviderIDLen {
+		return fmt.Errorf("provider_id must be at most %d characters", maxProviderIDLen)
+	}
+	if len(req.ModelName) > maxModelNameLen {
+		return fmt.Errorf("model_name must be at most %d characters", maxModelNameLen)
+	}
+	if len(req.Description) > maxDescriptionLen {
+		return fmt.Errorf("description must be at most %d characters", maxDescriptionLen)
+	}
+	if req.Priority < 0 {
+		return fmt.Errorf("priority must not be negative")
+	}
+	return nil
+}
+
+// validateAccountBody checks an account create/update body before it is stored
+func validateAccountBody(account *models.Account) error {
+	if account.ProviderID == "" {
+		return fmt.Errorf("provider_id is required")
+	}
+	if len(account.ProviderID) > maxProviderIDLen {
+		return fmt.Errorf("provider_id must be at most %d characters", maxProviderIDLen)
+	}
+	if strings.TrimSpace(account.Label) == "" {
+		return fmt.Errorf("label is required")
+	}
+	if len(account.Label) > maxLabelLen {
+		return fmt.Errorf("label must be at most %d characters", maxLabelLen)
+	}
+
+	if err := validateJSONObjectField("auth_data", account.AuthData, true); err != nil {
+		return err
+	}
+	if err := validateJSONObjectField("metadata", account.Metadata, false); err != nil {
+		return err
+	}
+	if err := validateProxyURLField("proxy_url", account.ProxyURL, false); err != nil {
+		return err
+	}
+
+	switch account.HealthStatus {
+	case "", string(models.HealthStatusHealthy), string(models.HealthStatusDegraded), string(models.HealthStatusDown):
+	default:
+		return fmt.Errorf("health_status must be one of healthy, degraded, down")
+	}
+
+	if err := validateModelPatterns("allowed_models", account.AllowedModels); err != nil {
+		return err
+	}
+	return validateModelPatterns("denied_models", account.DeniedModels)
+}
+
+// validateModelPatterns rejects empty model policy entries, which would never match
+func validateModelPatterns(field string, patterns models.StringArray) error {
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("%s must not contain empty entries", field)
+		}
+	}
+	return nil
+}
+
+// validateProxyURLField requires an http, https or socks5 URL with a host
+func validateProxyURLField(field, raw string, required bool) error {
+	if raw == "" {
+		if required {
+			return fmt.Errorf("%s is required", field)
+		}
+		return nil
+	}
+	if len(raw) > maxURLLen {
+		return fmt.Errorf("%s must be at most %d characters", field, maxURLLen)
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return fmt.Errorf("%s must be a URL like http://host:port", field)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("%s scheme must be http, https or socks5", field)
+	}
+	return nil
+}
+
+// validateJSONObjectField requires a string field to hold a JSON object
+func validateJSONObjectField(field, raw string, required bool) error {
+	if raw == "" {
+		if required {
+			return fmt.Errorf("%s is required", field)
+		}
+		return nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil || obj == nil {
+		return fmt.Errorf("%s must be a JSON object", field)
+	}
+	return nil
+}
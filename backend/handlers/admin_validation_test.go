@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aigateway-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendInvalid sends body to a handler and expects a 400 whose error contains want
+func sendInvalid(t *testing.T, method string, handler gin.HandlerFunc, body, want string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Handle(method, "/resource/:id", handler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, "/resource/1", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), want) {
+		t.Errorf("body = %s, want error containing %q", w.Body.String(), want)
+	}
+}
+
+func TestProxyManagement_RejectsInvalidBody(t *testing.T) {
+	handler := NewProxyManagementHandler(nil)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing url", `{"max_accounts":5}`, "url is required"},
+		{"malformed url", `{"url":"not a url"}`, "url must be a URL"},
+		{"unsupported scheme", `{"url":"ftp://proxy.example.com:21"}`, "url scheme must be http, https or socks5"},
+		{"negative max_accounts", `{"url":"http://proxy.example.com:8080","max_accounts":-1}`, "max_accounts must not be negative"},
+		{"unknown protocol", `{"url":"http://proxy.example.com:8080","protocol":"quic"}`, "protocol must be one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run("create "+tt.name, func(t *testing.T) {
+			sendInvalid(t, http.MethodPost, handler.Create, tt.body, tt.want)
+		})
+		t.Run("update "+tt.name, func(t *testing.T) {
+			sendInvalid(t, http.MethodPut, handler.Update, tt.body, tt.want)
+		})
+	}
+}
+
+func TestModelMapping_RejectsInvalidBody(t *testing.T) {
+	handler := NewModelMappingHandler(nil)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"whitespace alias", `{"alias":"my model","provider_id":"antigravity","model_name":"gemini-2.5-pro"}`, "alias must not contain whitespace"},
+		{"negative priority", `{"alias":"fast","provider_id":"antigravity","model_name":"gemini-2.5-flash","priority":-2}`, "priority must not be negative"},
+		{"long model name", `{"alias":"fast","provider_id":"antigravity","model_name":"` + strings.Repeat("m", 101) + `"}`, "model_name must be at most 100 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sendInvalid(t, http.MethodPost, handler.Create, tt.body, tt.want)
+		})
+	}
+}
+
+func TestAccount_RejectsInvalidBody(t *testing.T) {
+	handler := NewAccountHandler(nil)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing provider", `{"label":"a","auth_data":"{}"}`, "provider_id is required"},
+		{"missing label", `{"provider_id":"antigravity","auth_data":"{}"}`, "label is required"},
+		{"auth_data not json", `{"provider_id":"antigravity","label":"a","auth_data":"token"}`, "auth_data must be a JSON object"},
+		{"malformed proxy_url", `{"provider_id":"antigravity","label":"a","auth_data":"{}","proxy_url":"proxy:8080"}`, "proxy_url"},
+		{"unknown health_status", `{"provider_id":"antigravity","label":"a","auth_data":"{}","health_status":"fine"}`, "health_status must be one of"},
+		{"empty model pattern", `{"provider_id":"antigravity","label":"a","auth_data":"{}","allowed_models":[""]}`, "allowed_models must not contain empty entries"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sendInvalid(t, http.MethodPost, handler.Create, tt.body, tt.want)
+		})
+	}
+}
+
+func TestValidateBodies_AcceptValid(t *testing.T) {
+	if err := validateProxyBody(&models.Proxy{URL: "socks5://proxy.example.com:1080", MaxAccounts: 10, Protocol: models.ProxyProtocolSOCKS5}); err != nil {
+		t.Errorf("validateProxyBody() error = %v", err)
+	}
+	if err := validateMappingBody(&CreateMappingRequest{Alias: "fast", ProviderID: "antigravity", ModelName: "gemini-2.5-flash"}); err != nil {
+		t.Errorf("validateMappingBody() error = %v", err)
+	}
+	account := &models.Account{
+		ProviderID:    "antigravity",
+		Label:         "My Account",
+		AuthData:      `{"access_token":"ya29.xxx"}`,
+		ProxyURL:      "http://eu-proxy.example.com:8080",
+		AllowedModels: models.StringArray{"gemini-*"},
+	}
+	if err := validateAccountBody(account); err != nil {
+		t.Errorf("validateAccountBody() error = %v", err)
+	}
+}
@@ -13,13 +13,19 @@ import (
 )
 
 type APIKeyHandler struct {
-	apiKeyService *services.APIKeyService
+	apiKeyService    *services.APIKeyService
+	defaultRateLimit int // RPM for keys created without rate_limit_rpm, 0 = unlimited
 }
 
 func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
 	return &APIKeyHandler{apiKeyService: apiKeyService}
 }
 
+// SetDefaultRateLimit sets the requests-per-minute limit given to keys that don't set their own
+func (h *APIKeyHandler) SetDefaultRateLimit(rpm int) {
+	h.defaultRateLimit = rpm
+}
+
 func (h *APIKeyHandler) List(c *gin.Context) {
 	user := middleware.GetCurrentUser(c)
 	if user == nil {
@@ -63,7 +69,9 @@ func (h *APIKeyHandler) List(c *gin.Context) {
 }
 
 type CreateAPIKeyRequest struct {
-	Label string `json:"label"`
+	Label           string `json:"label"`
+	RateLimitRPM    *int   `json:"rate_limit_rpm"`   // Requests per minute, 0 = unlimited; admins only
+	ResponseProfile string `json:"response_profile"` // "", "string_content" or "array_content"
 }
 
 func (h *APIKeyHandler) Create(c *gin.Context) {
@@ -76,18 +84,38 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 	var req CreateAPIKeyRequest
 	c.ShouldBindJSON(&req)
 
-	apiKey, rawKey, err := h.apiKeyService.Generate(user.ID, req.Label)
+	// Only admins choose a key's rate limit; everyone else gets the configured default
+	rateLimitRPM := h.defaultRateLimit
+	if req.RateLimitRPM != nil {
+		if user.Role != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only admins can set rate_limit_rpm"})
+			return
+		}
+		if *req.RateLimitRPM < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rate_limit_rpm must be >= 0"})
+			return
+		}
+		rateLimitRPM = *req.RateLimitRPM
+	}
+	if !models.ValidResponseProfile(req.ResponseProfile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "response_profile must be string_content or array_content"})
+		return
+	}
+
+	apiKey, rawKey, err := h.apiKeyService.Generate(user.ID, req.Label, rateLimitRPM, req.ResponseProfile)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"id":         apiKey.ID,
-		"key":        rawKey,
-		"key_prefix": apiKey.KeyPrefix,
-		"label":      apiKey.Label,
-		"message":    "Save this key - it will not be shown again",
+		"id":               apiKey.ID,
+		"key":              rawKey,
+		"key_prefix":       apiKey.KeyPrefix,
+		"label":            apiKey.Label,
+		"rate_limit_rpm":   apiKey.RateLimitRPM,
+		"response_profile": apiKey.ResponseProfile,
+		"message":          "Save this key - it will not be shown again",
 	})
 }
 
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aigateway-backend/middleware"
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// createAPIKey posts body to APIKeyHandler.Create as a user with the given role
+func createAPIKey(t *testing.T, role models.Role, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.APIKey{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	handler := NewAPIKeyHandler(services.NewAPIKeyService(repositories.NewAPIKeyRepository(db), nil))
+	handler.SetDefaultRateLimit(30)
+
+	router := gin.New()
+	router.POST("/api-keys", func(c *gin.Context) {
+		c.Set(middleware.UserContextKey, &models.User{ID: "user-1", Role: role})
+		handler.Create(c)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api-keys", strings.NewReader(body)))
+	return w
+}
+
+func TestCreateAPIKey_RateLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     models.Role
+		body     string
+		wantCode int
+		wantRPM  int
+	}{
+		{"user gets default", models.RoleUser, `{"label":"k"}`, http.StatusCreated, 30},
+		{"user cannot set limit", models.RoleUser, `{"label":"k","rate_limit_rpm":0}`, http.StatusForbidden, 0},
+		{"admin gets default", models.RoleAdmin, `{"label":"k"}`, http.StatusCreated, 30},
+		{"admin sets unlimited", models.RoleAdmin, `{"label":"k","rate_limit_rpm":0}`, http.StatusCreated, 0},
+		{"admin sets limit", models.RoleAdmin, `{"label":"k","rate_limit_rpm":120}`, http.StatusCreated, 120},
+		{"admin negative limit", models.RoleAdmin, `{"label":"k","rate_limit_rpm":-1}`, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := createAPIKey(t, tt.role, tt.body)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var resp struct {
+				RateLimitRPM int `json:"rate_limit_rpm"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.RateLimitRPM != tt.wantRPM {
+				t.Errorf("rate_limit_rpm = %d, want %d", resp.RateLimitRPM, tt.wantRPM)
+			}
+		})
+	}
+}
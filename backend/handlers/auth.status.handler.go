@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"aigateway-backend/auth/manager"
+	"aigateway-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,6 +14,7 @@ import (
 type AuthStatusHandler struct {
 	manager *manager.Manager
 	metrics *manager.Metrics
+	breaker *services.CircuitBreaker
 }
 
 // NewAuthStatusHandler creates a new auth status handler
@@ -23,6 +25,11 @@ func NewAuthStatusHandler(m *manager.Manager, metrics *manager.Metrics) *AuthSta
 	}
 }
 
+// SetCircuitBreaker includes the router's per-provider breaker state in the health summary
+func (h *AuthStatusHandler) SetCircuitBreaker(breaker *services.CircuitBreaker) {
+	h.breaker = breaker
+}
+
 // GetAccountsStatus returns status of all accounts
 // GET /api/v1/auth/accounts
 func (h *AuthStatusHandler) GetAccountsStatus(c *gin.Context) {
@@ -149,22 +156,89 @@ func (h *AuthStatusHandler) GetHealthSummary(c *gin.Context) {
 		status = "critical"
 	}
 
+	breakers := make([]services.BreakerStatus, 0)
+	if h.breaker != nil {
+		breakers = h.breaker.Status()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":           status,
+		"total":            total,
+		"healthy":          healthy,
+		"blocked":          blocked,
+		"disabled":         disabled,
+		"provider_stats":   providerStats,
+		"circuit_breakers": breakers,
+		"checked_at":       now.Format(time.RFC3339),
+	})
+}
+
+// GetCandidates returns each account of a provider with its eligibility for a model
+// GET /api/v1/auth-manager/candidates?provider=...&model=...
+func (h *AuthStatusHandler) GetCandidates(c *gin.Context) {
+	if h.manager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "auth manager not initialized",
+		})
+		return
+	}
+
+	providerID := c.Query("provider")
+	model := c.Query("model")
+	if providerID == "" || model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "provider and model are required",
+		})
+		return
+	}
+
+	candidates := h.manager.Candidates(providerID, model)
+	eligible := 0
+	for _, candidate := range candidates {
+		if candidate.Eligible {
+			eligible++
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":         status,
-		"total":          total,
-		"healthy":        healthy,
-		"blocked":        blocked,
-		"disabled":       disabled,
-		"provider_stats": providerStats,
-		"checked_at":     now.Format(time.RFC3339),
+		"provider":   providerID,
+		"model":      model,
+		"candidates": candidates,
+		"total":      len(candidates),
+		"eligible":   eligible,
+		"checked_at": time.Now().Format(time.RFC3339),
+	})
+}
+
+// GetAccountEvents returns the health timeline of an account (block, unblock, disable)
+// GET /api/v1/auth-manager/accounts/:id/events
+func (h *AuthStatusHandler) GetAccountEvents(c *gin.Context) {
+	if h.manager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "auth manager not initialized",
+		})
+		return
+	}
+
+	accountID := c.Param("id")
+	events, ok := h.manager.GetAccountEvents(accountID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "account not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account_id": accountID,
+		"events":     events,
+		"total":      len(events),
 	})
 }
 
 func (h *AuthStatusHandler) buildAccountStatus(acc *manager.AccountState, now time.Time) AccountStatusResponse {
 	modelStatuses := make(map[string]ModelStatusResponse)
 
-	acc.Account = acc.Account // ensure not nil
-
 	for model, ms := range acc.ModelStates {
 		blocked, reason := acc.IsBlockedFor(model, now)
 		modelStatuses[model] = ModelStatusResponse{
@@ -184,6 +258,7 @@ func (h *AuthStatusHandler) buildAccountStatus(acc *manager.AccountState, now ti
 		Label:       acc.Account.Label,
 		IsDisabled:  acc.Disabled,
 		ModelStates: modelStatuses,
+		DailyBudget: h.manager.GetBudgetStatus(acc.Account.ID),
 		UpdatedAt:   formatTime(acc.UpdatedAt),
 	}
 }
@@ -199,12 +274,13 @@ func formatTime(t time.Time) string {
 
 // AccountStatusResponse represents account status in API response
 type AccountStatusResponse struct {
-	ID          string                       `json:"id"`
-	ProviderID  string                       `json:"provider_id"`
-	Label       string                       `json:"label"`
-	IsDisabled  bool                         `json:"is_disabled"`
+	ID          string                         `json:"id"`
+	ProviderID  string                         `json:"provider_id"`
+	Label       string                         `json:"label"`
+	IsDisabled  bool                           `json:"is_disabled"`
 	ModelStates map[string]ModelStatusResponse `json:"model_states"`
-	UpdatedAt   string                       `json:"updated_at"`
+	DailyBudget *manager.BudgetStatus          `json:"daily_budget,omitempty"`
+	UpdatedAt   string                         `json:"updated_at"`
 }
 
 // ModelStatusResponse represents model status in API response
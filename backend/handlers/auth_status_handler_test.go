@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/models"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetCandidates_ReportsBlockedAndEligibleAccounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := manager.NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", Label: "one", IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", Label: "two", IsActive: true})
+	m.AddAccount(&models.Account{ID: "acc-3", ProviderID: "openai", Label: "other", IsActive: true})
+
+	// Rate limit acc-1 so it enters cooldown for the model
+	m.MarkResult("acc-1", "gemini-2.5-pro", 429, nil, nil)
+
+	router := gin.New()
+	router.GET("/candidates", NewAuthStatusHandler(m, m.GetMetrics()).GetCandidates)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/candidates?provider=antigravity&model=gemini-2.5-pro", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Candidates []manager.CandidateStatus `json:"candidates"`
+		Eligible   int                       `json:"eligible"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(resp.Candidates) != 2 {
+		t.Fatalf("candidates = %+v, want only the two antigravity accounts", resp.Candidates)
+	}
+
+	blocked, eligible := resp.Candidates[0], resp.Candidates[1]
+	if blocked.AccountID != "acc-1" || blocked.Eligible || blocked.Reason != string(manager.BlockReasonCooldown) {
+		t.Errorf("acc-1 = %+v, want ineligible with reason %q", blocked, manager.BlockReasonCooldown)
+	}
+	if blocked.RetryAt == nil {
+		t.Error("acc-1 RetryAt should be set while in cooldown")
+	}
+	if eligible.AccountID != "acc-2" || !eligible.Eligible || eligible.Reason != "" {
+		t.Errorf("acc-2 = %+v, want eligible with no reason", eligible)
+	}
+	if resp.Eligible != 1 {
+		t.Errorf("eligible = %d, want 1", resp.Eligible)
+	}
+}
+
+func TestGetCandidates_RequiresProviderAndModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := manager.NewManager(nil, nil)
+	router := gin.New()
+	router.GET("/candidates", NewAuthStatusHandler(m, m.GetMetrics()).GetCandidates)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/candidates?provider=antigravity", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestGetHealthSummary_ReportsCircuitBreakers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := manager.NewManager(nil, nil)
+	breaker := services.NewCircuitBreaker(1, time.Minute)
+	breaker.RecordFailure("antigravity")
+	breaker.RecordSuccess("openai")
+
+	h := NewAuthStatusHandler(m, m.GetMetrics())
+	h.SetCircuitBreaker(breaker)
+	router := gin.New()
+	router.GET("/health", h.GetHealthSummary)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		CircuitBreakers []services.BreakerStatus `json:"circuit_breakers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(resp.CircuitBreakers) != 2 {
+		t.Fatalf("circuit_breakers = %+v, want antigravity and openai", resp.CircuitBreakers)
+	}
+	open, closed := resp.CircuitBreakers[0], resp.CircuitBreakers[1]
+	if open.ProviderID != "antigravity" || open.State != services.BreakerOpen || open.RetryAt == nil {
+		t.Errorf("antigravity breaker = %+v, want open with retry_at", open)
+	}
+	if closed.ProviderID != "openai" || closed.State != services.BreakerClosed {
+		t.Errorf("openai breaker = %+v, want closed", closed)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"aigateway-backend/services"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cache types accepted by the flush endpoint
+const (
+	CacheTypeTokens   = "tokens"
+	CacheTypeMappings = "mappings"
+)
+
+type CacheHandler struct {
+	oauthService   *services.OAuthService
+	mappingService *services.ModelMappingService
+}
+
+func NewCacheHandler(oauthService *services.OAuthService, mappingService *services.ModelMappingService) *CacheHandler {
+	return &CacheHandler{
+		oauthService:   oauthService,
+		mappingService: mappingService,
+	}
+}
+
+// Flush clears cached tokens and model-mapping resolutions
+// Query: type=tokens,mappings (comma-separated, default all)
+func (h *CacheHandler) Flush(c *gin.Context) {
+	types, err := parseCacheTypes(c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	cleared := make(map[string]int64)
+
+	for _, cacheType := range types {
+		var n int64
+		var err error
+
+		switch cacheType {
+		case CacheTypeTokens:
+			n, err = h.oauthService.FlushTokenCache(ctx)
+		case CacheTypeMappings:
+			n, err = h.mappingService.FlushCache(ctx)
+		}
+
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "cleared": cleared})
+			return
+		}
+		cleared[cacheType] = n
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cleared": cleared})
+}
+
+// parseCacheTypes validates the type query param, defaulting to all cache types
+func parseCacheTypes(param string) ([]string, error) {
+	if param == "" || param == "all" {
+		return []string{CacheTypeTokens, CacheTypeMappings}, nil
+	}
+
+	var types []string
+	for _, t := range strings.Split(param, ",") {
+		t = strings.TrimSpace(t)
+		switch t {
+		case CacheTypeTokens, CacheTypeMappings:
+			types = append(types, t)
+		default:
+			return nil, fmt.Errorf("unknown cache type %q (valid: tokens, mappings, all)", t)
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"aigateway-backend/middleware"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogHandler struct {
+	service *services.CatalogService
+}
+
+func NewCatalogHandler(service *services.CatalogService) *CatalogHandler {
+	return &CatalogHandler{service: service}
+}
+
+// GetCatalog returns every usable model with its provider, availability and capabilities
+// GET /api/v1/catalog
+func (h *CatalogHandler) GetCatalog(c *gin.Context) {
+	catalog, err := h.service.GetCatalog(middleware.GetCurrentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, catalog)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/middleware"
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+	"aigateway-backend/providers/antigravity"
+	"aigateway-backend/providers/openai"
+	"aigateway-backend/repositories"
+	"aigateway-backend/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupCatalog registers antigravity with a healthy account and openai with a rate-limited one
+func setupCatalog(t *testing.T) *CatalogHandler {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.ModelMapping{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	db.Create(&models.ModelMapping{Alias: "fast", ProviderID: "antigravity", ModelName: "gemini-2.5-flash", Enabled: true})
+	db.Create(&models.ModelMapping{Alias: "smart", ProviderID: "openai", ModelName: "gpt-4", Enabled: true})
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	registry := providers.NewRegistry()
+	registry.Register("antigravity", antigravity.NewAntigravityProvider())
+	registry.Register("openai", openai.NewOpenAIProvider())
+
+	authManager := manager.NewManager(nil, nil)
+	authManager.SetLogging(false)
+	authManager.AddAccount(&models.Account{ID: "acc-ag", ProviderID: "antigravity", IsActive: true})
+	authManager.AddAccount(&models.Account{ID: "acc-oa", ProviderID: "openai", IsActive: true})
+	authManager.MarkResult("acc-oa", "gpt-4", http.StatusTooManyRequests, nil, nil)
+
+	mappingService := services.NewModelMappingService(repositories.NewModelMappingRepository(db), client)
+	return NewCatalogHandler(services.NewCatalogService(registry, mappingService, authManager))
+}
+
+func getCatalog(t *testing.T, h *CatalogHandler) map[string]services.CatalogModel {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/api/v1/catalog", func(c *gin.Context) {
+		middleware.SetCurrentUser(c, &models.User{ID: "user-1", Role: models.RoleUser})
+		h.GetCatalog(c)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/catalog", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var resp services.CatalogResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	byID := make(map[string]services.CatalogModel, len(resp.Models))
+	for _, m := range resp.Models {
+		byID[m.ID] = m
+	}
+	return byID
+}
+
+func TestGetCatalog_AliasAvailabilityFollowsAccountHealth(t *testing.T) {
+	catalog := getCatalog(t, setupCatalog(t))
+
+	fast, ok := catalog["fast"]
+	if !ok {
+		t.Fatal("alias fast missing from catalog")
+	}
+	if !fast.Alias || fast.ProviderID != "antigravity" || fast.Model != "gemini-2.5-flash" || !fast.Available {
+		t.Errorf("fast = %+v, want available alias of antigravity/gemini-2.5-flash", fast)
+	}
+
+	smart, ok := catalog["smart"]
+	if !ok {
+		t.Fatal("alias smart missing from catalog")
+	}
+	if !smart.Alias || smart.ProviderID != "openai" || smart.Available {
+		t.Errorf("smart = %+v, want unavailable alias (its only openai account is rate limited)", smart)
+	}
+}
+
+func TestGetCatalog_ListsProviderModelsWithCapabilities(t *testing.T) {
+	catalog := getCatalog(t, setupCatalog(t))
+
+	thinking, ok := catalog["claude-sonnet-4-5-thinking"]
+	if !ok {
+		t.Fatal("antigravity model missing from catalog")
+	}
+	if thinking.Alias || !thinking.Available || !thinking.Capabilities.Thinking || !thinking.Capabilities.Streaming {
+		t.Errorf("claude-sonnet-4-5-thinking = %+v, want available streaming thinking model", thinking)
+	}
+
+	if gpt, ok := catalog["gpt-4"]; !ok || gpt.Available {
+		t.Errorf("gpt-4 = %+v (present %v), want listed but unavailable", gpt, ok)
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// HandleCompletions serves the legacy OpenAI /v1/completions endpoint
+// The prompt is sent through the regular pipeline as a single user message and the
+// Claude response is converted back to the text_completion shape. Streaming is not supported.
+func (h *ProxyHandler) HandleCompletions(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	model := gjson.GetBytes(body, "model").String()
+	if model == "" {
+		writeError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+
+	if gjson.GetBytes(body, "stream").Bool() || c.Query("stream") == "true" {
+		writeError(c, http.StatusBadRequest, "streaming is not supported for /v1/completions")
+		return
+	}
+
+	if body, model, err = h.applyModelSplit(body, model); err != nil {
+		writeError(c, http.StatusBadRequest, "failed to apply model split")
+		return
+	}
+
+	payload, prompt, err := legacyCompletionToClaude(body)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.execute(proxyContext(c, false), services.Request{
+		Model:     model,
+		Payload:   payload,
+		AccountID: pinnedAccount(c),
+		RequestID: requestID(c),
+	})
+	setUpstreamRequestID(c, resp.Headers)
+	if err != nil {
+		status, errBody := shapeError(c, err, resp.StatusCode, resp.Payload)
+		c.Data(status, "application/json", errBody)
+		return
+	}
+
+	echo := gjson.GetBytes(body, "echo").Bool()
+	c.Data(resp.StatusCode, "application/json", claudeToLegacyCompletion(resp.Payload, model, prompt, echo))
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// defaultLegacyMaxTokens matches the OpenAI default for /v1/completions when max_tokens is omitted
+const defaultLegacyMaxTokens = 16
+
+// legacyPrompt extracts the prompt from a legacy completion request
+// A single-element array is accepted; batched prompts are not supported.
+func legacyPrompt(body []byte) (string, error) {
+	prompt := gjson.GetBytes(body, "prompt")
+	switch {
+	case !prompt.Exists():
+		return "", fmt.Errorf("prompt is required")
+	case prompt.Type == gjson.String:
+		return prompt.String(), nil
+	case prompt.IsArray():
+		items := prompt.Array()
+		if len(items) != 1 || items[0].Type != gjson.String {
+			return "", fmt.Errorf("prompt must be a string or a single-element string array")
+		}
+		return items[0].String(), nil
+	default:
+		return "", fmt.Errorf("prompt must be a string")
+	}
+}
+
+// legacyCompletionToClaude converts a legacy text completion request into a Claude messages request
+// The prompt becomes a single user message; stop maps to stop_sequences. The extracted
+// prompt is returned alongside for echo.
+func legacyCompletionToClaude(body []byte) ([]byte, string, error) {
+	prompt, err := legacyPrompt(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	out := []byte(`{}`)
+	out, _ = sjson.SetBytes(out, "model", gjson.GetBytes(body, "model").String())
+	out, _ = sjson.SetBytes(out, "messages.0.role", "user")
+	out, _ = sjson.SetBytes(out, "messages.0.content", prompt)
+
+	maxTokens := int64(defaultLegacyMaxTokens)
+	if v := gjson.GetBytes(body, "max_tokens"); v.Exists() && v.Int() > 0 {
+		maxTokens = v.Int()
+	}
+	out, _ = sjson.SetBytes(out, "max_tokens", maxTokens)
+
+	for _, field := range []string{"temperature", "top_p"} {
+		if v := gjson.GetBytes(body, field); v.Exists() {
+			out, _ = sjson.SetRawBytes(out, field, []byte(v.Raw))
+		}
+	}
+
+	stop := gjson.GetBytes(body, "stop")
+	switch {
+	case stop.Type == gjson.String && stop.String() != "":
+		out, _ = sjson.SetBytes(out, "stop_sequences", []string{stop.String()})
+	case stop.IsArray():
+		var sequences []string
+		for _, s := range stop.Array() {
+			if s.String() != "" {
+				sequences = append(sequences, s.String())
+			}
+		}
+		if len(sequences) > 0 {
+			out, _ = sjson.SetBytes(out, "stop_sequences", sequences)
+		}
+	}
+
+	return out, prompt, nil
+}
+
+// claudeToLegacyCompletion converts a Claude messages response into the legacy text_completion shape
+// With echo set, the prompt is prepended to the completion text.
+func claudeToLegacyCompletion(claudeResp []byte, model, prompt string, echo bool) []byte {
+	var text strings.Builder
+	if echo {
+		text.WriteString(prompt)
+	}
+	for _, block := range gjson.GetBytes(claudeResp, "content").Array() {
+		if block.Get("type").String() == "text" {
+			text.WriteString(block.Get("text").String())
+		}
+	}
+
+	if m := gjson.GetBytes(claudeResp, "model").String(); m != "" {
+		model = m
+	}
+
+	inputTokens := gjson.GetBytes(claudeResp, "usage.input_tokens").Int()
+	outputTokens := gjson.GetBytes(claudeResp, "usage.output_tokens").Int()
+
+	out := []byte(`{"object":"text_completion"}`)
+	out, _ = sjson.SetBytes(out, "id", "cmpl-"+uuid.NewString())
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "choices.0.text", text.String())
+	out, _ = sjson.SetBytes(out, "choices.0.index", 0)
+	out, _ = sjson.SetRawBytes(out, "choices.0.logprobs", []byte("null"))
+	out, _ = sjson.SetBytes(out, "choices.0.finish_reason", legacyFinishReason(gjson.GetBytes(claudeResp, "stop_reason").String()))
+	out, _ = sjson.SetBytes(out, "usage.prompt_tokens", inputTokens)
+	out, _ = sjson.SetBytes(out, "usage.completion_tokens", outputTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", inputTokens+outputTokens)
+
+	return out
+}
+
+// legacyFinishReason maps a Claude stop_reason onto the legacy finish_reason values
+func legacyFinishReason(stopReason string) string {
+	if stopReason == "max_tokens" {
+		return "length"
+	}
+	return "stop"
+}
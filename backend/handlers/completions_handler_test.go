@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/models"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLegacyCompletionToClaude_BasicPrompt(t *testing.T) {
+	out, _, err := legacyCompletionToClaude([]byte(`{"model":"gpt-4","prompt":"Say hello","temperature":0.2}`))
+	if err != nil {
+		t.Fatalf("legacyCompletionToClaude() error = %v", err)
+	}
+
+	var req struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+		MaxTokens     int      `json:"max_tokens"`
+		Temperature   float64  `json:"temperature"`
+		StopSequences []string `json:"stop_sequences"`
+	}
+	if err := json.Unmarshal(out, &req); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out, err)
+	}
+
+	if req.Model != "gpt-4" {
+		t.Errorf("model = %q, want gpt-4", req.Model)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != "user" || req.Messages[0].Content != "Say hello" {
+		t.Errorf("messages = %+v, want a single user message with the prompt", req.Messages)
+	}
+	if req.MaxTokens != defaultLegacyMaxTokens {
+		t.Errorf("max_tokens = %d, want default %d", req.MaxTokens, defaultLegacyMaxTokens)
+	}
+	if req.Temperature != 0.2 {
+		t.Errorf("temperature = %v, want 0.2", req.Temperature)
+	}
+	if req.StopSequences != nil {
+		t.Errorf("stop_sequences = %v, want none", req.StopSequences)
+	}
+}
+
+func TestLegacyCompletionToClaude_Stop(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"string", `{"model":"m","prompt":"p","max_tokens":50,"stop":"\n"}`, []string{"\n"}},
+		{"array", `{"model":"m","prompt":["p"],"stop":["END","###"]}`, []string{"END", "###"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _, err := legacyCompletionToClaude([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("legacyCompletionToClaude() error = %v", err)
+			}
+
+			var req struct {
+				StopSequences []string `json:"stop_sequences"`
+			}
+			if err := json.Unmarshal(out, &req); err != nil {
+				t.Fatalf("invalid JSON %s: %v", out, err)
+			}
+			if strings.Join(req.StopSequences, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("stop_sequences = %q, want %q", req.StopSequences, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyCompletionToClaude_RejectsBatchedPrompts(t *testing.T) {
+	if _, _, err := legacyCompletionToClaude([]byte(`{"model":"m","prompt":["a","b"]}`)); err == nil {
+		t.Error("expected an error for multiple prompts")
+	}
+}
+
+func TestClaudeToLegacyCompletion(t *testing.T) {
+	claudeResp := []byte(`{
+		"id":"msg_1","type":"message","role":"assistant","model":"gpt-4",
+		"content":[{"type":"text","text":" world"}],
+		"stop_reason":"max_tokens",
+		"usage":{"input_tokens":3,"output_tokens":2}
+	}`)
+
+	tests := []struct {
+		name     string
+		echo     bool
+		wantText string
+	}{
+		{"no echo", false, " world"},
+		{"echo", true, "Hello world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				ID      string `json:"id"`
+				Object  string `json:"object"`
+				Model   string `json:"model"`
+				Choices []struct {
+					Text         string `json:"text"`
+					FinishReason string `json:"finish_reason"`
+				} `json:"choices"`
+				Usage struct {
+					TotalTokens int `json:"total_tokens"`
+				} `json:"usage"`
+			}
+			if err := json.Unmarshal(claudeToLegacyCompletion(claudeResp, "gpt-4", "Hello", tt.echo), &resp); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+
+			if resp.Object != "text_completion" || !strings.HasPrefix(resp.ID, "cmpl-") {
+				t.Errorf("object = %q, id = %q, want text_completion with cmpl- id", resp.Object, resp.ID)
+			}
+			if len(resp.Choices) != 1 || resp.Choices[0].Text != tt.wantText {
+				t.Fatalf("choices = %+v, want text %q", resp.Choices, tt.wantText)
+			}
+			if resp.Choices[0].FinishReason != "length" {
+				t.Errorf("finish_reason = %q, want length", resp.Choices[0].FinishReason)
+			}
+			if resp.Usage.TotalTokens != 5 {
+				t.Errorf("total_tokens = %d, want 5", resp.Usage.TotalTokens)
+			}
+		})
+	}
+}
+
+func TestHandleCompletions_Validation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/v1/completions", NewProxyHandler(nil, nil).HandleCompletions)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"missing model", `{"prompt":"hi"}`, "model is required"},
+		{"missing prompt", `{"model":"gpt-4"}`, "prompt is required"},
+		{"streaming", `{"model":"gpt-4","prompt":"hi","stream":true}`, "streaming is not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(tt.body)))
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body = %s, want error containing %q", w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleCompletions_RoutesLikeProxyRequests(t *testing.T) {
+	r, provider, router := setupProxyRouterService(t, models.RoleAdmin)
+	router.SetModelSplitter(services.NewModelSplitter(map[string][]services.SplitTarget{
+		"gemini-split": {{Model: "gemini-2.5-pro", Weight: 100}},
+	}, 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"model":"gemini-split","prompt":"hi"}`))
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set(manager.ExcludeAccountsHeader, "acc-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Request-ID"); got != "req-1" {
+		t.Errorf("X-Request-ID = %q, want req-1", got)
+	}
+	if fmt.Sprint(provider.accounts) != "[acc-2]" {
+		t.Errorf("accounts = %v, want [acc-2] with acc-1 excluded", provider.accounts)
+	}
+	if fmt.Sprint(provider.bodyModels) != "[gemini-2.5-pro]" {
+		t.Errorf("body models = %v, want [gemini-2.5-pro]", provider.bodyModels)
+	}
+}
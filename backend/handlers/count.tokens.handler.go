@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"aigateway-backend/providers"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// HandleCountTokens implements Anthropic's /v1/messages/count_tokens
+// Uses the routed provider's native count when available, otherwise a local estimate.
+func (h *ProxyHandler) HandleCountTokens(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	model := gjson.GetBytes(body, "model").String()
+	if model == "" {
+		writeError(c, http.StatusBadRequest, "model is required")
+		return
+	}
+	if !gjson.GetBytes(body, "messages").IsArray() {
+		writeError(c, http.StatusBadRequest, "messages is required")
+		return
+	}
+
+	ctx := context.Background()
+	if providers.SystemHintsDisabledByHeader(c.GetHeader(providers.SystemHintsHeader)) {
+		ctx = providers.WithSystemHintsDisabled(ctx)
+	}
+
+	count, err := h.routerService.CountTokens(ctx, services.Request{Model: model, Payload: body})
+	if err != nil {
+		writeError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	c.Header("X-Token-Count-Source", count.Source)
+	c.JSON(http.StatusOK, gin.H{"input_tokens": count.InputTokens})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aigateway-backend/providers"
+	"aigateway-backend/providers/glm"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// setupCountTokensRouter routes GLM models, which have no native count API
+func setupCountTokensRouter() *gin.Engine {
+	registry := providers.NewRegistry()
+	registry.Register("glm", glm.NewProvider())
+	router := services.NewRouterService(registry, nil, nil, nil, nil, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/messages/count_tokens", NewProxyHandler(nil, router).HandleCountTokens)
+	return r
+}
+
+func postCountTokens(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages/count_tokens", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleCountTokens_SimpleMessage(t *testing.T) {
+	// 12 characters of text -> 3 tokens, plus 3 tokens of message overhead
+	w := postCountTokens(setupCountTokensRouter(), `{"model":"glm-4.6","messages":[{"role":"user","content":"Hello, world"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := gjson.Get(w.Body.String(), "input_tokens").Int(); got != 6 {
+		t.Errorf("input_tokens = %d, want 6", got)
+	}
+	if got := w.Header().Get("X-Token-Count-Source"); got != services.TokenCountSourceEstimate {
+		t.Errorf("source = %q, want %q", got, services.TokenCountSourceEstimate)
+	}
+}
+
+func TestHandleCountTokens_Errors(t *testing.T) {
+	r := setupCountTokensRouter()
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"missing model", `{"messages":[{"role":"user","content":"hi"}]}`, http.StatusBadRequest},
+		{"missing messages", `{"model":"glm-4.6"}`, http.StatusBadRequest},
+		{"unknown model", `{"model":"no-such-model","messages":[{"role":"user","content":"hi"}]}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postCountTokens(r, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+			if gjson.Get(w.Body.String(), "type").String() != "error" {
+				t.Errorf("body = %s, want Claude error shape", w.Body.String())
+			}
+		})
+	}
+}
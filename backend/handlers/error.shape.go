@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/internal/apierror"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// wantsOpenAIErrors reports whether the endpoint's clients expect the OpenAI error shape
+func wantsOpenAIErrors(c *gin.Context) bool {
+	return apierror.WantsOpenAI(c.Request.URL.Path)
+}
+
+// errorBody builds the error envelope the endpoint's SDKs parse
+func errorBody(c *gin.Context, errType, message string) []byte {
+	return apierror.Body(wantsOpenAIErrors(c), errType, message)
+}
+
+// writeError sends an error in the endpoint's format, typed by status
+func writeError(c *gin.Context, status int, message string) {
+	apierror.Write(c, status, message)
+}
+
+// shapeError maps a failed request to the status and error body sent to the client
+// Gateway failures (all accounts blocked, quota exhausted, circuit open) become rate-limit
+// or overloaded errors with a Retry-After hint; upstream errors keep their status and message.
+func shapeError(c *gin.Context, err error, status int, upstreamBody []byte) (int, []byte) {
+	var (
+		allBlocked   *manager.AllBlockedError
+		allExhausted *manager.AllExhaustedError
+		circuitOpen  *services.CircuitOpenError
+	)
+	switch {
+	case errors.As(err, &circuitOpen):
+		setRetryAfter(c, time.Until(circuitOpen.RetryAt))
+		return http.StatusServiceUnavailable, errorBody(c, apierror.TypeOverloaded, err.Error())
+	case errors.As(err, &allBlocked):
+		setRetryAfter(c, time.Until(allBlocked.WaitDuration))
+		return http.StatusTooManyRequests, errorBody(c, apierror.TypeRateLimit, err.Error())
+	case errors.As(err, &allExhausted):
+		setRetryAfter(c, allExhausted.WaitDuration())
+		return http.StatusTooManyRequests, errorBody(c, apierror.TypeRateLimit, err.Error())
+	}
+
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	if isClientErrorShape(c, upstreamBody) {
+		return status, upstreamBody
+	}
+
+	message := upstreamErrorMessage(upstreamBody)
+	if message == "" && err != nil {
+		message = err.Error()
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return status, errorBody(c, apierror.TypeForStatus(status), message)
+}
+
+// isClientErrorShape reports whether an upstream error body is already in the endpoint's format
+func isClientErrorShape(c *gin.Context, body []byte) bool {
+	if len(body) == 0 || !gjson.GetBytes(body, "error.message").Exists() || !gjson.GetBytes(body, "error.type").Exists() {
+		return false
+	}
+	if wantsOpenAIErrors(c) {
+		return !gjson.GetBytes(body, "type").Exists()
+	}
+	return gjson.GetBytes(body, "type").String() == "error"
+}
+
+// upstreamErrorMessage extracts a readable message from a provider error body
+func upstreamErrorMessage(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	for _, path := range []string{"error.message", "message", "error"} {
+		if v := gjson.GetBytes(body, path); v.Type == gjson.String && v.String() != "" {
+			return v.String()
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// setRetryAfter hints when the client may retry, rounded up to whole seconds
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	if wait <= 0 {
+		return
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
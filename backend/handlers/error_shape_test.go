@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+func newErrorContext(path string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, path, nil)
+	return c, w
+}
+
+func TestShapeError_Classes(t *testing.T) {
+	resetAt := time.Now().Add(90 * time.Second)
+
+	tests := []struct {
+		name           string
+		err            error
+		status         int
+		upstreamBody   string
+		wantStatus     int
+		wantType       string
+		wantOpenAIType string
+		wantMessage    string
+		wantRetryAfter bool
+	}{
+		{
+			name:           "all blocked",
+			err:            fmt.Errorf("failed: %w", &manager.AllBlockedError{WaitDuration: resetAt, Message: "all accounts blocked"}),
+			wantStatus:     http.StatusTooManyRequests,
+			wantType:       "rate_limit_error",
+			wantOpenAIType: "rate_limit_error",
+			wantMessage:    "all accounts blocked",
+			wantRetryAfter: true,
+		},
+		{
+			name:           "quota exhausted",
+			err:            &manager.AllExhaustedError{ResetAt: &resetAt, AccountCount: 2},
+			wantStatus:     http.StatusTooManyRequests,
+			wantType:       "rate_limit_error",
+			wantOpenAIType: "rate_limit_error",
+			wantMessage:    "all 2 accounts quota exhausted",
+			wantRetryAfter: true,
+		},
+		{
+			name:           "circuit open",
+			err:            &services.CircuitOpenError{ProviderID: "antigravity", RetryAt: resetAt},
+			status:         http.StatusServiceUnavailable,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantType:       "overloaded_error",
+			wantOpenAIType: "server_error",
+			wantRetryAfter: true,
+		},
+		{
+			name:           "upstream rate limited",
+			err:            errors.New("provider execution failed"),
+			status:         http.StatusTooManyRequests,
+			upstreamBody:   `{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`,
+			wantStatus:     http.StatusTooManyRequests,
+			wantType:       "rate_limit_error",
+			wantOpenAIType: "rate_limit_error",
+			wantMessage:    "Resource has been exhausted",
+		},
+		{
+			name:           "upstream server error",
+			err:            errors.New("provider execution failed"),
+			status:         http.StatusBadGateway,
+			upstreamBody:   `upstream connect error`,
+			wantStatus:     http.StatusBadGateway,
+			wantType:       "api_error",
+			wantOpenAIType: "server_error",
+			wantMessage:    "upstream connect error",
+		},
+		{
+			name:           "internal failure",
+			err:            errors.New("failed to get access token: boom"),
+			wantStatus:     http.StatusInternalServerError,
+			wantType:       "api_error",
+			wantOpenAIType: "server_error",
+			wantMessage:    "failed to get access token: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newErrorContext("/v1/messages")
+			status, body := shapeError(c, tt.err, tt.status, []byte(tt.upstreamBody))
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if got := gjson.GetBytes(body, "type").String(); got != "error" {
+				t.Errorf("type = %q, want error in %s", got, body)
+			}
+			if got := gjson.GetBytes(body, "error.type").String(); got != tt.wantType {
+				t.Errorf("error.type = %q, want %q", got, tt.wantType)
+			}
+			if got := gjson.GetBytes(body, "error.message").String(); !strings.Contains(got, tt.wantMessage) {
+				t.Errorf("error.message = %q, want it to contain %q", got, tt.wantMessage)
+			}
+			if hasRetryAfter := w.Header().Get("Retry-After") != ""; hasRetryAfter != tt.wantRetryAfter {
+				t.Errorf("Retry-After set = %v, want %v", hasRetryAfter, tt.wantRetryAfter)
+			}
+
+			c, _ = newErrorContext("/v1/chat/completions")
+			status, body = shapeError(c, tt.err, tt.status, []byte(tt.upstreamBody))
+			if status != tt.wantStatus {
+				t.Errorf("openai status = %d, want %d", status, tt.wantStatus)
+			}
+			if gjson.GetBytes(body, "type").Exists() {
+				t.Errorf("openai body has a top-level type: %s", body)
+			}
+			if got := gjson.GetBytes(body, "error.type").String(); got != tt.wantOpenAIType {
+				t.Errorf("openai error.type = %q, want %q", got, tt.wantOpenAIType)
+			}
+			if got := gjson.GetBytes(body, "error.message").String(); !strings.Contains(got, tt.wantMessage) {
+				t.Errorf("openai error.message = %q, want it to contain %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestShapeError_PassesThroughMatchingUpstreamEnvelope(t *testing.T) {
+	upstream := `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: too large"}}`
+
+	c, _ := newErrorContext("/v1/messages")
+	status, body := shapeError(c, errors.New("provider execution failed"), http.StatusBadRequest, []byte(upstream))
+	if status != http.StatusBadRequest || string(body) != upstream {
+		t.Errorf("shapeError() = %d %s, want upstream body unchanged", status, body)
+	}
+}
+
+func TestHandleProxy_ValidationErrorShape(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler := NewProxyHandler(nil, nil)
+	router.POST("/v1/messages", handler.HandleProxy)
+	router.POST("/v1/chat/completions", handler.HandleProxy)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	if got := gjson.Get(w.Body.String(), "error.type").String(); got != "invalid_request_error" {
+		t.Errorf("messages error.type = %q, want invalid_request_error in %s", got, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`)))
+	if got := gjson.Get(w.Body.String(), "error.message").String(); got != "model is required" {
+		t.Errorf("chat completions error.message = %q, want model is required in %s", got, w.Body.String())
+	}
+	if !gjson.Get(w.Body.String(), "error.param").Exists() {
+		t.Errorf("chat completions body %s is missing the OpenAI param field", w.Body.String())
+	}
+}
+
+func TestHandleCompletions_ErrorShape(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/v1/completions", NewProxyHandler(nil, nil).HandleCompletions)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	if got := gjson.Get(w.Body.String(), "error.message").String(); got != "model is required" {
+		t.Errorf("error.message = %q, want model is required in %s", got, w.Body.String())
+	}
+	if !gjson.Get(w.Body.String(), "error.param").Exists() {
+		t.Errorf("body %s is missing the OpenAI param field", w.Body.String())
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"aigateway-backend/auth/manager"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// readinessTimeout bounds each dependency check so a hung backend can't stall the probe
+const readinessTimeout = 2 * time.Second
+
+// HealthHandler serves the readiness probe
+// GET /health stays on ProxyHandler as the lightweight liveness check.
+type HealthHandler struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	authManager *manager.Manager
+}
+
+func NewHealthHandler(db *gorm.DB, redis *redis.Client, authManager *manager.Manager) *HealthHandler {
+	return &HealthHandler{
+		db:          db,
+		redis:       redis,
+		authManager: authManager,
+	}
+}
+
+// ComponentStatus is the readiness result of one dependency
+type ComponentStatus struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ProviderReadiness is the healthy account count of one provider
+type ProviderReadiness struct {
+	Healthy bool `json:"healthy"`
+	Total   int  `json:"total"`
+	Ready   int  `json:"ready"`
+}
+
+// Ready reports whether the gateway can serve traffic
+// GET /health/ready - 200 when the database, Redis and every provider with accounts are healthy, 503 otherwise
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	components := map[string]ComponentStatus{
+		"database": h.checkDatabase(ctx),
+		"redis":    h.checkRedis(ctx),
+	}
+	providers := h.checkProviders(time.Now())
+
+	ready := true
+	for _, status := range components {
+		ready = ready && status.Healthy
+	}
+	for _, status := range providers {
+		ready = ready && status.Healthy
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, gin.H{
+		"status":     status,
+		"components": components,
+		"providers":  providers,
+		"checked_at": time.Now().Format(time.RFC3339),
+	})
+}
+
+func (h *HealthHandler) checkDatabase(ctx context.Context) ComponentStatus {
+	if h.db == nil {
+		return ComponentStatus{Error: "database not configured"}
+	}
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return ComponentStatus{Error: err.Error()}
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return ComponentStatus{Error: err.Error()}
+	}
+	return ComponentStatus{Healthy: true}
+}
+
+func (h *HealthHandler) checkRedis(ctx context.Context) ComponentStatus {
+	if h.redis == nil {
+		return ComponentStatus{Error: "redis not configured"}
+	}
+	if err := h.redis.Ping(ctx).Err(); err != nil {
+		return ComponentStatus{Error: err.Error()}
+	}
+	return ComponentStatus{Healthy: true}
+}
+
+// checkProviders counts accounts that are enabled and not blocked for any model
+// A provider is unhealthy when it has accounts but none of them can take traffic.
+func (h *HealthHandler) checkProviders(now time.Time) map[string]ProviderReadiness {
+	providers := make(map[string]ProviderReadiness)
+	if h.authManager == nil {
+		return providers
+	}
+
+	for _, acc := range h.authManager.GetAllAccounts() {
+		status := providers[acc.Account.ProviderID]
+		status.Total++
+		if accountReady(acc, now) {
+			status.Ready++
+		}
+		status.Healthy = status.Ready > 0
+		providers[acc.Account.ProviderID] = status
+	}
+	return providers
+}
+
+// accountReady reports whether an account is enabled and not blocked for any model
+func accountReady(acc *manager.AccountState, now time.Time) bool {
+	if acc.Disabled {
+		return false
+	}
+	for model := range acc.ModelStates {
+		if blocked, _ := acc.IsBlockedFor(model, now); blocked {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type readinessResponse struct {
+	Status     string                       `json:"status"`
+	Components map[string]ComponentStatus   `json:"components"`
+	Providers  map[string]ProviderReadiness `json:"providers"`
+}
+
+func setupReadiness(t *testing.T) (*miniredis.Miniredis, *manager.Manager, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	m := manager.NewManager(nil, nil)
+	m.SetLogging(false)
+	m.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true})
+
+	router := gin.New()
+	router.GET("/health/ready", NewHealthHandler(db, client, m).Ready)
+	return mr, m, router
+}
+
+func getReadiness(t *testing.T, router *gin.Engine) (int, readinessResponse) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var resp readinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return w.Code, resp
+}
+
+func TestReady_AllHealthy(t *testing.T) {
+	_, _, router := setupReadiness(t)
+
+	code, resp := getReadiness(t, router)
+	if code != http.StatusOK || resp.Status != "ready" {
+		t.Fatalf("status = %d %q, want 200 ready: %+v", code, resp.Status, resp)
+	}
+	if p := resp.Providers["antigravity"]; !p.Healthy || p.Ready != 1 {
+		t.Errorf("antigravity = %+v, want one ready account", p)
+	}
+}
+
+func TestReady_RedisDown(t *testing.T) {
+	mr, _, router := setupReadiness(t)
+	mr.Close()
+
+	code, resp := getReadiness(t, router)
+	if code != http.StatusServiceUnavailable || resp.Status != "not_ready" {
+		t.Fatalf("status = %d %q, want 503 not_ready", code, resp.Status)
+	}
+	if r := resp.Components["redis"]; r.Healthy || r.Error == "" {
+		t.Errorf("redis = %+v, want unhealthy with an error", r)
+	}
+	if !resp.Components["database"].Healthy {
+		t.Errorf("database = %+v, want healthy", resp.Components["database"])
+	}
+}
+
+func TestReady_NoHealthyAccounts(t *testing.T) {
+	_, m, router := setupReadiness(t)
+	m.MarkResult("acc-1", "gemini-2.5-pro", 429, nil, nil)
+
+	code, resp := getReadiness(t, router)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", code)
+	}
+	if p := resp.Providers["antigravity"]; p.Healthy || p.Total != 1 || p.Ready != 0 {
+		t.Errorf("antigravity = %+v, want unhealthy with no ready accounts", p)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/models"
+	"aigateway-backend/services"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prometheusContentType is the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type MetricsHandler struct {
+	quotaService *services.QuotaTrackerService
+	authManager  *manager.Manager
+}
+
+func NewMetricsHandler(quotaService *services.QuotaTrackerService, authManager *manager.Manager) *MetricsHandler {
+	return &MetricsHandler{
+		quotaService: quotaService,
+		authManager:  authManager,
+	}
+}
+
+// quotaGauge describes one exported per-account/model gauge
+type quotaGauge struct {
+	name  string
+	help  string
+	value func(s *models.QuotaStatus) (float64, bool)
+}
+
+var quotaGauges = []quotaGauge{
+	{
+		name:  "aigateway_account_requests_used",
+		help:  "Requests used in the current quota window",
+		value: func(s *models.QuotaStatus) (float64, bool) { return float64(s.RequestsUsed), true },
+	},
+	{
+		name:  "aigateway_account_tokens_used",
+		help:  "Tokens used in the current quota window",
+		value: func(s *models.QuotaStatus) (float64, bool) { return float64(s.TokensUsed), true },
+	},
+	{
+		name: "aigateway_account_exhausted",
+		help: "Whether the account quota is exhausted for the model (1 = exhausted)",
+		value: func(s *models.QuotaStatus) (float64, bool) {
+			if s.IsExhausted {
+				return 1, true
+			}
+			return 0, true
+		},
+	},
+	{
+		name: "aigateway_account_percent_used",
+		help: "Percent of the learned request limit used (only when a limit is known)",
+		value: func(s *models.QuotaStatus) (float64, bool) {
+			if s.PercentUsed == nil {
+				return 0, false
+			}
+			return *s.PercentUsed, true
+		},
+	},
+}
+
+// Metrics serves quota status for loaded accounts in Prometheus text format
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	statuses := h.collectQuotaStatuses()
+
+	c.Header("Content-Type", prometheusContentType)
+	c.Status(http.StatusOK)
+	writeQuotaMetrics(c.Writer, statuses)
+}
+
+// collectQuotaStatuses gathers quota status for every tracked model of each loaded account
+func (h *MetricsHandler) collectQuotaStatuses() []*models.QuotaStatus {
+	accounts := h.authManager.GetAllAccounts()
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Account.ID < accounts[j].Account.ID
+	})
+
+	var statuses []*models.QuotaStatus
+	for _, acc := range accounts {
+		for _, model := range h.quotaService.TrackedModels(acc.Account.ID) {
+			statuses = append(statuses, h.quotaService.GetQuotaStatus(acc.Account.ID, model))
+		}
+	}
+	return statuses
+}
+
+// writeQuotaMetrics renders quota gauges in Prometheus exposition format
+func writeQuotaMetrics(w io.Writer, statuses []*models.QuotaStatus) {
+	for _, gauge := range quotaGauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", gauge.name, gauge.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", gauge.name)
+
+		for _, status := range statuses {
+			value, ok := gauge.value(status)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "%s{account=\"%s\",model=\"%s\"} %g\n",
+				gauge.name, escapeLabelValue(status.AccountID), escapeLabelValue(status.Model), value)
+		}
+	}
+}
+
+// escapeLabelValue escapes backslash, quote and newline per the exposition format
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
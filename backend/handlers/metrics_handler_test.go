@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+	"aigateway-backend/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupMetricsHandler creates a handler backed by SQLite and miniredis with one loaded account
+func setupMetricsHandler(t *testing.T) (*MetricsHandler, *services.QuotaTrackerService, *repositories.QuotaPatternRepository, *miniredis.Miniredis) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to test db: %v", err)
+	}
+	err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS account_quota_pattern (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id TEXT NOT NULL,
+			model TEXT NOT NULL,
+			est_request_limit INTEGER,
+			est_token_limit INTEGER,
+			max_observed_requests INTEGER,
+			max_observed_tokens INTEGER,
+			confidence REAL DEFAULT 0,
+			sample_count INTEGER DEFAULT 0,
+			last_exhausted_at DATETIME,
+			last_reset_at DATETIME,
+			created_at DATETIME,
+			updated_at DATETIME,
+			UNIQUE(account_id, model)
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	patternRepo := repositories.NewQuotaPatternRepository(db)
+	quotaService := services.NewQuotaTrackerService(patternRepo, redisClient)
+
+	authManager := manager.NewManager(nil, redisClient)
+	authManager.SetLogging(false)
+	authManager.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", IsActive: true})
+
+	return NewMetricsHandler(quotaService, authManager), quotaService, patternRepo, mr
+}
+
+func TestMetricsHandler_QuotaGauges(t *testing.T) {
+	handler, quotaService, patternRepo, mr := setupMetricsHandler(t)
+	defer mr.Close()
+
+	model := "gemini-2.5-pro"
+	for i := 0; i < 3; i++ {
+		quotaService.RecordUsage("acc-1", model, 100)
+	}
+	limit := 10
+	if err := patternRepo.Upsert(&models.AccountQuotaPattern{AccountID: "acc-1", Model: model, EstRequestLimit: &limit}); err != nil {
+		t.Fatalf("failed to seed pattern: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/metrics", handler.Metrics)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want Prometheus text format", ct)
+	}
+
+	body := w.Body.String()
+	wantLines := []string{
+		"# TYPE aigateway_account_requests_used gauge",
+		`aigateway_account_requests_used{account="acc-1",model="gemini-2.5-pro"} 3`,
+		`aigateway_account_tokens_used{account="acc-1",model="gemini-2.5-pro"} 300`,
+		`aigateway_account_exhausted{account="acc-1",model="gemini-2.5-pro"} 0`,
+		`aigateway_account_percent_used{account="acc-1",model="gemini-2.5-pro"} 30`,
+	}
+	for _, line := range wantLines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing line %q in output:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsHandler_ExhaustedWithoutLearnedLimit(t *testing.T) {
+	handler, _, _, mr := setupMetricsHandler(t)
+	defer mr.Close()
+
+	mr.Set("quota:acc-1:gemini-2.5-flash:exhausted", "1")
+
+	var sb strings.Builder
+	writeQuotaMetrics(&sb, handler.collectQuotaStatuses())
+	body := sb.String()
+
+	if !strings.Contains(body, `aigateway_account_exhausted{account="acc-1",model="gemini-2.5-flash"} 1`) {
+		t.Errorf("missing exhausted gauge in output:\n%s", body)
+	}
+	if strings.Contains(body, `aigateway_account_percent_used{account="acc-1"`) {
+		t.Errorf("percent_used should be omitted without a learned limit:\n%s", body)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if got := escapeLabelValue("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("escapeLabelValue() = %q", got)
+	}
+}
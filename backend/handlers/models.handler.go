@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"aigateway-backend/middleware"
 	"aigateway-backend/services"
 	"net/http"
 
@@ -8,11 +9,12 @@ import (
 )
 
 type ModelsHandler struct {
-	service *services.ModelsService
+	service  *services.ModelsService
+	mappings *services.ModelMappingService
 }
 
-func NewModelsHandler(service *services.ModelsService) *ModelsHandler {
-	return &ModelsHandler{service: service}
+func NewModelsHandler(service *services.ModelsService, mappings *services.ModelMappingService) *ModelsHandler {
+	return &ModelsHandler{service: service, mappings: mappings}
 }
 
 func (h *ModelsHandler) GetModels(c *gin.Context) {
@@ -22,5 +24,20 @@ func (h *ModelsHandler) GetModels(c *gin.Context) {
 		return
 	}
 
+	// Routable aliases depend on the caller, so they are added after the shared cache
+	mappings, err := h.mappings.ListEnabledFor(middleware.GetCurrentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, m := range mappings {
+		response.Aliases = append(response.Aliases, services.AliasedModel{
+			ID:          m.Alias,
+			ProviderID:  m.ProviderID,
+			AliasedTo:   m.ModelName,
+			Description: m.Description,
+		})
+	}
+
 	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"aigateway-backend/middleware"
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+	"aigateway-backend/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupModels seeds one provider and mappings owned by nobody, user-1 and user-2
+func setupModels(t *testing.T) *ModelsHandler {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Provider{}, &models.ModelMapping{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	db.Create(&models.Provider{ID: "antigravity", Name: "Antigravity", SupportedAuthTypes: models.StringArray{"oauth"}, SupportedModels: `["gemini-2.5-pro"]`, IsActive: true})
+
+	user1, user2 := "user-1", "user-2"
+	mappings := []*models.ModelMapping{
+		{Alias: "fast", ProviderID: "antigravity", ModelName: "gemini-2.5-flash", Enabled: true},
+		{Alias: "retired", ProviderID: "antigravity", ModelName: "gemini-1.5-pro", Enabled: true},
+		{Alias: "mine", ProviderID: "antigravity", ModelName: "gemini-2.5-pro", Enabled: true, OwnerID: &user1},
+		{Alias: "theirs", ProviderID: "antigravity", ModelName: "gemini-2.5-pro", Enabled: true, OwnerID: &user2},
+	}
+	for _, m := range mappings {
+		db.Create(m)
+	}
+	// Enabled defaults to true, so disable after insert
+	db.Model(&models.ModelMapping{}).Where("alias = ?", "retired").Update("enabled", false)
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	mappingService := services.NewModelMappingService(repositories.NewModelMappingRepository(db), client)
+	return NewModelsHandler(services.NewModelsService(db, client), mappingService)
+}
+
+// listAliases calls GET /v1/models as user and returns "alias->model" entries
+func listAliases(t *testing.T, h *ModelsHandler, user *models.User) []string {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/v1/models", func(c *gin.Context) {
+		if user != nil {
+			middleware.SetCurrentUser(c, user)
+		}
+		h.GetModels(c)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var resp services.ModelsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Providers) != 1 || resp.Providers[0].ID != "antigravity" {
+		t.Errorf("providers = %+v, want antigravity", resp.Providers)
+	}
+
+	var aliases []string
+	for _, a := range resp.Aliases {
+		aliases = append(aliases, a.ID+"->"+a.AliasedTo)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+func TestGetModels_IncludesVisibleAliases(t *testing.T) {
+	h := setupModels(t)
+
+	tests := []struct {
+		name string
+		user *models.User
+		want []string
+	}{
+		{"anonymous", nil, []string{"fast->gemini-2.5-flash"}},
+		{"owner", &models.User{ID: "user-1", Role: models.RoleUser}, []string{"fast->gemini-2.5-flash", "mine->gemini-2.5-pro"}},
+		{"admin", &models.User{ID: "admin-1", Role: models.RoleAdmin}, []string{"fast->gemini-2.5-flash", "mine->gemini-2.5-pro", "theirs->gemini-2.5-pro"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run twice so the second call is served from the shared models cache
+			for i := 0; i < 2; i++ {
+				if got := listAliases(t, h, tt.user); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("aliases = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"aigateway-backend/middleware"
 	"aigateway-backend/services"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -49,7 +51,7 @@ func (h *OAuthHandler) Callback(c *gin.Context) {
 	// Check if this is a lite flow by looking for access key in session
 	accessKey := h.service.GetAccessKeyFromState(c.Request.Context(), c.Query("state"))
 
-	resp, err := h.service.ExchangeCode(c.Request.Context(), callbackURL)
+	resp, err := h.service.ExchangeCode(c.Request.Context(), callbackURL, nil)
 	if err != nil {
 		if accessKey != "" {
 			c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(h.liteErrorHTML(err.Error(), accessKey)))
@@ -77,9 +79,18 @@ func (h *OAuthHandler) Exchange(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.service.ExchangeCode(c.Request.Context(), req.CallbackURL)
+	var requestedBy *string
+	if user := middleware.GetCurrentUser(c); user != nil {
+		requestedBy = &user.ID
+	}
+
+	resp, err := h.service.ExchangeCode(c.Request.Context(), req.CallbackURL, requestedBy)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrOAuthSessionMismatch) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -113,6 +124,47 @@ func (h *OAuthHandler) RefreshToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "token refreshed successfully"})
 }
 
+// maxImportEntries caps one bulk import; each entry makes a token endpoint call
+const maxImportEntries = 500
+
+// ImportAccounts creates accounts from exported refresh tokens
+// POST /api/v1/accounts/import
+func (h *OAuthHandler) ImportAccounts(c *gin.Context) {
+	var entries []services.ImportAccountEntry
+	if err := c.ShouldBindJSON(&entries); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one account is required"})
+		return
+	}
+	if len(entries) > maxImportEntries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d accounts can be imported at once", maxImportEntries)})
+		return
+	}
+
+	var createdBy *string
+	if user := middleware.GetCurrentUser(c); user != nil {
+		createdBy = &user.ID
+	}
+
+	results := h.service.ImportAccounts(c.Request.Context(), entries, createdBy)
+
+	imported := 0
+	for _, r := range results {
+		if r.Success {
+			imported++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported": imported,
+		"failed":   len(results) - imported,
+		"results":  results,
+	})
+}
+
 // successHTML returns HTML that closes popup and notifies parent
 func (h *OAuthHandler) successHTML(accountName string) string {
 	return `<!DOCTYPE html>
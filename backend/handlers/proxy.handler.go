@@ -2,15 +2,22 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/internal/apierror"
+	"aigateway-backend/middleware"
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
 	"aigateway-backend/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 type ProxyHandler struct {
@@ -19,6 +26,7 @@ type ProxyHandler struct {
 	startTime     time.Time
 	version       string
 	authManagerEnabled bool
+	idempotency   *services.IdempotencyStore
 }
 
 func NewProxyHandler(executor *services.ExecutorService, routerService *services.RouterService) *ProxyHandler {
@@ -34,17 +42,22 @@ func (h *ProxyHandler) SetBuildInfo(version string, authManagerEnabled bool) {
 	h.authManagerEnabled = authManagerEnabled
 }
 
+// SetIdempotencyStore enables Idempotency-Key replay for non-streaming requests
+func (h *ProxyHandler) SetIdempotencyStore(store *services.IdempotencyStore) {
+	h.idempotency = store
+}
+
 // HandleProxy processes incoming AI model requests and routes them to appropriate providers
 func (h *ProxyHandler) HandleProxy(c *gin.Context) {
 	body, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		writeError(c, http.StatusBadRequest, "failed to read request body")
 		return
 	}
 
 	model := gjson.GetBytes(body, "model").String()
 	if model == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		writeError(c, http.StatusBadRequest, "model is required")
 		return
 	}
 
@@ -56,47 +69,183 @@ func (h *ProxyHandler) HandleProxy(c *gin.Context) {
 		}
 	}
 
-	accountID := c.Query("account_id")
+	clientBody := body
+	if body, model, err = h.applyModelSplit(body, model); err != nil {
+		writeError(c, http.StatusBadRequest, "failed to apply model split")
+		return
+	}
 
 	req := services.Request{
-		Model:     model,
-		Payload:   body,
-		Stream:    stream,
-		AccountID: accountID,
+		Model:       model,
+		Payload:     body,
+		Stream:      stream,
+		AccountID:   pinnedAccount(c),
+		ServiceTier: providers.ServiceTier(body),
+		RequestID:   requestID(c),
+		Format:      requestFormat(c),
 	}
 
-	ctx := context.Background()
+	ctx := proxyContext(c, stream)
 
 	// Handle streaming vs non-streaming
 	if stream {
 		h.handleStreaming(c, ctx, req)
 	} else {
-		h.handleNonStreaming(c, ctx, req)
+		h.handleNonStreaming(c, ctx, req, clientBody)
 	}
 }
 
-// handleNonStreaming handles regular non-streaming requests
-func (h *ProxyHandler) handleNonStreaming(c *gin.Context, ctx context.Context, req services.Request) {
-	resp, err := h.executor.Execute(ctx, req)
+// applyModelSplit resolves a weighted A/B split and rewrites the body's model to the served one
+// The pick is made once per request, so retries stay on the same target.
+func (h *ProxyHandler) applyModelSplit(body []byte, model string) ([]byte, string, error) {
+	if h.routerService == nil {
+		return body, model, nil
+	}
+	served := h.routerService.ResolveModelSplit(model)
+	if served == model {
+		return body, model, nil
+	}
+	body, err := sjson.SetBytes(body, "model", served)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		if resp.StatusCode > 0 {
-			statusCode = resp.StatusCode
-		}
+		return nil, "", err
+	}
+	return body, served, nil
+}
+
+// proxyContext builds the context a proxied request executes under, carrying its per-request
+// routing options. Streams end with the client connection; other requests run to completion.
+func proxyContext(c *gin.Context, stream bool) context.Context {
+	ctx := context.Background()
+	if stream {
+		ctx = c.Request.Context()
+	}
+
+	// Admins can keep specific accounts out of selection for this request
+	if middleware.GetCurrentRole(c) == models.RoleAdmin {
+		ctx = manager.WithExcludedAccounts(ctx, manager.ParseExcludedAccounts(c.GetHeader(manager.ExcludeAccountsHeader)))
+	}
 
-		if len(resp.Payload) > 0 {
-			c.Data(statusCode, "application/json", resp.Payload)
-		} else {
-			c.JSON(statusCode, gin.H{"error": err.Error()})
+	// Errors written after a stream starts use the endpoint's envelope too
+	if wantsOpenAIErrors(c) {
+		ctx = apierror.WithOpenAIShape(ctx)
+	}
+
+	// Clients can ask for their system prompt to reach the upstream unmodified
+	if providers.SystemHintsDisabledByHeader(c.GetHeader(providers.SystemHintsHeader)) {
+		ctx = providers.WithSystemHintsDisabled(ctx)
+	}
+
+	return ctx
+}
+
+// requestFormat returns the format the client's payload is in: OpenAI on /chat/completions
+func requestFormat(c *gin.Context) string {
+	if strings.HasSuffix(c.Request.URL.Path, "/chat/completions") {
+		return services.FormatOpenAI
+	}
+	return services.FormatClaude
+}
+
+// pinnedAccount returns the ?account_id= an admin pinned the request to; other callers can't pin
+func pinnedAccount(c *gin.Context) string {
+	if middleware.GetCurrentRole(c) != models.RoleAdmin {
+		return ""
+	}
+	return c.Query("account_id")
+}
+
+// requestID returns the client's X-Request-ID or generates one, echoing it on the response
+func requestID(c *gin.Context) string {
+	id := c.GetHeader("X-Request-ID")
+	if id == "" {
+		id = uuid.NewString()
+	}
+	c.Header("X-Request-ID", id)
+	return id
+}
+
+// setUpstreamRequestID exposes the provider's request ID so clients can quote it in support tickets
+func setUpstreamRequestID(c *gin.Context, headers http.Header) {
+	if id := providers.UpstreamRequestID(headers); id != "" {
+		c.Header(providers.UpstreamRequestIDHeader, id)
+	}
+}
+
+// handleNonStreaming handles regular non-streaming requests
+// Idempotency-Keys are matched against clientBody, the body as sent before any model split,
+// so a retry of a split alias replays even if the split would now pick another target.
+func (h *ProxyHandler) handleNonStreaming(c *gin.Context, ctx context.Context, req services.Request, clientBody []byte) {
+	key := c.GetHeader(services.IdempotencyKeyHeader)
+	if key == "" || h.idempotency == nil {
+		h.writeResponse(c, h.executeNonStreaming(c, ctx, req))
+		return
+	}
+
+	resp, replayed, err := h.idempotency.Do(ctx, idempotencyScope(c), key, clientBody, func() *services.IdempotentResponse {
+		return h.executeNonStreaming(c, ctx, req)
+	})
+	if err != nil {
+		status := http.StatusConflict
+		if err == services.ErrIdempotencyKeyReused {
+			status = http.StatusUnprocessableEntity
 		}
+		writeError(c, status, err.Error())
 		return
 	}
+	if replayed {
+		c.Header(services.IdempotentReplayedHeader, "true")
+	}
+	h.writeResponse(c, resp)
+}
 
-	c.Data(resp.StatusCode, "application/json", resp.Payload)
+// execute runs a non-streaming request, through the RouterService when the AuthManager is enabled
+// so account health, retries and per-request exclusions apply as they do for streams
+func (h *ProxyHandler) execute(ctx context.Context, req services.Request) (services.Response, error) {
+	if h.authManagerEnabled && h.routerService != nil {
+		return h.routerService.Execute(ctx, req)
+	}
+	return h.executor.Execute(ctx, req)
+}
+
+// executeNonStreaming runs the request upstream and builds the response body to send
+func (h *ProxyHandler) executeNonStreaming(c *gin.Context, ctx context.Context, req services.Request) *services.IdempotentResponse {
+	resp, err := h.execute(ctx, req)
+	result := &services.IdempotentResponse{
+		StatusCode:        resp.StatusCode,
+		UpstreamRequestID: providers.UpstreamRequestID(resp.Headers),
+	}
+	if err != nil {
+		result.StatusCode, result.Body = shapeError(c, err, resp.StatusCode, resp.Payload)
+		return result
+	}
+
+	result.Body = applyResponseProfile(c, resp.Payload)
+	return result
+}
+
+// writeResponse sends a non-streaming response, live or replayed
+func (h *ProxyHandler) writeResponse(c *gin.Context, resp *services.IdempotentResponse) {
+	if resp.UpstreamRequestID != "" {
+		c.Header(providers.UpstreamRequestIDHeader, resp.UpstreamRequestID)
+	}
+	c.Data(resp.StatusCode, "application/json", resp.Body)
+}
+
+// idempotencyScope keeps Idempotency-Keys from colliding across callers
+func idempotencyScope(c *gin.Context) string {
+	if apiKey := middleware.GetCurrentAPIKey(c); apiKey != nil {
+		return "key:" + apiKey.ID
+	}
+	return "user:" + middleware.GetCurrentUserID(c)
 }
 
 // handleStreaming handles streaming requests
 func (h *ProxyHandler) handleStreaming(c *gin.Context, ctx context.Context, req services.Request) {
+	if h.authManagerEnabled && h.routerService != nil {
+		h.handleRouterStreaming(c, ctx, req)
+		return
+	}
+
 	// Set SSE headers
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
@@ -106,20 +255,29 @@ func (h *ProxyHandler) handleStreaming(c *gin.Context, ctx context.Context, req
 	// Execute streaming request
 	streamResp, err := h.executor.ExecuteStream(ctx, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		status := 0
+		if streamResp != nil {
+			status = streamResp.StatusCode
+		}
+		status, body := shapeError(c, err, status, nil)
+		c.Data(status, "application/json", body)
 		return
 	}
 
+	if id := providers.StreamUpstreamRequestID(streamResp.Headers); id != "" {
+		c.Header(providers.UpstreamRequestIDHeader, id)
+	}
+
 	// Check status code
 	if streamResp.StatusCode < 200 || streamResp.StatusCode >= 300 {
-		c.JSON(streamResp.StatusCode, gin.H{"error": "upstream error"})
+		writeError(c, streamResp.StatusCode, "upstream error")
 		return
 	}
 
 	// Forward stream to client
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+		writeError(c, http.StatusInternalServerError, "streaming not supported")
 		return
 	}
 
@@ -139,7 +297,7 @@ func (h *ProxyHandler) handleStreaming(c *gin.Context, ctx context.Context, req
 
 		case err := <-streamResp.ErrCh:
 			if err != nil {
-				c.Writer.Write([]byte(fmt.Sprintf("event: error\ndata: {\"error\": \"%s\"}\n\n", err.Error())))
+				c.Writer.Write(apierror.StreamEvent(ctx, apierror.TypeAPI, err.Error()))
 				flusher.Flush()
 			}
 			return
@@ -147,12 +305,24 @@ func (h *ProxyHandler) handleStreaming(c *gin.Context, ctx context.Context, req
 		case <-streamResp.Done:
 			return
 
-		case <-c.Request.Context().Done():
+		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// handleRouterStreaming streams through the RouterService so account health and quota are tracked
+// The router writes SSE headers once the upstream stream opens; errors before that are returned as JSON.
+func (h *ProxyHandler) handleRouterStreaming(c *gin.Context, ctx context.Context, req services.Request) {
+	statusCode, err := h.routerService.ExecuteStream(ctx, req, c.Writer)
+	if err == nil || c.Writer.Written() {
+		return
+	}
+
+	status, body := shapeError(c, err, statusCode, nil)
+	c.Data(status, "application/json", body)
+}
+
 // GetProviders returns list of all registered providers
 func (h *ProxyHandler) GetProviders(c *gin.Context) {
 	providers := h.routerService.ListProviders()
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/middleware"
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+	"aigateway-backend/repositories"
+	"aigateway-backend/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/tidwall/gjson"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// recordingStreamProvider streams one Claude event and records the account and context of each call
+type recordingStreamProvider struct {
+	mu            sync.Mutex
+	accounts      []string
+	hintsDisabled []bool
+	bodyModels    []string
+}
+
+func (p *recordingStreamProvider) ID() string                { return "antigravity" }
+func (p *recordingStreamProvider) Name() string              { return "Recording" }
+func (p *recordingStreamProvider) AuthStrategy() string      { return "oauth" }
+func (p *recordingStreamProvider) SupportedModels() []string { return []string{"gemini-2.5-pro"} }
+func (p *recordingStreamProvider) SupportsStreaming() bool   { return true }
+
+func (p *recordingStreamProvider) TranslateRequest(format string, payload []byte, model string) ([]byte, error) {
+	return payload, nil
+}
+
+func (p *recordingStreamProvider) TranslateResponse(payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func (p *recordingStreamProvider) Execute(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
+	p.record(ctx, req)
+	return &providers.ExecuteResponse{StatusCode: http.StatusOK, Payload: []byte(`{"type":"message","content":[{"type":"text","text":"hi"}]}`)}, nil
+}
+
+func (p *recordingStreamProvider) ExecuteStream(ctx context.Context, req *providers.ExecuteRequest) (*providers.StreamResponse, error) {
+	p.record(ctx, req)
+
+	dataCh := make(chan []byte, 1)
+	errCh := make(chan error)
+	done := make(chan struct{})
+	dataCh <- []byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	close(errCh)
+	close(dataCh)
+	close(done)
+	return &providers.StreamResponse{StatusCode: http.StatusOK, DataCh: dataCh, ErrCh: errCh, Done: done}, nil
+}
+
+func (p *recordingStreamProvider) record(ctx context.Context, req *providers.ExecuteRequest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accounts = append(p.accounts, req.Account.ID)
+	p.hintsDisabled = append(p.hintsDisabled, providers.SystemHintsDisabled(ctx))
+	p.bodyModels = append(p.bodyModels, gjson.GetBytes(req.Payload, "model").String())
+}
+
+// setupProxyRouter serves /v1/messages and /v1/completions through the AuthManager router with accounts acc-1 and acc-2
+// acc-1 has the higher priority, so it is picked unless excluded. Requests run as role.
+func setupProxyRouter(t *testing.T, role models.Role) (*gin.Engine, *recordingStreamProvider) {
+	r, provider, _ := setupProxyRouterService(t, role)
+	return r, provider
+}
+
+// setupProxyRouterService is setupProxyRouter that also returns the router service for configuration
+func setupProxyRouterService(t *testing.T, role models.Role) (*gin.Engine, *recordingStreamProvider, *services.RouterService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.RequestLog{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	provider := &recordingStreamProvider{}
+	registry := providers.NewRegistry()
+	registry.Register("antigravity", provider)
+
+	authData := fmt.Sprintf(`{"access_token":"token","expires_at":"%s"}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	authManager := manager.NewManager(nil, client)
+	authManager.SetLogging(false)
+	authManager.AddAccount(&models.Account{ID: "acc-1", ProviderID: "antigravity", AuthData: authData, Metadata: `{"priority":1}`, IsActive: true})
+	authManager.AddAccount(&models.Account{ID: "acc-2", ProviderID: "antigravity", AuthData: authData, Metadata: "{}", IsActive: true})
+
+	accountRepo := repositories.NewAccountRepository(db)
+	router := services.NewRouterService(
+		registry,
+		nil,
+		nil,
+		nil,
+		nil,
+		services.NewOAuthService(client, accountRepo, nil, nil),
+		services.NewStatsTrackerService(repositories.NewStatsRepository(db), nil, client, nil),
+	)
+	router.SetAuthManager(authManager)
+	router.EnableAuthManager(true)
+
+	handler := NewProxyHandler(nil, router)
+	handler.SetBuildInfo("test", true)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	setUser := func(c *gin.Context) {
+		middleware.SetCurrentUser(c, &models.User{ID: "user-1", Role: role})
+	}
+	r.POST("/v1/messages", setUser, handler.HandleProxy)
+	r.POST("/v1/completions", setUser, handler.HandleCompletions)
+	return r, provider, router
+}
+
+func postProxy(r *gin.Engine, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleProxy_ExcludeAccountsApplies(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			r, provider := setupProxyRouter(t, models.RoleAdmin)
+
+			body := fmt.Sprintf(`{"model":"gemini-2.5-pro","stream":%v,"messages":[{"role":"user","content":"hi"}]}`, stream)
+			w := postProxy(r, body, map[string]string{manager.ExcludeAccountsHeader: "acc-1"})
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if fmt.Sprint(provider.accounts) != "[acc-2]" {
+				t.Errorf("accounts = %v, want [acc-2] with acc-1 excluded", provider.accounts)
+			}
+		})
+	}
+}
+
+func TestHandleProxy_ExcludeAccountsIgnoredForNonAdmin(t *testing.T) {
+	r, provider := setupProxyRouter(t, models.RoleUser)
+
+	w := postProxy(r, `{"model":"gemini-2.5-pro","stream":true,"messages":[]}`, map[string]string{manager.ExcludeAccountsHeader: "acc-1"})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if fmt.Sprint(provider.accounts) != "[acc-1]" {
+		t.Errorf("accounts = %v, want [acc-1] since only admins may exclude", provider.accounts)
+	}
+}
+
+func TestHandleProxy_AccountPinningIsAdminOnly(t *testing.T) {
+	tests := []struct {
+		role models.Role
+		want string
+	}{
+		{models.RoleAdmin, "[acc-2]"},
+		{models.RoleUser, "[acc-1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			r, provider := setupProxyRouter(t, tt.role)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/messages?account_id=acc-2", strings.NewReader(`{"model":"gemini-2.5-pro","stream":true,"messages":[]}`))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if fmt.Sprint(provider.accounts) != tt.want {
+				t.Errorf("accounts = %v, want %s", provider.accounts, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleProxy_SystemHintsOptOutReachesProvider(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			r, provider := setupProxyRouter(t, models.RoleUser)
+
+			body := fmt.Sprintf(`{"model":"gemini-2.5-pro","stream":%v,"messages":[]}`, stream)
+			w := postProxy(r, body, map[string]string{providers.SystemHintsHeader: "off"})
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if fmt.Sprint(provider.hintsDisabled) != "[true]" {
+				t.Errorf("hints disabled = %v, want [true]", provider.hintsDisabled)
+			}
+		})
+	}
+}
+
+func TestHandleProxy_ModelSplitRewritesBodyModel(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			r, provider, router := setupProxyRouterService(t, models.RoleUser)
+			router.SetModelSplitter(services.NewModelSplitter(map[string][]services.SplitTarget{
+				"gemini-split": {{Model: "gemini-2.5-pro", Weight: 100}},
+			}, 1))
+
+			body := fmt.Sprintf(`{"model":"gemini-split","stream":%v,"messages":[]}`, stream)
+			w := postProxy(r, body, nil)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if fmt.Sprint(provider.bodyModels) != "[gemini-2.5-pro]" {
+				t.Errorf("body models = %v, want [gemini-2.5-pro]", provider.bodyModels)
+			}
+		})
+	}
+}
+
+func TestHandleProxy_IdempotencyKeyReplaysAcrossModelSplit(t *testing.T) {
+	r, provider, router := setupProxyRouterService(t, models.RoleUser)
+	handler := NewProxyHandler(nil, router)
+	handler.SetBuildInfo("test", true)
+	handler.SetIdempotencyStore(services.NewIdempotencyStore(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), 0))
+	r.POST("/v1/messages-idempotent", func(c *gin.Context) {
+		middleware.SetCurrentUser(c, &models.User{ID: "user-1", Role: models.RoleUser})
+	}, handler.HandleProxy)
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/messages-idempotent", strings.NewReader(`{"model":"gemini-split","messages":[]}`))
+		req.Header.Set(services.IdempotencyKeyHeader, "retry-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	router.SetModelSplitter(services.NewModelSplitter(map[string][]services.SplitTarget{
+		"gemini-split": {{Model: "gemini-2.5-pro", Weight: 100}},
+	}, 1))
+	if w := post(); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	// The retry would now be split to another target
+	router.SetModelSplitter(services.NewModelSplitter(map[string][]services.SplitTarget{
+		"gemini-split": {{Model: "gemini-2.5-flash", Weight: 100}},
+	}, 1))
+	w := post()
+	if w.Code != http.StatusOK {
+		t.Fatalf("retry status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get(services.IdempotentReplayedHeader) != "true" {
+		t.Error("retry was not replayed")
+	}
+	if len(provider.bodyModels) != 1 {
+		t.Errorf("provider called %d times, want 1", len(provider.bodyModels))
+	}
+}
@@ -52,6 +52,10 @@ func (h *ProxyManagementHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateProxyBody(&proxy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := h.service.Create(&proxy); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -61,6 +65,22 @@ func (h *ProxyManagementHandler) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, proxy)
 }
 
+// ValidateProxyRequest is the body for validating a proxy URL before it is saved
+type ValidateProxyRequest struct {
+	URL string `json:"proxy_url" binding:"required"`
+}
+
+// Validate runs the TCP, HTTP and CONNECT checks against a proxy URL without saving it
+func (h *ProxyManagementHandler) Validate(c *gin.Context) {
+	var req ValidateProxyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.ValidateProxyURL(req.URL))
+}
+
 func (h *ProxyManagementHandler) Update(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	var proxy models.Proxy
@@ -68,6 +88,10 @@ func (h *ProxyManagementHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateProxyBody(&proxy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	proxy.ID = id
 	if err := h.service.Update(&proxy); err != nil {
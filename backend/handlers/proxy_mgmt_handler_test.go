@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeProxy answers proxied requests itself; connectStatus is the reply to CONNECT
+func fakeProxy(t *testing.T, httpStatus, connectStatus int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(httpStatus)
+			return
+		}
+		if connectStatus != http.StatusOK {
+			w.WriteHeader(connectStatus)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack failed: %v", err)
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+		conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func validateProxy(t *testing.T, proxyURL string) services.ProxyValidationResult {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/proxies/validate", NewProxyManagementHandler(nil).Validate)
+
+	w := httptest.NewRecorder()
+	body := `{"proxy_url":"` + proxyURL + `"}`
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/proxies/validate", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	var result services.ProxyValidationResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return result
+}
+
+func TestValidateProxy_WorkingProxy(t *testing.T) {
+	proxy := fakeProxy(t, http.StatusOK, http.StatusOK)
+
+	result := validateProxy(t, proxy.URL)
+	if !result.Valid || !result.TCP.Passed || !result.HTTP.Passed || !result.Connect.Passed {
+		t.Errorf("result = %+v, want every check to pass", result)
+	}
+}
+
+func TestValidateProxy_RejectsTunnel(t *testing.T) {
+	proxy := fakeProxy(t, http.StatusOK, http.StatusForbidden)
+
+	result := validateProxy(t, proxy.URL)
+	if result.Valid {
+		t.Error("proxy that refuses CONNECT should not be valid")
+	}
+	if !result.TCP.Passed || !result.HTTP.Passed {
+		t.Errorf("tcp = %+v, http = %+v, want both to pass", result.TCP, result.HTTP)
+	}
+	if result.Connect.Passed || !strings.Contains(result.Connect.Error, "403") {
+		t.Errorf("connect = %+v, want a 403 failure", result.Connect)
+	}
+}
+
+func TestValidateProxy_Unreachable(t *testing.T) {
+	proxy := fakeProxy(t, http.StatusOK, http.StatusOK)
+	proxyURL := proxy.URL
+	proxy.Close()
+
+	result := validateProxy(t, proxyURL)
+	if result.Valid || result.TCP.Passed {
+		t.Errorf("result = %+v, want the TCP check to fail", result)
+	}
+	if result.HTTP.Passed || result.Connect.Passed {
+		t.Errorf("http = %+v, connect = %+v, want both skipped", result.HTTP, result.Connect)
+	}
+}
+
+func TestValidateProxy_MissingURL(t *testing.T) {
+	router := gin.New()
+	router.POST("/proxies/validate", NewProxyManagementHandler(nil).Validate)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/proxies/validate", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
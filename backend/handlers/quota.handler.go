@@ -61,6 +61,17 @@ func (h *QuotaHandler) ListAccountsQuota(c *gin.Context) {
 		Models     map[string]*models.QuotaStatus  `json:"models"`
 	}
 
+	// Fetch every account+model status in one batch
+	var keys []services.QuotaStatusKey
+	for _, acc := range accounts {
+		for _, p := range patterns {
+			if p.AccountID == acc.ID {
+				keys = append(keys, services.QuotaStatusKey{AccountID: acc.ID, Model: p.Model})
+			}
+		}
+	}
+	statuses := h.quotaService.GetQuotaStatusBatch(keys)
+
 	result := make([]*AccountQuotaResponse, 0)
 
 	for _, acc := range accounts {
@@ -74,8 +85,7 @@ func (h *QuotaHandler) ListAccountsQuota(c *gin.Context) {
 		// Get quota status for each model this account has patterns for
 		for _, p := range patterns {
 			if p.AccountID == acc.ID {
-				status := h.quotaService.GetQuotaStatus(acc.ID, p.Model)
-				resp.Models[p.Model] = status
+				resp.Models[p.Model] = statuses[services.QuotaStatusKey{AccountID: acc.ID, Model: p.Model}]
 			}
 		}
 
@@ -97,10 +107,14 @@ func (h *QuotaHandler) GetAccountQuota(c *gin.Context) {
 
 	patterns, _ := h.patternRepo.ListByAccount(accountID)
 
-	modelsStatus := make(map[string]*models.QuotaStatus)
+	keys := make([]services.QuotaStatusKey, 0, len(patterns))
 	for _, p := range patterns {
-		status := h.quotaService.GetQuotaStatus(accountID, p.Model)
-		modelsStatus[p.Model] = status
+		keys = append(keys, services.QuotaStatusKey{AccountID: accountID, Model: p.Model})
+	}
+
+	modelsStatus := make(map[string]*models.QuotaStatus)
+	for key, status := range h.quotaService.GetQuotaStatusBatch(keys) {
+		modelsStatus[key.Model] = status
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -123,6 +137,16 @@ func (h *QuotaHandler) GetProviderSummary(c *gin.Context) {
 
 	patterns, _ := h.patternRepo.ListByProvider(providerID)
 
+	var keys []services.QuotaStatusKey
+	for _, acc := range accounts {
+		for _, p := range patterns {
+			if p.AccountID == acc.ID {
+				keys = append(keys, services.QuotaStatusKey{AccountID: acc.ID, Model: p.Model})
+			}
+		}
+	}
+	statuses := h.quotaService.GetQuotaStatusBatch(keys)
+
 	// Group by model
 	modelStats := make(map[string]*models.ModelQuotaStatus)
 	totalExhausted := 0
@@ -140,7 +164,7 @@ func (h *QuotaHandler) GetProviderSummary(c *gin.Context) {
 			ms := modelStats[p.Model]
 			ms.Total++
 
-			status := h.quotaService.GetQuotaStatus(acc.ID, p.Model)
+			status := statuses[services.QuotaStatusKey{AccountID: acc.ID, Model: p.Model}]
 			if status.IsExhausted {
 				ms.Exhausted++
 				totalExhausted++
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"aigateway-backend/middleware"
+	"aigateway-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyResponseProfile reshapes a translated Claude message for the calling API key's profile
+// Runs as the last step before the response is written; payloads that aren't Claude
+// messages, and requests not made with an API key, pass through unchanged.
+func applyResponseProfile(c *gin.Context, payload []byte) []byte {
+	apiKey := middleware.GetCurrentAPIKey(c)
+	if apiKey == nil {
+		return payload
+	}
+	return reshapeContent(payload, apiKey.ResponseProfile)
+}
+
+// reshapeContent converts the message content between string and block-array forms
+func reshapeContent(payload []byte, profile string) []byte {
+	if gjson.GetBytes(payload, "type").String() != "message" {
+		return payload
+	}
+	content := gjson.GetBytes(payload, "content")
+
+	switch profile {
+	case models.ResponseProfileStringContent:
+		// Only a lone text block collapses; tool calls and mixed content keep the array form
+		blocks := content.Array()
+		if !content.IsArray() || len(blocks) != 1 || blocks[0].Get("type").String() != "text" {
+			return payload
+		}
+		if result, err := sjson.SetBytes(payload, "content", blocks[0].Get("text").String()); err == nil {
+			return result
+		}
+
+	case models.ResponseProfileArrayContent:
+		if content.Type != gjson.String {
+			return payload
+		}
+		result, err := sjson.SetRawBytes(payload, "content", []byte(`[{"type":"text","text":""}]`))
+		if err != nil {
+			return payload
+		}
+		if result, err = sjson.SetBytes(result, "content.0.text", content.String()); err == nil {
+			return result
+		}
+	}
+
+	return payload
+}
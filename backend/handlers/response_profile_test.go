@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aigateway-backend/middleware"
+	"aigateway-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+const singleTextMessage = `{"type":"message","role":"assistant","content":[{"type":"text","text":"Hello!"}],"stop_reason":"end_turn"}`
+
+// profileResponse serves payload through applyResponseProfile for a key with the given profile
+func profileResponse(t *testing.T, profile, payload string) []byte {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		middleware.SetCurrentAPIKey(c, &models.APIKey{ID: "key-1", ResponseProfile: profile})
+		c.Data(http.StatusOK, "application/json", applyResponseProfile(c, []byte(payload)))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Body.Bytes()
+}
+
+func TestResponseProfile_StringContent(t *testing.T) {
+	body := profileResponse(t, models.ResponseProfileStringContent, singleTextMessage)
+
+	content := gjson.GetBytes(body, "content")
+	if content.Type != gjson.String || content.String() != "Hello!" {
+		t.Errorf("content = %s, want the string \"Hello!\"", content.Raw)
+	}
+}
+
+func TestResponseProfile_ArrayContent(t *testing.T) {
+	body := profileResponse(t, models.ResponseProfileArrayContent, singleTextMessage)
+	if string(body) != singleTextMessage {
+		t.Errorf("body = %s, want the array form unchanged", body)
+	}
+
+	// String content from a passthrough upstream is expanded into a text block
+	body = profileResponse(t, models.ResponseProfileArrayContent, `{"type":"message","role":"assistant","content":"Hi"}`)
+	if got := gjson.GetBytes(body, "content.0.text").String(); got != "Hi" {
+		t.Errorf("content = %s, want [{type:text,text:Hi}]", gjson.GetBytes(body, "content").Raw)
+	}
+}
+
+func TestResponseProfile_StringContentKeepsToolUse(t *testing.T) {
+	toolUse := `{"type":"message","role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}]}`
+
+	if body := profileResponse(t, models.ResponseProfileStringContent, toolUse); string(body) != toolUse {
+		t.Errorf("body = %s, want tool_use content unchanged", body)
+	}
+}
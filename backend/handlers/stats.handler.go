@@ -4,6 +4,7 @@ import (
 	"aigateway-backend/services"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -40,3 +41,36 @@ func (h *StatsHandler) GetRecentLogs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"logs": logs})
 }
+
+// maxLatencyWindow bounds the latency query to the default request log retention
+const maxLatencyWindow = 30 * 24 * time.Hour
+
+// GetLatency returns request latency percentiles
+// GET /api/v1/stats/latency?account=&model=&window=1h
+func (h *StatsHandler) GetLatency(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil || window <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be a positive duration like 15m or 24h"})
+		return
+	}
+	if window > maxLatencyWindow {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be at most 720h"})
+		return
+	}
+
+	accountID := c.Query("account")
+	model := c.Query("model")
+
+	latency, err := h.service.GetLatencyPercentiles(accountID, model, window)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account": accountID,
+		"model":   model,
+		"window":  window.String(),
+		"latency": latency,
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aigateway-backend/providers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TranslatePreviewRequest is the body for a dry-run translation
+type TranslatePreviewRequest struct {
+	Provider string          `json:"provider"`
+	Model    string          `json:"model" binding:"required"`
+	Payload  json.RawMessage `json:"payload" binding:"required"`
+}
+
+type TranslateHandler struct {
+	registry *providers.Registry
+}
+
+func NewTranslateHandler(registry *providers.Registry) *TranslateHandler {
+	return &TranslateHandler{registry: registry}
+}
+
+// Preview returns the outbound body a Claude-format payload translates to, without calling upstream.
+// When provider is omitted it is resolved from the model the same way the router does.
+func (h *TranslateHandler) Preview(c *gin.Context) {
+	var req TranslatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !json.Valid(req.Payload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payload must be valid JSON"})
+		return
+	}
+
+	var provider providers.Provider
+	model := req.Model
+	var err error
+
+	if req.Provider != "" {
+		provider, err = h.registry.Get(req.Provider)
+	} else {
+		provider, model, err = h.registry.GetByModel(req.Model)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	translated, err := provider.TranslateRequest("claude", req.Payload, model)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"provider": provider.ID(),
+		"model":    model,
+		"payload":  json.RawMessage(translated),
+	})
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aigateway-backend/providers"
+	"aigateway-backend/providers/antigravity"
+	"aigateway-backend/providers/glm"
+	"aigateway-backend/providers/openai"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const previewClaudePayload = `{
+	"model": "ignored",
+	"max_tokens": 256,
+	"system": "You are terse.",
+	"messages": [{"role": "user", "content": [{"type": "text", "text": "Hello"}]}],
+	"tools": [{"name": "lookup", "description": "Lookup", "input_schema": {"type": "object", "properties": {"q": {"type": "string"}}}}]
+}`
+
+// setupTranslateRouter registers all providers the way main does
+func setupTranslateRouter() *gin.Engine {
+	registry := providers.NewRegistry()
+	registry.Register("antigravity", antigravity.NewAntigravityProvider())
+	registry.Register("openai", openai.NewOpenAIProvider())
+	registry.Register("glm", glm.NewProvider())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/v1/translate/preview", NewTranslateHandler(registry).Preview)
+	return r
+}
+
+func postPreview(t *testing.T, r *gin.Engine, provider, model string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{
+		"provider": provider,
+		"model":    model,
+		"payload":  json.RawMessage(previewClaudePayload),
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/translate/preview", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// stripVolatile removes per-request random identifiers so bodies can be compared
+func stripVolatile(body string) string {
+	body, _ = sjson.Delete(body, "requestId")
+	body, _ = sjson.Delete(body, "request.sessionId")
+	return body
+}
+
+func assertSameJSON(t *testing.T, got, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("preview payload is not JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("expected payload is not JSON: %v", err)
+	}
+	gb, _ := json.Marshal(g)
+	wb, _ := json.Marshal(w)
+	if !bytes.Equal(gb, wb) {
+		t.Errorf("preview mismatch\n got: %s\nwant: %s", gb, wb)
+	}
+}
+
+func TestTranslatePreview_Antigravity(t *testing.T) {
+	w := postPreview(t, setupTranslateRouter(), "antigravity", "gemini-2.5-pro")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	resp := gjson.Parse(w.Body.String())
+	if resp.Get("provider").String() != "antigravity" {
+		t.Errorf("provider = %q", resp.Get("provider").String())
+	}
+
+	got := resp.Get("payload").Raw
+	if gjson.Get(got, "request.sessionId").String() == "" {
+		t.Error("expected session ID in preview")
+	}
+	want := string(antigravity.TranslateClaudeToAntigravity([]byte(previewClaudePayload), "gemini-2.5-pro"))
+	assertSameJSON(t, stripVolatile(got), stripVolatile(want))
+}
+
+func TestTranslatePreview_OpenAI(t *testing.T) {
+	w := postPreview(t, setupTranslateRouter(), "openai", "gpt-4")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	want, err := openai.ClaudeToOpenAI([]byte(previewClaudePayload), "gpt-4")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI: %v", err)
+	}
+	got := gjson.Get(w.Body.String(), "payload").Raw
+	assertSameJSON(t, got, string(want))
+
+	if gjson.Get(got, "messages.0.role").String() != "system" {
+		t.Errorf("expected system message first, got %s", got)
+	}
+}
+
+func TestTranslatePreview_GLM(t *testing.T) {
+	w := postPreview(t, setupTranslateRouter(), "glm", "glm-4")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	want := glm.TranslateClaudeToGLM([]byte(previewClaudePayload), "glm-4")
+	got := gjson.Get(w.Body.String(), "payload").Raw
+	assertSameJSON(t, got, string(want))
+
+	if gjson.Get(got, "model").String() != "glm-4" {
+		t.Errorf("model = %q, want glm-4", gjson.Get(got, "model").String())
+	}
+}
+
+func TestTranslatePreview_ResolvesProviderFromModel(t *testing.T) {
+	w := postPreview(t, setupTranslateRouter(), "", "glm-4-flash")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if p := gjson.Get(w.Body.String(), "provider").String(); p != "glm" {
+		t.Errorf("provider = %q, want glm", p)
+	}
+}
+
+func TestTranslatePreview_Errors(t *testing.T) {
+	r := setupTranslateRouter()
+
+	if w := postPreview(t, r, "nope", "gpt-4"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown provider: status = %d, want 404", w.Code)
+	}
+	if w := postPreview(t, r, "antigravity", "not-a-model"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unsupported model: status = %d, want 422", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/translate/preview", bytes.NewReader([]byte(`{"provider":"glm"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing fields: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,121 @@
+// Package apierror builds the error envelopes proxy clients' SDKs parse, shared by the
+// handlers, the middleware in front of them and the router's mid-stream errors.
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Anthropic error types (https://docs.anthropic.com/en/api/errors)
+const (
+	TypeInvalidRequest  = "invalid_request_error"
+	TypeAuthentication  = "authentication_error"
+	TypePermission      = "permission_error"
+	TypeNotFound        = "not_found_error"
+	TypeRequestTooLarge = "request_too_large"
+	TypeRateLimit       = "rate_limit_error"
+	TypeAPI             = "api_error"
+	TypeOverloaded      = "overloaded_error"
+)
+
+// openAITypes maps Anthropic error types to their OpenAI equivalents
+var openAITypes = map[string]string{
+	TypeInvalidRequest:  "invalid_request_error",
+	TypeAuthentication:  "authentication_error",
+	TypePermission:      "permission_error",
+	TypeNotFound:        "not_found_error",
+	TypeRequestTooLarge: "invalid_request_error",
+	TypeRateLimit:       "rate_limit_error",
+	TypeAPI:             "server_error",
+	TypeOverloaded:      "server_error",
+}
+
+// TypeForStatus returns the Anthropic error type for an HTTP status
+func TypeForStatus(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return TypeAuthentication
+	case status == http.StatusForbidden:
+		return TypePermission
+	case status == http.StatusNotFound:
+		return TypeNotFound
+	case status == http.StatusRequestEntityTooLarge:
+		return TypeRequestTooLarge
+	case status == http.StatusTooManyRequests:
+		return TypeRateLimit
+	case status == http.StatusServiceUnavailable || status == 529:
+		return TypeOverloaded
+	case status >= 400 && status < 500:
+		return TypeInvalidRequest
+	default:
+		return TypeAPI
+	}
+}
+
+// WantsOpenAI reports whether clients of the endpoint at path expect the OpenAI error shape
+// Covers /v1/chat/completions and the legacy /v1/completions.
+func WantsOpenAI(path string) bool {
+	return strings.HasSuffix(path, "/completions")
+}
+
+// Body builds the error envelope the endpoint's SDKs parse
+// Anthropic: {"type":"error","error":{"type":...,"message":...}}
+// OpenAI:    {"error":{"message":...,"type":...,"param":null,"code":...}}
+func Body(openAI bool, errType, message string) []byte {
+	var body any
+	if openAI {
+		var code any
+		if errType == TypeRateLimit {
+			code = "rate_limit_exceeded"
+		}
+		body = gin.H{"error": gin.H{
+			"message": message,
+			"type":    openAITypes[errType],
+			"param":   nil,
+			"code":    code,
+		}}
+	} else {
+		body = gin.H{"type": "error", "error": gin.H{"type": errType, "message": message}}
+	}
+
+	data, _ := json.Marshal(body)
+	return data
+}
+
+// Write sends an error in the request endpoint's format, typed by status
+func Write(c *gin.Context, status int, message string) {
+	c.Data(status, "application/json", Body(WantsOpenAI(c.Request.URL.Path), TypeForStatus(status), message))
+}
+
+// Abort sends an error like Write and stops the handler chain
+func Abort(c *gin.Context, status int, message string) {
+	Write(c, status, message)
+	c.Abort()
+}
+
+type openAIShapeKey struct{}
+
+// WithOpenAIShape marks a request context as served by an endpoint expecting OpenAI errors
+func WithOpenAIShape(ctx context.Context) context.Context {
+	return context.WithValue(ctx, openAIShapeKey{}, true)
+}
+
+// OpenAIShape reports whether ctx was marked with WithOpenAIShape
+func OpenAIShape(ctx context.Context) bool {
+	openAI, _ := ctx.Value(openAIShapeKey{}).(bool)
+	return openAI
+}
+
+// StreamEvent frames an error for an SSE stream already under way
+// Anthropic streams send an "error" event; OpenAI streams a data line holding the error object.
+func StreamEvent(ctx context.Context, errType, message string) []byte {
+	if OpenAIShape(ctx) {
+		return []byte("data: " + string(Body(true, errType, message)) + "\n\n")
+	}
+	return []byte("event: error\ndata: " + string(Body(false, errType, message)) + "\n\n")
+}
@@ -0,0 +1,38 @@
+package apierror
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestWantsOpenAI(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/v1/messages":         false,
+		"/v1/chat/completions": true,
+		"/v1/completions":      true,
+	} {
+		if got := WantsOpenAI(path); got != want {
+			t.Errorf("WantsOpenAI(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestStreamEvent(t *testing.T) {
+	event := string(StreamEvent(context.Background(), TypeAPI, `upstream said "no"`))
+	if !strings.HasPrefix(event, "event: error\ndata: ") || !strings.HasSuffix(event, "\n\n") {
+		t.Fatalf("Anthropic event = %q, want an SSE error event", event)
+	}
+	data := strings.TrimSuffix(strings.TrimPrefix(event, "event: error\ndata: "), "\n\n")
+	if gjson.Get(data, "type").String() != "error" || gjson.Get(data, "error.message").String() != `upstream said "no"` {
+		t.Errorf("Anthropic event data = %s, want the error envelope", data)
+	}
+
+	event = string(StreamEvent(WithOpenAIShape(context.Background()), TypeAPI, "boom"))
+	data = strings.TrimSuffix(strings.TrimPrefix(event, "data: "), "\n\n")
+	if gjson.Get(data, "error.type").String() != "server_error" || gjson.Get(data, "type").Exists() {
+		t.Errorf("OpenAI event = %q, want an OpenAI error object", event)
+	}
+}
@@ -7,12 +7,25 @@ import (
 )
 
 type Config struct {
-	Server      ServerConfig               `yaml:"server"`
-	Database    DatabaseConfig             `yaml:"database"`
-	Redis       RedisConfig                `yaml:"redis"`
-	Proxy       ProxyConfig                `yaml:"proxy"`
-	AuthManager AuthManagerConfig          `yaml:"auth_manager"`
-	Providers   map[string]ProviderConfig  `yaml:"providers"`
+	Server      ServerConfig              `yaml:"server"`
+	Database    DatabaseConfig            `yaml:"database"`
+	Redis       RedisConfig               `yaml:"redis"`
+	Proxy       ProxyConfig               `yaml:"proxy"`
+	AuthManager AuthManagerConfig         `yaml:"auth_manager"`
+	OAuth       OAuthConfig               `yaml:"oauth"`
+	Stats       StatsConfig               `yaml:"stats"`
+	CORS        CORSConfig                `yaml:"cors"`
+	Providers   map[string]ProviderConfig `yaml:"providers"`
+
+	// Deprecated model names routed to their successors with a Warning header
+	ModelDeprecations map[string]string `yaml:"model_deprecations"`
+
+	// Fallback models tried in order when a model's provider has no usable account
+	ModelFailover map[string][]string `yaml:"model_failover"`
+
+	// Weighted targets per requested model for A/B traffic splits; seed 0 = time-based
+	ModelSplits    map[string][]ModelSplitTarget `yaml:"model_splits"`
+	ModelSplitSeed int64                         `yaml:"model_split_seed"`
 }
 
 type ProviderConfig struct {
@@ -20,12 +33,58 @@ type ProviderConfig struct {
 	AuthStrategy string   `yaml:"auth_strategy"`
 	BaseURL      string   `yaml:"base_url"`
 	BaseURLs     []string `yaml:"base_urls"`
+	Gzip         bool     `yaml:"gzip"`          // Negotiate gzip-compressed upstream responses
+	UpstreamMode string   `yaml:"upstream_mode"` // Non-stream requests: auto, stream, or non_stream
+
+	// Outbound request timeout; 0 keeps the 120s default. Raise it for slow thinking models.
+	TimeoutSeconds int `yaml:"timeout_seconds"`
+
+	// tool_result text longer than this is truncated with a marker before forwarding; 0 = unlimited
+	MaxToolResultChars int `yaml:"max_tool_result_chars"`
+
+	// Learned-quota window length; 0 keeps the 5h default. Per-model entries override it.
+	QuotaWindowSec      int            `yaml:"quota_window_sec"`
+	ModelQuotaWindowSec map[string]int `yaml:"model_quota_window_sec"`
+
+	// Antigravity only: max_tokens applied when a request omits it
+	DefaultMaxTokens int            `yaml:"default_max_tokens"`
+	ModelMaxTokens   map[string]int `yaml:"model_max_tokens"` // Per-model overrides of default_max_tokens
+
+	// Antigravity only: stop appending the interleaved-thinking hint to system prompts
+	DisableSystemHints bool `yaml:"disable_system_hints"`
+
+	// openai_compatible upstreams (vLLM, Ollama, Together): set type and list the models they serve.
+	// base_url includes the API version (e.g. http://localhost:8000/v1); the account's api_key or
+	// this api_key is sent in auth_header (default Authorization: Bearer).
+	Type       string   `yaml:"type"`
+	Name       string   `yaml:"name"`
+	Models     []string `yaml:"models"`
+	AuthHeader string   `yaml:"auth_header"`
+	APIKey     string   `yaml:"api_key"`
+}
+
+type ModelSplitTarget struct {
+	Model  string `yaml:"model"`
+	Weight int    `yaml:"weight"`
 }
 
 type ServerConfig struct {
 	Host      string `yaml:"host"`
 	Port      int    `yaml:"port"`
 	JWTSecret string `yaml:"jwt_secret"`
+
+	ShutdownTimeoutSec    int `yaml:"shutdown_timeout_sec"`     // Drain window for in-flight requests, 0 = default 30s
+	MaxConcurrentStreams  int `yaml:"max_concurrent_streams"`   // Server-wide streaming request cap, 0 = unlimited
+	IdempotencyTTLSec     int `yaml:"idempotency_ttl_sec"`      // Idempotency-Key replay window, 0 = default 10m
+	StreamPingIntervalSec int `yaml:"stream_ping_interval_sec"` // Keep-alive ping after this much upstream silence, 0 = disabled
+	DefaultAPIKeyRPM      int `yaml:"default_api_key_rpm"`      // Rate limit of API keys created without one (only admins may set it), 0 = unlimited
+	UserRPM               int `yaml:"user_rpm"`                 // Per-user rate limit of requests made with an access key or login token, 0 = unlimited
+
+	// Write [DEBUG] logs (translated payloads, upstream error bodies); credentials are redacted
+	DebugLogging bool `yaml:"debug_logging"`
+
+	// Log every upstream request as sent (after translation) with its response, truncated to 4KB
+	RequestTap bool `yaml:"request_tap"`
 }
 
 type DatabaseConfig struct {
@@ -44,7 +103,7 @@ type RedisConfig struct {
 }
 
 type ProxyConfig struct {
-	SelectionStrategy    string `yaml:"selection_strategy"`
+	SelectionStrategy    string `yaml:"selection_strategy"` // fill_first (default) or lowest_latency
 	HealthCheckInterval  int    `yaml:"health_check_interval"`
 	MaxFailures          int    `yaml:"max_failures"`
 	MaxRetries           int    `yaml:"max_retries"`
@@ -54,12 +113,43 @@ type ProxyConfig struct {
 }
 
 type AuthManagerConfig struct {
-	Enabled                     bool `yaml:"enabled"`
-	PeriodicReconcileIntervalMin int  `yaml:"periodic_reconcile_interval_min"`
-	AutoRetry                   bool `yaml:"auto_retry"`
-	MaxRetries                  int  `yaml:"max_retries"`
+	Enabled                      bool    `yaml:"enabled"`
+	PeriodicReconcileIntervalMin int     `yaml:"periodic_reconcile_interval_min"`
+	AutoRetry                    bool    `yaml:"auto_retry"`
+	MaxRetries                   int     `yaml:"max_retries"`
+	DailyRequestBudget           int64   `yaml:"daily_request_budget"`           // Per-account requests/day, 0 = unlimited
+	SlowStartWindowSec           int     `yaml:"slow_start_window_sec"`          // Ramp after all-blocked recovery, 0 = disabled
+	SlowStartMinFraction         float64 `yaml:"slow_start_min_fraction"`        // Share of traffic admitted at ramp start
+	RotationCooldownMs           int     `yaml:"rotation_cooldown_ms"`           // Min interval between picks of one account, 0 = disabled
+	ServiceTierRouting           bool    `yaml:"service_tier_routing"`           // Route service_tier requests to {"pool":"priority"} accounts
+	PreferWarmTokens             bool    `yaml:"prefer_warm_tokens"`             // Break selection ties toward accounts with a cached access token
+	MetricsSnapshotIntervalSec   int     `yaml:"metrics_snapshot_interval_sec"`  // Persist metric counters to Redis, 0 = in-memory only
+	StateSnapshotIntervalSec     int     `yaml:"state_snapshot_interval_sec"`    // Persist account blocks/cooldowns to Redis, 0 = in-memory only
+	AuthFailureDisableThreshold  int     `yaml:"auth_failure_disable_threshold"` // Consecutive 401s before deactivating an account, 0 = disabled
+	CircuitBreakerThreshold      int     `yaml:"circuit_breaker_threshold"`      // Consecutive no-usable-account failures before failing fast, 0 = disabled
+	CircuitBreakerCooldownSec    int     `yaml:"circuit_breaker_cooldown_sec"`   // Fail-fast window before probing recovery, 0 = 30s
+	StaleQuotaLimitGrace         bool    `yaml:"stale_quota_limit_grace"`        // Keep applying learned limits after their confidence decays
+	QuotaWebhookURL              string  `yaml:"quota_webhook_url"`              // POST a JSON event when an account+model is exhausted, "" = disabled
+	CooldownJitterFraction       float64 `yaml:"cooldown_jitter_fraction"`       // Max random extra share of computed cooldowns, 0 = default 0.2, negative = disabled
+	DisableTransportRetry        bool    `yaml:"disable_transport_retry"`        // Don't retry timeouts and connection resets/refusals on another account
+
+	// Empty 200 responses by Claude stop_reason ("*" = any): pass_through, retry or refusal
+	EmptyResponsePolicy map[string]string `yaml:"empty_response_policy"`
 }
 
+type OAuthConfig struct {
+	AllowDuplicateAccounts bool `yaml:"allow_duplicate_accounts"` // Skip provider+email dedup on re-authentication
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"` // Origins reflected in Access-Control-Allow-Origin with credentials, "*" = any (without credentials); empty = local frontend dev server
+	AllowedMethods []string `yaml:"allowed_methods"` // Empty = POST, OPTIONS, GET, PUT, DELETE, PATCH
+	AllowedHeaders []string `yaml:"allowed_headers"` // Empty = the frontend and API-key headers
+}
+
+type StatsConfig struct {
+	LogRetentionDays int `yaml:"log_retention_days"` // Request log retention, 0 = default 30
+}
 
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRun_DrainsInFlightRequest(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	quit := make(chan os.Signal, 1)
+	runErr := make(chan error, 1)
+	go func() { runErr <- Run(ln, handler, quit, 5*time.Second) }()
+
+	respCh := make(chan *http.Response, 1)
+	reqErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			reqErr <- err
+			return
+		}
+		resp.Body.Close()
+		respCh <- resp
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler never started")
+	}
+	quit <- syscall.SIGTERM
+
+	select {
+	case resp := <-respCh:
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+	case err := <-reqErr:
+		t.Fatalf("in-flight request failed: %v", err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("in-flight request did not complete")
+	}
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("Run returned error: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not return after shutdown")
+	}
+
+	if _, err := net.DialTimeout("tcp", ln.Addr().String(), 500*time.Millisecond); err == nil {
+		t.Fatal("expected listener to be closed after shutdown")
+	}
+}
+
+func TestRun_ShutdownTimeoutExceeded(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	quit := make(chan os.Signal, 1)
+	runErr := make(chan error, 1)
+	go func() { runErr <- Run(ln, handler, quit, 100*time.Millisecond) }()
+	go http.Get("http://" + ln.Addr().String() + "/stuck")
+
+	<-started
+	quit <- syscall.SIGTERM
+
+	select {
+	case err := <-runErr:
+		if err == nil {
+			t.Fatal("expected timeout error when handler outlives shutdown window")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not honour shutdown timeout")
+	}
+}
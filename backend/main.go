@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -12,11 +13,14 @@ import (
 
 	"aigateway-backend/auth/claude"
 	"aigateway-backend/auth/codex"
+	autherrors "aigateway-backend/auth/errors"
 	"aigateway-backend/auth/manager"
 	"aigateway-backend/handlers"
 	"aigateway-backend/internal/config"
 	"aigateway-backend/internal/database"
+	"aigateway-backend/internal/server"
 	"aigateway-backend/middleware"
+	"aigateway-backend/models"
 	"aigateway-backend/providers"
 	"aigateway-backend/providers/antigravity"
 	"aigateway-backend/providers/glm"
@@ -59,6 +63,9 @@ func main() {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
 
+	// Verbose upstream dumps stay off unless explicitly enabled
+	providers.SetDebugLogging(cfg.Server.DebugLogging)
+
 	// Create background context for services
 	ctx := context.Background()
 
@@ -82,6 +89,7 @@ func main() {
 	accountService.SetProxyService(proxyService) // Wire proxy service for availability checks
 	oauthService := services.NewOAuthService(redis, accountRepo, httpClientService, errorLogService)
 	oauthFlowService := services.NewOAuthFlowService(redis, accountService, accountRepo, proxyService)
+	oauthFlowService.SetAllowDuplicateAccounts(cfg.OAuth.AllowDuplicateAccounts)
 
 	// Initialize and start token refresh service (legacy)
 	tokenRefreshService := services.NewTokenRefreshService(accountRepo, redis)
@@ -89,15 +97,23 @@ func main() {
 
 	proxyHealthService := services.NewProxyHealthService(proxyRepo, redis)
 	statsTrackerService := services.NewStatsTrackerService(statsRepo, proxyRepo, redis, proxyHealthService)
+	statsTrackerService.StartLogCleanupRoutine(cfg.Stats.LogRetentionDays) // Purge request logs past retention
 
 	// Initialize proxy health check service (automatic recovery)
 	proxyHealthCheckService := services.NewProxyHealthCheckService(proxyRepo, 5, 1440) // Check every 5 min, recover after 1 day down
 	proxyHealthCheckService.Start(ctx)
 	statsQueryService := services.NewStatsQueryService(statsRepo)
 	quotaTrackerService := services.NewQuotaTrackerService(quotaPatternRepo, redis)
+	quotaTrackerService.SetStaleLimitGrace(cfg.AuthManager.StaleQuotaLimitGrace)
+	if cfg.AuthManager.QuotaWebhookURL != "" {
+		quotaTrackerService.SetExhaustionWebhook(services.NewQuotaWebhook(cfg.AuthManager.QuotaWebhookURL))
+	}
 	tokenExtractor := services.NewTokenExtractor()
 	modelsService := services.NewModelsService(db, redis)
 	modelMappingService := services.NewModelMappingService(modelMappingRepo, redis)
+	if err := modelMappingService.StartInvalidationListener(ctx); err != nil {
+		log.Printf("Warning: Model mapping cache invalidation disabled: %v", err)
+	}
 
 	// Initialize RBAC services
 	passwordService := services.NewPasswordService()
@@ -108,8 +124,22 @@ func main() {
 
 	// Initialize providers
 	antigravityProvider := antigravity.NewAntigravityProvider()
+	antigravityProvider.SetGzip(cfg.Providers["antigravity"].Gzip)
+	upstreamMode, err := providers.ParseUpstreamMode(cfg.Providers["antigravity"].UpstreamMode)
+	if err != nil {
+		log.Fatalf("Invalid antigravity config: %v", err)
+	}
+	antigravityProvider.SetUpstreamMode(upstreamMode)
+	antigravityProvider.SetMaxTokenDefaults(cfg.Providers["antigravity"].DefaultMaxTokens, cfg.Providers["antigravity"].ModelMaxTokens)
+	antigravityProvider.SetTimeout(providers.RequestTimeout(cfg.Providers["antigravity"].TimeoutSeconds))
+	antigravityProvider.SetSystemHints(!cfg.Providers["antigravity"].DisableSystemHints)
+	antigravityProvider.SetMaxToolResultChars(cfg.Providers["antigravity"].MaxToolResultChars)
 	openaiProvider := openai.NewOpenAIProvider()
+	openaiProvider.SetTimeout(providers.RequestTimeout(cfg.Providers["openai"].TimeoutSeconds))
+	openaiProvider.SetMaxToolResultChars(cfg.Providers["openai"].MaxToolResultChars)
 	glmProvider := glm.NewProvider()
+	glmProvider.SetTimeout(providers.RequestTimeout(cfg.Providers["glm"].TimeoutSeconds))
+	glmProvider.SetMaxToolResultChars(cfg.Providers["glm"].MaxToolResultChars)
 
 	// Initialize provider registry
 	registry := providers.NewRegistry()
@@ -117,6 +147,12 @@ func main() {
 	registry.Register("openai", openaiProvider)
 	registry.Register("glm", glmProvider)
 
+	// Self-hosted OpenAI-compatible upstreams declared in config
+	compatibleIDs, err := registerCompatibleProviders(registry, cfg.Providers)
+	if err != nil {
+		log.Fatalf("Invalid openai_compatible config: %v", err)
+	}
+
 	// Set custom model mapping resolver
 	registry.SetMappingResolver(modelMappingService)
 
@@ -141,12 +177,112 @@ func main() {
 	authManager.RegisterRefresher("codex", codex.NewRefresher())
 	// Note: antigravity uses existing tokenRefreshService
 
+	// OpenAI-compatible upstreams return OpenAI-style errors
+	for _, providerID := range compatibleIDs {
+		authManager.RegisterParser(providerID, &autherrors.CodexParser{})
+	}
+
 	// Wire quota tracker to AuthManager
 	authManager.SetQuotaTracker(quotaTrackerService, tokenExtractor)
 
+	// Per-provider quota windows
+	for providerID, providerCfg := range cfg.Providers {
+		modelWindows := make(map[string]time.Duration, len(providerCfg.ModelQuotaWindowSec))
+		for model, sec := range providerCfg.ModelQuotaWindowSec {
+			modelWindows[model] = time.Duration(sec) * time.Second
+		}
+		quotaTrackerService.SetProviderWindow(providerID, time.Duration(providerCfg.QuotaWindowSec)*time.Second, modelWindows)
+	}
+	quotaTrackerService.SetAccountProviderResolver(func(accountID string) string {
+		account, err := accountRepo.GetByID(accountID)
+		if err != nil {
+			return ""
+		}
+		return account.ProviderID
+	})
+
+	// Per-account daily request budget (operator cost control)
+	authManager.SetDailyRequestBudget(cfg.AuthManager.DailyRequestBudget)
+
+	// Gradual traffic ramp after accounts recover from all-blocked
+	authManager.SetSlowStart(
+		time.Duration(cfg.AuthManager.SlowStartWindowSec)*time.Second,
+		cfg.AuthManager.SlowStartMinFraction,
+	)
+
+	// Stagger recovery of accounts blocked by the same provider-wide limit
+	if cfg.AuthManager.CooldownJitterFraction != 0 {
+		authManager.SetCooldownJitter(cfg.AuthManager.CooldownJitterFraction)
+	}
+
+	// Spread traffic by resting each account briefly after it's picked
+	authManager.SetRotationCooldown(time.Duration(cfg.AuthManager.RotationCooldownMs) * time.Millisecond)
+
+	// Deactivate accounts whose credentials keep getting rejected
+	authManager.SetAuthFailureThreshold(cfg.AuthManager.AuthFailureDisableThreshold)
+	authManager.SetOnAccountDisabled(func(account *models.Account, reason string) {
+		log.Printf("ALERT: account %s (%s, %s) auto-disabled: %s", account.ID, account.ProviderID, account.Label, reason)
+	})
+
+	// Dedicated priority account pool for service_tier "auto" requests
+	authManager.SetServiceTierRouting(cfg.AuthManager.ServiceTierRouting)
+
+	// Avoid on-demand token refresh latency when accounts are otherwise equal
+	if cfg.AuthManager.PreferWarmTokens {
+		authManager.SetWarmTokenPreference(oauthService)
+	}
+
+	// Carry rotation/cooldown counters across restarts
+	if cfg.AuthManager.MetricsSnapshotIntervalSec > 0 {
+		if err := authManager.RestoreMetrics(ctx); err != nil {
+			log.Printf("Warning: Failed to restore AuthManager metrics: %v", err)
+		}
+		authManager.StartMetricsSnapshot(ctx, time.Duration(cfg.AuthManager.MetricsSnapshotIntervalSec)*time.Second)
+	}
+
+	// Keep recently blocked accounts blocked across deploys (restored by LoadAccounts)
+	authManager.StartStateSnapshot(ctx, time.Duration(cfg.AuthManager.StateSnapshotIntervalSec)*time.Second)
+
 	// Wire AuthManager to RouterService
 	routerService.SetAuthManager(authManager)
 
+	// Retry or replace empty (e.g. safety-filtered) responses
+	emptyResponsePolicy, err := services.ParseEmptyResponsePolicy(cfg.AuthManager.EmptyResponsePolicy)
+	if err != nil {
+		log.Fatalf("Invalid auth_manager config: %v", err)
+	}
+	routerService.SetEmptyResponsePolicy(emptyResponsePolicy)
+
+	// Timeouts and connection resets are retried on another account unless disabled
+	routerService.SetRetryOnTransportErrors(!cfg.AuthManager.DisableTransportRetry)
+
+	// Serve requests from another provider while every account of the primary is blocked
+	routerService.SetFailover(cfg.ModelFailover)
+
+	// Weighted model splits for A/B testing
+	if len(cfg.ModelSplits) > 0 {
+		splits := make(map[string][]services.SplitTarget, len(cfg.ModelSplits))
+		for alias, targets := range cfg.ModelSplits {
+			for _, target := range targets {
+				splits[alias] = append(splits[alias], services.SplitTarget{Model: target.Model, Weight: target.Weight})
+			}
+		}
+		routerService.SetModelSplitter(services.NewModelSplitter(splits, cfg.ModelSplitSeed))
+	}
+
+	// Keep long, silent (thinking) streams alive through idle-timeout intermediaries
+	routerService.SetStreamPingInterval(time.Duration(cfg.Server.StreamPingIntervalSec) * time.Second)
+
+	// Fail fast with 503 while every account of a provider is unusable
+	var circuitBreaker *services.CircuitBreaker
+	if cfg.AuthManager.CircuitBreakerThreshold > 0 {
+		circuitBreaker = services.NewCircuitBreaker(
+			cfg.AuthManager.CircuitBreakerThreshold,
+			time.Duration(cfg.AuthManager.CircuitBreakerCooldownSec)*time.Second,
+		)
+		routerService.SetCircuitBreaker(circuitBreaker)
+	}
+
 	// Wire AuthManager to OAuthFlowService for hot-reload
 	oauthFlowService.SetAuthManager(authManager)
 
@@ -154,14 +290,16 @@ func main() {
 	authManager.StartAutoRefresh(ctx, 30*time.Second)
 
 	// Start periodic reconciliation for hot-reload recovery (from config)
-	providerIDs := []string{"antigravity", "claude", "codex"}
+	// Compatible providers' accounts go through the AuthManager like the built-in ones, and so
+	// do those of providers serving failover models
+	providerIDs := routerService.FailoverProviderIDs(append([]string{"antigravity", "claude", "codex"}, compatibleIDs...))
 	reconcileInterval := time.Duration(cfg.AuthManager.PeriodicReconcileIntervalMin) * time.Minute
 	authManager.StartPeriodicReconcile(ctx, reconcileInterval, providerIDs)
 
 	// Load accounts async after server starts
 	go func() {
 		time.Sleep(2 * time.Second)
-		if err := authManager.LoadAccounts(ctx, "antigravity", "claude", "codex"); err != nil {
+		if err := authManager.LoadAccounts(ctx, providerIDs...); err != nil {
 			log.Printf("Warning: Failed to load accounts into AuthManager: %v", err)
 		}
 	}()
@@ -179,6 +317,13 @@ func main() {
 		log.Println("AuthManager disabled - using legacy round-robin selection")
 	}
 
+	// Debug tap for upstream payloads (off by default)
+	if cfg.Server.RequestTap {
+		routerService.SetRequestTap(services.NewLogRequestTap(4096))
+		routerService.EnableRequestTap(true)
+		log.Println("RequestTap enabled - logging upstream payloads")
+	}
+
 	// ========================================
 
 	// Initialize executor service with router
@@ -196,24 +341,34 @@ func main() {
 	// Get git commit hash for version tracking
 	gitVersion := getGitCommitHash()
 	proxyHandler.SetBuildInfo(gitVersion, useAuthManager)
+	proxyHandler.SetIdempotencyStore(services.NewIdempotencyStore(redis, time.Duration(cfg.Server.IdempotencyTTLSec)*time.Second))
 
 	accountHandler := handlers.NewAccountHandler(accountService)
 	proxyMgmtHandler := handlers.NewProxyManagementHandler(proxyService)
 	statsHandler := handlers.NewStatsHandler(statsQueryService)
 	logsHandler := handlers.NewLogsHandler(errorLogService)
-	modelsHandler := handlers.NewModelsHandler(modelsService)
+	modelsHandler := handlers.NewModelsHandler(modelsService, modelMappingService)
 	modelMappingHandler := handlers.NewModelMappingHandler(modelMappingService)
 	authHandler := handlers.NewAuthHandler(authService, userService)
 	userHandler := handlers.NewUserHandler(userService)
 	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	apiKeyHandler.SetDefaultRateLimit(cfg.Server.DefaultAPIKeyRPM)
 	oauthHandler := handlers.NewOAuthHandler(oauthFlowService)
 	quotaHandler := handlers.NewQuotaHandler(quotaTrackerService, accountRepo, quotaPatternRepo)
+	cacheHandler := handlers.NewCacheHandler(oauthService, modelMappingService)
+	metricsHandler := handlers.NewMetricsHandler(quotaTrackerService, authManager)
+	translateHandler := handlers.NewTranslateHandler(registry)
+	healthHandler := handlers.NewHealthHandler(db, redis, authManager)
+	catalogHandler := handlers.NewCatalogHandler(services.NewCatalogService(registry, modelMappingService, authManager))
 
 	// Initialize auth status handler (for AuthManager dashboard)
 	authStatusHandler := handlers.NewAuthStatusHandler(authManager, authManager.GetMetrics())
+	authStatusHandler.SetCircuitBreaker(circuitBreaker)
 
 	// Initialize auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
+	authMiddleware.SetRateLimiter(services.NewAPIKeyRateLimiter(redis))
+	authMiddleware.SetUserRateLimit(cfg.Server.UserRPM)
 
 	// Setup routes
 	r := gin.Default()
@@ -232,6 +387,11 @@ func main() {
 		apiKeyHandler,
 		oauthHandler,
 		quotaHandler,
+		cacheHandler,
+		metricsHandler,
+		translateHandler,
+		healthHandler,
+		catalogHandler,
 		authMiddleware,
 	)
 
@@ -242,28 +402,68 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start server in goroutine
+	// Start server; blocks until shutdown signal, then drains in-flight requests
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
 	log.Printf("Server starting on %s", addr)
 
-	go func() {
-		if err := r.Run(addr); err != nil {
-			log.Printf("Server error: %v", err)
-		}
-	}()
-
-	// Wait for shutdown signal
-	<-quit
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSec) * time.Second
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = server.DefaultShutdownTimeout
+	}
+	if err := server.Run(ln, r, quit, shutdownTimeout); err != nil {
+		log.Printf("Server error: %v", err)
+	}
 	log.Println("Shutting down server...")
 
+	// Persist stats/quota writes spawned by the requests that just drained
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), shutdownTimeout)
+	if err := services.DrainAsyncWrites(drainCtx); err != nil {
+		log.Printf("Async writes not flushed: %v", err)
+	}
+	cancelDrain()
+
 	// Stop background services
 	tokenRefreshService.Stop()
 	authManager.StopAutoRefresh()
 	authManager.StopPeriodicReconcile()
+	authManager.StopMetricsSnapshot()
+	authManager.StopStateSnapshot()
 
 	log.Println("Server exited")
 }
 
+// registerCompatibleProviders registers every provider configured with type openai_compatible
+func registerCompatibleProviders(registry *providers.Registry, providerCfgs map[string]config.ProviderConfig) ([]string, error) {
+	var ids []string
+	for providerID, providerCfg := range providerCfgs {
+		if providerCfg.Type != openai.CompatibleType {
+			continue
+		}
+
+		provider, err := openai.RegisterCompatible(registry, openai.CompatibleConfig{
+			ID:         providerID,
+			Name:       providerCfg.Name,
+			BaseURL:    providerCfg.BaseURL,
+			Models:     providerCfg.Models,
+			AuthHeader: providerCfg.AuthHeader,
+			APIKey:     providerCfg.APIKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		provider.SetTimeout(providers.RequestTimeout(providerCfg.TimeoutSeconds))
+		provider.SetMaxToolResultChars(providerCfg.MaxToolResultChars)
+
+		log.Printf("Registered openai_compatible provider %s (%s, %d models)", providerID, providerCfg.BaseURL, len(providerCfg.Models))
+		ids = append(ids, providerID)
+	}
+	return ids, nil
+}
+
 // setupAuthStatusRoutes registers AuthManager status endpoints
 func setupAuthStatusRoutes(r *gin.Engine, h *handlers.AuthStatusHandler, authMiddleware *middleware.AuthMiddleware) {
 	authStatus := r.Group("/api/v1/auth-manager")
@@ -272,8 +472,10 @@ func setupAuthStatusRoutes(r *gin.Engine, h *handlers.AuthStatusHandler, authMid
 	{
 		authStatus.GET("/accounts", h.GetAccountsStatus)
 		authStatus.GET("/accounts/:id", h.GetAccountStatus)
+		authStatus.GET("/accounts/:id/events", h.GetAccountEvents)
 		authStatus.GET("/metrics", h.GetMetrics)
 		authStatus.GET("/health", h.GetHealthSummary)
+		authStatus.GET("/candidates", h.GetCandidates)
 	}
 }
 
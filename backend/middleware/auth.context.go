@@ -9,6 +9,9 @@ import (
 
 const UserContextKey = "current_user"
 
+// APIKeyContextKey holds the API key used to authenticate the request (absent for JWT/access keys)
+const APIKeyContextKey = "current_api_key"
+
 func SetCurrentUser(c *gin.Context, user *models.User) {
 	c.Set(UserContextKey, user)
 }
@@ -40,3 +43,19 @@ func GetCurrentRole(c *gin.Context) models.Role {
 	}
 	return user.Role
 }
+
+func SetCurrentAPIKey(c *gin.Context, key *models.APIKey) {
+	c.Set(APIKeyContextKey, key)
+}
+
+func GetCurrentAPIKey(c *gin.Context) *models.APIKey {
+	val, exists := c.Get(APIKeyContextKey)
+	if !exists {
+		return nil
+	}
+	key, ok := val.(*models.APIKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
@@ -11,6 +11,8 @@ import (
 
 type AuthMiddleware struct {
 	authService *services.AuthService
+	rateLimiter *services.APIKeyRateLimiter
+	userRPM     int // Per-user limit of requests made without an API key, 0 = unlimited
 }
 
 func NewAuthMiddleware(authService *services.AuthService) *AuthMiddleware {
@@ -22,10 +24,7 @@ func (m *AuthMiddleware) ExtractAuth() gin.HandlerFunc {
 		// Try X-API-Key header first
 		apiKey := c.GetHeader("X-API-Key")
 		if apiKey != "" {
-			user, err := m.authService.ValidateAPIKey(apiKey)
-			if err == nil {
-				SetCurrentUser(c, user)
-			}
+			m.authenticateAPIKey(c, apiKey)
 			c.Next()
 			return
 		}
@@ -62,10 +61,7 @@ func (m *AuthMiddleware) ExtractAuth() gin.HandlerFunc {
 		case "bearer":
 			// Could be JWT or API key or access key
 			if strings.HasPrefix(token, "ak_") {
-				user, err := m.authService.ValidateAPIKey(token)
-				if err == nil {
-					SetCurrentUser(c, user)
-				}
+				m.authenticateAPIKey(c, token)
 			} else if strings.HasPrefix(token, "uk_") {
 				user, err := m.authService.ValidateAccessKey(token)
 				if err == nil {
@@ -83,3 +79,13 @@ func (m *AuthMiddleware) ExtractAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// authenticateAPIKey sets the current user and API key when rawKey is valid
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, rawKey string) {
+	key, err := m.authService.AuthenticateAPIKey(rawKey)
+	if err != nil {
+		return
+	}
+	SetCurrentUser(c, key.User)
+	SetCurrentAPIKey(c, key)
+}
@@ -1,19 +1,64 @@
 package middleware
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 )
 
+// DefaultCORSOrigins allows only the local frontend dev server when no origins are configured
+var DefaultCORSOrigins = []string{"http://localhost:5173", "http://127.0.0.1:5173"}
+
+// DefaultCORSMethods are the methods allowed when none are configured
+var DefaultCORSMethods = []string{"POST", "OPTIONS", "GET", "PUT", "DELETE", "PATCH"}
+
+// DefaultCORSHeaders are the request headers allowed when none are configured
+var DefaultCORSHeaders = []string{
+	"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization",
+	"accept", "origin", "Cache-Control", "X-Requested-With", "X-Access-Key", "X-API-Key",
+}
+
 // CORS middleware untuk allow cross-origin requests dari frontend
-func CORS() gin.HandlerFunc {
+// Only whitelisted origins are reflected in Access-Control-Allow-Origin, with credentials; "*" in
+// allowedOrigins answers any other origin with a literal "*" and no credentials, since browsers
+// reject credentialed wildcard responses. Empty lists fall back to the Default* values.
+func CORS(allowedOrigins, allowedMethods, allowedHeaders []string) gin.HandlerFunc {
+	if len(allowedOrigins) == 0 {
+		allowedOrigins = DefaultCORSOrigins
+	}
+	if len(allowedMethods) == 0 {
+		allowedMethods = DefaultCORSMethods
+	}
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = DefaultCORSHeaders
+	}
+
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[strings.TrimRight(origin, "/")] = true
+	}
+	methods := strings.Join(allowedMethods, ", ")
+	headers := strings.Join(allowedHeaders, ", ")
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Access-Key")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		// Responses differ by Origin, so shared caches must key on it
+		c.Writer.Header().Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		if origin != "" && (origins["*"] || origins[origin]) {
+			if origins[origin] {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+				c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			} else {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+			c.Writer.Header().Set("Access-Control-Allow-Headers", headers)
+			c.Writer.Header().Set("Access-Control-Allow-Methods", methods)
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupCORSRouter(origins []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORS(origins, nil, nil))
+	r.GET("/api/v1/accounts", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	return r
+}
+
+func requestWithOrigin(r *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/accounts", nil)
+	req.Header.Set("Origin", origin)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_ReflectsWhitelistedOrigin(t *testing.T) {
+	r := setupCORSRouter([]string{"https://admin.example.com"})
+
+	w := requestWithOrigin(r, http.MethodGet, "https://admin.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the whitelisted origin", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got == "" {
+		t.Error("Access-Control-Allow-Methods should be set for a whitelisted origin")
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORS_OmitsNonWhitelistedOrigin(t *testing.T) {
+	r := setupCORSRouter([]string{"https://admin.example.com"})
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		w := requestWithOrigin(r, method, "https://evil.example.com")
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want none for a non-whitelisted origin", method, got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("%s: Access-Control-Allow-Credentials = %q, want none", method, got)
+		}
+	}
+}
+
+func TestCORS_PreflightAndDefaults(t *testing.T) {
+	r := setupCORSRouter(nil)
+
+	w := requestWithOrigin(r, http.MethodOptions, DefaultCORSOrigins[0])
+	if w.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want 204", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != DefaultCORSOrigins[0] {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the default dev origin", got)
+	}
+
+	if got := requestWithOrigin(r, http.MethodGet, "https://other.example.com").Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("default policy reflected %q, want no wildcard", got)
+	}
+}
+
+func TestCORS_WildcardAllowsAnyOrigin(t *testing.T) {
+	r := setupCORSRouter([]string{"*"})
+
+	w := requestWithOrigin(r, http.MethodGet, "https://any.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want none with a wildcard origin", got)
+	}
+}
+
+func TestCORS_WildcardKeepsCredentialsForListedOrigin(t *testing.T) {
+	r := setupCORSRouter([]string{"https://admin.example.com", "*"})
+
+	w := requestWithOrigin(r, http.MethodGet, "https://admin.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the listed origin", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"aigateway-backend/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// RedirectDeprecatedModels rewrites requests for deprecated models to their successors
+// deprecations maps deprecated model names to successors. Redirected responses carry an
+// RFC 7234 Warning header (code 299) so clients learn about the deprecation.
+func RedirectDeprecatedModels(deprecations map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(deprecations) == 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		model := gjson.GetBytes(body, "model").String()
+		successor, deprecated := deprecations[model]
+		if !deprecated || successor == "" {
+			c.Next()
+			return
+		}
+
+		rewritten, err := sjson.SetBytes(body, "model", successor)
+		if err != nil {
+			apierror.Abort(c, http.StatusBadRequest, "failed to rewrite deprecated model")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(rewritten))
+		c.Request.ContentLength = int64(len(rewritten))
+
+		log.Printf("[Deprecation] Redirecting deprecated model %s to %s", model, successor)
+		c.Header("Warning", fmt.Sprintf(`299 - "Model %s is deprecated; request served by %s"`, model, successor))
+
+		c.Next()
+	}
+}
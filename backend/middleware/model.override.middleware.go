@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+
+	"aigateway-backend/internal/apierror"
+	"aigateway-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/sjson"
+)
+
+// ModelOverrideHeader lets admins serve a request with a different model without client changes
+const ModelOverrideHeader = "X-Model-Override"
+
+// OverrideModel replaces the request body's model with the X-Model-Override header value
+// Only admins may override; the header is ignored for other callers.
+func OverrideModel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		override := c.GetHeader(ModelOverrideHeader)
+		if override == "" || c.Request.Body == nil || GetCurrentRole(c) != models.RoleAdmin {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		rewritten, err := sjson.SetBytes(body, "model", override)
+		if err != nil {
+			apierror.Abort(c, http.StatusBadRequest, "failed to apply model override")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(rewritten))
+		c.Request.ContentLength = int64(len(rewritten))
+
+		log.Printf("[ModelOverride] User %s overrode model to %s", GetCurrentUserID(c), override)
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// setupDeprecationRouter serves /v1/messages with a handler echoing the model it received
+func setupDeprecationRouter(deprecations map[string]string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/messages", RedirectDeprecatedModels(deprecations), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, gjson.GetBytes(body, "model").String())
+	})
+	return r
+}
+
+func TestRedirectDeprecatedModels_RoutesToSuccessorWithWarning(t *testing.T) {
+	r := setupDeprecationRouter(map[string]string{"claude-3-opus": "claude-opus-4-5"})
+
+	w := postMessage(r, `{"model":"claude-3-opus","messages":[{"role":"user","content":"Hi"}]}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if w.Body.String() != "claude-opus-4-5" {
+		t.Errorf("handler saw model %q, want claude-opus-4-5", w.Body.String())
+	}
+
+	warning := w.Header().Get("Warning")
+	if !strings.HasPrefix(warning, "299 ") || !strings.Contains(warning, "claude-3-opus") || !strings.Contains(warning, "claude-opus-4-5") {
+		t.Errorf("Warning = %q, want a 299 warning naming both models", warning)
+	}
+}
+
+func TestRedirectDeprecatedModels_LeavesCurrentModels(t *testing.T) {
+	r := setupDeprecationRouter(map[string]string{"claude-3-opus": "claude-opus-4-5"})
+
+	w := postMessage(r, `{"model":"claude-sonnet-4-5"}`)
+
+	if w.Body.String() != "claude-sonnet-4-5" {
+		t.Errorf("handler saw model %q, want claude-sonnet-4-5", w.Body.String())
+	}
+	if w.Header().Get("Warning") != "" {
+		t.Errorf("Warning = %q, want none for a current model", w.Header().Get("Warning"))
+	}
+}
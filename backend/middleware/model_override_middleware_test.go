@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aigateway-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// setupOverrideRouter authenticates every request with role and echoes the model the handler received
+func setupOverrideRouter(role models.Role) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/messages", func(c *gin.Context) {
+		SetCurrentUser(c, &models.User{ID: "user-1", Role: role})
+	}, OverrideModel(), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, gjson.GetBytes(body, "model").String())
+	})
+	return r
+}
+
+func postWithOverride(r *gin.Engine, body, override string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body))
+	req.Header.Set(ModelOverrideHeader, override)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestOverrideModel_AdminReplacesModel(t *testing.T) {
+	r := setupOverrideRouter(models.RoleAdmin)
+
+	w := postWithOverride(r, `{"model":"claude-sonnet-4-5","messages":[]}`, "gpt-5")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if w.Body.String() != "gpt-5" {
+		t.Errorf("handler saw model %q, want gpt-5", w.Body.String())
+	}
+}
+
+func TestOverrideModel_IgnoredForNonAdmin(t *testing.T) {
+	r := setupOverrideRouter(models.RoleUser)
+
+	w := postWithOverride(r, `{"model":"claude-sonnet-4-5","messages":[]}`, "gpt-5")
+
+	if w.Body.String() != "claude-sonnet-4-5" {
+		t.Errorf("handler saw model %q, want claude-sonnet-4-5", w.Body.String())
+	}
+}
+
+func TestOverrideModel_NoHeaderLeavesModel(t *testing.T) {
+	r := setupOverrideRouter(models.RoleAdmin)
+
+	w := postMessage(r, `{"model":"claude-sonnet-4-5"}`)
+
+	if w.Body.String() != "claude-sonnet-4-5" {
+		t.Errorf("handler saw model %q, want claude-sonnet-4-5", w.Body.String())
+	}
+}
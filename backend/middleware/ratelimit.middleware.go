@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"aigateway-backend/internal/apierror"
+	"aigateway-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRateLimiter enables per-API-key rate limiting for RateLimit
+func (m *AuthMiddleware) SetRateLimiter(limiter *services.APIKeyRateLimiter) {
+	m.rateLimiter = limiter
+}
+
+// SetUserRateLimit limits each user's requests made without an API key to rpm per minute (0 = unlimited)
+// These are requests authenticated with a user access key (uk_) or a login token.
+func (m *AuthMiddleware) SetUserRateLimit(rpm int) {
+	m.userRPM = rpm
+}
+
+// RateLimit rejects requests over their requests-per-minute with 429
+// API key requests count against the key's own limit, other authenticated requests against
+// the per-user limit. Requests without a limit pass through. Limiter errors fail open so a
+// Redis outage doesn't take down the proxy.
+func (m *AuthMiddleware) RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.rateLimiter == nil {
+			c.Next()
+			return
+		}
+
+		var (
+			allowed    = true
+			retryAfter time.Duration
+			err        error
+			subject    string
+			message    string
+		)
+		if key := GetCurrentAPIKey(c); key != nil {
+			if key.RateLimitRPM > 0 {
+				allowed, retryAfter, err = m.rateLimiter.Allow(c.Request.Context(), key.ID, key.RateLimitRPM)
+				subject, message = "key "+key.KeyPrefix, "API key rate limit exceeded"
+			}
+		} else if user := GetCurrentUser(c); user != nil && m.userRPM > 0 {
+			allowed, retryAfter, err = m.rateLimiter.AllowUser(c.Request.Context(), user.ID, m.userRPM)
+			subject, message = "user "+user.ID, "user rate limit exceeded"
+		}
+
+		if err != nil {
+			log.Printf("[RateLimit] %v, allowing request for %s", err, subject)
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			apierror.Abort(c, http.StatusTooManyRequests, message)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aigateway-backend/models"
+	"aigateway-backend/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/tidwall/gjson"
+)
+
+func TestRateLimitAPIKey_Returns429WithRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	m := NewAuthMiddleware(nil)
+	m.SetRateLimiter(services.NewAPIKeyRateLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+
+	key := &models.APIKey{ID: "key-1", KeyPrefix: "ak_test", RateLimitRPM: 2}
+	r := gin.New()
+	r.POST("/v1/messages", func(c *gin.Context) { SetCurrentAPIKey(c, key) }, m.RateLimit(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	codes := make([]int, 0, 3)
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		last = httptest.NewRecorder()
+		r.ServeHTTP(last, httptest.NewRequest(http.MethodPost, "/v1/messages", nil))
+		codes = append(codes, last.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("status codes = %v, want [200 200 429]", codes)
+	}
+	if last.Header().Get("Retry-After") == "" {
+		t.Error("429 response should carry Retry-After")
+	}
+	if got := gjson.Get(last.Body.String(), "error.type").String(); got != "rate_limit_error" {
+		t.Errorf("error.type = %q, want rate_limit_error in %s", got, last.Body.String())
+	}
+}
+
+func TestRateLimitAPIKey_SkipsRequestsWithoutAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := NewAuthMiddleware(nil)
+	m.SetRateLimiter(services.NewAPIKeyRateLimiter(nil)) // Never reached without an API key
+
+	r := gin.New()
+	r.POST("/v1/messages", m.RateLimit(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimit_LimitsUsersWithoutAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	m := NewAuthMiddleware(nil)
+	m.SetRateLimiter(services.NewAPIKeyRateLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()})))
+	m.SetUserRateLimit(2)
+
+	// Access keys and login tokens only set the current user
+	r := gin.New()
+	r.POST("/v1/messages", func(c *gin.Context) {
+		SetCurrentUser(c, &models.User{ID: c.GetHeader("X-User"), Role: models.RoleUser})
+	}, m.RateLimit(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+		req.Header.Set("X-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	codes := make([]int, 0, 3)
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		last = post("user-1")
+		codes = append(codes, last.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("status codes = %v, want [200 200 429]", codes)
+	}
+	if last.Header().Get("Retry-After") == "" {
+		t.Error("429 response should carry Retry-After")
+	}
+
+	// Each user has their own window
+	if w := post("user-2"); w.Code != http.StatusOK {
+		t.Errorf("other user status = %d, want 200", w.Code)
+	}
+}
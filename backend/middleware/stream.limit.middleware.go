@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"aigateway-backend/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// streamRetryAfterSeconds is the Retry-After hint sent when all stream slots are taken
+const streamRetryAfterSeconds = "1"
+
+// StreamLimiter caps concurrent streaming requests across the whole server
+// It is independent of per-account in-flight limits, which only see upstream selection.
+type StreamLimiter struct {
+	slots chan struct{}
+}
+
+// NewStreamLimiter creates a limiter allowing max concurrent streams (nil = unlimited when max <= 0)
+func NewStreamLimiter(max int) *StreamLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &StreamLimiter{slots: make(chan struct{}, max)}
+}
+
+// TryAcquire takes a stream slot without blocking; returns false when saturated
+func (l *StreamLimiter) TryAcquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release frees a slot taken by TryAcquire
+func (l *StreamLimiter) Release() {
+	<-l.slots
+}
+
+// InUse returns the number of streams currently holding a slot
+func (l *StreamLimiter) InUse() int {
+	return len(l.slots)
+}
+
+// LimitConcurrentStreams rejects streaming requests with 503 while the limiter is saturated
+// Non-streaming requests pass through untouched. A nil limiter disables the check.
+func LimitConcurrentStreams(l *StreamLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil || !isStreamRequest(c) {
+			c.Next()
+			return
+		}
+
+		if !l.TryAcquire() {
+			c.Header("Retry-After", streamRetryAfterSeconds)
+			apierror.Abort(c, http.StatusServiceUnavailable, "too many concurrent streams, retry shortly")
+			return
+		}
+		defer l.Release()
+
+		c.Next()
+	}
+}
+
+// isStreamRequest mirrors the proxy handler's stream detection (?stream=true or "stream": true)
+// The body is restored so the handler can read it again.
+func isStreamRequest(c *gin.Context) bool {
+	if c.Query("stream") == "true" {
+		return true
+	}
+	if c.Request.Body == nil {
+		return false
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return gjson.GetBytes(body, "stream").Bool()
+}
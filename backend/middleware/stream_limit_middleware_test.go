@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupStreamLimitRouter serves /v1/messages with a handler that blocks until release is closed
+func setupStreamLimitRouter(limiter *StreamLimiter, release <-chan struct{}, started chan<- struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/messages", LimitConcurrentStreams(limiter), func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	return r
+}
+
+func postMessage(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body)))
+	return w
+}
+
+func TestLimitConcurrentStreams_RejectsBeyondLimit(t *testing.T) {
+	limiter := NewStreamLimiter(1)
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	r := setupStreamLimitRouter(limiter, release, started)
+
+	// First stream takes the only slot
+	first := make(chan *httptest.ResponseRecorder, 1)
+	go func() { first <- postMessage(r, `{"stream": true}`) }()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first stream did not start")
+	}
+
+	w := postMessage(r, `{"stream": true}`)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("second stream status = %d, want 503", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("503 response should carry Retry-After")
+	}
+
+	// Once the first stream completes a new one is admitted
+	close(release)
+	if got := <-first; got.Code != http.StatusOK {
+		t.Fatalf("first stream status = %d, want 200", got.Code)
+	}
+	if w := postMessage(r, `{"stream": true}`); w.Code != http.StatusOK {
+		t.Errorf("stream after release status = %d, want 200", w.Code)
+	}
+	if limiter.InUse() != 0 {
+		t.Errorf("InUse() = %d, want 0 after all streams finished", limiter.InUse())
+	}
+}
+
+func TestLimitConcurrentStreams_NonStreamingUnaffected(t *testing.T) {
+	limiter := NewStreamLimiter(1)
+	limiter.TryAcquire() // Saturate the limiter
+
+	release := make(chan struct{})
+	close(release)
+	r := setupStreamLimitRouter(limiter, release, make(chan struct{}, 1))
+
+	if w := postMessage(r, `{"stream": false}`); w.Code != http.StatusOK {
+		t.Errorf("non-streaming status = %d, want 200", w.Code)
+	}
+}
+
+func TestLimitConcurrentStreams_NilLimiterIsUnlimited(t *testing.T) {
+	if NewStreamLimiter(0) != nil {
+		t.Fatal("NewStreamLimiter(0) should disable the limit")
+	}
+
+	release := make(chan struct{})
+	close(release)
+	r := setupStreamLimitRouter(nil, release, make(chan struct{}, 1))
+
+	if w := postMessage(r, `{"stream": true}`); w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,11 @@
+-- Migration: Add per-account model allow/deny lists
+-- Date: 2026-10-16
+
+ALTER TABLE accounts
+ADD COLUMN allowed_models JSON NULL AFTER usage_count,
+ADD COLUMN denied_models JSON NULL AFTER allowed_models;
+
+-- Rollback script (save for reference):
+-- ALTER TABLE accounts
+-- DROP COLUMN allowed_models,
+-- DROP COLUMN denied_models;
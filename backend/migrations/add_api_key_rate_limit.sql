@@ -0,0 +1,9 @@
+-- Migration: Add per-key requests-per-minute limit to api_keys
+-- Date: 2026-10-16
+
+ALTER TABLE api_keys
+ADD COLUMN rate_limit_rpm INT NOT NULL DEFAULT 0 AFTER is_active;
+
+-- Rollback script (save for reference):
+-- ALTER TABLE api_keys
+-- DROP COLUMN rate_limit_rpm;
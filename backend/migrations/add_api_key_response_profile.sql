@@ -0,0 +1,9 @@
+-- Migration: Add per-key response profile to api_keys
+-- Date: 2026-10-16
+
+ALTER TABLE api_keys
+ADD COLUMN response_profile VARCHAR(32) NOT NULL DEFAULT '' AFTER rate_limit_rpm;
+
+-- Rollback script (save for reference):
+-- ALTER TABLE api_keys
+-- DROP COLUMN response_profile;
@@ -0,0 +1,11 @@
+-- Migration: Track the running max of usage observed at quota exhaustion
+-- Date: 2026-10-16
+
+ALTER TABLE account_quota_pattern
+ADD COLUMN max_observed_requests INT NULL AFTER est_token_limit,
+ADD COLUMN max_observed_tokens BIGINT NULL AFTER max_observed_requests;
+
+-- Rollback script (save for reference):
+-- ALTER TABLE account_quota_pattern
+-- DROP COLUMN max_observed_requests,
+-- DROP COLUMN max_observed_tokens;
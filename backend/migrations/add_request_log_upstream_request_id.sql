@@ -0,0 +1,11 @@
+-- Migration: Record the provider's request ID on request logs
+-- Date: 2026-10-16
+
+ALTER TABLE request_logs
+ADD COLUMN upstream_request_id VARCHAR(128) NOT NULL DEFAULT '' AFTER switched_from_account_id,
+ADD INDEX idx_request_logs_upstream_request_id (upstream_request_id);
+
+-- Rollback script (save for reference):
+-- ALTER TABLE request_logs
+-- DROP INDEX idx_request_logs_upstream_request_id,
+-- DROP COLUMN upstream_request_id;
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Account represents authentication credentials for a provider
 type Account struct {
@@ -16,6 +19,10 @@ type Account struct {
 	LastUsedAt *time.Time `json:"last_used_at"`
 	UsageCount int64      `gorm:"default:0" json:"usage_count"`
 
+	// Model access policy (empty = allow all). Entries match exactly or by prefix with a trailing "*".
+	AllowedModels StringArray `gorm:"type:json" json:"allowed_models"`
+	DeniedModels  StringArray `gorm:"type:json" json:"denied_models"`
+
 	// Health tracking
 	HealthStatus   string     `gorm:"size:20;default:'healthy';index" json:"health_status"` // healthy, degraded, down
 	FailureCount   int        `gorm:"default:0" json:"failure_count"`
@@ -34,3 +41,26 @@ type Account struct {
 func (Account) TableName() string {
 	return "accounts"
 }
+
+// AllowsModel reports whether the account's model policy permits model
+// Denied entries take precedence; an empty allow list permits every model not denied.
+func (a *Account) AllowsModel(model string) bool {
+	if matchesModel(a.DeniedModels, model) {
+		return false
+	}
+	return len(a.AllowedModels) == 0 || matchesModel(a.AllowedModels, model)
+}
+
+// matchesModel reports whether model matches any pattern exactly or by "prefix*"
+func matchesModel(patterns []string, model string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(model, prefix) {
+				return true
+			}
+		} else if pattern == model {
+			return true
+		}
+	}
+	return false
+}
@@ -4,19 +4,37 @@ package models
 import "time"
 
 type APIKey struct {
-	ID         string     `gorm:"type:varchar(36);primaryKey" json:"id"`
-	UserID     string     `gorm:"type:varchar(36);index;not null" json:"user_id"`
-	KeyHash    string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
-	KeyPrefix  string     `gorm:"type:varchar(12);not null" json:"key_prefix"`
-	Label      string     `gorm:"type:varchar(100)" json:"label"`
-	IsActive   bool       `gorm:"default:true" json:"is_active"`
-	LastUsedAt *time.Time `json:"last_used_at"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
+	ID              string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID          string     `gorm:"type:varchar(36);index;not null" json:"user_id"`
+	KeyHash         string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	KeyPrefix       string     `gorm:"type:varchar(12);not null" json:"key_prefix"`
+	Label           string     `gorm:"type:varchar(100)" json:"label"`
+	IsActive        bool       `gorm:"default:true" json:"is_active"`
+	RateLimitRPM    int        `gorm:"default:0" json:"rate_limit_rpm"`                              // Requests per minute, 0 = unlimited
+	ResponseProfile string     `gorm:"type:varchar(32);not null;default:''" json:"response_profile"` // Response shape for the client SDK, "" = unchanged
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 
 	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// Response profiles adjust the Claude message shape for clients with stricter SDKs
+const (
+	ResponseProfileDefault       = ""               // Return the response as translated
+	ResponseProfileStringContent = "string_content" // Collapse a single text block into a string
+	ResponseProfileArrayContent  = "array_content"  // Always return content as an array of blocks
+)
+
+// ValidResponseProfile reports whether profile is a known response profile
+func ValidResponseProfile(profile string) bool {
+	switch profile {
+	case ResponseProfileDefault, ResponseProfileStringContent, ResponseProfileArrayContent:
+		return true
+	}
+	return false
+}
+
 func (APIKey) TableName() string {
 	return "api_keys"
 }
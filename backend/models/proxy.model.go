@@ -77,6 +77,7 @@ type RequestLog struct {
 	LatencyMs            int       `json:"latency_ms"`
 	RetryCount           int       `gorm:"default:0" json:"retry_count"`
 	SwitchedFromAccountID *string  `gorm:"size:36" json:"switched_from_account_id,omitempty"`
+	UpstreamRequestID    string    `gorm:"size:128;index" json:"upstream_request_id,omitempty"` // Provider's request ID for support tickets
 	Error                string    `gorm:"type:text" json:"error"`
 	CreatedAt            time.Time `gorm:"index:idx_created" json:"created_at"`
 }
@@ -84,3 +85,13 @@ type RequestLog struct {
 func (RequestLog) TableName() string {
 	return "request_logs"
 }
+
+// LatencyPercentiles summarizes request latency over a window of request logs
+type LatencyPercentiles struct {
+	Count int64   `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	MaxMs int     `json:"max_ms"`
+	P50Ms int     `json:"p50_ms"`
+	P95Ms int     `json:"p95_ms"`
+	P99Ms int     `json:"p99_ms"`
+}
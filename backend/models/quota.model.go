@@ -12,6 +12,10 @@ type AccountQuotaPattern struct {
 	EstRequestLimit *int   `json:"est_request_limit"`
 	EstTokenLimit   *int64 `json:"est_token_limit"`
 
+	// Decaying maximum of usage observed at exhaustion; floors the learned limits
+	MaxObservedRequests *int   `json:"max_observed_requests"`
+	MaxObservedTokens   *int64 `json:"max_observed_tokens"`
+
 	// Confidence tracking
 	Confidence  float64 `gorm:"type:decimal(3,2);default:0" json:"confidence"`
 	SampleCount int     `gorm:"default:0" json:"sample_count"`
@@ -32,16 +36,19 @@ func (AccountQuotaPattern) TableName() string {
 
 // QuotaStatus represents current quota state for an account+model
 type QuotaStatus struct {
-	AccountID       string     `json:"account_id"`
-	Model           string     `json:"model"`
-	RequestsUsed    int        `json:"requests_used"`
-	TokensUsed      int64      `json:"tokens_used"`
-	EstRequestLimit *int       `json:"est_request_limit"`
-	EstTokenLimit   *int64     `json:"est_token_limit"`
-	PercentUsed     *float64   `json:"percent_used"`
-	Confidence      float64    `json:"confidence"`
-	IsExhausted     bool       `json:"is_exhausted"`
-	ResetsAt        *time.Time `json:"resets_at"`
+	AccountID          string     `json:"account_id"`
+	Model              string     `json:"model"`
+	RequestsUsed       int        `json:"requests_used"`
+	TokensUsed         int64      `json:"tokens_used"`
+	EstRequestLimit    *int       `json:"est_request_limit"`
+	EstTokenLimit      *int64     `json:"est_token_limit"`
+	PercentUsed        *float64   `json:"percent_used"`                   // Max of request and token utilization
+	RequestPercentUsed *float64   `json:"request_percent_used,omitempty"` // Utilization of EstRequestLimit
+	TokenPercentUsed   *float64   `json:"token_percent_used,omitempty"`   // Utilization of EstTokenLimit
+	Confidence         float64    `json:"confidence"`
+	LowConfidence      bool       `json:"low_confidence,omitempty"` // Stale limits still applied in grace mode
+	IsExhausted        bool       `json:"is_exhausted"`
+	ResetsAt           *time.Time `json:"resets_at"`
 }
 
 // ProviderQuotaSummary represents quota summary for a provider
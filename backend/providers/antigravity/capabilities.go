@@ -0,0 +1,13 @@
+package antigravity
+
+import "aigateway-backend/providers"
+
+// ModelCapabilities reports thinking support and the output cap from the static model config
+func (p *AntigravityProvider) ModelCapabilities(model string) providers.ModelCapabilities {
+	capabilities := providers.ModelCapabilities{Streaming: p.SupportsStreaming()}
+	if cfg, ok := GetModelConfig()[model]; ok {
+		capabilities.Thinking = cfg.Thinking != nil
+		capabilities.MaxOutputTokens = cfg.MaxCompletionTokens
+	}
+	return capabilities
+}
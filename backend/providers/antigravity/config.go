@@ -31,6 +31,9 @@ const (
 	// EndpointStream is the streaming endpoint (without query params)
 	EndpointStream = "/v1internal:streamGenerateContent"
 
+	// EndpointCountTokens counts the tokens of request contents
+	EndpointCountTokens = "/v1internal:countTokens"
+
 	// UserAgent is the HTTP User-Agent header value (same as reference)
 	UserAgent = "antigravity/1.104.0 darwin/arm64"
 
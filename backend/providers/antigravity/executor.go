@@ -1,6 +1,7 @@
 package antigravity
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
@@ -9,6 +10,10 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
 )
 
 // ExecuteRequest represents a request to execute against Antigravity API
@@ -25,6 +30,7 @@ type ExecuteResponse struct {
 	StatusCode int
 	Body       []byte
 	Latency    int64
+	Headers    http.Header
 	Error      error
 }
 
@@ -33,7 +39,9 @@ type StreamHandler func(chunk []byte) error
 
 // Executor handles HTTP communication with Antigravity API
 type Executor struct {
-	baseURLs []string
+	baseURLs     []string
+	gzip         bool                   // Request gzip-compressed responses
+	upstreamMode providers.UpstreamMode // Endpoint preference for non-streaming requests
 }
 
 // NewExecutor creates a new Executor instance
@@ -43,21 +51,106 @@ func NewExecutor() *Executor {
 	}
 }
 
+// SetGzip enables gzip negotiation for upstream responses (including SSE streams)
+func (e *Executor) SetGzip(enabled bool) {
+	e.gzip = enabled
+}
+
+// SetUpstreamMode sets which endpoint serves non-streaming requests
+func (e *Executor) SetUpstreamMode(mode providers.UpstreamMode) {
+	e.upstreamMode = mode
+}
+
+// useStreamEndpoint reports whether a request goes to the streaming endpoint
+// By default Claude models are forced onto streamGenerateContent even for non-stream requests.
+func (e *Executor) useStreamEndpoint(req *ExecuteRequest) bool {
+	if req.Stream {
+		return true
+	}
+	switch e.upstreamMode {
+	case providers.UpstreamModeStream:
+		return true
+	case providers.UpstreamModeNonStream:
+		return false
+	default:
+		return IsClaudeModel(req.Model)
+	}
+}
+
+// generateRejected reports whether the non-streaming endpoint itself is unavailable for the model
+// Only endpoint-level refusals count: 404/405/501, or a 400 saying generateContent is unsupported.
+// Other 400s are about the request and would fail on the stream endpoint too.
+func generateRejected(resp *ExecuteResponse) bool {
+	if resp == nil {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	case http.StatusBadRequest:
+		status := gjson.GetBytes(resp.Body, "error.status").String()
+		message := strings.ToLower(gjson.GetBytes(resp.Body, "error.message").String())
+		return status == "UNIMPLEMENTED" ||
+			(strings.Contains(message, "generatecontent") && strings.Contains(message, "not supported"))
+	}
+	return false
+}
+
+// setAcceptEncoding requests gzip when negotiation is enabled
+func (e *Executor) setAcceptEncoding(httpReq *http.Request) {
+	if e.gzip {
+		httpReq.Header.Set("Accept-Encoding", providers.EncodingGzip)
+	}
+}
+
 // Execute performs a non-streaming request to Antigravity API with fallback URLs
 func (e *Executor) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
 	var lastErr error
 	var lastResp *ExecuteResponse
 
+	stream := e.useStreamEndpoint(req)
+
 	for _, baseURL := range e.baseURLs {
 		endpoint := baseURL + EndpointGenerate
-		// Claude models always use streaming endpoint with alt=sse
-		if req.Stream || IsClaudeModel(req.Model) {
+		if stream {
 			endpoint = baseURL + EndpointStream + "?alt=sse"
 		}
 
-		fmt.Printf("[DEBUG] Model: %s, IsClaudeModel: %v, Endpoint: %s\n", req.Model, IsClaudeModel(req.Model), endpoint)
+		providers.Debugf("Model: %s, IsClaudeModel: %v, Endpoint: %s", req.Model, IsClaudeModel(req.Model), endpoint)
+
+		resp, err := e.executeRequest(ctx, req, endpoint, stream)
+
+		// Preferred non-streaming endpoint refused the model: fall back to streaming on the same host
+		if !stream && e.upstreamMode == providers.UpstreamModeNonStream && generateRejected(resp) {
+			providers.Debugf("Generate endpoint rejected %s (HTTP %d), falling back to stream", req.Model, resp.StatusCode)
+			resp, err = e.executeRequest(ctx, req, baseURL+EndpointStream+"?alt=sse", true)
+		}
 
-		resp, err := e.executeRequest(ctx, req, endpoint)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		lastResp = resp
+		lastErr = err
+
+		if resp != nil && resp.StatusCode == 401 {
+			return resp, err
+		}
+	}
+
+	if lastResp != nil {
+		return lastResp, lastErr
+	}
+	return nil, lastErr
+}
+
+// CountTokens posts a countTokens request with fallback URLs
+func (e *Executor) CountTokens(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	var lastErr error
+	var lastResp *ExecuteResponse
+
+	for _, baseURL := range e.baseURLs {
+		resp, err := e.executeRequest(ctx, req, baseURL+EndpointCountTokens, false)
 		if err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
 			return resp, nil
 		}
@@ -88,7 +181,7 @@ func resolveHost(base string) string {
 	return strings.TrimPrefix(strings.TrimPrefix(base, "https://"), "http://")
 }
 
-func (e *Executor) executeRequest(ctx context.Context, req *ExecuteRequest, endpoint string) (*ExecuteResponse, error) {
+func (e *Executor) executeRequest(ctx context.Context, req *ExecuteRequest, endpoint string, stream bool) (*ExecuteResponse, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(req.Payload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
@@ -100,12 +193,14 @@ func (e *Executor) executeRequest(ctx context.Context, req *ExecuteRequest, endp
 	httpReq.Header.Set("User-Agent", UserAgent)
 
 	// Stream uses text/event-stream, non-stream uses application/json
-	if req.Stream || IsClaudeModel(req.Model) {
+	if stream {
 		httpReq.Header.Set("Accept", "text/event-stream")
 	} else {
 		httpReq.Header.Set("Accept", "application/json")
 	}
 
+	e.setAcceptEncoding(httpReq)
+
 	// Set Host header
 	if host := resolveHost(endpoint); host != "" {
 		httpReq.Host = host
@@ -125,7 +220,18 @@ func (e *Executor) executeRequest(ctx context.Context, req *ExecuteRequest, endp
 	}
 	defer httpResp.Body.Close()
 
-	body, err := io.ReadAll(httpResp.Body)
+	respBody, err := providers.DecodeBody(httpResp)
+	if err != nil {
+		return &ExecuteResponse{
+			StatusCode: httpResp.StatusCode,
+			Body:       nil,
+			Latency:    latency,
+			Error:      err,
+		}, err
+	}
+	defer respBody.Close()
+
+	body, err := io.ReadAll(respBody)
 	if err != nil {
 		return &ExecuteResponse{
 			StatusCode: httpResp.StatusCode,
@@ -136,11 +242,12 @@ func (e *Executor) executeRequest(ctx context.Context, req *ExecuteRequest, endp
 	}
 
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		fmt.Printf("[DEBUG] Error response: %s\n", string(body))
+		providers.Debugf("Error response: %s", body)
 		return &ExecuteResponse{
 			StatusCode: httpResp.StatusCode,
 			Body:       body,
 			Latency:    latency,
+			Headers:    httpResp.Header,
 			Error:      fmt.Errorf("upstream error: status %d", httpResp.StatusCode),
 		}, fmt.Errorf("upstream error: status %d", httpResp.StatusCode)
 	}
@@ -149,6 +256,7 @@ func (e *Executor) executeRequest(ctx context.Context, req *ExecuteRequest, endp
 		StatusCode: httpResp.StatusCode,
 		Body:       body,
 		Latency:    latency,
+		Headers:    httpResp.Header,
 		Error:      nil,
 	}, nil
 }
@@ -190,6 +298,7 @@ func (e *Executor) executeStreamRequest(ctx context.Context, req *ExecuteRequest
 	httpReq.Header.Set("Authorization", "Bearer "+req.AccessToken)
 	httpReq.Header.Set("User-Agent", UserAgent)
 	httpReq.Header.Set("Accept", "text/event-stream")
+	e.setAcceptEncoding(httpReq)
 
 	startTime := time.Now()
 	httpResp, err := req.HTTPClient.Do(httpReq)
@@ -205,8 +314,20 @@ func (e *Executor) executeStreamRequest(ctx context.Context, req *ExecuteRequest
 	}
 	defer httpResp.Body.Close()
 
+	// Decompress before the SSE reader so compressed streams parse line by line
+	respBody, err := providers.DecodeBody(httpResp)
+	if err != nil {
+		return &ExecuteResponse{
+			StatusCode: httpResp.StatusCode,
+			Body:       nil,
+			Latency:    latency,
+			Error:      err,
+		}, err
+	}
+	defer respBody.Close()
+
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		body, _ := io.ReadAll(httpResp.Body)
+		body, _ := io.ReadAll(respBody)
 		return &ExecuteResponse{
 			StatusCode: httpResp.StatusCode,
 			Body:       body,
@@ -215,7 +336,7 @@ func (e *Executor) executeStreamRequest(ctx context.Context, req *ExecuteRequest
 		}, fmt.Errorf("upstream error: status %d", httpResp.StatusCode)
 	}
 
-	reader := NewSSEReader(httpResp.Body)
+	reader := NewSSEReader(respBody)
 	for {
 		event, err := reader.ReadEvent()
 		if err == io.EOF {
@@ -255,13 +376,15 @@ type SSEEvent struct {
 }
 
 // SSEReader reads Server-Sent Events from a stream
+// Input is buffered across calls, so a single read containing several events
+// (common with compressed streams) yields each event in turn.
 type SSEReader struct {
-	reader io.Reader
+	reader *bufio.Reader
 }
 
 // NewSSEReader creates a new SSE reader
 func NewSSEReader(reader io.Reader) *SSEReader {
-	return &SSEReader{reader: reader}
+	return &SSEReader{reader: bufio.NewReaderSize(reader, 4096)}
 }
 
 // ReadEvent reads the next SSE event from the stream
@@ -269,48 +392,30 @@ func (r *SSEReader) ReadEvent() (*SSEEvent, error) {
 	var event SSEEvent
 	var dataLines [][]byte
 
-	buf := make([]byte, 4096)
-	var line []byte
-
 	for {
-		n, err := r.reader.Read(buf)
+		line, err := r.reader.ReadBytes('\n')
 		if err != nil && err != io.EOF {
 			return nil, err
 		}
 
-		line = append(line, buf[:n]...)
-
-		// Process complete lines
-		for {
-			idx := bytes.IndexByte(line, '\n')
-			if idx == -1 {
-				break
-			}
-
-			currentLine := line[:idx]
-			line = line[idx+1:]
-
-			// Remove trailing \r if present
-			if len(currentLine) > 0 && currentLine[len(currentLine)-1] == '\r' {
-				currentLine = currentLine[:len(currentLine)-1]
-			}
+		// Remove trailing \n and \r if present
+		currentLine := bytes.TrimSuffix(line, []byte("\n"))
+		currentLine = bytes.TrimSuffix(currentLine, []byte("\r"))
 
+		if len(currentLine) == 0 {
 			// Empty line means end of event
-			if len(currentLine) == 0 {
-				if len(dataLines) > 0 {
-					event.Data = bytes.Join(dataLines, []byte("\n"))
-					return &event, nil
-				}
-				continue
+			if len(dataLines) > 0 {
+				event.Data = bytes.Join(dataLines, []byte("\n"))
+				return &event, nil
 			}
-
+			// An event without data is dropped; its type must not leak into the next one
+			event.Event = ""
+		} else if bytes.HasPrefix(currentLine, []byte("event:")) {
 			// Parse field
-			if bytes.HasPrefix(currentLine, []byte("event:")) {
-				event.Event = string(bytes.TrimSpace(currentLine[6:]))
-			} else if bytes.HasPrefix(currentLine, []byte("data:")) {
-				data := bytes.TrimSpace(currentLine[5:])
-				dataLines = append(dataLines, data)
-			}
+			event.Event = string(bytes.TrimSpace(currentLine[6:]))
+		} else if bytes.HasPrefix(currentLine, []byte("data:")) {
+			data := bytes.TrimSpace(currentLine[5:])
+			dataLines = append(dataLines, append([]byte(nil), data...))
 		}
 
 		if err == io.EOF {
@@ -0,0 +1,313 @@
+package antigravity
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
+)
+
+// gzipSSEServer serves a gzip-compressed SSE stream and records the Accept-Encoding header
+func gzipSSEServer(t *testing.T, events []string, acceptEncoding *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*acceptEncoding = r.Header.Get("Accept-Encoding")
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		for _, event := range events {
+			gz.Write([]byte("data: " + event + "\n\n"))
+		}
+		gz.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+	}))
+}
+
+func TestExecuteStream_GzipSSE(t *testing.T) {
+	events := []string{
+		`{"response":{"candidates":[{"content":{"parts":[{"text":"Hello"}]}}]}}`,
+		`{"response":{"candidates":[{"content":{"parts":[{"text":" world"}]},"finishReason":"STOP"}],"usageMetadata":{"candidatesTokenCount":2}}}`,
+	}
+	var acceptEncoding string
+	srv := gzipSSEServer(t, events, &acceptEncoding)
+	defer srv.Close()
+
+	executor := &Executor{baseURLs: []string{srv.URL}}
+	executor.SetGzip(true)
+
+	translator := NewStreamTranslator("gemini-2.5-pro")
+	var out bytes.Buffer
+	resp, err := executor.ExecuteStream(context.Background(), &ExecuteRequest{
+		Model:      "gemini-2.5-pro",
+		Payload:    []byte(`{}`),
+		HTTPClient: srv.Client(),
+	}, func(chunk []byte) error {
+		out.Write(translator.Translate(chunk))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+	if acceptEncoding != "gzip" {
+		t.Errorf("Accept-Encoding = %q, want gzip", acceptEncoding)
+	}
+
+	var text strings.Builder
+	for _, line := range strings.Split(out.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if gjson.Get(data, "delta.type").String() == "text_delta" {
+			text.WriteString(gjson.Get(data, "delta.text").String())
+		}
+	}
+	if text.String() != "Hello world" {
+		t.Errorf("translated text = %q, want %q\noutput:\n%s", text.String(), "Hello world", out.String())
+	}
+	if !strings.Contains(out.String(), "message_stop") {
+		t.Errorf("expected message_stop in output:\n%s", out.String())
+	}
+}
+
+func TestExecute_GzipJSON(t *testing.T) {
+	var acceptEncoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(`{"response":{"candidates":[]}}`))
+		gz.Close()
+	}))
+	defer srv.Close()
+
+	executor := &Executor{baseURLs: []string{srv.URL}}
+	executor.SetGzip(true)
+
+	resp, err := executor.Execute(context.Background(), &ExecuteRequest{
+		Model:      "gemini-2.5-pro",
+		Payload:    []byte(`{}`),
+		HTTPClient: srv.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if acceptEncoding != "gzip" {
+		t.Errorf("Accept-Encoding = %q, want gzip", acceptEncoding)
+	}
+	if string(resp.Body) != `{"response":{"candidates":[]}}` {
+		t.Errorf("Body = %s, want decoded JSON", resp.Body)
+	}
+}
+
+// generateOK is a successful generateContent response body
+const generateOK = `{"response":{"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}}`
+
+// endpointRecorder serves both endpoints and records which paths were hit
+// The generate endpoint answers generateStatus with generateBody.
+func endpointRecorder(t *testing.T, generateStatus int, generateBody string, paths *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		if r.URL.Path == EndpointGenerate {
+			w.WriteHeader(generateStatus)
+			w.Write([]byte(generateBody))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"response\":{\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"ok\"}]}}]}}\n\n"))
+	}))
+}
+
+func TestExecute_UpstreamModeEndpoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   providers.UpstreamMode
+		model  string
+		stream bool
+		want   string
+	}{
+		{"auto claude forced to stream", providers.UpstreamModeAuto, "claude-sonnet-4-5", false, EndpointStream},
+		{"auto gemini uses generate", providers.UpstreamModeAuto, "gemini-2.5-pro", false, EndpointGenerate},
+		{"prefer non-stream claude uses generate", providers.UpstreamModeNonStream, "claude-sonnet-4-5", false, EndpointGenerate},
+		{"prefer stream gemini uses stream", providers.UpstreamModeStream, "gemini-2.5-pro", false, EndpointStream},
+		{"stream client ignores preference", providers.UpstreamModeNonStream, "gemini-2.5-pro", true, EndpointStream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			srv := endpointRecorder(t, http.StatusOK, generateOK, &paths)
+			defer srv.Close()
+
+			executor := &Executor{baseURLs: []string{srv.URL}}
+			executor.SetUpstreamMode(tt.mode)
+
+			resp, err := executor.Execute(context.Background(), &ExecuteRequest{
+				Model:      tt.model,
+				Payload:    []byte(`{}`),
+				Stream:     tt.stream,
+				HTTPClient: srv.Client(),
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+			}
+			if len(paths) != 1 || paths[0] != tt.want {
+				t.Errorf("paths = %v, want [%s]", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute_NonStreamPreferenceFallsBackToStream(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantFallback bool
+	}{
+		{"not found", http.StatusNotFound, `{"error":{"code":404,"message":"Not found"}}`, true},
+		{"not implemented", http.StatusNotImplemented, `{}`, true},
+		{"method unsupported", http.StatusBadRequest, `{"error":{"code":400,"message":"Model is not supported for generateContent","status":"INVALID_ARGUMENT"}}`, true},
+		{"unimplemented", http.StatusBadRequest, `{"error":{"code":400,"message":"Not available","status":"UNIMPLEMENTED"}}`, true},
+		{"invalid request", http.StatusBadRequest, `{"error":{"code":400,"message":"Invalid JSON payload received","status":"INVALID_ARGUMENT"}}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			srv := endpointRecorder(t, tt.status, tt.body, &paths)
+			defer srv.Close()
+
+			executor := &Executor{baseURLs: []string{srv.URL}}
+			executor.SetUpstreamMode(providers.UpstreamModeNonStream)
+
+			resp, _ := executor.Execute(context.Background(), &ExecuteRequest{
+				Model:      "claude-sonnet-4-5",
+				Payload:    []byte(`{}`),
+				HTTPClient: srv.Client(),
+			})
+
+			if !tt.wantFallback {
+				if len(paths) != 1 || paths[0] != EndpointGenerate {
+					t.Errorf("paths = %v, want generate only", paths)
+				}
+				if resp == nil || resp.StatusCode != tt.status {
+					t.Errorf("resp = %+v, want the generate endpoint's %d", resp, tt.status)
+				}
+				return
+			}
+			if resp == nil || resp.StatusCode != http.StatusOK {
+				t.Errorf("resp = %+v, want 200 from the stream endpoint", resp)
+			}
+			if len(paths) != 2 || paths[0] != EndpointGenerate || paths[1] != EndpointStream {
+				t.Errorf("paths = %v, want generate then stream", paths)
+			}
+		})
+	}
+}
+
+func TestParseUpstreamMode(t *testing.T) {
+	for input, want := range map[string]providers.UpstreamMode{
+		"":           providers.UpstreamModeAuto,
+		"auto":       providers.UpstreamModeAuto,
+		"STREAM":     providers.UpstreamModeStream,
+		"non_stream": providers.UpstreamModeNonStream,
+	} {
+		got, err := providers.ParseUpstreamMode(input)
+		if err != nil || got != want {
+			t.Errorf("ParseUpstreamMode(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := providers.ParseUpstreamMode("sometimes"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestSetTimeout_AppliedToOutboundClient(t *testing.T) {
+	p := NewAntigravityProvider()
+	if got := p.getHTTPClient("").Timeout; got != providers.DefaultRequestTimeout {
+		t.Errorf("default timeout = %v, want %v", got, providers.DefaultRequestTimeout)
+	}
+
+	p.SetTimeout(providers.RequestTimeout(600))
+
+	// Clients cached before the change are replaced, including proxied ones
+	for _, proxyURL := range []string{"", "http://proxy.example.com:8080"} {
+		if got := p.getHTTPClient(proxyURL).Timeout; got != 600*time.Second {
+			t.Errorf("timeout for proxy %q = %v, want 10m0s", proxyURL, got)
+		}
+	}
+}
+
+// chunkedReader returns one scripted chunk per Read, like a network body
+type chunkedReader struct {
+	chunks []string
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	if n < len(r.chunks[0]) {
+		r.chunks[0] = r.chunks[0][n:]
+	} else {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+func TestSSEReader_EventsAcrossReadBoundaries(t *testing.T) {
+	reader := NewSSEReader(&chunkedReader{chunks: []string{
+		// Three events delivered by a single Read
+		"data: {\"n\":1}\n\ndata: {\"n\":2}\n\nevent: update\ndata: {\"n\":3}\n\n",
+		// One event split mid-line across Reads, with CRLF line endings
+		"data: {\"n\":", "4}\r\n\r\n",
+		// A data-less event is skipped without leaking its type
+		"event: keepalive\n\n",
+		// Multi-line data, then a final event without a trailing blank line
+		"data: {\"n\":\ndata: 5}\n\ndata: {\"n\":6}",
+	}})
+
+	want := []struct {
+		event string
+		data  string
+	}{
+		{"", `{"n":1}`},
+		{"", `{"n":2}`},
+		{"update", `{"n":3}`},
+		{"", `{"n":4}`},
+		{"", "{\"n\":\n5}"},
+		{"", `{"n":6}`},
+	}
+
+	for i, w := range want {
+		event, err := reader.ReadEvent()
+		if err != nil {
+			t.Fatalf("event %d: ReadEvent() error = %v", i, err)
+		}
+		if event.Event != w.event || string(event.Data) != w.data {
+			t.Errorf("event %d = (%q, %q), want (%q, %q)", i, event.Event, event.Data, w.event, w.data)
+		}
+	}
+	if _, err := reader.ReadEvent(); err != io.EOF {
+		t.Errorf("ReadEvent() after last event error = %v, want io.EOF", err)
+	}
+}
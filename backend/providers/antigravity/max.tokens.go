@@ -0,0 +1,40 @@
+package antigravity
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// DefaultMaxOutputTokens is applied when a Claude request omits max_tokens and no default is configured
+// Antigravity truncates unpredictably without an explicit maxOutputTokens.
+const DefaultMaxOutputTokens = 8192
+
+// SetMaxTokenDefaults configures the max_tokens applied to requests that omit it
+// perModel overrides the provider-wide default; a zero default keeps DefaultMaxOutputTokens.
+func (p *AntigravityProvider) SetMaxTokenDefaults(defaultTokens int, perModel map[string]int) {
+	p.defaultMaxTokens = defaultTokens
+	p.modelMaxTokens = perModel
+}
+
+// defaultMaxTokensFor returns the max_tokens default for model
+func (p *AntigravityProvider) defaultMaxTokensFor(model string) int {
+	if tokens := p.modelMaxTokens[model]; tokens > 0 {
+		return tokens
+	}
+	if p.defaultMaxTokens > 0 {
+		return p.defaultMaxTokens
+	}
+	return DefaultMaxOutputTokens
+}
+
+// applyDefaultMaxTokens sets max_tokens on a Claude payload that doesn't specify one
+func (p *AntigravityProvider) applyDefaultMaxTokens(payload []byte, model string) []byte {
+	if gjson.GetBytes(payload, "max_tokens").Exists() {
+		return payload
+	}
+	result, err := sjson.SetBytes(payload, "max_tokens", p.defaultMaxTokensFor(model))
+	if err != nil {
+		return payload
+	}
+	return result
+}
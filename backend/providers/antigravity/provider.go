@@ -9,6 +9,8 @@ import (
 	"net/url"
 	"sync"
 	"time"
+
+	"github.com/tidwall/gjson"
 )
 
 // AntigravityProvider implements the Provider interface for Antigravity (Google Cloud Code) API
@@ -16,6 +18,13 @@ type AntigravityProvider struct {
 	httpClients map[string]*http.Client
 	clientMu    sync.RWMutex
 	executor    *Executor
+	timeout     time.Duration // Applied to every outbound HTTP client
+
+	defaultMaxTokens int            // Applied when max_tokens is omitted (0 = DefaultMaxOutputTokens)
+	modelMaxTokens   map[string]int // Per-model overrides of defaultMaxTokens
+
+	disableSystemHints bool // Pass system prompts through without the interleaved-thinking hint
+	maxToolResultChars int  // Truncate longer tool_result text before translation (0 = unlimited)
 }
 
 // NewAntigravityProvider creates a new Antigravity provider instance
@@ -23,9 +32,38 @@ func NewAntigravityProvider() *AntigravityProvider {
 	return &AntigravityProvider{
 		httpClients: make(map[string]*http.Client),
 		executor:    NewExecutor(),
+		timeout:     providers.DefaultRequestTimeout,
 	}
 }
 
+// SetTimeout sets the outbound request timeout, replacing any cached clients
+func (p *AntigravityProvider) SetTimeout(timeout time.Duration) {
+	p.clientMu.Lock()
+	defer p.clientMu.Unlock()
+	p.timeout = timeout
+	p.httpClients = make(map[string]*http.Client)
+}
+
+// SetGzip enables gzip negotiation with the upstream API
+func (p *AntigravityProvider) SetGzip(enabled bool) {
+	p.executor.SetGzip(enabled)
+}
+
+// SetUpstreamMode sets the preferred upstream endpoint for non-streaming requests
+func (p *AntigravityProvider) SetUpstreamMode(mode providers.UpstreamMode) {
+	p.executor.SetUpstreamMode(mode)
+}
+
+// SetMaxToolResultChars caps tool_result text forwarded upstream (0 = unlimited)
+func (p *AntigravityProvider) SetMaxToolResultChars(maxChars int) {
+	p.maxToolResultChars = maxChars
+}
+
+// preparePayload applies the request defaults and limits configured for this provider
+func (p *AntigravityProvider) preparePayload(payload []byte, model string) []byte {
+	return providers.TruncateToolResults(p.applyDefaultMaxTokens(payload, model), p.maxToolResultChars)
+}
+
 // ID returns the provider identifier
 func (p *AntigravityProvider) ID() string {
 	return ProviderID
@@ -46,6 +84,11 @@ func (p *AntigravityProvider) SupportedModels() []string {
 	return SupportedModels
 }
 
+// ExecutesClaudeFormat reports that Execute translates Claude payloads itself
+func (p *AntigravityProvider) ExecutesClaudeFormat() bool {
+	return true
+}
+
 // TranslateRequest converts a request from Claude format to Antigravity format
 func (p *AntigravityProvider) TranslateRequest(format string, payload []byte, model string) ([]byte, error) {
 	// Currently only supporting Claude format
@@ -65,7 +108,11 @@ func (p *AntigravityProvider) TranslateRequest(format string, payload []byte, mo
 		return nil, fmt.Errorf("unsupported model: %s", model)
 	}
 
-	translated := TranslateClaudeToAntigravity(payload, model)
+	if err := p.CheckRequest(payload, model); err != nil {
+		return nil, err
+	}
+
+	translated := TranslateClaudeToAntigravityWithOptions(p.preparePayload(payload, model), model, p.translateOptions(context.Background(), ""))
 	return translated, nil
 }
 
@@ -101,10 +148,10 @@ func (p *AntigravityProvider) Execute(ctx context.Context, req *providers.Execut
 	projectID, _ := authData["project_id"].(string)
 
 	// Translate payload to antigravity format with project ID
-	translatedPayload := TranslateClaudeToAntigravityWithProject(req.Payload, req.Model, projectID)
+	translatedPayload := TranslateClaudeToAntigravityWithOptions(p.preparePayload(req.Payload, req.Model), req.Model, p.translateOptions(ctx, projectID))
 
 	// Debug log
-	fmt.Printf("[DEBUG] Translated payload: %s\n", string(translatedPayload))
+	providers.Debugf("Translated payload: %s", translatedPayload)
 
 	// Get or create HTTP client for this proxy
 	httpClient := p.getHTTPClient(req.ProxyURL)
@@ -126,9 +173,11 @@ func (p *AntigravityProvider) Execute(ctx context.Context, req *providers.Execut
 
 	// Convert to provider response format
 	return &providers.ExecuteResponse{
-		StatusCode: execResp.StatusCode,
-		Payload:    execResp.Body,
-		LatencyMs:  int(execResp.Latency),
+		StatusCode:  execResp.StatusCode,
+		Payload:     execResp.Body,
+		LatencyMs:   int(execResp.Latency),
+		Headers:     execResp.Headers,
+		SentPayload: translatedPayload,
 	}, nil
 }
 
@@ -173,7 +222,7 @@ func (p *AntigravityProvider) getHTTPClient(proxyURL string) *http.Client {
 
 	// Create new HTTP client with configured transport
 	client := &http.Client{
-		Timeout:   120 * time.Second,
+		Timeout:   p.timeout,
 		Transport: transport,
 	}
 
@@ -207,7 +256,7 @@ func (p *AntigravityProvider) ExecuteStream(ctx context.Context, req *providers.
 	projectID, _ := authData["project_id"].(string)
 
 	// Translate payload to antigravity format with project ID
-	translatedPayload := TranslateClaudeToAntigravityWithProject(req.Payload, req.Model, projectID)
+	translatedPayload := TranslateClaudeToAntigravityWithOptions(p.preparePayload(req.Payload, req.Model), req.Model, p.translateOptions(ctx, projectID))
 
 	// Get or create HTTP client for this proxy
 	httpClient := p.getHTTPClient(req.ProxyURL)
@@ -222,10 +271,49 @@ func (p *AntigravityProvider) ExecuteStream(ctx context.Context, req *providers.
 	}
 
 	// Execute streaming request
-	return executeStreamAdapter(ctx, p.executor, execReq)
+	streamResp, err := executeStreamAdapter(ctx, p.executor, execReq)
+	if streamResp != nil {
+		streamResp.SentPayload = translatedPayload
+	}
+	return streamResp, err
 }
 
 // SupportsStreaming indicates that Antigravity supports streaming
 func (p *AntigravityProvider) SupportsStreaming() bool {
 	return true
 }
+
+// CheckRequest rejects requests Antigravity cannot serve; the router runs it before selecting an account
+func (p *AntigravityProvider) CheckRequest(payload []byte, model string) error {
+	if err := checkServerTools(payload); err != nil {
+		return err
+	}
+	if err := checkCandidateCount(payload); err != nil {
+		return err
+	}
+	return checkDocuments(payload, model)
+}
+
+// checkServerTools rejects Claude server tools that have no Gemini built-in equivalent
+func checkServerTools(payload []byte) error {
+	return providers.CheckServerTools(payload, ProviderID,
+		providers.ServerToolCodeExecution,
+		providers.ServerToolWebSearch,
+	)
+}
+
+// checkDocuments rejects document blocks Gemini cannot read (anything but PDFs and plain text)
+func checkDocuments(payload []byte, model string) error {
+	return providers.CheckDocuments(payload, ProviderID, model, true)
+}
+
+// checkCandidateCount rejects requests for more than one candidate.
+// Claude format represents a single message, so extra candidates could not be returned.
+func checkCandidateCount(payload []byte) error {
+	for _, path := range []string{"candidate_count", "candidateCount", "generationConfig.candidateCount"} {
+		if count := gjson.GetBytes(payload, path); count.Exists() && count.Int() > 1 {
+			return fmt.Errorf("candidate_count %d is not supported: Claude format returns a single message", count.Int())
+		}
+	}
+	return nil
+}
@@ -2,7 +2,6 @@ package antigravity
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"time"
 
@@ -19,11 +18,14 @@ func executeStreamAdapter(ctx context.Context, executor *Executor, req *ExecuteR
 	// Start time for latency tracking
 	startTime := time.Now()
 
-	// Create handler that forwards to dataCh
-	handler := func(chunk []byte) error {
-		// Copy chunk to avoid race conditions
-		data := make([]byte, len(chunk))
-		copy(data, chunk)
+	// Translate upstream chunks into the full Claude event lifecycle
+	translator := NewStreamTranslator(req.Model)
+
+	// send forwards translated events to dataCh
+	send := func(data []byte) error {
+		if len(data) == 0 {
+			return nil
+		}
 
 		select {
 		case dataCh <- data:
@@ -33,6 +35,12 @@ func executeStreamAdapter(ctx context.Context, executor *Executor, req *ExecuteR
 		}
 	}
 
+	// Create handler that translates and forwards to dataCh
+	// (translated output is a fresh buffer, so no copy is needed)
+	handler := func(chunk []byte) error {
+		return send(translator.Translate(chunk))
+	}
+
 	// Execute stream in goroutine
 	go func() {
 		defer close(dataCh)
@@ -47,9 +55,12 @@ func executeStreamAdapter(ctx context.Context, executor *Executor, req *ExecuteR
 
 		if resp.Error != nil {
 			errCh <- resp.Error
+		} else {
+			// Close the message if upstream ended without a finish reason
+			send(translator.Finish())
 		}
 
-		fmt.Printf("[DEBUG] Antigravity stream completed in %dms\n", time.Since(startTime).Milliseconds())
+		providers.Debugf("Antigravity stream completed in %dms", time.Since(startTime).Milliseconds())
 	}()
 
 	return &providers.StreamResponse{
@@ -3,6 +3,11 @@ package antigravity
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
 )
 
 // TranslateAntigravityStreamToClaude converts Antigravity SSE to Claude format
@@ -27,16 +32,19 @@ func TranslateAntigravityStreamToClaude(data []byte, eventType string) []byte {
 
 		part := parts[0].(map[string]interface{})
 
-		// Check for text content
+		// Check for text content; the final chunk may carry text and the finish reason together
+		var out []byte
 		if text, ok := part["text"].(string); ok {
-			return buildClaudeContentDelta(text)
+			out = buildClaudeContentDelta(text)
 		}
 
 		// Check for finish reason
-		if finishReason, ok := candidate["finishReason"].(string); ok {
-			if finishReason == "STOP" || finishReason == "MAX_TOKENS" {
-				return buildClaudeChunk("message_stop", map[string]interface{}{})
-			}
+		if finishReason, ok := candidate["finishReason"].(string); ok && finishReason != "" {
+			out = append(out, buildClaudeStop(finishReason, antigravityResp["usageMetadata"])...)
+		}
+
+		if out != nil {
+			return out
 		}
 	}
 
@@ -63,9 +71,320 @@ func buildClaudeContentDelta(text string) []byte {
 	return []byte(fmt.Sprintf("event: content_block_delta\ndata: %s\n\n", data))
 }
 
+// buildClaudeStop creates the final message_delta (stop_reason, usage) and the terminal message_stop
+func buildClaudeStop(finishReason string, usageMetadata interface{}) []byte {
+	messageDelta := map[string]interface{}{
+		"delta": map[string]interface{}{
+			"stop_reason":   convertFinishReason(finishReason),
+			"stop_sequence": nil,
+		},
+	}
+	if usage, ok := usageMetadata.(map[string]interface{}); ok {
+		outputTokens, _ := usage["candidatesTokenCount"].(float64)
+		messageDelta["usage"] = map[string]interface{}{
+			"output_tokens": int64(outputTokens),
+		}
+	}
+
+	out := buildClaudeChunk("message_delta", messageDelta)
+	return append(out, buildClaudeChunk("message_stop", map[string]interface{}{})...)
+}
+
 // buildClaudeChunk creates a Claude SSE event
 func buildClaudeChunk(eventType string, data map[string]interface{}) []byte {
 	data["type"] = eventType
 	jsonData, _ := json.Marshal(data)
 	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, jsonData))
 }
+
+// StreamTranslator converts an Antigravity SSE stream into the full Claude event lifecycle:
+// message_start, content_block_start, content_block_delta, content_block_stop, message_delta, message_stop.
+// It is stateful and must be used for a single stream only.
+type StreamTranslator struct {
+	model     string
+	messageID string
+
+	started  bool
+	finished bool
+
+	blockOpen  bool
+	blockType  string // "text", "thinking" or "tool_use"
+	blockIndex int
+
+	// Open tool_use block; Gemini may split one call's args across parts and chunks
+	toolID     string
+	toolName   string
+	toolArgs   string // String args fragments streamed so far
+	toolObject string // Object args buffered until the call completes ("" = fragment mode)
+	toolCalls  int    // tool_use blocks started, numbering generated IDs
+
+	sawToolUse   bool
+	stopReason   string
+	inputTokens  int64
+	outputTokens int64
+}
+
+// NewStreamTranslator creates a translator for one streamed response
+func NewStreamTranslator(model string) *StreamTranslator {
+	return &StreamTranslator{
+		model:      model,
+		messageID:  providers.NewMessageID(),
+		blockIndex: -1,
+	}
+}
+
+// Translate converts one Antigravity SSE data payload into zero or more Claude SSE events
+func (t *StreamTranslator) Translate(data []byte) []byte {
+	if t.finished || !gjson.ValidBytes(data) {
+		return nil
+	}
+
+	// Cloud Code wraps the Gemini response in a "response" key
+	responseNode := gjson.GetBytes(data, "response")
+	if !responseNode.Exists() {
+		responseNode = gjson.ParseBytes(data)
+	}
+
+	if usage := responseNode.Get("usageMetadata"); usage.Exists() {
+		t.inputTokens = usage.Get("promptTokenCount").Int()
+		t.outputTokens = usage.Get("candidatesTokenCount").Int()
+	}
+
+	var out []byte
+	if !t.started {
+		out = append(out, t.messageStart()...)
+	}
+
+	// Blocked prompts end the stream without candidates; surface the block as text
+	if _, notice, blocked := promptBlocked(responseNode); blocked {
+		out = append(out, t.ensureBlock("text", map[string]interface{}{
+			"type": "text",
+			"text": "",
+		})...)
+		out = append(out, t.blockDelta(map[string]interface{}{
+			"type": "text_delta",
+			"text": notice,
+		})...)
+		return append(out, t.Finish()...)
+	}
+
+	candidate := responseNode.Get("candidates.0")
+	for _, part := range candidate.Get("content.parts").Array() {
+		out = append(out, t.translatePart(part)...)
+	}
+
+	if finishReason := candidate.Get("finishReason"); finishReason.Exists() {
+		t.stopReason = convertFinishReason(finishReason.String())
+		out = append(out, t.Finish()...)
+	}
+
+	return out
+}
+
+// Finish closes any open content block and emits message_delta and message_stop.
+// Safe to call more than once; only the first call after the message started emits events.
+func (t *StreamTranslator) Finish() []byte {
+	if !t.started || t.finished {
+		return nil
+	}
+	t.finished = true
+
+	out := t.closeBlock()
+
+	stopReason := t.stopReason
+	if t.sawToolUse {
+		stopReason = "tool_use"
+	}
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+
+	out = append(out, buildClaudeChunk("message_delta", map[string]interface{}{
+		"delta": map[string]interface{}{
+			"stop_reason":   stopReason,
+			"stop_sequence": nil,
+		},
+		"usage": map[string]interface{}{
+			"output_tokens": t.outputTokens,
+		},
+	})...)
+	out = append(out, buildClaudeChunk("message_stop", map[string]interface{}{})...)
+
+	return out
+}
+
+// translatePart emits block framing and deltas for a single Gemini part
+func (t *StreamTranslator) translatePart(part gjson.Result) []byte {
+	var out []byte
+
+	switch {
+	case part.Get("thought").Bool():
+		out = append(out, t.ensureBlock("thinking", map[string]interface{}{
+			"type":     "thinking",
+			"thinking": "",
+		})...)
+		if text := part.Get("text").String(); text != "" {
+			out = append(out, t.blockDelta(map[string]interface{}{
+				"type":     "thinking_delta",
+				"thinking": text,
+			})...)
+		}
+		if signature := part.Get("thoughtSignature").String(); signature != "" {
+			out = append(out, t.blockDelta(map[string]interface{}{
+				"type":      "signature_delta",
+				"signature": signature,
+			})...)
+		}
+
+	case part.Get("functionCall").Exists():
+		out = append(out, t.translateFunctionCall(part.Get("functionCall"))...)
+		t.sawToolUse = true
+
+	case part.Get("text").Exists():
+		out = append(out, t.ensureBlock("text", map[string]interface{}{
+			"type": "text",
+			"text": "",
+		})...)
+		if text := part.Get("text").String(); text != "" {
+			out = append(out, t.blockDelta(map[string]interface{}{
+				"type": "text_delta",
+				"text": text,
+			})...)
+		}
+	}
+
+	return out
+}
+
+// translateFunctionCall streams one functionCall part into a tool_use block.
+// The block is started once per call; string args arrive as JSON fragments and are forwarded as
+// partial_json as-is, object args are merged and sent whole, so the concatenated partial_json
+// always parses. The block stays open until the args are complete.
+func (t *StreamTranslator) translateFunctionCall(functionCall gjson.Result) []byte {
+	var out []byte
+
+	name := functionCall.Get("name").String()
+	toolID := functionCall.Get("id").String()
+	if !t.continuesToolCall(toolID, name) {
+		t.toolCalls++
+		if toolID == "" {
+			// Unique per call, so clients can tell apart repeated id-less calls to the same tool
+			toolID = fmt.Sprintf("toolu_%s_%d", strings.TrimPrefix(t.messageID, providers.MessageIDPrefix), t.toolCalls)
+		}
+		out = append(out, t.closeBlock()...)
+		out = append(out, t.openBlock("tool_use", map[string]interface{}{
+			"type":  "tool_use",
+			"id":    toolID,
+			"name":  name,
+			"input": map[string]interface{}{},
+		})...)
+		t.toolID, t.toolName, t.toolArgs, t.toolObject = toolID, name, "", ""
+	}
+
+	args := functionCall.Get("args")
+	switch {
+	case args.Type == gjson.String && (t.toolArgs != "" || !gjson.Valid(args.String())):
+		// Fragment of a JSON document streamed across parts
+		if fragment := args.String(); fragment != "" {
+			t.toolArgs += fragment
+			out = append(out, t.blockDelta(map[string]interface{}{
+				"type":         "input_json_delta",
+				"partial_json": fragment,
+			})...)
+		}
+	case t.toolObject == "":
+		t.toolObject = toolInputJSON(args)
+	default:
+		// Later parts of an object-args call carry more keys; unmarshaling into the map merges them
+		merged := make(map[string]json.RawMessage)
+		json.Unmarshal([]byte(t.toolObject), &merged)
+		json.Unmarshal([]byte(toolInputJSON(args)), &merged)
+		if data, err := json.Marshal(merged); err == nil {
+			t.toolObject = string(data)
+		}
+	}
+
+	complete := t.toolObject != "" || gjson.Valid(t.toolArgs)
+	if complete && !functionCall.Get("willContinue").Bool() {
+		out = append(out, t.closeBlock()...)
+	}
+	return out
+}
+
+// continuesToolCall reports whether a functionCall part belongs to the still-open tool_use block
+func (t *StreamTranslator) continuesToolCall(toolID, name string) bool {
+	if !t.blockOpen || t.blockType != "tool_use" {
+		return false
+	}
+	return (toolID == "" || toolID == t.toolID) && (name == "" || name == t.toolName)
+}
+
+// messageStart emits the message_start event
+func (t *StreamTranslator) messageStart() []byte {
+	t.started = true
+	return buildClaudeChunk("message_start", map[string]interface{}{
+		"message": map[string]interface{}{
+			"id":            t.messageID,
+			"type":          "message",
+			"role":          "assistant",
+			"model":         t.model,
+			"content":       []interface{}{},
+			"stop_reason":   nil,
+			"stop_sequence": nil,
+			"usage": map[string]interface{}{
+				"input_tokens":  t.inputTokens,
+				"output_tokens": 0,
+			},
+		},
+	})
+}
+
+// ensureBlock keeps the current block if it has the same type, otherwise starts a new one
+func (t *StreamTranslator) ensureBlock(blockType string, contentBlock map[string]interface{}) []byte {
+	if t.blockOpen && t.blockType == blockType {
+		return nil
+	}
+	out := t.closeBlock()
+	return append(out, t.openBlock(blockType, contentBlock)...)
+}
+
+// openBlock emits content_block_start for the next block index
+func (t *StreamTranslator) openBlock(blockType string, contentBlock map[string]interface{}) []byte {
+	t.blockIndex++
+	t.blockOpen = true
+	t.blockType = blockType
+	return buildClaudeChunk("content_block_start", map[string]interface{}{
+		"index":         t.blockIndex,
+		"content_block": contentBlock,
+	})
+}
+
+// blockDelta emits content_block_delta for the current block
+func (t *StreamTranslator) blockDelta(delta map[string]interface{}) []byte {
+	return buildClaudeChunk("content_block_delta", map[string]interface{}{
+		"index": t.blockIndex,
+		"delta": delta,
+	})
+}
+
+// closeBlock emits content_block_stop if a block is open
+func (t *StreamTranslator) closeBlock() []byte {
+	if !t.blockOpen {
+		return nil
+	}
+
+	// Buffered object args are sent in one piece right before the tool_use block ends
+	var out []byte
+	if t.blockType == "tool_use" && t.toolObject != "" {
+		out = t.blockDelta(map[string]interface{}{
+			"type":         "input_json_delta",
+			"partial_json": t.toolObject,
+		})
+		t.toolObject = ""
+	}
+
+	t.blockOpen = false
+	return append(out, buildClaudeChunk("content_block_stop", map[string]interface{}{
+		"index": t.blockIndex,
+	})...)
+}
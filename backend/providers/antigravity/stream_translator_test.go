@@ -0,0 +1,304 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// parseSSEEvents splits translated output into (event, data) pairs
+func parseSSEEvents(t *testing.T, out []byte) ([]string, []map[string]interface{}) {
+	var names []string
+	var payloads []map[string]interface{}
+
+	for _, raw := range strings.Split(strings.TrimSpace(string(out)), "\n\n") {
+		lines := strings.SplitN(raw, "\n", 2)
+		if len(lines) != 2 {
+			t.Fatalf("malformed SSE event: %q", raw)
+		}
+
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &data); err != nil {
+			t.Fatalf("invalid event JSON: %v", err)
+		}
+
+		names = append(names, strings.TrimPrefix(lines[0], "event: "))
+		payloads = append(payloads, data)
+	}
+
+	return names, payloads
+}
+
+func TestStreamTranslator_TwoBlockLifecycle(t *testing.T) {
+	translator := NewStreamTranslator("claude-sonnet-4-5-thinking")
+
+	chunks := []string{
+		`{"response":{"candidates":[{"content":{"role":"model","parts":[{"thought":true,"text":"Let me think"}]}}],"usageMetadata":{"promptTokenCount":12}}}`,
+		`{"response":{"candidates":[{"content":{"role":"model","parts":[{"thought":true,"text":"","thoughtSignature":"sig123"}]}}]}}`,
+		`{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]}}]}}`,
+		`{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":" world"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":7}}}`,
+	}
+
+	var out []byte
+	for _, chunk := range chunks {
+		out = append(out, translator.Translate([]byte(chunk))...)
+	}
+	// Finish after an explicit finishReason must not emit anything more
+	out = append(out, translator.Finish()...)
+
+	names, payloads := parseSSEEvents(t, out)
+
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta",
+		"content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_delta",
+		"content_block_stop",
+		"message_delta",
+		"message_stop",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v\nwant     %v", names, want)
+	}
+
+	message := payloads[0]["message"].(map[string]interface{})
+	if !strings.HasPrefix(message["id"].(string), "msg_") {
+		t.Errorf("message.id = %v, want msg_ prefix", message["id"])
+	}
+	if message["model"] != "claude-sonnet-4-5-thinking" {
+		t.Errorf("message.model = %v", message["model"])
+	}
+
+	// Block 0: thinking with signature
+	if payloads[1]["index"] != float64(0) || payloads[1]["content_block"].(map[string]interface{})["type"] != "thinking" {
+		t.Errorf("first content_block_start = %v, want thinking at index 0", payloads[1])
+	}
+	if payloads[3]["delta"].(map[string]interface{})["type"] != "signature_delta" {
+		t.Errorf("expected signature_delta, got %v", payloads[3]["delta"])
+	}
+
+	// Block 1: text, deltas and stop reference index 1
+	if payloads[5]["index"] != float64(1) || payloads[5]["content_block"].(map[string]interface{})["type"] != "text" {
+		t.Errorf("second content_block_start = %v, want text at index 1", payloads[5])
+	}
+	for _, i := range []int{6, 7, 8} {
+		if payloads[i]["index"] != float64(1) {
+			t.Errorf("event %d (%s) index = %v, want 1", i, names[i], payloads[i]["index"])
+		}
+	}
+
+	messageDelta := payloads[9]
+	if messageDelta["delta"].(map[string]interface{})["stop_reason"] != "end_turn" {
+		t.Errorf("stop_reason = %v, want end_turn", messageDelta["delta"])
+	}
+	if messageDelta["usage"].(map[string]interface{})["output_tokens"] != float64(7) {
+		t.Errorf("usage = %v, want output_tokens 7", messageDelta["usage"])
+	}
+}
+
+func TestStreamTranslator_ToolUse(t *testing.T) {
+	translator := NewStreamTranslator("gemini-2.5-pro")
+
+	out := translator.Translate([]byte(`{"candidates":[{"content":{"parts":[{"text":"Checking"},{"functionCall":{"id":"call_1","name":"get_weather","args":{"city":"Jakarta"}}}]},"finishReason":"STOP"}]}`))
+
+	names, payloads := parseSSEEvents(t, out)
+
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v\nwant     %v", names, want)
+	}
+
+	toolBlock := payloads[4]["content_block"].(map[string]interface{})
+	if toolBlock["type"] != "tool_use" || toolBlock["id"] != "call_1" || toolBlock["name"] != "get_weather" {
+		t.Errorf("tool content_block = %v", toolBlock)
+	}
+	if payloads[5]["delta"].(map[string]interface{})["partial_json"] != `{"city":"Jakarta"}` {
+		t.Errorf("input_json_delta = %v", payloads[5]["delta"])
+	}
+	if payloads[7]["delta"].(map[string]interface{})["stop_reason"] != "tool_use" {
+		t.Errorf("stop_reason = %v, want tool_use", payloads[7]["delta"])
+	}
+}
+
+func TestStreamTranslator_ToolUseStringArgs(t *testing.T) {
+	translator := NewStreamTranslator("gemini-2.5-pro")
+
+	out := translator.Translate([]byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"id":"call_1","name":"get_weather","args":"{\"city\":\"Jakarta\"}"}}]},"finishReason":"STOP"}]}`))
+
+	names, payloads := parseSSEEvents(t, out)
+	for i, name := range names {
+		if name != "content_block_delta" {
+			continue
+		}
+		if got := payloads[i]["delta"].(map[string]interface{})["partial_json"]; got != `{"city":"Jakarta"}` {
+			t.Errorf("input_json_delta = %v, want decoded object", got)
+		}
+		return
+	}
+	t.Fatalf("no content_block_delta in events %v", names)
+}
+
+// toolInputs concatenates partial_json per tool_use block index and counts block starts
+func toolInputs(names []string, payloads []map[string]interface{}) (map[float64]string, int) {
+	inputs := make(map[float64]string)
+	starts := 0
+	for i, name := range names {
+		switch name {
+		case "content_block_start":
+			if block := payloads[i]["content_block"].(map[string]interface{}); block["type"] == "tool_use" {
+				starts++
+				inputs[payloads[i]["index"].(float64)] = ""
+			}
+		case "content_block_delta":
+			delta := payloads[i]["delta"].(map[string]interface{})
+			if delta["type"] == "input_json_delta" {
+				inputs[payloads[i]["index"].(float64)] += delta["partial_json"].(string)
+			}
+		}
+	}
+	return inputs, starts
+}
+
+func TestStreamTranslator_ToolUseStreamedArgs(t *testing.T) {
+	translator := NewStreamTranslator("gemini-2.5-pro")
+
+	chunks := []string{
+		`{"candidates":[{"content":{"parts":[{"functionCall":{"id":"call_1","name":"get_weather","args":"{\"city\":\"Jak"}}]}}]}`,
+		`{"candidates":[{"content":{"parts":[{"functionCall":{"id":"call_1","args":"arta\",\"days\":"}}]}}]}`,
+		`{"candidates":[{"content":{"parts":[{"functionCall":{"id":"call_1","args":"3}"}}]},"finishReason":"STOP"}]}`,
+	}
+	var out []byte
+	for _, chunk := range chunks {
+		out = append(out, translator.Translate([]byte(chunk))...)
+	}
+
+	names, payloads := parseSSEEvents(t, out)
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v\nwant     %v", names, want)
+	}
+
+	inputs, starts := toolInputs(names, payloads)
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(inputs[0]), &input); err != nil {
+		t.Fatalf("concatenated partial_json %q does not parse: %v", inputs[0], err)
+	}
+	if starts != 1 || input["city"] != "Jakarta" || input["days"] != float64(3) {
+		t.Errorf("tool_use starts = %d, input = %v, want one block with city Jakarta, days 3", starts, input)
+	}
+}
+
+func TestStreamTranslator_ToolUseObjectArgsContinued(t *testing.T) {
+	translator := NewStreamTranslator("gemini-2.5-pro")
+
+	chunks := []string{
+		`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"get_weather","args":{"city":"Jakarta"},"willContinue":true}}]}}]}`,
+		`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"get_weather","args":{"days":3}}},{"functionCall":{"name":"get_time","args":{"tz":"WIB"}}}]},"finishReason":"STOP"}]}`,
+	}
+	var out []byte
+	for _, chunk := range chunks {
+		out = append(out, translator.Translate([]byte(chunk))...)
+	}
+
+	names, payloads := parseSSEEvents(t, out)
+	inputs, starts := toolInputs(names, payloads)
+	if starts != 2 {
+		t.Fatalf("tool_use blocks = %d, want 2 (continued call plus get_time)", starts)
+	}
+	for index, raw := range inputs {
+		if !json.Valid([]byte(raw)) {
+			t.Errorf("block %v partial_json %q does not parse", index, raw)
+		}
+	}
+	if inputs[0] != `{"city":"Jakarta","days":3}` || inputs[1] != `{"tz":"WIB"}` {
+		t.Errorf("tool inputs = %v, want merged get_weather args and get_time args", inputs)
+	}
+}
+
+func TestStreamTranslator_FinishWithoutFinishReason(t *testing.T) {
+	translator := NewStreamTranslator("gemini-2.5-pro")
+
+	translator.Translate([]byte(`{"candidates":[{"content":{"parts":[{"text":"partial"}]}}]}`))
+	names, _ := parseSSEEvents(t, translator.Finish())
+
+	want := "content_block_stop,message_delta,message_stop"
+	if strings.Join(names, ",") != want {
+		t.Errorf("Finish() events = %v, want %s", names, want)
+	}
+	if out := translator.Finish(); out != nil {
+		t.Errorf("second Finish() = %q, want nil", out)
+	}
+}
+
+func TestStreamTranslator_PromptBlocked(t *testing.T) {
+	translator := NewStreamTranslator("gemini-2.5-pro")
+
+	out := translator.Translate([]byte(`{"response":{"promptFeedback":{"blockReason":"PROHIBITED_CONTENT"},"usageMetadata":{"promptTokenCount":9}}}`))
+	names, payloads := parseSSEEvents(t, out)
+
+	want := "message_start,content_block_start,content_block_delta,content_block_stop,message_delta,message_stop"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("events = %v, want %s", names, want)
+	}
+	delta := payloads[2]["delta"].(map[string]interface{})
+	if text, _ := delta["text"].(string); !strings.Contains(text, "PROHIBITED_CONTENT") {
+		t.Errorf("text delta = %q, want the block reason", text)
+	}
+	stop := payloads[4]["delta"].(map[string]interface{})
+	if stop["stop_reason"] != "end_turn" {
+		t.Errorf("stop_reason = %v, want end_turn", stop["stop_reason"])
+	}
+}
+
+func TestTranslateAntigravityStreamToClaude_FinalChunkEndsWithMessageStop(t *testing.T) {
+	chunk := `{"candidates":[{"content":{"parts":[{"text":"bye"}]},"finishReason":"MAX_TOKENS"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":11}}`
+
+	names, payloads := parseSSEEvents(t, TranslateAntigravityStreamToClaude([]byte(chunk), ""))
+
+	want := "content_block_delta,message_delta,message_stop"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("events = %v, want %s", names, want)
+	}
+	if payloads[0]["delta"].(map[string]interface{})["text"] != "bye" {
+		t.Errorf("text delta = %v, want bye", payloads[0]["delta"])
+	}
+	if payloads[1]["delta"].(map[string]interface{})["stop_reason"] != "max_tokens" {
+		t.Errorf("stop_reason = %v, want max_tokens", payloads[1]["delta"])
+	}
+	if payloads[1]["usage"].(map[string]interface{})["output_tokens"] != float64(11) {
+		t.Errorf("usage = %v, want output_tokens 11", payloads[1]["usage"])
+	}
+}
+
+func TestStreamTranslator_ToolUseGeneratedIDsAreUnique(t *testing.T) {
+	translator := NewStreamTranslator("gemini-2.5-pro")
+
+	out := translator.Translate([]byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"get_weather","args":{"city":"Jakarta"}}},{"functionCall":{"name":"get_weather","args":{"city":"Bandung"}}}]},"finishReason":"STOP"}]}`))
+
+	names, payloads := parseSSEEvents(t, out)
+	var ids []string
+	for i, name := range names {
+		if name != "content_block_start" {
+			continue
+		}
+		if block := payloads[i]["content_block"].(map[string]interface{}); block["type"] == "tool_use" {
+			ids = append(ids, block["id"].(string))
+		}
+	}
+
+	if len(ids) != 2 {
+		t.Fatalf("tool_use blocks = %d, want 2", len(ids))
+	}
+	if ids[0] == ids[1] || !strings.HasPrefix(ids[0], "toolu_") || !strings.HasPrefix(ids[1], "toolu_") {
+		t.Errorf("tool_use ids = %v, want two distinct toolu_ ids", ids)
+	}
+}
@@ -0,0 +1,21 @@
+package antigravity
+
+import (
+	"context"
+
+	"aigateway-backend/providers"
+)
+
+// SetSystemHints enables or disables the interleaved-thinking hint appended to system prompts
+// Hints are enabled by default; clients can also opt out per request with X-Gateway-System-Hints.
+func (p *AntigravityProvider) SetSystemHints(enabled bool) {
+	p.disableSystemHints = !enabled
+}
+
+// translateOptions returns the translation options for a request made under ctx
+func (p *AntigravityProvider) translateOptions(ctx context.Context, projectID string) TranslateOptions {
+	return TranslateOptions{
+		ProjectID:       projectID,
+		SkipSystemHints: p.disableSystemHints || providers.SystemHintsDisabled(ctx),
+	}
+}
@@ -0,0 +1,57 @@
+package antigravity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CountTokens counts a Claude-format request's input tokens with Cloud Code's countTokens
+// The endpoint only counts contents, so system instructions and tool declarations are
+// estimated locally and added. Claude models are not served by countTokens.
+func (p *AntigravityProvider) CountTokens(ctx context.Context, req *providers.ExecuteRequest) (int64, error) {
+	if IsClaudeModel(req.Model) {
+		return 0, fmt.Errorf("countTokens does not support %s", req.Model)
+	}
+	if req.Account == nil {
+		return 0, fmt.Errorf("account is required")
+	}
+
+	accessToken := req.Token
+	if accessToken == "" {
+		accessToken = gjson.Get(req.Account.AuthData, "access_token").String()
+	}
+	if accessToken == "" {
+		return 0, fmt.Errorf("no access token found in account")
+	}
+
+	translated := TranslateClaudeToAntigravityWithOptions(req.Payload, req.Model, p.translateOptions(ctx, ""))
+	body, _ := sjson.SetBytes([]byte(`{"request":{}}`), "request.model", "models/"+req.Model)
+	body, _ = sjson.SetRawBytes(body, "request.contents", []byte(gjson.GetBytes(translated, "request.contents").Raw))
+
+	resp, err := p.executor.CountTokens(ctx, &ExecuteRequest{
+		Model:       req.Model,
+		Payload:     body,
+		AccessToken: accessToken,
+		HTTPClient:  p.getHTTPClient(req.ProxyURL),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		TotalTokens *int64 `json:"totalTokens"`
+	}
+	if err := json.Unmarshal(resp.Body, &result); err != nil || result.TotalTokens == nil {
+		return 0, fmt.Errorf("unexpected countTokens response: %s", resp.Body)
+	}
+
+	// Everything but the messages, which countTokens already covered
+	rest, _ := sjson.DeleteBytes(req.Payload, "messages")
+	return *result.TotalTokens + providers.EstimateInputTokens(rest), nil
+}
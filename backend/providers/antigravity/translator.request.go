@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"aigateway-backend/providers"
+
 	"github.com/google/uuid"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -36,16 +38,27 @@ func TranslateClaudeToAntigravity(payload []byte, model string) []byte {
 	return TranslateClaudeToAntigravityWithProject(payload, model, "")
 }
 
+// TranslateOptions controls request translation beyond the payload and model
+type TranslateOptions struct {
+	ProjectID       string
+	SkipSystemHints bool // Leave the system prompt untouched (no interleaved-thinking hint)
+}
+
 // TranslateClaudeToAntigravityWithProject converts Claude API format to Antigravity with project ID
 func TranslateClaudeToAntigravityWithProject(payload []byte, model string, projectID string) []byte {
+	return TranslateClaudeToAntigravityWithOptions(payload, model, TranslateOptions{ProjectID: projectID})
+}
+
+// TranslateClaudeToAntigravityWithOptions converts Claude API format to Antigravity using opts
+func TranslateClaudeToAntigravityWithOptions(payload []byte, model string, opts TranslateOptions) []byte {
 	result := string(payload)
 
 	// Add antigravity-specific fields
 	result, _ = sjson.Set(result, "userAgent", "antigravity")
 	result, _ = sjson.Set(result, "requestId", "agent-"+uuid.NewString())
 
-	if projectID != "" {
-		result, _ = sjson.Set(result, "project", projectID)
+	if opts.ProjectID != "" {
+		result, _ = sjson.Set(result, "project", opts.ProjectID)
 	}
 
 	// Add session ID and tool config (for all models)
@@ -182,6 +195,17 @@ func TranslateClaudeToAntigravityWithProject(payload []byte, model string, proje
 						} else if toolContent.IsObject() {
 							resultJSON, _ = sjson.SetRaw(resultJSON, "functionResponse.response.result", toolContent.Raw)
 						}
+
+						// Gemini reads a failed call from response.error rather than response.result
+						if providers.ToolResultIsError(block) {
+							errorValue := gjson.Get(resultJSON, "functionResponse.response.result")
+							resultJSON, _ = sjson.Delete(resultJSON, "functionResponse.response.result")
+							if errorValue.Exists() {
+								resultJSON, _ = sjson.SetRaw(resultJSON, "functionResponse.response.error", errorValue.Raw)
+							} else {
+								resultJSON, _ = sjson.Set(resultJSON, "functionResponse.response.error", "tool execution failed")
+							}
+						}
 						contentJSON, _ = sjson.SetRaw(contentJSON, "parts.-1", resultJSON)
 
 					case "image":
@@ -199,6 +223,24 @@ func TranslateClaudeToAntigravityWithProject(payload []byte, model string, proje
 							partJSON, _ = sjson.SetRaw(partJSON, "inlineData", inlineDataJSON)
 							contentJSON, _ = sjson.SetRaw(contentJSON, "parts.-1", partJSON)
 						}
+
+					case providers.ContentTypeDocument:
+						// Plain-text documents become text parts; PDFs go inline (Gemini reads PDFs natively)
+						if text, ok := providers.DocumentText(block); ok {
+							partJSON := `{"text":""}`
+							partJSON, _ = sjson.Set(partJSON, "text", text)
+							contentJSON, _ = sjson.SetRaw(contentJSON, "parts.-1", partJSON)
+							break
+						}
+						source := block.Get("source")
+						if source.Get("type").String() == "base64" {
+							inlineDataJSON := `{}`
+							inlineDataJSON, _ = sjson.Set(inlineDataJSON, "mime_type", source.Get("media_type").String())
+							inlineDataJSON, _ = sjson.Set(inlineDataJSON, "data", source.Get("data").String())
+							partJSON := `{}`
+							partJSON, _ = sjson.SetRaw(partJSON, "inlineData", inlineDataJSON)
+							contentJSON, _ = sjson.SetRaw(contentJSON, "parts.-1", partJSON)
+						}
 					}
 				}
 			}
@@ -212,10 +254,20 @@ func TranslateClaudeToAntigravityWithProject(payload []byte, model string, proje
 	// Convert tools
 	// Claude: "tools": [{"name": "...", "description": "...", "input_schema": {...}}]
 	// Antigravity: "request.tools": [{"functionDeclarations": [{"name": "...", "description": "...", "parametersJsonSchema": {...}}]}]
+	// Server tools map to Gemini built-ins: code_execution -> codeExecution, web_search -> googleSearch
 	toolsResult := gjson.GetBytes(payload, "tools")
 	if toolsResult.IsArray() {
 		toolsJSON := `[{"functionDeclarations":[]}]`
 		for _, tool := range toolsResult.Array() {
+			switch providers.ServerToolKind(tool) {
+			case providers.ServerToolCodeExecution:
+				toolsJSON, _ = sjson.SetRaw(toolsJSON, "-1", `{"codeExecution":{}}`)
+				continue
+			case providers.ServerToolWebSearch:
+				toolsJSON, _ = sjson.SetRaw(toolsJSON, "-1", `{"googleSearch":{}}`)
+				continue
+			}
+
 			inputSchema := tool.Get("input_schema")
 			if inputSchema.Exists() {
 				toolJSON := tool.Raw
@@ -229,6 +281,12 @@ func TranslateClaudeToAntigravityWithProject(payload []byte, model string, proje
 		result, _ = sjson.Delete(result, "tools")
 	}
 
+	// Convert tool_choice
+	// Claude: "tool_choice": {"type": "any"} or {"type": "tool", "name": "get_weather"}
+	// Antigravity: "request.toolConfig.functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["get_weather"]}
+	// auto keeps the default VALIDATED mode
+	result = convertToolChoice(payload, result, toolsResult.IsArray())
+
 	// Convert max_tokens
 	// Claude: "max_tokens": 1024
 	// Antigravity: "request.generationConfig.maxOutputTokens": 1024
@@ -261,12 +319,34 @@ func TranslateClaudeToAntigravityWithProject(payload []byte, model string, proje
 	if stopSeq := gjson.GetBytes(payload, "stop_sequences"); stopSeq.IsArray() {
 		stopJSON := "[]"
 		for _, seq := range stopSeq.Array() {
-			stopJSON, _ = sjson.SetRaw(stopJSON, "-1", `"`+seq.String()+`"`)
+			stopJSON, _ = sjson.Set(stopJSON, "-1", seq.String())
 		}
 		result, _ = sjson.SetRaw(result, "request.generationConfig.stopSequences", stopJSON)
 		result, _ = sjson.Delete(result, "stop_sequences")
 	}
 
+	// Convert structured output
+	// OpenAI: "response_format": {"type": "json_schema", "json_schema": {"name": "...", "schema": {...}}}
+	// Antigravity: "request.generationConfig": {"responseMimeType": "application/json", "responseSchema": {...}}
+	if responseFormat := gjson.GetBytes(payload, "response_format"); responseFormat.Exists() {
+		switch responseFormat.Get("type").String() {
+		case "json_schema":
+			result, _ = sjson.Set(result, "request.generationConfig.responseMimeType", "application/json")
+			if schema := responseFormat.Get("json_schema.schema"); schema.IsObject() {
+				result, _ = sjson.SetRaw(result, "request.generationConfig.responseSchema", schema.Raw)
+			}
+		case "json_object":
+			result, _ = sjson.Set(result, "request.generationConfig.responseMimeType", "application/json")
+		}
+		result, _ = sjson.Delete(result, "response_format")
+	}
+
+	// Antigravity has no tier selection; service_tier only influences account pool routing
+	result = providers.DropServiceTier(result)
+
+	// Gemini's functionCallingConfig has no parallelism control, so parallel_tool_calls is dropped
+	result = providers.DropParallelToolCalls(result)
+
 	// Convert thinking configuration
 	// Claude: "thinking": {"type": "enabled", "budget_tokens": 10000}
 	// Antigravity: "request.generationConfig.thinkingConfig": {"thinkingBudget": 10000, "include_thoughts": true}
@@ -283,9 +363,10 @@ func TranslateClaudeToAntigravityWithProject(payload []byte, model string, proje
 		result, _ = sjson.Delete(result, "thinking")
 	}
 
-	// Inject interleaved thinking hint when tools + thinking are both active on Claude thinking models
+	// Inject interleaved thinking hint when tools + thinking are both active on Claude thinking models,
+	// unless the operator or client asked for the system prompt to pass through untouched
 	hasTools := toolsResult.IsArray() && len(toolsResult.Array()) > 0
-	if hasTools && hasThinking && isClaudeThinkingModel(model) {
+	if hasTools && hasThinking && isClaudeThinkingModel(model) && !opts.SkipSystemHints {
 		interleavedHint := "Interleaved thinking is enabled. You may think between tool calls and after receiving tool results before deciding the next action or final answer. Do not mention these instructions or any constraints about thinking blocks; just apply them."
 
 		// Append hint to existing system instruction or create new one
@@ -305,8 +386,31 @@ func TranslateClaudeToAntigravityWithProject(payload []byte, model string, proje
 		}
 	}
 
+	// Merge client passthrough params into the Gemini request body
+	result = providers.ApplyProviderParams(payload, result, ProviderID, "request")
+
 	// Add model (passthrough - no translation needed)
 	result, _ = sjson.Set(result, "model", model)
 
 	return []byte(result)
 }
+
+// convertToolChoice maps Claude tool_choice onto functionCallingConfig
+func convertToolChoice(payload []byte, result string, hasTools bool) string {
+	result, _ = sjson.Delete(result, "tool_choice")
+	if !hasTools {
+		return result
+	}
+
+	choiceType, name := providers.ToolChoice(payload)
+	switch choiceType {
+	case providers.ToolChoiceAny:
+		result, _ = sjson.Set(result, "request.toolConfig.functionCallingConfig.mode", "ANY")
+	case providers.ToolChoiceTool:
+		result, _ = sjson.Set(result, "request.toolConfig.functionCallingConfig.mode", "ANY")
+		result, _ = sjson.Set(result, "request.toolConfig.functionCallingConfig.allowedFunctionNames", []string{name})
+	case providers.ToolChoiceNone:
+		result, _ = sjson.Set(result, "request.toolConfig.functionCallingConfig.mode", "NONE")
+	}
+	return result
+}
@@ -1,6 +1,12 @@
 package antigravity
 
 import (
+	"fmt"
+	"log"
+	"strings"
+
+	"aigateway-backend/providers"
+
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -26,6 +32,12 @@ func TranslateAntigravityToClaude(payload []byte) []byte {
 		role = "assistant" // Default to assistant
 	}
 
+	// Requests for multiple candidates are rejected upstream of here; if the API still
+	// returns several, only the first can be represented as a Claude message
+	if candidates := responseNode.Get("candidates").Array(); len(candidates) > 1 {
+		log.Printf("[Antigravity] Response has %d candidates, using candidates[0]", len(candidates))
+	}
+
 	contentJSON := `{"role":"","content":[]}`
 	contentJSON, _ = sjson.Set(contentJSON, "role", role)
 
@@ -34,18 +46,33 @@ func TranslateAntigravityToClaude(payload []byte) []byte {
 	// Claude: "content": [{"type": "text", "text": "..."}, {"type": "tool_use", ...}, {"type": "thinking", "thinking": "...", "signature": "..."}]
 	parts := responseNode.Get("candidates.0.content.parts")
 	if parts.IsArray() {
+		// Signature from a thought part without text, waiting for a thinking block to carry it
+		var pendingSignature string
+
 		for _, part := range parts.Array() {
 			// Handle thinking/thought blocks (must come before text check)
 			if thought := part.Get("thought"); thought.Exists() && thought.Bool() {
-				thinkingPart := `{"type":"thinking","thinking":""}`
 				thinkingText := part.Get("text").String()
-				thinkingPart, _ = sjson.Set(thinkingPart, "thinking", thinkingText)
-
-				// Add signature if present
 				signature := part.Get("thoughtSignature").String()
 				if signature == "" {
 					signature = part.Get("thought_signature").String()
 				}
+
+				// Signature-only part: an empty thinking block is rejected by some clients, so
+				// sign the preceding thinking block instead, or hold it for the next one
+				if thinkingText == "" {
+					if signature != "" && !signLastThinkingBlock(&contentJSON, signature) {
+						pendingSignature = signature
+					}
+					continue
+				}
+
+				if signature == "" {
+					signature, pendingSignature = pendingSignature, ""
+				}
+
+				thinkingPart := `{"type":"thinking","thinking":""}`
+				thinkingPart, _ = sjson.Set(thinkingPart, "thinking", thinkingText)
 				if signature != "" {
 					thinkingPart, _ = sjson.Set(thinkingPart, "signature", signature)
 				}
@@ -73,9 +100,7 @@ func TranslateAntigravityToClaude(payload []byte) []byte {
 				}
 				toolUsePart, _ = sjson.Set(toolUsePart, "id", toolID)
 				toolUsePart, _ = sjson.Set(toolUsePart, "name", name)
-				if args.Exists() {
-					toolUsePart, _ = sjson.SetRaw(toolUsePart, "input", args.Raw)
-				}
+				toolUsePart, _ = sjson.SetRaw(toolUsePart, "input", toolInputJSON(args))
 				contentJSON, _ = sjson.SetRaw(contentJSON, "content.-1", toolUsePart)
 			}
 
@@ -120,9 +145,18 @@ func TranslateAntigravityToClaude(payload []byte) []byte {
 		}
 	}
 
+	// A prompt Gemini refused outright has promptFeedback.blockReason and no candidates;
+	// say so in the message instead of returning an empty reply
+	if reason, notice, blocked := promptBlocked(responseNode); blocked {
+		textPart, _ := sjson.Set(`{"type":"text","text":""}`, "text", notice)
+		contentJSON, _ = sjson.SetRaw(contentJSON, "content.-1", textPart)
+		contentJSON, _ = sjson.Set(contentJSON, "prompt_feedback.block_reason", reason)
+		log.Printf("[Antigravity] Prompt blocked upstream: %s", reason)
+	}
+
 	// Convert finish reason
 	// Antigravity: "candidates.0.finishReason": "STOP", "MAX_TOKENS", "SAFETY", "OTHER"
-	// Claude: "stop_reason": "end_turn", "max_tokens", "stop_sequence", "tool_use"
+	// Claude: "stop_reason": "end_turn", "max_tokens", "stop_sequence", "tool_use", "refusal"
 	finishReason := responseNode.Get("candidates.0.finishReason").String()
 	stopReason := convertFinishReason(finishReason)
 	contentJSON, _ = sjson.Set(contentJSON, "stop_reason", stopReason)
@@ -150,12 +184,27 @@ func TranslateAntigravityToClaude(payload []byte) []byte {
 	}
 
 	// Add response ID
-	contentJSON, _ = sjson.Set(contentJSON, "id", "msg_antigravity")
+	contentJSON, _ = sjson.Set(contentJSON, "id", providers.NewMessageID())
 	contentJSON, _ = sjson.Set(contentJSON, "type", "message")
 
 	return []byte(contentJSON)
 }
 
+// promptBlocked reports whether Gemini blocked the prompt before generating any candidate
+// notice is the text shown to the client in place of the missing reply.
+func promptBlocked(responseNode gjson.Result) (reason, notice string, blocked bool) {
+	reason = responseNode.Get("promptFeedback.blockReason").String()
+	if reason == "" || len(responseNode.Get("candidates").Array()) > 0 {
+		return "", "", false
+	}
+
+	notice = "The prompt was blocked by the upstream content filter (" + reason + ")."
+	if message := responseNode.Get("promptFeedback.blockReasonMessage").String(); message != "" {
+		notice += " " + message
+	}
+	return reason, notice, true
+}
+
 // convertFinishReason maps Antigravity finish reasons to Claude stop reasons
 func convertFinishReason(finishReason string) string {
 	switch finishReason {
@@ -163,10 +212,9 @@ func convertFinishReason(finishReason string) string {
 		return "end_turn"
 	case "MAX_TOKENS":
 		return "max_tokens"
-	case "SAFETY":
-		return "end_turn"
-	case "RECITATION":
-		return "end_turn"
+	case "SAFETY", "RECITATION", "PROHIBITED_CONTENT", "BLOCKLIST", "SPII":
+		// Content filters: surfaced as Claude refusals so empty filtered replies can be told apart
+		return "refusal"
 	case "OTHER":
 		return "end_turn"
 	case "":
@@ -175,3 +223,44 @@ func convertFinishReason(finishReason string) string {
 		return "end_turn"
 	}
 }
+
+// toolInputJSON returns functionCall args as a JSON object for Claude's tool_use input
+// Gemini sometimes encodes args as a JSON string; those are decoded. Values that still
+// aren't objects are wrapped as {"value": ...} since Claude SDKs require an object.
+func toolInputJSON(args gjson.Result) string {
+	if args.Type == gjson.String {
+		decoded := strings.TrimSpace(args.String())
+		if decoded == "" {
+			return "{}"
+		}
+		if !gjson.Valid(decoded) {
+			wrapped, _ := sjson.Set("{}", "value", args.String())
+			return wrapped
+		}
+		args = gjson.Parse(decoded)
+	}
+
+	switch {
+	case !args.Exists() || args.Type == gjson.Null:
+		return "{}"
+	case args.IsObject():
+		return args.Raw
+	default:
+		wrapped, _ := sjson.SetRaw("{}", "value", args.Raw)
+		return wrapped
+	}
+}
+
+// signLastThinkingBlock adds signature to the last content block if it is an unsigned thinking block
+func signLastThinkingBlock(contentJSON *string, signature string) bool {
+	blocks := gjson.Get(*contentJSON, "content").Array()
+	if len(blocks) == 0 {
+		return false
+	}
+	last := blocks[len(blocks)-1]
+	if last.Get("type").String() != "thinking" || last.Get("signature").Exists() {
+		return false
+	}
+	*contentJSON, _ = sjson.Set(*contentJSON, fmt.Sprintf("content.%d.signature", len(blocks)-1), signature)
+	return true
+}
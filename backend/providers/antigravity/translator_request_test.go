@@ -1,9 +1,14 @@
 package antigravity
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
+	"aigateway-backend/providers"
+
 	"github.com/tidwall/gjson"
 )
 
@@ -81,6 +86,36 @@ func TestTranslateClaudeToAntigravity_ToolResult(t *testing.T) {
 	}
 }
 
+func TestTranslateClaudeToAntigravity_ToolResultError(t *testing.T) {
+	claudeReq := `{
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "get_weather-123-456", "is_error": true, "content": "city not found"},
+				{"type": "tool_result", "tool_use_id": "get_time-123-456", "is_error": true},
+				{"type": "tool_result", "tool_use_id": "get_date-123-456", "content": "2026-10-16"}
+			]}
+		]
+	}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-2.5-pro")
+	parts := gjson.GetBytes(result, "request.contents.0.parts").Array()
+	if len(parts) != 3 {
+		t.Fatalf("parts = %d, want 3", len(parts))
+	}
+
+	failed := parts[0].Get("functionResponse.response")
+	if failed.Get("error").String() != "city not found" || failed.Get("result").Exists() {
+		t.Errorf("failed response = %s, want the output under error", failed.Raw)
+	}
+	if got := parts[1].Get("functionResponse.response.error").String(); got == "" {
+		t.Errorf("failed response without content = %s, want an error marker", parts[1].Get("functionResponse.response").Raw)
+	}
+	ok := parts[2].Get("functionResponse.response")
+	if ok.Get("result").String() != "2026-10-16" || ok.Get("error").Exists() {
+		t.Errorf("successful response = %s, want result only", ok.Raw)
+	}
+}
+
 func TestTranslateClaudeToAntigravity_ToolUse(t *testing.T) {
 	claudeReq := `{
 		"messages": [{
@@ -182,6 +217,46 @@ func TestTranslateClaudeToAntigravity_Tools(t *testing.T) {
 	}
 }
 
+func TestTranslateClaudeToAntigravity_ServerTools(t *testing.T) {
+	claudeReq := `{
+		"tools": [
+			{"type": "code_execution_20250522", "name": "code_execution"},
+			{"name": "get_weather", "input_schema": {"type": "object"}}
+		],
+		"messages": [{"role": "user", "content": "Compute 2^64"}]
+	}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "claude-sonnet-4-5")
+
+	tools := gjson.GetBytes(result, "request.tools").Array()
+	if len(tools) != 2 {
+		t.Fatalf("request.tools length = %d, want 2", len(tools))
+	}
+
+	funcDecls := tools[0].Get("functionDeclarations").Array()
+	if len(funcDecls) != 1 || funcDecls[0].Get("name").String() != "get_weather" {
+		t.Errorf("functionDeclarations = %s, want only get_weather", tools[0].Get("functionDeclarations").Raw)
+	}
+	if !tools[1].Get("codeExecution").Exists() {
+		t.Errorf("request.tools[1] = %s, want codeExecution", tools[1].Raw)
+	}
+}
+
+func TestAntigravityProvider_TranslateRequest_UnsupportedServerTool(t *testing.T) {
+	claudeReq := `{
+		"tools": [{"type": "web_fetch_20250910", "name": "web_fetch"}],
+		"messages": [{"role": "user", "content": "Fetch example.com"}]
+	}`
+
+	_, err := NewAntigravityProvider().TranslateRequest("claude", []byte(claudeReq), "claude-sonnet-4-5")
+	if err == nil {
+		t.Fatal("expected error for unsupported server tool")
+	}
+	if !strings.Contains(err.Error(), "unsupported server tool web_fetch_20250910 for provider antigravity") {
+		t.Errorf("error = %q", err.Error())
+	}
+}
+
 func TestTranslateClaudeToAntigravity_GenerationConfig(t *testing.T) {
 	claudeReq := `{
 		"max_tokens": 1024,
@@ -215,6 +290,33 @@ func TestTranslateClaudeToAntigravity_GenerationConfig(t *testing.T) {
 	}
 }
 
+func TestTranslateClaudeToAntigravity_StopSequences(t *testing.T) {
+	claudeReq := `{
+		"stop_sequences": ["END", "\n\nHuman:", "say \"stop\""],
+		"messages": [{"role": "user", "content": "Hello"}]
+	}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-2.5-pro")
+
+	if !gjson.ValidBytes(result) {
+		t.Fatalf("result is not valid JSON: %s", result)
+	}
+
+	stops := gjson.GetBytes(result, "request.generationConfig.stopSequences").Array()
+	want := []string{"END", "\n\nHuman:", `say "stop"`}
+	if len(stops) != len(want) {
+		t.Fatalf("stopSequences length = %d, want %d", len(stops), len(want))
+	}
+	for i, w := range want {
+		if stops[i].String() != w {
+			t.Errorf("stopSequences[%d] = %q, want %q", i, stops[i].String(), w)
+		}
+	}
+	if gjson.GetBytes(result, "stop_sequences").Exists() {
+		t.Error("stop_sequences should be removed")
+	}
+}
+
 func TestTranslateClaudeToAntigravity_ModelPassthrough(t *testing.T) {
 	claudeReq := `{"messages": [{"role": "user", "content": "Hi"}]}`
 
@@ -327,3 +429,271 @@ func TestTranslateClaudeToAntigravity_AntigravityFields(t *testing.T) {
 		t.Error("sessionId should start with '-'")
 	}
 }
+
+func TestTranslateClaudeToAntigravity_ProviderParams(t *testing.T) {
+	claudeReq := `{
+		"messages": [{"role": "user", "content": "Hi"}],
+		"max_tokens": 1024,
+		"provider_params": {
+			"antigravity": {
+				"generationConfig": {"seed": 42, "responseMimeType": "application/json"},
+				"labels": {"team": "search"}
+			}
+		}
+	}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-2.5-pro")
+
+	if !json.Valid(result) {
+		t.Fatalf("invalid JSON output: %s", result)
+	}
+	if got := gjson.GetBytes(result, "request.generationConfig.seed").Int(); got != 42 {
+		t.Errorf("seed = %d, want 42", got)
+	}
+	if got := gjson.GetBytes(result, "request.generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Errorf("responseMimeType = %q, want application/json", got)
+	}
+	// Merge keeps translated fields alongside passthrough ones
+	if got := gjson.GetBytes(result, "request.generationConfig.maxOutputTokens").Int(); got != 1024 {
+		t.Errorf("maxOutputTokens = %d, want 1024", got)
+	}
+	if got := gjson.GetBytes(result, "request.labels.team").String(); got != "search" {
+		t.Errorf("labels.team = %q, want search", got)
+	}
+	if gjson.GetBytes(result, "provider_params").Exists() {
+		t.Error("provider_params should be removed from upstream payload")
+	}
+}
+
+func TestTranslateClaudeToAntigravity_PDFDocument(t *testing.T) {
+	claudeReq := `{
+		"messages": [{
+			"role": "user",
+			"content": [
+				{"type": "document", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERi0xLjQ="}},
+				{"type": "text", "text": "Summarize this"}
+			]
+		}]
+	}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-2.5-pro")
+
+	part := gjson.GetBytes(result, "request.contents.0.parts.0")
+	if got := part.Get("inlineData.mime_type").String(); got != "application/pdf" {
+		t.Errorf("inlineData.mime_type = %q, want application/pdf", got)
+	}
+	if got := part.Get("inlineData.data").String(); got != "JVBERi0xLjQ=" {
+		t.Errorf("inlineData.data = %q", got)
+	}
+	if got := gjson.GetBytes(result, "request.contents.0.parts.1.text").String(); got != "Summarize this" {
+		t.Errorf("parts[1].text = %q", got)
+	}
+}
+
+func TestTranslateRequest_RejectsURLDocument(t *testing.T) {
+	claudeReq := `{"messages": [{"role": "user", "content": [
+		{"type": "document", "source": {"type": "url", "url": "https://example.com/a.pdf"}}
+	]}]}`
+
+	_, err := NewAntigravityProvider().TranslateRequest("claude", []byte(claudeReq), "gemini-2.5-pro")
+	var docErr *providers.UnsupportedDocumentError
+	if !errors.As(err, &docErr) {
+		t.Fatalf("expected UnsupportedDocumentError, got %v", err)
+	}
+}
+
+func TestTranslateClaudeToAntigravity_DropsServiceTier(t *testing.T) {
+	claudeReq := `{"service_tier": "standard_only", "messages": [{"role": "user", "content": "Hi"}]}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-pro")
+
+	if strings.Contains(string(result), "service_tier") {
+		t.Errorf("service_tier should be dropped, got %s", result)
+	}
+}
+
+func TestTranslateClaudeToAntigravity_DropsParallelToolCalls(t *testing.T) {
+	claudeReq := `{
+		"parallel_tool_calls": false,
+		"tools": [{"name": "get_weather", "input_schema": {"type": "object"}}],
+		"messages": [{"role": "user", "content": "Hi"}]
+	}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-pro")
+
+	if strings.Contains(string(result), "parallel_tool_calls") {
+		t.Errorf("parallel_tool_calls should be dropped, got %s", result)
+	}
+	if !gjson.GetBytes(result, "request.tools.0.functionDeclarations.0").Exists() {
+		t.Errorf("tools should still be translated, got %s", result)
+	}
+}
+
+func TestAntigravityProvider_TranslateRequest_DefaultMaxTokens(t *testing.T) {
+	p := NewAntigravityProvider()
+	p.SetMaxTokenDefaults(4096, map[string]int{"gemini-2.5-flash": 2048})
+
+	tests := []struct {
+		name    string
+		model   string
+		payload string
+		want    int64
+	}{
+		{"provider default", "gemini-2.5-pro", `{"messages":[{"role":"user","content":"hi"}]}`, 4096},
+		{"per-model default", "gemini-2.5-flash", `{"messages":[{"role":"user","content":"hi"}]}`, 2048},
+		{"explicit value wins", "gemini-2.5-flash", `{"max_tokens":100,"messages":[{"role":"user","content":"hi"}]}`, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.TranslateRequest("claude", []byte(tt.payload), tt.model)
+			if err != nil {
+				t.Fatalf("TranslateRequest() error = %v", err)
+			}
+			if got := gjson.GetBytes(result, "request.generationConfig.maxOutputTokens").Int(); got != tt.want {
+				t.Errorf("maxOutputTokens = %d, want %d", got, tt.want)
+			}
+			if gjson.GetBytes(result, "max_tokens").Exists() {
+				t.Error("max_tokens should not remain at the top level")
+			}
+		})
+	}
+}
+
+func TestAntigravityProvider_TranslateRequest_BuiltinMaxTokens(t *testing.T) {
+	result, err := NewAntigravityProvider().TranslateRequest("claude", []byte(`{"messages":[{"role":"user","content":"hi"}]}`), "gemini-2.5-pro")
+	if err != nil {
+		t.Fatalf("TranslateRequest() error = %v", err)
+	}
+	if got := gjson.GetBytes(result, "request.generationConfig.maxOutputTokens").Int(); got != DefaultMaxOutputTokens {
+		t.Errorf("maxOutputTokens = %d, want %d", got, DefaultMaxOutputTokens)
+	}
+}
+
+func TestTranslateClaudeToAntigravity_ToolChoice(t *testing.T) {
+	tests := []struct {
+		name        string
+		toolChoice  string
+		wantMode    string
+		wantAllowed string // Expected raw allowedFunctionNames, "" = absent
+	}{
+		{"auto", `{"type": "auto"}`, "VALIDATED", ""},
+		{"any", `{"type": "any"}`, "ANY", ""},
+		{"named tool", `{"type": "tool", "name": "get_weather"}`, "ANY", `["get_weather"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claudeReq := `{
+				"tool_choice": ` + tt.toolChoice + `,
+				"tools": [{"name": "get_weather", "input_schema": {"type": "object"}}],
+				"messages": [{"role": "user", "content": "Hi"}]
+			}`
+			result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-pro")
+
+			config := gjson.GetBytes(result, "request.toolConfig.functionCallingConfig")
+			if got := config.Get("mode").String(); got != tt.wantMode {
+				t.Errorf("mode = %q, want %q", got, tt.wantMode)
+			}
+			if got := config.Get("allowedFunctionNames").Raw; got != tt.wantAllowed {
+				t.Errorf("allowedFunctionNames = %s, want %s", got, tt.wantAllowed)
+			}
+			if gjson.GetBytes(result, "tool_choice").Exists() {
+				t.Errorf("tool_choice should be removed, got %s", result)
+			}
+		})
+	}
+}
+
+func TestTranslateClaudeToAntigravity_ResponseFormat(t *testing.T) {
+	claudeReq := `{
+		"response_format": {
+			"type": "json_schema",
+			"json_schema": {"name": "weather", "schema": {"type": "object", "properties": {"temp": {"type": "number"}}}}
+		},
+		"messages": [{"role": "user", "content": "Hi"}]
+	}`
+
+	result := TranslateClaudeToAntigravity([]byte(claudeReq), "gemini-pro")
+
+	config := gjson.GetBytes(result, "request.generationConfig")
+	if got := config.Get("responseMimeType").String(); got != "application/json" {
+		t.Errorf("responseMimeType = %q, want application/json", got)
+	}
+	if got := config.Get("responseSchema.properties.temp.type").String(); got != "number" {
+		t.Errorf("responseSchema = %s, want the json_schema.schema object", config.Get("responseSchema").Raw)
+	}
+	if gjson.GetBytes(result, "response_format").Exists() {
+		t.Errorf("response_format should be removed, got %s", result)
+	}
+
+	// json_object only sets the MIME type
+	result = TranslateClaudeToAntigravity([]byte(`{"response_format": {"type": "json_object"}, "messages": []}`), "gemini-pro")
+	config = gjson.GetBytes(result, "request.generationConfig")
+	if config.Get("responseMimeType").String() != "application/json" || config.Get("responseSchema").Exists() {
+		t.Errorf("generationConfig = %s, want only responseMimeType for json_object", config.Raw)
+	}
+}
+
+const interleavedHintRequest = `{
+	"system": "You are a careful assistant.",
+	"thinking": {"type": "enabled", "budget_tokens": 2048},
+	"tools": [{"name": "lookup", "input_schema": {"type": "object"}}],
+	"messages": [{"role": "user", "content": "hi"}]
+}`
+
+func TestTranslateClaudeToAntigravity_InterleavedHint(t *testing.T) {
+	const model = "claude-sonnet-4-5-thinking"
+
+	injected := TranslateClaudeToAntigravity([]byte(interleavedHintRequest), model)
+	parts := gjson.GetBytes(injected, "request.systemInstruction.parts").Array()
+	if len(parts) != 2 || !strings.Contains(parts[1].Get("text").String(), "Interleaved thinking is enabled") {
+		t.Fatalf("default translation should append the hint, got parts %v", parts)
+	}
+
+	skipped := TranslateClaudeToAntigravityWithOptions([]byte(interleavedHintRequest), model, TranslateOptions{SkipSystemHints: true})
+	parts = gjson.GetBytes(skipped, "request.systemInstruction.parts").Array()
+	if len(parts) != 1 || parts[0].Get("text").String() != "You are a careful assistant." {
+		t.Errorf("SkipSystemHints should leave the system prompt untouched, got parts %v", parts)
+	}
+}
+
+func TestAntigravityProvider_SystemHintsDisabled(t *testing.T) {
+	const model = "claude-sonnet-4-5-thinking"
+	hasHint := func(opts TranslateOptions) bool {
+		translated := TranslateClaudeToAntigravityWithOptions([]byte(interleavedHintRequest), model, opts)
+		return strings.Contains(gjson.GetBytes(translated, "request.systemInstruction").Raw, "Interleaved thinking")
+	}
+
+	p := NewAntigravityProvider()
+	if !hasHint(p.translateOptions(context.Background(), "")) {
+		t.Error("hints should be injected by default")
+	}
+	if hasHint(p.translateOptions(providers.WithSystemHintsDisabled(context.Background()), "")) {
+		t.Error("X-Gateway-System-Hints opt-out should skip the hint")
+	}
+
+	p.SetSystemHints(false)
+	if hasHint(p.translateOptions(context.Background(), "")) {
+		t.Error("SetSystemHints(false) should skip the hint")
+	}
+}
+
+func TestAntigravityProvider_TranslateRequest_TruncatesToolResults(t *testing.T) {
+	p := NewAntigravityProvider()
+	p.SetMaxToolResultChars(5)
+
+	payload := `{"messages":[
+		{"role":"assistant","content":[{"type":"tool_use","id":"read_file-1-2","name":"read_file","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"read_file-1-2","content":"0123456789"}]}
+	]}`
+	translated, err := p.TranslateRequest("claude", []byte(payload), "gemini-2.5-pro")
+	if err != nil {
+		t.Fatalf("TranslateRequest() error = %v", err)
+	}
+
+	got := gjson.GetBytes(translated, "request.contents.1.parts.0.functionResponse.response.result").String()
+	if got != "01234\n\n[... truncated 5 characters]" {
+		t.Errorf("functionResponse result = %q, want truncated with marker", got)
+	}
+}
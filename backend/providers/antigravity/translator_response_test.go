@@ -0,0 +1,174 @@
+package antigravity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestTranslateAntigravityToClaude_UniqueMessageID(t *testing.T) {
+	antigravityResp := `{"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]},"finishReason":"STOP"}]}}`
+
+	firstID := gjson.GetBytes(TranslateAntigravityToClaude([]byte(antigravityResp)), "id").String()
+	secondID := gjson.GetBytes(TranslateAntigravityToClaude([]byte(antigravityResp)), "id").String()
+
+	if !strings.HasPrefix(firstID, "msg_") || !strings.HasPrefix(secondID, "msg_") {
+		t.Errorf("ids = %q, %q, want msg_ prefix", firstID, secondID)
+	}
+	if firstID == secondID {
+		t.Errorf("ids should be unique, both = %q", firstID)
+	}
+}
+
+func TestTranslateAntigravityToClaude_MultipleCandidatesUsesFirst(t *testing.T) {
+	antigravityResp := `{"response":{"candidates":[
+		{"content":{"role":"model","parts":[{"text":"first"}]},"finishReason":"STOP"},
+		{"content":{"role":"model","parts":[{"text":"second"}]},"finishReason":"MAX_TOKENS"}
+	]}}`
+
+	result := TranslateAntigravityToClaude([]byte(antigravityResp))
+
+	content := gjson.GetBytes(result, "content").Array()
+	if len(content) != 1 || content[0].Get("text").String() != "first" {
+		t.Errorf("content = %s, want only the first candidate", gjson.GetBytes(result, "content").Raw)
+	}
+	if stop := gjson.GetBytes(result, "stop_reason").String(); stop != "end_turn" {
+		t.Errorf("stop_reason = %q, want first candidate's end_turn", stop)
+	}
+}
+
+func TestAntigravityProvider_TranslateRequest_RejectsMultipleCandidates(t *testing.T) {
+	provider := NewAntigravityProvider()
+
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"claude style", `{"candidate_count": 2, "messages": [{"role": "user", "content": "Hi"}]}`},
+		{"gemini style", `{"generationConfig": {"candidateCount": 3}, "messages": [{"role": "user", "content": "Hi"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.TranslateRequest("claude", []byte(tt.payload), "gemini-2.5-pro")
+			if err == nil || !strings.Contains(err.Error(), "candidate_count") {
+				t.Errorf("error = %v, want candidate_count rejection", err)
+			}
+		})
+	}
+
+	single := `{"candidate_count": 1, "messages": [{"role": "user", "content": "Hi"}]}`
+	if _, err := provider.TranslateRequest("claude", []byte(single), "gemini-2.5-pro"); err != nil {
+		t.Errorf("candidate_count 1 should be accepted, got %v", err)
+	}
+}
+
+func TestTranslateAntigravityToClaude_ToolUseInputAlwaysObject(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{"object args", `{"city":"Jakarta"}`, `{"city":"Jakarta"}`},
+		{"string-encoded args", `"{\"city\":\"Jakarta\"}"`, `{"city":"Jakarta"}`},
+		{"empty string args", `""`, `{}`},
+		{"missing args", ``, `{}`},
+		{"non-JSON string args", `"Jakarta"`, `{"value":"Jakarta"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			functionCall := `{"id":"call_1","name":"get_weather"}`
+			if tt.args != "" {
+				functionCall = `{"id":"call_1","name":"get_weather","args":` + tt.args + `}`
+			}
+			antigravityResp := `{"response":{"candidates":[{"content":{"role":"model","parts":[{"functionCall":` + functionCall + `}]},"finishReason":"STOP"}]}}`
+
+			result := TranslateAntigravityToClaude([]byte(antigravityResp))
+
+			input := gjson.GetBytes(result, "content.0.input")
+			if !input.IsObject() {
+				t.Fatalf("input = %s, want a JSON object", input.Raw)
+			}
+			if input.Raw != tt.want {
+				t.Errorf("input = %s, want %s", input.Raw, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateAntigravityToClaude_SafetyFinishIsRefusal(t *testing.T) {
+	payload := `{"response":{"candidates":[{"content":{"role":"model","parts":[]},"finishReason":"SAFETY"}]}}`
+
+	result := TranslateAntigravityToClaude([]byte(payload))
+
+	if !strings.Contains(string(result), `"stop_reason":"refusal"`) {
+		t.Errorf("stop_reason should be refusal for SAFETY, got %s", result)
+	}
+}
+
+func TestTranslateAntigravityToClaude_SignatureOnlyThoughtPart(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts string
+		want  string
+	}{
+		{
+			name:  "signs preceding thinking block",
+			parts: `[{"thought":true,"text":"Let me think"},{"thought":true,"thoughtSignature":"sig-1"},{"text":"Answer"}]`,
+			want:  `[{"type":"thinking","thinking":"Let me think","signature":"sig-1"},{"type":"text","text":"Answer"}]`,
+		},
+		{
+			name:  "carried forward to next thinking block",
+			parts: `[{"thought":true,"thoughtSignature":"sig-1"},{"thought":true,"text":"Let me think"},{"text":"Answer"}]`,
+			want:  `[{"type":"thinking","thinking":"Let me think","signature":"sig-1"},{"type":"text","text":"Answer"}]`,
+		},
+		{
+			name:  "no thinking block to carry it",
+			parts: `[{"thought":true,"thoughtSignature":"sig-1"},{"text":"Answer"}]`,
+			want:  `[{"type":"text","text":"Answer"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := `{"response":{"candidates":[{"content":{"role":"model","parts":` + tt.parts + `},"finishReason":"STOP"}]}}`
+
+			content := gjson.GetBytes(TranslateAntigravityToClaude([]byte(payload)), "content").Raw
+			if content != tt.want {
+				t.Errorf("content = %s, want %s", content, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateAntigravityToClaude_PromptBlocked(t *testing.T) {
+	payload := `{"response":{"promptFeedback":{"blockReason":"SAFETY","safetyRatings":[{"category":"HARM_CATEGORY_DANGEROUS_CONTENT","probability":"HIGH"}]},"usageMetadata":{"promptTokenCount":9}}}`
+
+	result := TranslateAntigravityToClaude([]byte(payload))
+
+	if got := gjson.GetBytes(result, "stop_reason").String(); got != "end_turn" {
+		t.Errorf("stop_reason = %q, want end_turn", got)
+	}
+	if got := gjson.GetBytes(result, "prompt_feedback.block_reason").String(); got != "SAFETY" {
+		t.Errorf("prompt_feedback.block_reason = %q, want SAFETY", got)
+	}
+	content := gjson.GetBytes(result, "content").Array()
+	if len(content) != 1 || content[0].Get("type").String() != "text" || !strings.Contains(content[0].Get("text").String(), "blocked") {
+		t.Errorf("content = %s, want a text block saying the prompt was blocked", gjson.GetBytes(result, "content").Raw)
+	}
+}
+
+func TestTranslateAntigravityToClaude_BlockReasonIgnoredWithCandidates(t *testing.T) {
+	payload := `{"response":{"promptFeedback":{"blockReason":"OTHER"},"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]},"finishReason":"STOP"}]}}`
+
+	result := TranslateAntigravityToClaude([]byte(payload))
+
+	if gjson.GetBytes(result, "prompt_feedback").Exists() {
+		t.Errorf("prompt_feedback should only be set when no candidates are returned, got %s", result)
+	}
+	if got := gjson.GetBytes(result, "content.0.text").String(); got != "Hi" {
+		t.Errorf("content.0.text = %q, want Hi", got)
+	}
+}
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// CountCacheControl counts Claude prompt-caching markers in system, message content and tools
+func CountCacheControl(payload []byte) int {
+	count := 0
+	countBlocks := func(blocks gjson.Result) {
+		if !blocks.IsArray() {
+			return
+		}
+		for _, block := range blocks.Array() {
+			if block.Get("cache_control").Exists() {
+				count++
+			}
+		}
+	}
+
+	countBlocks(gjson.GetBytes(payload, "system"))
+	countBlocks(gjson.GetBytes(payload, "tools"))
+	for _, msg := range gjson.GetBytes(payload, "messages").Array() {
+		countBlocks(msg.Get("content"))
+	}
+	return count
+}
+
+// LogDiscardedCacheControl logs when cache_control markers are dropped for a provider
+// without a prompt caching equivalent
+func LogDiscardedCacheControl(payload []byte, providerID string) {
+	if n := CountCacheControl(payload); n > 0 {
+		Debugf("%s: discarded %d cache_control marker(s), prompt caching not supported", providerID, n)
+	}
+}
+
+// SystemText extracts Claude system content as plain text
+// Accepts a string or an array of text blocks; text blocks are joined with newlines
+// and non-text fields (e.g. cache_control) are ignored.
+func SystemText(system gjson.Result) string {
+	if system.Type == gjson.String {
+		return system.String()
+	}
+	if !system.IsArray() {
+		return ""
+	}
+
+	var parts []string
+	for _, block := range system.Array() {
+		if block.Get("type").String() == "text" {
+			parts = append(parts, block.Get("text").String())
+		}
+	}
+	return strings.Join(parts, "\n")
+}
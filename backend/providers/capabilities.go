@@ -0,0 +1,69 @@
+package providers
+
+// ModelCapabilities describes what a model supports through the gateway
+type ModelCapabilities struct {
+	Streaming       bool `json:"streaming"`
+	Thinking        bool `json:"thinking"`
+	MaxOutputTokens int  `json:"max_output_tokens,omitempty"` // 0 when the provider doesn't publish a cap
+}
+
+// CapabilityReporter is implemented by providers that know per-model capabilities
+type CapabilityReporter interface {
+	ModelCapabilities(model string) ModelCapabilities
+}
+
+// CapabilitiesFor returns a model's capabilities, falling back to provider-wide streaming support
+func CapabilitiesFor(provider Provider, model string) ModelCapabilities {
+	if reporter, ok := provider.(CapabilityReporter); ok {
+		return reporter.ModelCapabilities(model)
+	}
+	return ModelCapabilities{Streaming: provider.SupportsStreaming()}
+}
+
+// ClaudeNativeExecutor is implemented by providers whose Execute takes Claude-format payloads
+// and returns Claude-format responses, translating to and from the upstream internally
+type ClaudeNativeExecutor interface {
+	ExecutesClaudeFormat() bool
+}
+
+// ExecutesClaudeFormat reports whether a Claude-format payload can be passed to Execute as-is
+// Other providers need TranslateRequest before Execute and TranslateResponse after it.
+func ExecutesClaudeFormat(provider Provider) bool {
+	native, ok := provider.(ClaudeNativeExecutor)
+	return ok && native.ExecutesClaudeFormat()
+}
+
+// StreamFormatReporter is implemented by providers whose ExecuteStream output format isn't
+// implied by ExecutesClaudeFormat, e.g. because they translate upstream chunks themselves
+type StreamFormatReporter interface {
+	// StreamFormat returns the format streamed for a client request in clientFormat
+	StreamFormat(clientFormat string) string
+}
+
+// StreamFormat returns the format ("claude" or "openai") provider's ExecuteStream emits for a
+// client request in clientFormat. Claude-native providers stream Claude events; other providers
+// forward their OpenAI-compatible upstream chunks unless they report otherwise.
+func StreamFormat(provider Provider, clientFormat string) string {
+	if reporter, ok := provider.(StreamFormatReporter); ok {
+		return reporter.StreamFormat(clientFormat)
+	}
+	if ExecutesClaudeFormat(provider) {
+		return "claude"
+	}
+	return "openai"
+}
+
+// RequestChecker is implemented by providers that reject some Claude-format requests outright
+// (unsupported tools, documents or options), so they fail once before any account is selected
+type RequestChecker interface {
+	CheckRequest(payload []byte, model string) error
+}
+
+// CheckRequest returns the provider's rejection of a Claude-format request, nil when it has none
+// Callers answer a rejection with 400 since retrying on another account cannot help.
+func CheckRequest(provider Provider, payload []byte, model string) error {
+	if checker, ok := provider.(RequestChecker); ok {
+		return checker.CheckRequest(payload, model)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EncodingGzip is the Accept-Encoding/Content-Encoding token for gzip
+const EncodingGzip = "gzip"
+
+// gzipBody closes both the gzip reader and the underlying response body
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipBody) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// DecodeBody returns the response body, transparently gunzipping it when compressed
+// net/http only decodes automatically when it set Accept-Encoding itself, so requests
+// that negotiate gzip explicitly must decode here (before any SSE reader).
+func DecodeBody(resp *http.Response) (io.ReadCloser, error) {
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), EncodingGzip) {
+		return resp.Body, nil
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip response: %w", err)
+	}
+	return &gzipBody{Reader: reader, body: resp.Body}, nil
+}
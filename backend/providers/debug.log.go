@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sync/atomic"
+)
+
+// debugLogging gates verbose dumps (translated payloads, upstream error bodies)
+var debugLogging atomic.Bool
+
+// SetDebugLogging enables or disables [DEBUG] logs (off by default)
+func SetDebugLogging(enabled bool) {
+	debugLogging.Store(enabled)
+}
+
+// DebugEnabled reports whether [DEBUG] logs are written
+func DebugEnabled() bool {
+	return debugLogging.Load()
+}
+
+// Debugf logs a redacted [DEBUG] line when debug logging is enabled
+func Debugf(format string, args ...interface{}) {
+	if !debugLogging.Load() {
+		return
+	}
+	log.Print("[DEBUG] " + Redact(fmt.Sprintf(format, args...)))
+}
+
+const redacted = "[REDACTED]"
+
+var (
+	// Authorization header values, e.g. "Bearer ya29.a0..."
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9\-._~+/]+=*`)
+
+	// JSON string fields holding credentials, e.g. "access_token": "..."
+	secretFieldPattern = regexp.MustCompile(`(?i)("(?:access_token|refresh_token|id_token|api_key|apikey|client_secret|password|authorization|x-api-key)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+	// Form-encoded or query credentials, e.g. refresh_token=1//0g...
+	secretParamPattern = regexp.MustCompile(`(?i)\b((?:access_token|refresh_token|id_token|api_key|client_secret|key)=)[^&\s"]+`)
+
+	// Google OAuth tokens quoted without a field name
+	googleTokenPattern = regexp.MustCompile(`\bya29\.[A-Za-z0-9\-._~+/]+|\b1//[A-Za-z0-9\-._~+/]{20,}`)
+)
+
+// Redact masks bearer tokens and known secret fields in s
+// Safe for JSON bodies, headers and form-encoded strings; everything else is left as-is.
+func Redact(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "$1 "+redacted)
+	s = secretFieldPattern.ReplaceAllString(s, `$1"`+redacted+`"`)
+	s = secretParamPattern.ReplaceAllString(s, "${1}"+redacted)
+	return googleTokenPattern.ReplaceAllString(s, redacted)
+}
@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ContentTypeDocument is the Claude content block type for attached files
+// Claude: {"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"..."}}
+const ContentTypeDocument = "document"
+
+// MediaTypePDF is the only binary document type Claude accepts
+const MediaTypePDF = "application/pdf"
+
+// UnsupportedDocumentError is returned when a request carries a document the target model cannot read
+type UnsupportedDocumentError struct {
+	ProviderID string
+	Model      string
+	Source     string // e.g. "base64 application/pdf" or "url"
+}
+
+func (e *UnsupportedDocumentError) Error() string {
+	return fmt.Sprintf("document content (%s) is not supported by %s model %s", e.Source, e.ProviderID, e.Model)
+}
+
+// CheckDocuments validates every document block in a Claude payload.
+// Plain-text documents are always accepted (they translate to text); base64 PDFs
+// only when pdfSupported is true; anything else is rejected rather than dropped.
+func CheckDocuments(payload []byte, providerID, model string, pdfSupported bool) error {
+	for _, msg := range gjson.GetBytes(payload, "messages").Array() {
+		for _, block := range msg.Get("content").Array() {
+			if block.Get("type").String() != ContentTypeDocument {
+				continue
+			}
+
+			source := block.Get("source")
+			sourceType := source.Get("type").String()
+			if sourceType == "text" {
+				continue
+			}
+
+			mediaType := source.Get("media_type").String()
+			if pdfSupported && sourceType == "base64" && IsPDF(mediaType) {
+				continue
+			}
+
+			return &UnsupportedDocumentError{
+				ProviderID: providerID,
+				Model:      model,
+				Source:     strings.TrimSpace(sourceType + " " + mediaType),
+			}
+		}
+	}
+	return nil
+}
+
+// IsPDF reports whether a media type denotes a PDF document
+func IsPDF(mediaType string) bool {
+	return strings.EqualFold(strings.TrimSpace(mediaType), MediaTypePDF)
+}
+
+// DocumentText returns the inline text of a plain-text document block
+// Claude: {"type":"document","source":{"type":"text","media_type":"text/plain","data":"..."}}
+func DocumentText(block gjson.Result) (string, bool) {
+	source := block.Get("source")
+	if source.Get("type").String() != "text" {
+		return "", false
+	}
+	return source.Get("data").String(), true
+}
@@ -15,7 +15,7 @@ import (
 
 // executeHTTP performs the actual HTTP request to GLM API
 // Handles both streaming and non-streaming requests
-func executeHTTP(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
+func executeHTTP(ctx context.Context, req *providers.ExecuteRequest, timeout time.Duration) (*providers.ExecuteResponse, error) {
 	// Extract API key from account auth data
 	apiKey, err := extractAPIKey(req)
 	if err != nil {
@@ -36,7 +36,7 @@ func executeHTTP(ctx context.Context, req *providers.ExecuteRequest) (*providers
 	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
 
 	// Create HTTP client with optional proxy
-	client := createHTTPClient(req.ProxyURL)
+	client := createHTTPClient(req.ProxyURL, timeout)
 
 	// Execute request and measure latency
 	startTime := time.Now()
@@ -59,6 +59,7 @@ func executeHTTP(ctx context.Context, req *providers.ExecuteRequest) (*providers
 		StatusCode: httpResp.StatusCode,
 		Payload:    body,
 		LatencyMs:  latencyMs,
+		Headers:    httpResp.Header,
 	}, nil
 }
 
@@ -89,7 +90,7 @@ func extractAPIKey(req *providers.ExecuteRequest) (string, error) {
 }
 
 // createHTTPClient creates an HTTP client with optional proxy configuration
-func createHTTPClient(proxyURL string) *http.Client {
+func createHTTPClient(proxyURL string, timeout time.Duration) *http.Client {
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		IdleConnTimeout:     90 * time.Second,
@@ -106,6 +107,6 @@ func createHTTPClient(proxyURL string) *http.Client {
 
 	return &http.Client{
 		Transport: transport,
-		Timeout:   120 * time.Second,
+		Timeout:   timeout,
 	}
 }
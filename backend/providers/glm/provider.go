@@ -3,16 +3,30 @@ package glm
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"aigateway-backend/providers"
 )
 
 // Provider implements the providers.Provider interface for Zhipu AI (GLM)
-type Provider struct{}
+type Provider struct {
+	timeout            time.Duration // Outbound request timeout
+	maxToolResultChars int           // Truncate longer tool_result text before translation (0 = unlimited)
+}
 
 // NewProvider creates a new GLM provider instance
 func NewProvider() *Provider {
-	return &Provider{}
+	return &Provider{timeout: providers.DefaultRequestTimeout}
+}
+
+// SetTimeout sets the outbound request timeout
+func (p *Provider) SetTimeout(timeout time.Duration) {
+	p.timeout = timeout
+}
+
+// SetMaxToolResultChars caps tool_result text forwarded upstream (0 = unlimited)
+func (p *Provider) SetMaxToolResultChars(maxChars int) {
+	p.maxToolResultChars = maxChars
 }
 
 // ID returns the unique identifier for the GLM provider
@@ -40,7 +54,14 @@ func (p *Provider) SupportedModels() []string {
 func (p *Provider) TranslateRequest(format string, payload []byte, model string) ([]byte, error) {
 	switch format {
 	case "claude", "anthropic":
-		return TranslateClaudeToGLM(payload, model), nil
+		if err := providers.CheckServerTools(payload, ProviderID); err != nil {
+			return nil, err
+		}
+		// GLM chat completions has no file content part, so PDFs cannot be forwarded
+		if err := providers.CheckDocuments(payload, ProviderID, model, false); err != nil {
+			return nil, err
+		}
+		return TranslateClaudeToGLM(providers.TruncateToolResults(payload, p.maxToolResultChars), model), nil
 	case "openai":
 		// GLM uses OpenAI-compatible format, minimal translation needed
 		return TranslateOpenAIToGLM(payload, model), nil
@@ -76,7 +97,7 @@ func (p *Provider) Execute(ctx context.Context, req *providers.ExecuteRequest) (
 	}
 
 	// Execute HTTP request to GLM API
-	resp, err := executeHTTP(ctx, req)
+	resp, err := executeHTTP(ctx, req, p.timeout)
 	if err != nil {
 		return nil, fmt.Errorf("http execution failed: %w", err)
 	}
@@ -104,7 +125,15 @@ func (p *Provider) ExecuteStream(ctx context.Context, req *providers.ExecuteRequ
 	}
 
 	// Execute streaming HTTP request to GLM API
-	return executeHTTPStream(ctx, req)
+	return executeHTTPStream(ctx, req, p.timeout)
+}
+
+// StreamFormat reports that GLM streams answer in the client's format (see readGLMStream)
+func (p *Provider) StreamFormat(clientFormat string) string {
+	if clientFormat == "openai" {
+		return "openai"
+	}
+	return "claude"
 }
 
 // SupportsStreaming indicates that GLM supports streaming
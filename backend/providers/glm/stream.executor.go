@@ -14,7 +14,7 @@ import (
 )
 
 // executeHTTPStream performs a streaming HTTP request to GLM API
-func executeHTTPStream(ctx context.Context, req *providers.ExecuteRequest) (*providers.StreamResponse, error) {
+func executeHTTPStream(ctx context.Context, req *providers.ExecuteRequest, timeout time.Duration) (*providers.StreamResponse, error) {
 	// Extract API key
 	apiKey, err := extractAPIKey(req)
 	if err != nil {
@@ -36,7 +36,7 @@ func executeHTTPStream(ctx context.Context, req *providers.ExecuteRequest) (*pro
 	httpReq.Header.Set("Accept", "text/event-stream")
 
 	// Create HTTP client with optional proxy
-	client := createHTTPClient(req.ProxyURL)
+	client := createHTTPClient(req.ProxyURL, timeout)
 
 	// Execute request
 	startTime := time.Now()
@@ -51,7 +51,7 @@ func executeHTTPStream(ctx context.Context, req *providers.ExecuteRequest) (*pro
 		httpResp.Body.Close()
 		return &providers.StreamResponse{
 			StatusCode: httpResp.StatusCode,
-		}, fmt.Errorf("upstream error: status %d, body: %s", httpResp.StatusCode, string(body))
+		}, fmt.Errorf("upstream error: status %d, body: %s", httpResp.StatusCode, providers.Redact(string(body)))
 	}
 
 	// Create channels
@@ -66,11 +66,16 @@ func executeHTTPStream(ctx context.Context, req *providers.ExecuteRequest) (*pro
 		defer close(done)
 		defer httpResp.Body.Close()
 
-		if err := readGLMStream(httpResp.Body, dataCh); err != nil && err != io.EOF {
+		// OpenAI-format clients get GLM's chunks as-is, Claude-format clients Claude events
+		var translator *StreamTranslator
+		if req.Format != "openai" {
+			translator = NewStreamTranslator(req.Model)
+		}
+		if err := readGLMStream(httpResp.Body, dataCh, translator); err != nil && err != io.EOF {
 			errCh <- err
 		}
 
-		fmt.Printf("[DEBUG] GLM stream completed in %dms\n", time.Since(startTime).Milliseconds())
+		providers.Debugf("GLM stream completed in %dms", time.Since(startTime).Milliseconds())
 	}()
 
 	return &providers.StreamResponse{
@@ -82,8 +87,9 @@ func executeHTTPStream(ctx context.Context, req *providers.ExecuteRequest) (*pro
 	}, nil
 }
 
-// readGLMStream reads SSE events from GLM stream (OpenAI-compatible)
-func readGLMStream(body io.Reader, dataCh chan<- []byte) error {
+// readGLMStream reads SSE events from GLM stream (OpenAI-compatible), converting them to
+// Claude events with translator (nil = forward chunks as-is)
+func readGLMStream(body io.Reader, dataCh chan<- []byte, translator *StreamTranslator) error {
 	scanner := bufio.NewScanner(body)
 	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
 
@@ -113,13 +119,28 @@ func readGLMStream(body io.Reader, dataCh chan<- []byte) error {
 			continue
 		}
 
-		// Send chunk to channel (copy to avoid race)
-		chunk := make([]byte, len(data))
-		copy(chunk, data)
-		dataCh <- chunk
+		if translator == nil {
+			// Send chunk to channel (copy to avoid race)
+			chunk := make([]byte, len(data))
+			copy(chunk, data)
+			dataCh <- chunk
+		} else if events := translator.Translate(data); len(events) > 0 {
+			// Translated output is a fresh buffer, so no copy is needed
+			dataCh <- events
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
 	}
 
-	return scanner.Err()
+	// Close the message if upstream ended without a finish reason
+	if translator != nil {
+		if events := translator.Finish(); len(events) > 0 {
+			dataCh <- events
+		}
+	}
+	return nil
 }
 
 // extractHeaders converts http.Header to map[string]string
@@ -3,63 +3,229 @@ package glm
 import (
 	"encoding/json"
 	"fmt"
+
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
 )
 
-// TranslateGLMStreamToClaude converts GLM SSE chunk to Claude SSE format
-// GLM uses OpenAI-compatible format, so translation is similar to OpenAI
-func TranslateGLMStreamToClaude(chunk []byte) []byte {
-	var glmChunk map[string]interface{}
-	if err := json.Unmarshal(chunk, &glmChunk); err != nil {
-		return chunk
+// streamStopReasons maps GLM finish_reason values to Claude stop_reason
+var streamStopReasons = map[string]string{
+	"stop":          "end_turn",
+	"length":        "max_tokens",
+	"tool_calls":    "tool_use",
+	"function_call": "tool_use",
+	"sensitive":     "refusal",
+}
+
+// StreamTranslator converts a GLM (OpenAI-compatible) chunk stream into the full Claude event lifecycle:
+// message_start, content_block_start, content_block_delta, content_block_stop, message_delta, message_stop.
+// It is stateful and must be used for a single stream only.
+type StreamTranslator struct {
+	model     string
+	messageID string
+
+	started  bool
+	finished bool
+
+	blockOpen  bool
+	blockType  string // "thinking", "text" or "tool_use"
+	blockIndex int
+
+	toolCall int // GLM tool_calls index of the open tool_use block
+
+	sawToolUse   bool
+	stopReason   string
+	inputTokens  int64
+	outputTokens int64
+}
+
+// NewStreamTranslator creates a translator for one streamed response
+func NewStreamTranslator(model string) *StreamTranslator {
+	return &StreamTranslator{
+		model:      model,
+		messageID:  providers.NewMessageID(),
+		blockIndex: -1,
 	}
+}
 
-	choices, ok := glmChunk["choices"].([]interface{})
-	if !ok || len(choices) == 0 {
-		return buildClaudeChunk("message_start", map[string]interface{}{})
+// Translate converts one GLM SSE data payload into zero or more Claude SSE events
+func (t *StreamTranslator) Translate(data []byte) []byte {
+	if t.finished || !gjson.ValidBytes(data) {
+		return nil
 	}
 
-	choice := choices[0].(map[string]interface{})
-	delta, ok := choice["delta"].(map[string]interface{})
-	if !ok {
-		return chunk
+	chunk := gjson.ParseBytes(data)
+	if usage := chunk.Get("usage"); usage.Exists() {
+		t.inputTokens = usage.Get("prompt_tokens").Int()
+		t.outputTokens = usage.Get("completion_tokens").Int()
 	}
 
-	// Check finish reason
-	finishReason, _ := choice["finish_reason"].(string)
-	if finishReason == "stop" || finishReason == "length" {
-		return buildClaudeChunk("message_stop", map[string]interface{}{})
+	var out []byte
+	if !t.started {
+		out = append(out, t.messageStart()...)
 	}
 
-	// Check for content delta
-	if content, ok := delta["content"].(string); ok {
-		return buildClaudeContentDelta(content)
+	choice := chunk.Get("choices.0")
+	delta := choice.Get("delta")
+
+	// A chunk may carry the last reasoning fragment together with the first content one
+	if reasoning := delta.Get("reasoning_content").String(); reasoning != "" {
+		out = append(out, t.ensureBlock("thinking", map[string]interface{}{
+			"type":     "thinking",
+			"thinking": "",
+		})...)
+		out = append(out, t.blockDelta(map[string]interface{}{
+			"type":     "thinking_delta",
+			"thinking": reasoning,
+		})...)
 	}
 
-	// Check for role (message start)
-	if role, ok := delta["role"].(string); ok && role == "assistant" {
-		return buildClaudeChunk("message_start", map[string]interface{}{
-			"message": map[string]interface{}{
-				"role": "assistant",
-			},
-		})
+	if content := delta.Get("content").String(); content != "" {
+		out = append(out, t.ensureBlock("text", map[string]interface{}{
+			"type": "text",
+			"text": "",
+		})...)
+		out = append(out, t.blockDelta(map[string]interface{}{
+			"type": "text_delta",
+			"text": content,
+		})...)
+	}
+
+	for _, toolCall := range delta.Get("tool_calls").Array() {
+		out = append(out, t.translateToolCall(toolCall)...)
+	}
+
+	// The final chunk may carry a last delta along with the finish reason; it is sent first
+	if finishReason := choice.Get("finish_reason").String(); finishReason != "" {
+		t.stopReason = streamStopReasons[finishReason]
+		out = append(out, t.Finish()...)
 	}
 
-	return chunk
+	return out
 }
 
-// buildClaudeContentDelta creates a Claude content_block_delta event
-func buildClaudeContentDelta(text string) []byte {
-	event := map[string]interface{}{
-		"type":  "content_block_delta",
-		"index": 0,
+// Finish closes any open content block and emits message_delta and message_stop.
+// Safe to call more than once; only the first call after the message started emits events.
+func (t *StreamTranslator) Finish() []byte {
+	if !t.started || t.finished {
+		return nil
+	}
+	t.finished = true
+
+	out := t.closeBlock()
+
+	stopReason := t.stopReason
+	if t.sawToolUse && (stopReason == "" || stopReason == "end_turn") {
+		stopReason = "tool_use"
+	}
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+
+	out = append(out, buildClaudeChunk("message_delta", map[string]interface{}{
 		"delta": map[string]interface{}{
-			"type": "text_delta",
-			"text": text,
+			"stop_reason":   stopReason,
+			"stop_sequence": nil,
+		},
+		"usage": map[string]interface{}{
+			"output_tokens": t.outputTokens,
 		},
+	})...)
+	out = append(out, buildClaudeChunk("message_stop", map[string]interface{}{})...)
+
+	return out
+}
+
+// translateToolCall streams one delta.tool_calls entry into a tool_use block.
+// The first fragment of a call carries its id and name and opens the block; later fragments
+// of the same call append their arguments as partial_json. Calls stream one after another,
+// so fragments of a call whose block was already closed are dropped.
+func (t *StreamTranslator) translateToolCall(toolCall gjson.Result) []byte {
+	var out []byte
+
+	index := int(toolCall.Get("index").Int())
+	if id := toolCall.Get("id").String(); id != "" {
+		out = append(out, t.closeBlock()...)
+		out = append(out, t.openBlock("tool_use", map[string]interface{}{
+			"type":  "tool_use",
+			"id":    id,
+			"name":  toolCall.Get("function.name").String(),
+			"input": map[string]interface{}{},
+		})...)
+		t.toolCall = index
+		t.sawToolUse = true
+	}
+
+	if !t.blockOpen || t.blockType != "tool_use" || t.toolCall != index {
+		return out
 	}
+	if args := toolCall.Get("function.arguments").String(); args != "" {
+		out = append(out, t.blockDelta(map[string]interface{}{
+			"type":         "input_json_delta",
+			"partial_json": args,
+		})...)
+	}
+	return out
+}
 
-	data, _ := json.Marshal(event)
-	return []byte(fmt.Sprintf("event: content_block_delta\ndata: %s\n\n", data))
+// messageStart emits the message_start event
+func (t *StreamTranslator) messageStart() []byte {
+	t.started = true
+	return buildClaudeChunk("message_start", map[string]interface{}{
+		"message": map[string]interface{}{
+			"id":            t.messageID,
+			"type":          "message",
+			"role":          "assistant",
+			"model":         t.model,
+			"content":       []interface{}{},
+			"stop_reason":   nil,
+			"stop_sequence": nil,
+			"usage": map[string]interface{}{
+				"input_tokens":  t.inputTokens,
+				"output_tokens": 0,
+			},
+		},
+	})
+}
+
+// ensureBlock keeps the current block if it has the same type, otherwise starts a new one
+func (t *StreamTranslator) ensureBlock(blockType string, contentBlock map[string]interface{}) []byte {
+	if t.blockOpen && t.blockType == blockType {
+		return nil
+	}
+	out := t.closeBlock()
+	return append(out, t.openBlock(blockType, contentBlock)...)
+}
+
+// openBlock emits content_block_start for the next block index
+func (t *StreamTranslator) openBlock(blockType string, contentBlock map[string]interface{}) []byte {
+	t.blockIndex++
+	t.blockOpen = true
+	t.blockType = blockType
+	return buildClaudeChunk("content_block_start", map[string]interface{}{
+		"index":         t.blockIndex,
+		"content_block": contentBlock,
+	})
+}
+
+// blockDelta emits content_block_delta for the current block
+func (t *StreamTranslator) blockDelta(delta map[string]interface{}) []byte {
+	return buildClaudeChunk("content_block_delta", map[string]interface{}{
+		"index": t.blockIndex,
+		"delta": delta,
+	})
+}
+
+// closeBlock emits content_block_stop if a block is open
+func (t *StreamTranslator) closeBlock() []byte {
+	if !t.blockOpen {
+		return nil
+	}
+	t.blockOpen = false
+	return buildClaudeChunk("content_block_stop", map[string]interface{}{
+		"index": t.blockIndex,
+	})
 }
 
 // buildClaudeChunk creates a Claude SSE event
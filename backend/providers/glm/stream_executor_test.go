@@ -0,0 +1,49 @@
+package glm
+
+import (
+	"strings"
+	"testing"
+)
+
+const glmSSE = "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Hi\"}}]}\n\n" +
+	"data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+	"data: [DONE]\n\n"
+
+// readChunks runs readGLMStream over glmSSE and collects what it emits
+func readChunks(t *testing.T, translator *StreamTranslator) []string {
+	dataCh := make(chan []byte, 10)
+	if err := readGLMStream(strings.NewReader(glmSSE), dataCh, translator); err != nil {
+		t.Fatalf("readGLMStream() error = %v", err)
+	}
+	close(dataCh)
+
+	var chunks []string
+	for chunk := range dataCh {
+		chunks = append(chunks, string(chunk))
+	}
+	return chunks
+}
+
+func TestReadGLMStream_OpenAIClientGetsChunksAsIs(t *testing.T) {
+	chunks := readChunks(t, nil)
+
+	want := []string{
+		`{"choices":[{"delta":{"role":"assistant","content":"Hi"}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+	}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("chunks = %q, want %q", chunks, want)
+	}
+}
+
+func TestReadGLMStream_ClaudeClientGetsClaudeEvents(t *testing.T) {
+	chunks := readChunks(t, NewStreamTranslator("glm-4-plus"))
+
+	joined := strings.Join(chunks, "")
+	if !strings.Contains(joined, "event: content_block_delta") || !strings.Contains(joined, "event: message_stop") {
+		t.Errorf("chunks = %q, want Claude events", chunks)
+	}
+	if strings.Contains(joined, `"choices"`) {
+		t.Errorf("chunks = %q, want no raw GLM chunks", chunks)
+	}
+}
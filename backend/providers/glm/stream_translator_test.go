@@ -6,131 +6,291 @@ import (
 	"testing"
 )
 
-func TestTranslateGLMStreamToClaude_ContentDelta(t *testing.T) {
-	glmChunk := `{"choices":[{"delta":{"content":"Hello from GLM"}}]}`
+// parseSSEEvents splits translated output into (event, data) pairs
+func parseSSEEvents(t *testing.T, out []byte) ([]string, []map[string]interface{}) {
+	var names []string
+	var payloads []map[string]interface{}
 
-	result := TranslateGLMStreamToClaude([]byte(glmChunk))
+	for _, raw := range strings.Split(strings.TrimSpace(string(out)), "\n\n") {
+		lines := strings.SplitN(raw, "\n", 2)
+		if len(lines) != 2 {
+			t.Fatalf("malformed SSE event: %q", raw)
+		}
 
-	resultStr := string(result)
-	if !strings.Contains(resultStr, "event: content_block_delta") {
-		t.Error("Missing content_block_delta event")
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &data); err != nil {
+			t.Fatalf("invalid event JSON: %v", err)
+		}
+
+		names = append(names, strings.TrimPrefix(lines[0], "event: "))
+		payloads = append(payloads, data)
+	}
+
+	return names, payloads
+}
+
+// translateAll runs chunks through a fresh translator and parses the events it emits
+func translateAll(t *testing.T, chunks ...string) ([]string, []map[string]interface{}) {
+	translator := NewStreamTranslator("glm-4-plus")
+
+	var out []byte
+	for _, chunk := range chunks {
+		out = append(out, translator.Translate([]byte(chunk))...)
+	}
+	// Finish after an explicit finish_reason must not emit anything more
+	out = append(out, translator.Finish()...)
+
+	return parseSSEEvents(t, out)
+}
+
+func TestStreamTranslator_TextLifecycle(t *testing.T) {
+	names, payloads := translateAll(t,
+		`{"choices":[{"delta":{"role":"assistant","content":""}}]}`,
+		`{"choices":[{"delta":{"content":"Hello"}}]}`,
+		`{"choices":[{"delta":{"content":" from GLM"}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":42}}`,
+	)
+
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
 	}
-	if !strings.Contains(resultStr, "Hello from GLM") {
-		t.Error("Missing content text")
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v\nwant     %v", names, want)
 	}
-	if !strings.Contains(resultStr, "text_delta") {
-		t.Error("Missing text_delta type")
+
+	message := payloads[0]["message"].(map[string]interface{})
+	if !strings.HasPrefix(message["id"].(string), "msg_") {
+		t.Errorf("message.id = %v, want msg_ prefix", message["id"])
+	}
+	if message["model"] != "glm-4-plus" || message["role"] != "assistant" {
+		t.Errorf("message = %v, want an assistant message for glm-4-plus", message)
+	}
+
+	for i := 1; i <= 4; i++ {
+		if payloads[i]["index"] != float64(0) {
+			t.Errorf("%s index = %v, want 0", names[i], payloads[i]["index"])
+		}
+	}
+	if block := payloads[1]["content_block"].(map[string]interface{}); block["type"] != "text" {
+		t.Errorf("content_block = %v, want text", block)
+	}
+	if delta := payloads[2]["delta"].(map[string]interface{}); delta["type"] != "text_delta" || delta["text"] != "Hello" {
+		t.Errorf("first delta = %v, want text_delta Hello", delta)
+	}
+
+	messageDelta := payloads[5]
+	if messageDelta["delta"].(map[string]interface{})["stop_reason"] != "end_turn" {
+		t.Errorf("stop_reason = %v, want end_turn", messageDelta["delta"])
+	}
+	if messageDelta["usage"].(map[string]interface{})["output_tokens"] != float64(42) {
+		t.Errorf("usage = %v, want output_tokens 42", messageDelta["usage"])
 	}
 }
 
-func TestTranslateGLMStreamToClaude_MessageStart(t *testing.T) {
-	glmChunk := `{"choices":[{"delta":{"role":"assistant"}}]}`
+func TestStreamTranslator_ToolUseAfterText(t *testing.T) {
+	names, payloads := translateAll(t,
+		`{"choices":[{"delta":{"content":"Checking"}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Jakarta\"}"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+	)
+
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_delta", "content_block_stop",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v\nwant     %v", names, want)
+	}
+
+	first := payloads[4]["content_block"].(map[string]interface{})
+	if payloads[4]["index"] != float64(1) || first["id"] != "call_1" || first["name"] != "get_weather" {
+		t.Errorf("first tool_use start = %v, want call_1 get_weather at index 1", payloads[4])
+	}
+	args := payloads[5]["delta"].(map[string]interface{})["partial_json"].(string) +
+		payloads[6]["delta"].(map[string]interface{})["partial_json"].(string)
+	if args != `{"city":"Jakarta"}` {
+		t.Errorf("partial_json = %q, want the concatenated arguments", args)
+	}
+
+	second := payloads[8]["content_block"].(map[string]interface{})
+	if payloads[8]["index"] != float64(2) || second["id"] != "call_2" {
+		t.Errorf("second tool_use start = %v, want call_2 at index 2", payloads[8])
+	}
+	if payloads[11]["delta"].(map[string]interface{})["stop_reason"] != "tool_use" {
+		t.Errorf("stop_reason = %v, want tool_use", payloads[11]["delta"])
+	}
+}
 
-	result := TranslateGLMStreamToClaude([]byte(glmChunk))
+func TestStreamTranslator_ToolUseWithoutText(t *testing.T) {
+	names, payloads := translateAll(t,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`,
+	)
 
-	resultStr := string(result)
-	if !strings.Contains(resultStr, "event: message_start") {
-		t.Error("Missing message_start event")
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v\nwant     %v", names, want)
+	}
+	if payloads[1]["index"] != float64(0) {
+		t.Errorf("tool_use index = %v, want 0 when no text block precedes it", payloads[1]["index"])
 	}
 }
 
-func TestTranslateGLMStreamToClaude_MessageStop(t *testing.T) {
+func TestStreamTranslator_FinishStopReason(t *testing.T) {
 	tests := []struct {
-		name         string
 		finishReason string
+		wantStop     string
 	}{
-		{"stop", "stop"},
-		{"length", "length"},
+		{"stop", "end_turn"},
+		{"length", "max_tokens"},
+		{"tool_calls", "tool_use"},
+		{"sensitive", "refusal"},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			glmChunk := `{"choices":[{"delta":{},"finish_reason":"` + tt.finishReason + `"}]}`
-
-			result := TranslateGLMStreamToClaude([]byte(glmChunk))
+		t.Run(tt.finishReason, func(t *testing.T) {
+			names, payloads := translateAll(t, `{"choices":[{"delta":{},"finish_reason":"`+tt.finishReason+`"}]}`)
 
-			resultStr := string(result)
-			if !strings.Contains(resultStr, "event: message_stop") {
-				t.Errorf("Missing message_stop event for finish_reason=%s", tt.finishReason)
+			want := []string{"message_start", "message_delta", "message_stop"}
+			if strings.Join(names, ",") != strings.Join(want, ",") {
+				t.Fatalf("events = %v\nwant     %v", names, want)
+			}
+			if got := payloads[1]["delta"].(map[string]interface{})["stop_reason"]; got != tt.wantStop {
+				t.Errorf("stop_reason = %v, want %s", got, tt.wantStop)
 			}
 		})
 	}
 }
 
-func TestTranslateGLMStreamToClaude_EmptyChoices(t *testing.T) {
-	glmChunk := `{"choices":[]}`
+func TestStreamTranslator_DeltaWithFinishReason(t *testing.T) {
+	tests := []struct {
+		name  string
+		delta string
+		want  []string
+	}{
+		{"content", `{"content":"last words"}`, []string{
+			"message_start", "content_block_start", "content_block_delta", "content_block_stop", "message_delta", "message_stop",
+		}},
+		{"tool call", `{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"f","arguments":"{}"}}]}`, []string{
+			"message_start", "content_block_start", "content_block_delta", "content_block_stop", "message_delta", "message_stop",
+		}},
+	}
 
-	result := TranslateGLMStreamToClaude([]byte(glmChunk))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, _ := translateAll(t, `{"choices":[{"delta":`+tt.delta+`,"finish_reason":"stop"}]}`)
 
-	resultStr := string(result)
-	if !strings.Contains(resultStr, "message_start") {
-		t.Error("Should return message_start for empty choices")
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("events = %v\nwant     %v", names, tt.want)
+			}
+		})
 	}
 }
 
-func TestTranslateGLMStreamToClaude_InvalidJSON(t *testing.T) {
-	invalidJSON := `not valid json`
+func TestStreamTranslator_ChunksWithoutChoices(t *testing.T) {
+	translator := NewStreamTranslator("glm-4-plus")
 
-	result := TranslateGLMStreamToClaude([]byte(invalidJSON))
+	names, _ := parseSSEEvents(t, translator.Translate([]byte(`{"choices":[]}`)))
+	if strings.Join(names, ",") != "message_start" {
+		t.Errorf("first chunk events = %v, want [message_start]", names)
+	}
+	if out := translator.Translate([]byte(`{"choices":[]}`)); out != nil {
+		t.Errorf("second chunk = %s, want no events", out)
+	}
+}
 
-	// Should return original input for invalid JSON
-	if string(result) != invalidJSON {
-		t.Errorf("Should return original input, got: %s", string(result))
+func TestStreamTranslator_InvalidJSON(t *testing.T) {
+	if out := NewStreamTranslator("glm-4-plus").Translate([]byte(`not valid json`)); out != nil {
+		t.Errorf("Translate() = %s, want no events", out)
 	}
 }
 
-func TestBuildClaudeContentDelta_GLM(t *testing.T) {
-	result := buildClaudeContentDelta("GLM content")
+func TestStreamTranslator_FinishWithoutFinishReason(t *testing.T) {
+	translator := NewStreamTranslator("glm-4-plus")
 
-	resultStr := string(result)
+	out := translator.Translate([]byte(`{"choices":[{"delta":{"content":"cut off"}}]}`))
+	out = append(out, translator.Finish()...)
 
-	// Check SSE format
-	if !strings.HasPrefix(resultStr, "event: content_block_delta\n") {
-		t.Error("Missing event prefix")
+	names, payloads := parseSSEEvents(t, out)
+	want := []string{
+		"message_start",
+		"content_block_start", "content_block_delta", "content_block_stop",
+		"message_delta", "message_stop",
 	}
-	if !strings.Contains(resultStr, "data: ") {
-		t.Error("Missing data prefix")
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("events = %v\nwant     %v", names, want)
 	}
-
-	// Extract and verify JSON
-	dataStart := strings.Index(resultStr, "data: ") + 6
-	dataEnd := strings.LastIndex(resultStr, "\n\n")
-	jsonData := resultStr[dataStart:dataEnd]
-
-	var event map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonData), &event); err != nil {
-		t.Fatalf("Invalid JSON: %v", err)
+	if payloads[4]["delta"].(map[string]interface{})["stop_reason"] != "end_turn" {
+		t.Errorf("stop_reason = %v, want end_turn", payloads[4]["delta"])
 	}
-
-	delta := event["delta"].(map[string]interface{})
-	if delta["text"] != "GLM content" {
-		t.Errorf("delta.text = %v, want 'GLM content'", delta["text"])
+	if more := translator.Finish(); more != nil {
+		t.Errorf("second Finish() = %s, want no events", more)
 	}
 }
 
-func TestBuildClaudeChunk_GLM(t *testing.T) {
-	data := map[string]interface{}{
-		"message": map[string]interface{}{
-			"role": "assistant",
-		},
-	}
-
-	result := buildClaudeChunk("message_start", data)
-
-	resultStr := string(result)
+func TestStreamTranslator_ReasoningThenContentThenToolUse(t *testing.T) {
+	names, payloads := translateAll(t,
+		`{"choices":[{"delta":{"role":"assistant","reasoning_content":"Let me"}}]}`,
+		`{"choices":[{"delta":{"reasoning_content":" think"}}]}`,
+		`{"choices":[{"delta":{"reasoning_content":"done","content":"Answer"}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"lookup","arguments":"{}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+	)
 
-	if !strings.Contains(resultStr, "event: message_start") {
-		t.Error("Missing message_start event")
+	want := []struct {
+		event string
+		index float64
+		kind  string // content_block type or delta type
+	}{
+		{"message_start", -1, ""},
+		{"content_block_start", 0, "thinking"},
+		{"content_block_delta", 0, "thinking_delta"},
+		{"content_block_delta", 0, "thinking_delta"},
+		{"content_block_delta", 0, "thinking_delta"},
+		{"content_block_stop", 0, ""},
+		{"content_block_start", 1, "text"},
+		{"content_block_delta", 1, "text_delta"},
+		{"content_block_stop", 1, ""},
+		{"content_block_start", 2, "tool_use"},
+		{"content_block_delta", 2, "input_json_delta"},
+		{"content_block_stop", 2, ""},
+		{"message_delta", -1, ""},
+		{"message_stop", -1, ""},
+	}
+	if len(names) != len(want) {
+		t.Fatalf("events = %v, want %d events", names, len(want))
 	}
 
-	dataStart := strings.Index(resultStr, "data: ") + 6
-	dataEnd := strings.LastIndex(resultStr, "\n\n")
-	jsonData := resultStr[dataStart:dataEnd]
-
-	var parsed map[string]interface{}
-	json.Unmarshal([]byte(jsonData), &parsed)
+	for i, w := range want {
+		if names[i] != w.event {
+			t.Fatalf("event %d = %s, want %s (events %v)", i, names[i], w.event, names)
+		}
+		if w.index >= 0 && payloads[i]["index"] != w.index {
+			t.Errorf("event %d (%s) index = %v, want %v", i, w.event, payloads[i]["index"], w.index)
+		}
 
-	if parsed["type"] != "message_start" {
-		t.Errorf("type = %v, want message_start", parsed["type"])
+		var kind interface{}
+		switch w.event {
+		case "content_block_start":
+			kind = payloads[i]["content_block"].(map[string]interface{})["type"]
+		case "content_block_delta":
+			kind = payloads[i]["delta"].(map[string]interface{})["type"]
+		default:
+			continue
+		}
+		if kind != w.kind {
+			t.Errorf("event %d (%s) type = %v, want %s", i, w.event, kind, w.kind)
+		}
 	}
 }
@@ -3,6 +3,8 @@ package glm
 import (
 	"fmt"
 
+	"aigateway-backend/providers"
+
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -10,13 +12,21 @@ import (
 // TranslateClaudeToGLM converts Claude format to GLM OpenAI-compatible format
 // Handles system messages, tools, tool_result, and multimodal content
 func TranslateClaudeToGLM(payload []byte, model string) []byte {
+	// GLM has no prompt caching markers; content extraction drops them
+	providers.LogDiscardedCacheControl(payload, ProviderID)
+
 	result := string(payload)
 
 	// Convert messages first (includes tool_result and image translation)
 	result = convertMessages(payload, result)
 	// Then prepend system message
-	result = prependSystem(payload, result)
+	result = providers.PrependSystemMessage(payload, result)
 	result = convertTools(payload, result)
+	result = providers.ApplyToolChoice(payload, result)
+	result = providers.ApplyParallelToolCalls(payload, result)
+	// GLM has no tier selection; service_tier only influences account pool routing
+	result = providers.DropServiceTier(result)
+	result = providers.ApplyProviderParams(payload, result, ProviderID, "", providers.ChatCompletionsOwnedParams...)
 
 	if !gjson.GetBytes(payload, "stream").Exists() {
 		result, _ = sjson.Set(result, "stream", false)
@@ -25,34 +35,6 @@ func TranslateClaudeToGLM(payload []byte, model string) []byte {
 	return []byte(result)
 }
 
-// prependSystem prepends system message to already-converted messages array
-func prependSystem(payload []byte, result string) string {
-	systemResult := gjson.GetBytes(payload, "system")
-	if !systemResult.Exists() {
-		return result
-	}
-
-	systemContent := extractTextContent(systemResult)
-	result, _ = sjson.Delete(result, "system")
-	if systemContent == "" {
-		return result
-	}
-
-	systemMsg := `{"role":"system","content":""}`
-	systemMsg, _ = sjson.Set(systemMsg, "content", systemContent)
-
-	// Get already-converted messages from result
-	messagesResult := gjson.Get(result, "messages")
-	if messagesResult.IsArray() {
-		newMessages := "[" + systemMsg + "]"
-		for _, msg := range messagesResult.Array() {
-			newMessages, _ = sjson.SetRaw(newMessages, "-1", msg.Raw)
-		}
-		result, _ = sjson.SetRaw(result, "messages", newMessages)
-	}
-	return result
-}
-
 // convertMessages translates messages array including tool_result and images
 func convertMessages(payload []byte, result string) string {
 	messagesResult := gjson.GetBytes(payload, "messages")
@@ -89,16 +71,16 @@ func convertMessage(msg gjson.Result) string {
 			return convertToolResultMessage(blocks[0])
 		}
 
-		// Check for multimodal content (has images)
-		hasImage := false
+		// Check for multimodal content (has images or documents)
+		hasMedia := false
 		for _, block := range blocks {
-			if block.Get("type").String() == "image" {
-				hasImage = true
+			if blockType := block.Get("type").String(); blockType == "image" || blockType == providers.ContentTypeDocument {
+				hasMedia = true
 				break
 			}
 		}
 
-		if hasImage {
+		if hasMedia {
 			// Multimodal: convert to OpenAI content array
 			contentArray := "[]"
 			for _, block := range blocks {
@@ -145,11 +127,16 @@ func convertToolResultMessage(block gjson.Result) string {
 		toolMsg, _ = sjson.SetRaw(toolMsg, "content", toolContent.Raw)
 	}
 
+	// Tool messages have no error flag; mark the content instead
+	if providers.ToolResultIsError(block) {
+		toolMsg, _ = sjson.Set(toolMsg, "content", providers.AnnotateToolError(gjson.Get(toolMsg, "content").String()))
+	}
+
 	return toolMsg
 }
 
 // translateContentPart converts Claude content block to GLM/OpenAI format
-// Handles text and image types
+// Handles text, image and plain-text document types
 func translateContentPart(block gjson.Result) string {
 	blockType := block.Get("type").String()
 
@@ -183,6 +170,14 @@ func translateContentPart(block gjson.Result) string {
 			return part
 		}
 
+	case providers.ContentTypeDocument:
+		// Only plain-text documents reach here; PDFs are rejected by CheckDocuments
+		if text, ok := providers.DocumentText(block); ok {
+			part := `{"type":"text","text":""}`
+			part, _ = sjson.Set(part, "text", text)
+			return part
+		}
+
 	default:
 		// Unknown type: return as text
 		part := `{"type":"text","text":"[unsupported content]"}`
@@ -2,14 +2,15 @@ package glm
 
 import (
 	"encoding/json"
-	"fmt"
+
+	"aigateway-backend/providers"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
 // TranslateGLMToClaude converts GLM response to Claude format
-// Handles text content, tool_calls (tool_use), and usage statistics
+// Handles reasoning_content (thinking), text content, tool_calls (tool_use), and usage statistics
 func TranslateGLMToClaude(payload []byte) []byte {
 	result := `{"role":"assistant","content":[]}`
 	choice := gjson.GetBytes(payload, "choices.0")
@@ -33,6 +34,7 @@ func TranslateGLMToClaude(payload []byte) []byte {
 		"length":        "max_tokens",
 		"tool_calls":    "tool_use",
 		"function_call": "tool_use",
+		"sensitive":     "refusal", // GLM content filter
 	}
 	stopReason := stopMap[choice.Get("finish_reason").String()]
 	if stopReason == "" {
@@ -50,32 +52,35 @@ func TranslateGLMToClaude(payload []byte) []byte {
 		result, _ = sjson.Set(result, "model", model.String())
 	}
 
+	// Assign a unique Claude-style ID
+	result, _ = sjson.Set(result, "id", providers.NewMessageID())
+
 	return []byte(result)
 }
 
 // buildContentArray constructs Claude content array from GLM message
-// Handles text content and tool_calls
+// Handles reasoning_content (thinking), text content and tool_calls
 func buildContentArray(message gjson.Result, claudeResponse string) string {
 	contentArray := "[]"
-	contentIndex := 0
+
+	// Reasoning models (GLM-4.5+) return their reasoning in reasoning_content; it precedes the text
+	if reasoning := message.Get("reasoning_content").String(); reasoning != "" {
+		thinkingBlock := `{"type":"thinking","thinking":""}`
+		thinkingBlock, _ = sjson.Set(thinkingBlock, "thinking", reasoning)
+		contentArray, _ = sjson.SetRaw(contentArray, "-1", thinkingBlock)
+	}
 
 	// Add text content if present
 	content := message.Get("content")
 	if content.Exists() && content.String() != "" {
 		textBlock := `{"type":"text","text":""}`
 		textBlock, _ = sjson.Set(textBlock, "text", content.String())
-		contentArray, _ = sjson.SetRaw(contentArray, "0", textBlock)
-		contentIndex = 1
+		contentArray, _ = sjson.SetRaw(contentArray, "-1", textBlock)
 	}
 
-	// Handle tool_calls - convert to tool_use blocks
-	toolCalls := message.Get("tool_calls")
-	if toolCalls.IsArray() && len(toolCalls.Array()) > 0 {
-		for _, toolCall := range toolCalls.Array() {
-			toolUseBlock := buildToolUseBlock(toolCall)
-			contentArray, _ = sjson.SetRaw(contentArray, fmt.Sprintf("%d", contentIndex), toolUseBlock)
-			contentIndex++
-		}
+	// Handle tool_calls - convert to tool_use blocks (appended after text)
+	for _, toolCall := range message.Get("tool_calls").Array() {
+		contentArray, _ = sjson.SetRaw(contentArray, "-1", buildToolUseBlock(toolCall))
 	}
 
 	claudeResponse, _ = sjson.SetRaw(claudeResponse, "content", contentArray)
@@ -2,6 +2,8 @@ package glm
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -34,6 +36,28 @@ func TestTranslateClaudeToGLM_ToolResult(t *testing.T) {
 	}
 }
 
+func TestTranslateClaudeToGLM_ToolResultError(t *testing.T) {
+	claudeReq := `{
+		"messages": [
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "call_123", "is_error": true, "content": "permission denied"}]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "call_456", "is_error": false, "content": "ok"}]}
+		]
+	}`
+
+	result := TranslateClaudeToGLM([]byte(claudeReq), "glm-4")
+
+	var glmReq map[string]interface{}
+	json.Unmarshal(result, &glmReq)
+
+	messages := glmReq["messages"].([]interface{})
+	if got := messages[0].(map[string]interface{})["content"]; got != "[Tool error] permission denied" {
+		t.Errorf("failed tool content = %v, want the error marked", got)
+	}
+	if got := messages[1].(map[string]interface{})["content"]; got != "ok" {
+		t.Errorf("successful tool content = %v, want unchanged", got)
+	}
+}
+
 func TestTranslateClaudeToGLM_ImageContent(t *testing.T) {
 	claudeReq := `{
 		"messages": [{
@@ -138,6 +162,103 @@ func TestTranslateClaudeToGLM_ModelSet(t *testing.T) {
 	}
 }
 
+func TestTranslateClaudeToGLM_ProviderParams(t *testing.T) {
+	claudeReq := `{
+		"messages": [{"role": "user", "content": "Hi"}],
+		"provider_params": {
+			"glm": {"do_sample": false, "thinking": {"type": "enabled"}},
+			"antigravity": {"generationConfig": {"seed": 7}}
+		}
+	}`
+
+	result := TranslateClaudeToGLM([]byte(claudeReq), "glm-4.6")
+
+	var glmReq map[string]interface{}
+	if err := json.Unmarshal(result, &glmReq); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if glmReq["do_sample"] != false {
+		t.Errorf("do_sample = %v, want false", glmReq["do_sample"])
+	}
+	thinking, _ := glmReq["thinking"].(map[string]interface{})
+	if thinking["type"] != "enabled" {
+		t.Errorf("thinking = %v, want type=enabled", glmReq["thinking"])
+	}
+	if _, ok := glmReq["provider_params"]; ok {
+		t.Error("provider_params should be removed from upstream payload")
+	}
+	if _, ok := glmReq["generationConfig"]; ok {
+		t.Error("other providers' params should not be merged")
+	}
+}
+
+func TestTranslateClaudeToGLM_ProviderParamsCannotOverrideOwnedFields(t *testing.T) {
+	claudeReq := `{
+		"messages": [{"role": "user", "content": "Hi"}],
+		"provider_params": {
+			"glm": {
+				"model": "glm-other",
+				"stream": true,
+				"messages": [],
+				"tools": [{"type": "web_search"}],
+				"do_sample": false
+			}
+		}
+	}`
+
+	result := TranslateClaudeToGLM([]byte(claudeReq), "glm-4.6")
+
+	var glmReq map[string]interface{}
+	if err := json.Unmarshal(result, &glmReq); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if glmReq["model"] != "glm-4.6" {
+		t.Errorf("model = %v, want glm-4.6", glmReq["model"])
+	}
+	if glmReq["stream"] != false {
+		t.Errorf("stream = %v, want false", glmReq["stream"])
+	}
+	if messages, _ := glmReq["messages"].([]interface{}); len(messages) != 1 {
+		t.Errorf("messages = %v, want the translated user message", glmReq["messages"])
+	}
+	if _, ok := glmReq["tools"]; ok {
+		t.Errorf("tools = %v, want none", glmReq["tools"])
+	}
+	if glmReq["do_sample"] != false {
+		t.Errorf("do_sample = %v, want false from provider_params", glmReq["do_sample"])
+	}
+}
+
+func TestTranslateClaudeToGLM_SystemArrayWithCacheControl(t *testing.T) {
+	claudeReq := `{
+		"system": [
+			{"type": "text", "text": "You are a coding assistant."},
+			{"type": "text", "text": "Project context: Go backend.", "cache_control": {"type": "ephemeral"}}
+		],
+		"messages": [{"role": "user", "content": "Hi"}]
+	}`
+
+	result := TranslateClaudeToGLM([]byte(claudeReq), "glm-4.6")
+	if !json.Valid(result) {
+		t.Fatalf("invalid JSON output: %s", result)
+	}
+
+	var glmReq map[string]interface{}
+	json.Unmarshal(result, &glmReq)
+
+	messages := glmReq["messages"].([]interface{})
+	systemMsg := messages[0].(map[string]interface{})
+	want := "You are a coding assistant.\nProject context: Go backend."
+	if systemMsg["content"] != want {
+		t.Errorf("system content = %q, want %q", systemMsg["content"], want)
+	}
+	if strings.Contains(string(result), "cache_control") {
+		t.Errorf("cache_control should be stripped: %s", result)
+	}
+}
+
 // Response Translation Tests
 
 func TestTranslateGLMToClaude_TextContent(t *testing.T) {
@@ -228,6 +349,69 @@ func TestTranslateGLMToClaude_ToolCalls(t *testing.T) {
 	}
 }
 
+func TestTranslateGLMToClaude_TextWithMultipleToolCalls(t *testing.T) {
+	glmResp := `{
+		"choices": [{
+			"message": {
+				"role": "assistant",
+				"content": "Checking both cities",
+				"tool_calls": [
+					{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Jakarta\"}"}},
+					{"id": "call_2", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Bandung\"}"}}
+				]
+			},
+			"finish_reason": "tool_calls"
+		}]
+	}`
+
+	var claudeResp map[string]interface{}
+	if err := json.Unmarshal(TranslateGLMToClaude([]byte(glmResp)), &claudeResp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	content := claudeResp["content"].([]interface{})
+	if len(content) != 3 {
+		t.Fatalf("content length = %d, want 3", len(content))
+	}
+
+	if block := content[0].(map[string]interface{}); block["type"] != "text" || block["text"] != "Checking both cities" {
+		t.Errorf("content[0] = %v, want text block", block)
+	}
+
+	wantCities := []string{"Jakarta", "Bandung"}
+	for i, city := range wantCities {
+		block := content[i+1].(map[string]interface{})
+		if block["type"] != "tool_use" || block["id"] != fmt.Sprintf("call_%d", i+1) {
+			t.Errorf("content[%d] = %v, want tool_use call_%d", i+1, block, i+1)
+			continue
+		}
+		input := block["input"].(map[string]interface{})
+		if input["city"] != city {
+			t.Errorf("content[%d].input.city = %v, want %s", i+1, input["city"], city)
+		}
+	}
+}
+
+func TestTranslateGLMToClaude_UniqueMessageID(t *testing.T) {
+	glmResp := `{
+		"id": "glm-upstream-1",
+		"choices": [{"message": {"role": "assistant", "content": "Hi"}, "finish_reason": "stop"}]
+	}`
+
+	var firstResp, secondResp map[string]interface{}
+	json.Unmarshal(TranslateGLMToClaude([]byte(glmResp)), &firstResp)
+	json.Unmarshal(TranslateGLMToClaude([]byte(glmResp)), &secondResp)
+
+	firstID, _ := firstResp["id"].(string)
+	secondID, _ := secondResp["id"].(string)
+	if !strings.HasPrefix(firstID, "msg_") || !strings.HasPrefix(secondID, "msg_") {
+		t.Errorf("ids = %q, %q, want msg_ prefix", firstID, secondID)
+	}
+	if firstID == secondID {
+		t.Errorf("ids should be unique, both = %q", firstID)
+	}
+}
+
 func TestTranslateOpenAIToGLM(t *testing.T) {
 	openaiReq := `{"model": "gpt-4", "messages": [{"role": "user", "content": "Hi"}]}`
 
@@ -241,3 +425,150 @@ func TestTranslateOpenAIToGLM(t *testing.T) {
 		t.Errorf("model = %v, want 'glm-4'", glmReq["model"])
 	}
 }
+
+func TestTranslateRequest_PDFDocumentRejected(t *testing.T) {
+	claudeReq := `{"messages": [{"role": "user", "content": [
+		{"type": "document", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERi0xLjQ="}},
+		{"type": "text", "text": "Summarize this"}
+	]}]}`
+
+	_, err := NewProvider().TranslateRequest("claude", []byte(claudeReq), "glm-4")
+	if err == nil {
+		t.Fatal("expected error for PDF document on GLM")
+	}
+	if !strings.Contains(err.Error(), "application/pdf") || !strings.Contains(err.Error(), "glm-4") {
+		t.Errorf("error = %q, want it to name the media type and model", err.Error())
+	}
+}
+
+func TestTranslateClaudeToGLM_TextDocument(t *testing.T) {
+	claudeReq := `{"messages": [{"role": "user", "content": [
+		{"type": "document", "source": {"type": "text", "media_type": "text/plain", "data": "quarterly numbers"}},
+		{"type": "text", "text": "Summarize this"}
+	]}]}`
+
+	result := TranslateClaudeToGLM([]byte(claudeReq), "glm-4")
+
+	var glmReq map[string]interface{}
+	json.Unmarshal(result, &glmReq)
+
+	content := glmReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+	if got := content[0].(map[string]interface{})["text"]; got != "quarterly numbers" {
+		t.Errorf("content[0].text = %v, want document text", got)
+	}
+}
+
+func TestTranslateClaudeToGLM_DropsServiceTier(t *testing.T) {
+	claudeReq := `{"service_tier": "auto", "messages": [{"role": "user", "content": "Hi"}]}`
+
+	result := TranslateClaudeToGLM([]byte(claudeReq), "glm-4")
+
+	if strings.Contains(string(result), "service_tier") {
+		t.Errorf("service_tier should be dropped, got %s", result)
+	}
+}
+
+func TestTranslateClaudeToGLM_ParallelToolCalls(t *testing.T) {
+	tools := `"tools": [{"name": "get_weather", "input_schema": {"type": "object"}}]`
+
+	tests := []struct {
+		name    string
+		request string
+		want    string // Expected parallel_tool_calls value, "" = absent
+	}{
+		{"openai flag", `{"parallel_tool_calls": false, ` + tools + `, "messages": []}`, "false"},
+		{"claude disable_parallel_tool_use", `{"tool_choice": {"type": "auto", "disable_parallel_tool_use": true}, ` + tools + `, "messages": []}`, "false"},
+		{"unset", `{` + tools + `, "messages": []}`, ""},
+		{"no tools", `{"parallel_tool_calls": false, "messages": []}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req map[string]interface{}
+			if err := json.Unmarshal(TranslateClaudeToGLM([]byte(tt.request), "glm-4"), &req); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+
+			got := ""
+			if v, ok := req["parallel_tool_calls"]; ok {
+				got = fmt.Sprint(v)
+			}
+			if got != tt.want {
+				t.Errorf("parallel_tool_calls = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateClaudeToGLM_ToolChoice(t *testing.T) {
+	tools := `"tools": [{"name": "get_weather", "input_schema": {"type": "object"}}]`
+
+	tests := []struct {
+		name    string
+		request string
+		want    string // Expected raw tool_choice JSON, "" = absent
+	}{
+		{"auto", `{"tool_choice": {"type": "auto"}, ` + tools + `, "messages": []}`, `"auto"`},
+		{"any", `{"tool_choice": {"type": "any"}, ` + tools + `, "messages": []}`, `"required"`},
+		{"named tool", `{"tool_choice": {"type": "tool", "name": "get_weather"}, ` + tools + `, "messages": []}`, `{"type":"function","function":{"name":"get_weather"}}`},
+		{"no tools", `{"tool_choice": {"type": "any"}, "messages": []}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req map[string]json.RawMessage
+			if err := json.Unmarshal(TranslateClaudeToGLM([]byte(tt.request), "glm-4"), &req); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if got := string(req["tool_choice"]); got != tt.want {
+				t.Errorf("tool_choice = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateClaudeToGLM_PreservesResponseFormat(t *testing.T) {
+	responseFormat := `{"type":"json_object"}`
+	claudeReq := `{"response_format": ` + responseFormat + `, "messages": [{"role": "user", "content": "Hi"}]}`
+
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(TranslateClaudeToGLM([]byte(claudeReq), "glm-4"), &req); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got := string(req["response_format"]); got != responseFormat {
+		t.Errorf("response_format = %s, want %s", got, responseFormat)
+	}
+}
+
+func TestTranslateGLMToClaude_ReasoningContent(t *testing.T) {
+	glmResp := `{
+		"choices": [{
+			"message": {"role": "assistant", "reasoning_content": "The user greets me.", "content": "Hello!"},
+			"finish_reason": "stop"
+		}],
+		"model": "glm-4.6"
+	}`
+
+	result := TranslateGLMToClaude([]byte(glmResp))
+
+	var claudeResp map[string]interface{}
+	json.Unmarshal(result, &claudeResp)
+
+	content := claudeResp["content"].([]interface{})
+	if len(content) != 2 {
+		t.Fatalf("content length = %d, want 2", len(content))
+	}
+
+	thinkingBlock := content[0].(map[string]interface{})
+	if thinkingBlock["type"] != "thinking" {
+		t.Errorf("content[0].type = %v, want 'thinking'", thinkingBlock["type"])
+	}
+	if thinkingBlock["thinking"] != "The user greets me." {
+		t.Errorf("content[0].thinking = %v, want 'The user greets me.'", thinkingBlock["thinking"])
+	}
+
+	textBlock := content[1].(map[string]interface{})
+	if textBlock["type"] != "text" || textBlock["text"] != "Hello!" {
+		t.Errorf("content[1] = %v, want text block 'Hello!'", textBlock)
+	}
+}
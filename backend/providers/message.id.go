@@ -0,0 +1,22 @@
+package providers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+)
+
+// MessageIDPrefix is the Claude convention for message identifiers
+const MessageIDPrefix = "msg_"
+
+// NewMessageID returns a unique Claude-style message ID for a translated response
+// Format: msg_{24 hex chars}
+func NewMessageID() string {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand never fails on supported platforms; fall back to a UUID anyway
+		return MessageIDPrefix + uuid.NewString()
+	}
+	return MessageIDPrefix + hex.EncodeToString(buf)
+}
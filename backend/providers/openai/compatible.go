@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"fmt"
+	"strings"
+
+	"aigateway-backend/providers"
+)
+
+// CompatibleType is the provider config type for self-hosted OpenAI-compatible upstreams
+const CompatibleType = "openai_compatible"
+
+// CompatibleConfig describes an upstream speaking the OpenAI chat completions API (vLLM, Ollama, Together)
+type CompatibleConfig struct {
+	ID         string   // Registry and account provider ID
+	Name       string   // Human-readable name, defaults to ID
+	BaseURL    string   // API base including version, e.g. http://localhost:8000/v1
+	Models     []string // Model names routed to this upstream
+	AuthHeader string   // Header carrying the API key; "" = Authorization: Bearer
+	APIKey     string   // Used when the account's auth data has no api_key
+}
+
+// NewCompatibleProvider creates a provider for an OpenAI-compatible upstream.
+// It reuses the OpenAI translators; accounts without any API key are sent unauthenticated.
+func NewCompatibleProvider(cfg CompatibleConfig) (*OpenAIProvider, error) {
+	if cfg.ID == "" {
+		return nil, fmt.Errorf("openai_compatible provider requires an id")
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("openai_compatible provider %s requires base_url", cfg.ID)
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = cfg.ID
+	}
+
+	return &OpenAIProvider{
+		id:          cfg.ID,
+		name:        name,
+		baseURL:     strings.TrimRight(cfg.BaseURL, "/"),
+		models:      cfg.Models,
+		authHeader:  cfg.AuthHeader,
+		apiKey:      cfg.APIKey,
+		keyOptional: true,
+		timeout:     providers.DefaultRequestTimeout,
+	}, nil
+}
+
+// RegisterCompatible creates an OpenAI-compatible provider and routes its models to it
+func RegisterCompatible(registry *providers.Registry, cfg CompatibleConfig) (*OpenAIProvider, error) {
+	provider, err := NewCompatibleProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if registry.Exists(cfg.ID) {
+		return nil, fmt.Errorf("provider %s is already registered", cfg.ID)
+	}
+
+	registry.Register(cfg.ID, provider)
+	registry.RegisterModels(cfg.ID, cfg.Models)
+	return provider, nil
+}
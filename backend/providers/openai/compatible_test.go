@@ -0,0 +1,115 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+)
+
+// compatibleUpstream serves chat completions and counts hits, recording the last auth header value
+func compatibleUpstream(t *testing.T, authHeader string) (*httptest.Server, *int32, *string) {
+	t.Helper()
+
+	var hits int32
+	var lastAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1"+EndpointChatCompletions {
+			t.Errorf("upstream path = %s, want /v1%s", r.URL.Path, EndpointChatCompletions)
+		}
+		atomic.AddInt32(&hits, 1)
+		lastAuth = r.Header.Get(authHeader)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits, &lastAuth
+}
+
+func TestCompatibleProviders_RouteToEachUpstream(t *testing.T) {
+	vllm, vllmHits, vllmAuth := compatibleUpstream(t, "Authorization")
+	ollama, ollamaHits, ollamaAuth := compatibleUpstream(t, "X-Api-Key")
+
+	registry := providers.NewRegistry()
+	registry.Register(ProviderID, NewOpenAIProvider())
+	if _, err := RegisterCompatible(registry, CompatibleConfig{ID: "vllm", BaseURL: vllm.URL + "/v1/", Models: []string{"Llama-3-70B"}}); err != nil {
+		t.Fatalf("RegisterCompatible(vllm) error = %v", err)
+	}
+	if _, err := RegisterCompatible(registry, CompatibleConfig{ID: "ollama", BaseURL: ollama.URL + "/v1", Models: []string{"qwen2.5", "gpt-oss"}, AuthHeader: "X-Api-Key", APIKey: "local-key"}); err != nil {
+		t.Fatalf("RegisterCompatible(ollama) error = %v", err)
+	}
+
+	tests := []struct {
+		model    string
+		wantID   string
+		wantHits *int32
+	}{
+		{"llama-3-70b", "vllm", vllmHits},
+		{"qwen2.5", "ollama", ollamaHits},
+		{"gpt-oss", "ollama", ollamaHits}, // Registered names win over the gpt- prefix
+	}
+	for _, tt := range tests {
+		provider, resolved, err := registry.GetByModel(tt.model)
+		if err != nil {
+			t.Fatalf("GetByModel(%q) error = %v", tt.model, err)
+		}
+		if provider.ID() != tt.wantID {
+			t.Fatalf("GetByModel(%q) routed to %s, want %s", tt.model, provider.ID(), tt.wantID)
+		}
+
+		before := atomic.LoadInt32(tt.wantHits)
+		resp, err := provider.Execute(context.Background(), &providers.ExecuteRequest{
+			Model:   resolved,
+			Payload: []byte(`{"messages":[]}`),
+			Account: &models.Account{ID: "acc-1", AuthData: `{"api_key":"account-key"}`},
+		})
+		if err != nil {
+			t.Fatalf("Execute(%q) error = %v", tt.model, err)
+		}
+		if resp.StatusCode != http.StatusOK || atomic.LoadInt32(tt.wantHits) != before+1 {
+			t.Errorf("Execute(%q) status = %d, upstream %s not hit", tt.model, resp.StatusCode, tt.wantID)
+		}
+	}
+
+	if *vllmAuth != "Bearer account-key" {
+		t.Errorf("vllm Authorization = %q, want the account key as bearer", *vllmAuth)
+	}
+	if *ollamaAuth != "account-key" {
+		t.Errorf("ollama X-Api-Key = %q, want the account key", *ollamaAuth)
+	}
+}
+
+func TestCompatibleProvider_KeylessAccount(t *testing.T) {
+	server, hits, auth := compatibleUpstream(t, "Authorization")
+	provider, err := NewCompatibleProvider(CompatibleConfig{ID: "local", BaseURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("NewCompatibleProvider() error = %v", err)
+	}
+
+	if _, err := provider.Execute(context.Background(), &providers.ExecuteRequest{
+		Model:   "llama3",
+		Payload: []byte(`{"messages":[]}`),
+		Account: &models.Account{ID: "acc-1", AuthData: `{}`},
+	}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if *hits != 1 || *auth != "" {
+		t.Errorf("hits = %d, Authorization = %q, want one unauthenticated request", *hits, *auth)
+	}
+}
+
+func TestRegisterCompatible_RejectsInvalidConfig(t *testing.T) {
+	registry := providers.NewRegistry()
+	registry.Register(ProviderID, NewOpenAIProvider())
+
+	if _, err := RegisterCompatible(registry, CompatibleConfig{ID: "vllm"}); err == nil {
+		t.Error("missing base_url should be rejected")
+	}
+	if _, err := RegisterCompatible(registry, CompatibleConfig{ID: ProviderID, BaseURL: "http://localhost/v1"}); err == nil {
+		t.Error("an ID clashing with a built-in provider should be rejected")
+	}
+}
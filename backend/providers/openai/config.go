@@ -26,3 +26,20 @@ var SupportedModels = []string{
 	"gpt-4-turbo",
 	"gpt-3.5-turbo",
 }
+
+// DocumentUnsupportedModels lists models known to reject file (PDF) content parts
+var DocumentUnsupportedModels = []string{
+	"gpt-4",
+	"gpt-4-turbo",
+	"gpt-3.5-turbo",
+}
+
+// SupportsDocuments reports whether a model accepts PDF file content parts
+func SupportsDocuments(model string) bool {
+	for _, m := range DocumentUnsupportedModels {
+		if m == model {
+			return false
+		}
+	}
+	return true
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"aigateway-backend/providers"
@@ -14,20 +15,20 @@ import (
 
 // HTTPRequest contains parameters for OpenAI HTTP request
 type HTTPRequest struct {
-	Model    string
-	Payload  []byte
-	Stream   bool
-	APIKey   string
-	ProxyURL string
+	Model      string
+	Payload    []byte
+	Stream     bool
+	BaseURL    string // Upstream API base, e.g. https://api.openai.com/v1
+	APIKey     string
+	AuthHeader string // Header carrying APIKey; "" = Authorization: Bearer
+	ProxyURL   string
+	Timeout    time.Duration
 }
 
 // executeHTTP performs the HTTP request to OpenAI API
 func executeHTTP(ctx context.Context, req *HTTPRequest) (*providers.ExecuteResponse, error) {
-	// Build endpoint URL
-	endpoint := BaseURL + EndpointChatCompletions
-	if req.Stream {
-		endpoint = BaseURL + EndpointChatCompletions // OpenAI uses same endpoint, streaming controlled by request body
-	}
+	// Build endpoint URL (OpenAI uses the same endpoint for streaming, controlled by the request body)
+	endpoint := req.BaseURL + EndpointChatCompletions
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(req.Payload))
@@ -37,11 +38,11 @@ func executeHTTP(ctx context.Context, req *HTTPRequest) (*providers.ExecuteRespo
 
 	// Set headers
 	httpReq.Header.Set("Content-Type", ContentType)
-	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
+	setAuthHeader(httpReq, req)
 	httpReq.Header.Set("User-Agent", UserAgent)
 
 	// Create HTTP client with optional proxy
-	client, err := createHTTPClient(req.ProxyURL)
+	client, err := createHTTPClient(req.ProxyURL, req.Timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
@@ -67,11 +68,24 @@ func executeHTTP(ctx context.Context, req *HTTPRequest) (*providers.ExecuteRespo
 		StatusCode: httpResp.StatusCode,
 		Payload:    body,
 		LatencyMs:  latencyMs,
+		Headers:    httpResp.Header,
 	}, nil
 }
 
+// setAuthHeader attaches the API key, skipping it for keyless upstreams
+func setAuthHeader(httpReq *http.Request, req *HTTPRequest) {
+	if req.APIKey == "" {
+		return
+	}
+	if req.AuthHeader == "" || strings.EqualFold(req.AuthHeader, "Authorization") {
+		httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
+		return
+	}
+	httpReq.Header.Set(req.AuthHeader, req.APIKey)
+}
+
 // createHTTPClient creates an HTTP client with optional proxy configuration
-func createHTTPClient(proxyURL string) (*http.Client, error) {
+func createHTTPClient(proxyURL string, timeout time.Duration) (*http.Client, error) {
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		IdleConnTimeout:     90 * time.Second,
@@ -89,6 +103,6 @@ func createHTTPClient(proxyURL string) (*http.Client, error) {
 
 	return &http.Client{
 		Transport: transport,
-		Timeout:   120 * time.Second, // 2 minute timeout
+		Timeout:   timeout,
 	}, nil
 }
@@ -4,26 +4,53 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"aigateway-backend/providers"
 )
 
 // OpenAIProvider implements the Provider interface for OpenAI API
-type OpenAIProvider struct{}
+type OpenAIProvider struct {
+	id                 string
+	name               string
+	baseURL            string
+	models             []string
+	authHeader         string        // Header carrying the API key; "" = Authorization: Bearer
+	apiKey             string        // Fallback key when the account has none
+	keyOptional        bool          // Upstream may be called without any API key
+	timeout            time.Duration // Outbound request timeout
+	maxToolResultChars int           // Truncate longer tool_result text before translation (0 = unlimited)
+}
 
 // NewOpenAIProvider creates a new OpenAI provider instance
 func NewOpenAIProvider() *OpenAIProvider {
-	return &OpenAIProvider{}
+	return &OpenAIProvider{
+		id:      ProviderID,
+		name:    "OpenAI",
+		baseURL: BaseURL,
+		models:  SupportedModels,
+		timeout: providers.DefaultRequestTimeout,
+	}
+}
+
+// SetTimeout sets the outbound request timeout
+func (p *OpenAIProvider) SetTimeout(timeout time.Duration) {
+	p.timeout = timeout
+}
+
+// SetMaxToolResultChars caps tool_result text forwarded upstream (0 = unlimited)
+func (p *OpenAIProvider) SetMaxToolResultChars(maxChars int) {
+	p.maxToolResultChars = maxChars
 }
 
 // ID returns the unique identifier for OpenAI provider
 func (p *OpenAIProvider) ID() string {
-	return ProviderID
+	return p.id
 }
 
 // Name returns the human-readable name
 func (p *OpenAIProvider) Name() string {
-	return "OpenAI"
+	return p.name
 }
 
 // AuthStrategy returns the authentication strategy identifier
@@ -33,13 +60,13 @@ func (p *OpenAIProvider) AuthStrategy() string {
 
 // SupportedModels returns the list of supported model identifiers
 func (p *OpenAIProvider) SupportedModels() []string {
-	return SupportedModels
+	return p.models
 }
 
 // TranslateRequest converts Claude format to OpenAI format
 func (p *OpenAIProvider) TranslateRequest(format string, payload []byte, model string) ([]byte, error) {
 	if format == "claude" || format == "anthropic" {
-		return ClaudeToOpenAI(payload, model)
+		return ClaudeToOpenAI(providers.TruncateToolResults(payload, p.maxToolResultChars), model)
 	}
 
 	// If already in OpenAI format or unknown, pass through
@@ -53,48 +80,28 @@ func (p *OpenAIProvider) TranslateResponse(payload []byte) ([]byte, error) {
 
 // Execute performs the API call to OpenAI
 func (p *OpenAIProvider) Execute(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
-	if req == nil {
-		return nil, fmt.Errorf("execute request cannot be nil")
-	}
-
-	if req.Account == nil {
-		return nil, fmt.Errorf("account cannot be nil")
-	}
-
-	// Extract API key from account auth data
-	var authData map[string]interface{}
-	if err := json.Unmarshal([]byte(req.Account.AuthData), &authData); err != nil {
-		return nil, fmt.Errorf("failed to parse auth data: %w", err)
+	httpReq, err := p.buildHTTPRequest(req)
+	if err != nil {
+		return nil, err
 	}
+	httpReq.Stream = req.Stream
 
-	apiKey, ok := authData["api_key"].(string)
-	if !ok || apiKey == "" {
-		// Fall back to Token field if api_key not in AuthData
-		if req.Token != "" {
-			apiKey = req.Token
-		} else {
-			return nil, fmt.Errorf("api_key not found in auth data")
-		}
-	}
+	return executeHTTP(ctx, httpReq)
+}
 
-	// Determine proxy URL
-	proxyURL := req.ProxyURL
-	if proxyURL == "" && req.Account.ProxyURL != "" {
-		proxyURL = req.Account.ProxyURL
+// ExecuteStream performs a streaming API call to OpenAI
+func (p *OpenAIProvider) ExecuteStream(ctx context.Context, req *providers.ExecuteRequest) (*providers.StreamResponse, error) {
+	httpReq, err := p.buildHTTPRequest(req)
+	if err != nil {
+		return nil, err
 	}
+	httpReq.Stream = true
 
-	// Execute HTTP request
-	return executeHTTP(ctx, &HTTPRequest{
-		Model:    req.Model,
-		Payload:  req.Payload,
-		Stream:   req.Stream,
-		APIKey:   apiKey,
-		ProxyURL: proxyURL,
-	})
+	return executeHTTPStream(ctx, httpReq)
 }
 
-// ExecuteStream performs a streaming API call to OpenAI
-func (p *OpenAIProvider) ExecuteStream(ctx context.Context, req *providers.ExecuteRequest) (*providers.StreamResponse, error) {
+// buildHTTPRequest resolves the API key and proxy for an execute request
+func (p *OpenAIProvider) buildHTTPRequest(req *providers.ExecuteRequest) (*HTTPRequest, error) {
 	if req == nil {
 		return nil, fmt.Errorf("execute request cannot be nil")
 	}
@@ -109,13 +116,16 @@ func (p *OpenAIProvider) ExecuteStream(ctx context.Context, req *providers.Execu
 		return nil, fmt.Errorf("failed to parse auth data: %w", err)
 	}
 
-	apiKey, ok := authData["api_key"].(string)
-	if !ok || apiKey == "" {
-		if req.Token != "" {
-			apiKey = req.Token
-		} else {
-			return nil, fmt.Errorf("api_key not found in auth data")
-		}
+	apiKey, _ := authData["api_key"].(string)
+	if apiKey == "" {
+		// Fall back to Token field, then the provider-level key
+		apiKey = req.Token
+	}
+	if apiKey == "" {
+		apiKey = p.apiKey
+	}
+	if apiKey == "" && !p.keyOptional {
+		return nil, fmt.Errorf("api_key not found in auth data")
 	}
 
 	// Determine proxy URL
@@ -124,14 +134,20 @@ func (p *OpenAIProvider) ExecuteStream(ctx context.Context, req *providers.Execu
 		proxyURL = req.Account.ProxyURL
 	}
 
-	// Execute streaming HTTP request
-	return executeHTTPStream(ctx, &HTTPRequest{
-		Model:    req.Model,
-		Payload:  req.Payload,
-		Stream:   true,
-		APIKey:   apiKey,
-		ProxyURL: proxyURL,
-	})
+	return &HTTPRequest{
+		Model:      req.Model,
+		Payload:    req.Payload,
+		BaseURL:    p.baseURL,
+		APIKey:     apiKey,
+		AuthHeader: p.authHeader,
+		ProxyURL:   proxyURL,
+		Timeout:    p.timeout,
+	}, nil
+}
+
+// StreamFormat reports that OpenAI streams are translated to Claude events (see readOpenAIStream)
+func (p *OpenAIProvider) StreamFormat(clientFormat string) string {
+	return "claude"
 }
 
 // SupportsStreaming indicates that OpenAI supports streaming
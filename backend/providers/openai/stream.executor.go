@@ -14,7 +14,7 @@ import (
 
 // executeHTTPStream performs a streaming HTTP request to OpenAI API
 func executeHTTPStream(ctx context.Context, req *HTTPRequest) (*providers.StreamResponse, error) {
-	endpoint := BaseURL + EndpointChatCompletions
+	endpoint := req.BaseURL + EndpointChatCompletions
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(req.Payload))
@@ -24,12 +24,12 @@ func executeHTTPStream(ctx context.Context, req *HTTPRequest) (*providers.Stream
 
 	// Set headers
 	httpReq.Header.Set("Content-Type", ContentType)
-	httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
+	setAuthHeader(httpReq, req)
 	httpReq.Header.Set("User-Agent", UserAgent)
 	httpReq.Header.Set("Accept", "text/event-stream")
 
 	// Create HTTP client with optional proxy
-	client, err := createHTTPClient(req.ProxyURL)
+	client, err := createHTTPClient(req.ProxyURL, req.Timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
@@ -47,7 +47,7 @@ func executeHTTPStream(ctx context.Context, req *HTTPRequest) (*providers.Stream
 		httpResp.Body.Close()
 		return &providers.StreamResponse{
 			StatusCode: httpResp.StatusCode,
-		}, fmt.Errorf("upstream error: status %d, body: %s", httpResp.StatusCode, string(body))
+		}, fmt.Errorf("upstream error: status %d, body: %s", httpResp.StatusCode, providers.Redact(string(body)))
 	}
 
 	// Create channels
@@ -66,7 +66,7 @@ func executeHTTPStream(ctx context.Context, req *HTTPRequest) (*providers.Stream
 			errCh <- err
 		}
 
-		fmt.Printf("[DEBUG] Stream completed in %dms\n", time.Since(startTime).Milliseconds())
+		providers.Debugf("Stream completed in %dms", time.Since(startTime).Milliseconds())
 	}()
 
 	return &providers.StreamResponse{
@@ -78,7 +78,7 @@ func executeHTTPStream(ctx context.Context, req *HTTPRequest) (*providers.Stream
 	}, nil
 }
 
-// readOpenAIStream reads SSE events from OpenAI stream
+// readOpenAIStream reads SSE events from OpenAI stream and emits Claude SSE events
 func readOpenAIStream(body io.Reader, dataCh chan<- []byte) error {
 	scanner := bufio.NewScanner(body)
 	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
@@ -104,10 +104,10 @@ func readOpenAIStream(body io.Reader, dataCh chan<- []byte) error {
 			break
 		}
 
-		// Send chunk to channel (copy to avoid race)
+		// Copy to avoid race, then translate to Claude SSE format
 		chunk := make([]byte, len(data))
 		copy(chunk, data)
-		dataCh <- chunk
+		dataCh <- TranslateOpenAIStreamToClaude(chunk)
 	}
 
 	return scanner.Err()
@@ -2,6 +2,9 @@ package openai
 
 import (
 	"fmt"
+	"strings"
+
+	"aigateway-backend/providers"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -10,49 +13,49 @@ import (
 // ClaudeToOpenAI converts Claude format request to OpenAI format
 // Handles system messages, tool calling, tool results, and multimodal content
 func ClaudeToOpenAI(payload []byte, model string) ([]byte, error) {
+	// Claude server tools (code execution, web search) have no chat completions equivalent
+	if err := providers.CheckServerTools(payload, ProviderID); err != nil {
+		return nil, err
+	}
+
+	// PDFs map to file content parts on models that accept them; other models fail loudly
+	if err := providers.CheckDocuments(payload, ProviderID, model, SupportsDocuments(model)); err != nil {
+		return nil, err
+	}
+
+	// OpenAI has no prompt caching markers; content extraction drops them
+	providers.LogDiscardedCacheControl(payload, ProviderID)
+
 	result := string(payload)
 
 	// Convert messages first (includes tool_result and image translation)
 	result = convertMessages(payload, result)
 
 	// Then prepend system message if exists
-	result = prependSystemMessage(payload, result)
+	result = providers.PrependSystemMessage(payload, result)
 
 	// Convert tools
 	result = convertTools(payload, result)
 
-	// Set model
-	result, _ = sjson.Set(result, "model", model)
+	// Forced or disabled tool use (tool_choice)
+	result = providers.ApplyToolChoice(payload, result)
 
-	return []byte(result), nil
-}
+	// Sequential tool calling (parallel_tool_calls or Claude's disable_parallel_tool_use)
+	result = providers.ApplyParallelToolCalls(payload, result)
 
-// prependSystemMessage prepends Claude system to OpenAI messages array
-// This should be called after convertMessages to work on the already-converted messages
-func prependSystemMessage(payload []byte, result string) string {
-	systemResult := gjson.GetBytes(payload, "system")
-	if !systemResult.Exists() {
-		return result
-	}
+	// Map thinking config to reasoning_effort
+	result = convertReasoningEffort(payload, result, model)
 
-	// Get the already-converted messages from result
-	messagesResult := gjson.Get(result, "messages")
-	if !messagesResult.IsArray() {
-		return result
-	}
+	// Map Claude service_tier to OpenAI's tier names
+	result = convertServiceTier(payload, result)
 
-	// Prepend system message to messages array
-	systemMsg := fmt.Sprintf(`{"role":"system","content":"%s"}`, systemResult.String())
-	messages := messagesResult.Array()
-	newMessages := `[` + systemMsg
-	for _, msg := range messages {
-		newMessages += `,` + msg.Raw
-	}
-	newMessages += `]`
-	result, _ = sjson.SetRaw(result, "messages", newMessages)
-	result, _ = sjson.Delete(result, "system")
+	// Merge client passthrough params
+	result = providers.ApplyProviderParams(payload, result, ProviderID, "", providers.ChatCompletionsOwnedParams...)
 
-	return result
+	// Set model
+	result, _ = sjson.Set(result, "model", model)
+
+	return []byte(result), nil
 }
 
 // convertMessages handles message array translation including tool_result and images
@@ -94,16 +97,16 @@ func convertMessage(msg gjson.Result) string {
 			return convertToolResultMessage(blocks[0])
 		}
 
-		// Check for multimodal content (has images)
-		hasImage := false
+		// Check for multimodal content (has images or documents)
+		hasMedia := false
 		for _, block := range blocks {
-			if block.Get("type").String() == "image" {
-				hasImage = true
+			if blockType := block.Get("type").String(); blockType == "image" || blockType == providers.ContentTypeDocument {
+				hasMedia = true
 				break
 			}
 		}
 
-		if hasImage {
+		if hasMedia {
 			// Multimodal: convert to OpenAI content array
 			contentArray := "[]"
 			for _, block := range blocks {
@@ -156,11 +159,16 @@ func convertToolResultMessage(block gjson.Result) string {
 		toolMsg, _ = sjson.SetRaw(toolMsg, "content", toolContent.Raw)
 	}
 
+	// Tool messages have no error flag; mark the content instead
+	if providers.ToolResultIsError(block) {
+		toolMsg, _ = sjson.Set(toolMsg, "content", providers.AnnotateToolError(gjson.Get(toolMsg, "content").String()))
+	}
+
 	return toolMsg
 }
 
 // translateContentPart converts Claude content block to OpenAI format
-// Handles text and image types
+// Handles text, image and document types
 func translateContentPart(block gjson.Result) string {
 	blockType := block.Get("type").String()
 
@@ -194,6 +202,26 @@ func translateContentPart(block gjson.Result) string {
 			return part
 		}
 
+	case providers.ContentTypeDocument:
+		// Plain-text document: inline as text
+		if text, ok := providers.DocumentText(block); ok {
+			part := `{"type":"text","text":""}`
+			part, _ = sjson.Set(part, "text", text)
+			return part
+		}
+
+		// PDF document (validated by CheckDocuments): file part with data URL
+		// OpenAI: {"type":"file","file":{"filename":"doc.pdf","file_data":"data:application/pdf;base64,..."}}
+		source := block.Get("source")
+		filename := block.Get("title").String()
+		if filename == "" {
+			filename = "document.pdf"
+		}
+		part := `{"type":"file","file":{"filename":"","file_data":""}}`
+		part, _ = sjson.Set(part, "file.filename", filename)
+		part, _ = sjson.Set(part, "file.file_data", fmt.Sprintf("data:%s;base64,%s", source.Get("media_type").String(), source.Get("data").String()))
+		return part
+
 	default:
 		// Unknown type: return as text
 		part := `{"type":"text","text":"[unsupported content]"}`
@@ -244,3 +272,70 @@ func convertTools(payload []byte, result string) string {
 
 	return result
 }
+
+// Reasoning effort buckets derived from Claude thinking.budget_tokens
+const (
+	reasoningEffortLowMaxBudget    = 5000
+	reasoningEffortMediumMaxBudget = 20000
+)
+
+// convertReasoningEffort preserves an explicit reasoning_effort and derives one
+// from Claude's thinking.budget_tokens when targeting an o-series model.
+// The Claude thinking block has no OpenAI equivalent and is always removed.
+func convertReasoningEffort(payload []byte, result string, model string) string {
+	result, _ = sjson.Delete(result, "thinking")
+
+	if effort := gjson.GetBytes(payload, "reasoning_effort"); effort.Exists() {
+		result, _ = sjson.Set(result, "reasoning_effort", effort.String())
+		return result
+	}
+
+	if !isReasoningModel(model) {
+		return result
+	}
+
+	thinking := gjson.GetBytes(payload, "thinking")
+	if thinking.Get("type").String() != "enabled" {
+		return result
+	}
+
+	budget := thinking.Get("budget_tokens").Int()
+	if budget <= 0 {
+		return result
+	}
+
+	result, _ = sjson.Set(result, "reasoning_effort", reasoningEffortForBudget(budget))
+	return result
+}
+
+// convertServiceTier maps Claude's service_tier onto OpenAI's: auto stays auto and
+// standard_only becomes default. Unrecognized values are dropped rather than rejected upstream.
+func convertServiceTier(payload []byte, result string) string {
+	result = providers.DropServiceTier(result)
+
+	switch providers.ServiceTier(payload) {
+	case providers.ServiceTierAuto:
+		result, _ = sjson.Set(result, "service_tier", "auto")
+	case providers.ServiceTierStandardOnly:
+		result, _ = sjson.Set(result, "service_tier", "default")
+	}
+	return result
+}
+
+// reasoningEffortForBudget maps a thinking token budget to an OpenAI effort level
+func reasoningEffortForBudget(budget int64) string {
+	switch {
+	case budget < reasoningEffortLowMaxBudget:
+		return "low"
+	case budget < reasoningEffortMediumMaxBudget:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// isReasoningModel reports whether model is an OpenAI o-series reasoning model (o1, o3, o4-mini...)
+func isReasoningModel(model string) bool {
+	m := strings.ToLower(model)
+	return len(m) >= 2 && m[0] == 'o' && m[1] >= '1' && m[1] <= '9'
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"aigateway-backend/providers"
+
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -63,11 +65,8 @@ func OpenAIToClaude(payload []byte) ([]byte, error) {
 		claudeResponse, _ = sjson.Set(claudeResponse, "model", model.String())
 	}
 
-	// Map ID
-	id := result.Get("id")
-	if id.Exists() {
-		claudeResponse, _ = sjson.Set(claudeResponse, "id", id.String())
-	}
+	// Assign a unique Claude-style ID (upstream chatcmpl-* IDs don't follow the msg_ convention)
+	claudeResponse, _ = sjson.Set(claudeResponse, "id", providers.NewMessageID())
 
 	return []byte(claudeResponse), nil
 }
@@ -146,7 +145,7 @@ func mapFinishReason(finishReason string) string {
 	case "tool_calls":
 		return "tool_use"
 	case "content_filter":
-		return "stop_sequence"
+		return "refusal"
 	default:
 		return "end_turn"
 	}
@@ -2,7 +2,11 @@ package openai
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
+
+	"aigateway-backend/providers"
 )
 
 func TestClaudeToOpenAI_ToolResult(t *testing.T) {
@@ -40,6 +44,28 @@ func TestClaudeToOpenAI_ToolResult(t *testing.T) {
 	}
 }
 
+func TestClaudeToOpenAI_ToolResultError(t *testing.T) {
+	claudeReq := `{
+		"messages": [
+			{"role": "assistant", "content": [{"type": "tool_use", "id": "call_123", "name": "get_weather", "input": {"city": "Atlantis"}}]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "call_123", "is_error": true, "content": [{"type": "text", "text": "city not found"}]}]}
+		]
+	}`
+
+	result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI() error = %v", err)
+	}
+
+	var openaiReq map[string]interface{}
+	json.Unmarshal(result, &openaiReq)
+
+	toolMsg := openaiReq["messages"].([]interface{})[1].(map[string]interface{})
+	if toolMsg["content"] != "[Tool error] city not found" {
+		t.Errorf("content = %v, want the error marked", toolMsg["content"])
+	}
+}
+
 func TestClaudeToOpenAI_ImageContent(t *testing.T) {
 	claudeReq := `{
 		"messages": [{
@@ -199,3 +225,342 @@ func TestTranslateContentPart_URLImage(t *testing.T) {
 		t.Errorf("image_url.url = %v, want URL", imageURL["url"])
 	}
 }
+
+func TestClaudeToOpenAI_ReasoningEffortPassthrough(t *testing.T) {
+	claudeReq := `{
+		"reasoning_effort": "high",
+		"thinking": {"type": "enabled", "budget_tokens": 1024},
+		"messages": [{"role": "user", "content": "Solve this"}]
+	}`
+
+	result, _ := ClaudeToOpenAI([]byte(claudeReq), "o3-mini")
+
+	var openaiReq map[string]interface{}
+	json.Unmarshal(result, &openaiReq)
+
+	if openaiReq["reasoning_effort"] != "high" {
+		t.Errorf("reasoning_effort = %v, want 'high'", openaiReq["reasoning_effort"])
+	}
+	if _, exists := openaiReq["thinking"]; exists {
+		t.Error("thinking field should be removed from request")
+	}
+}
+
+func TestClaudeToOpenAI_ReasoningEffortFromThinking(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  string
+		budget int
+		want   interface{}
+	}{
+		{"low budget", "o1", 1024, "low"},
+		{"medium budget", "o3-mini", 5000, "medium"},
+		{"high budget", "o4-mini", 20000, "high"},
+		{"non o-series model", "gpt-4", 20000, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claudeReq := fmt.Sprintf(`{
+				"thinking": {"type": "enabled", "budget_tokens": %d},
+				"messages": [{"role": "user", "content": "Solve this"}]
+			}`, tt.budget)
+
+			result, _ := ClaudeToOpenAI([]byte(claudeReq), tt.model)
+
+			var openaiReq map[string]interface{}
+			json.Unmarshal(result, &openaiReq)
+
+			if openaiReq["reasoning_effort"] != tt.want {
+				t.Errorf("reasoning_effort = %v, want %v", openaiReq["reasoning_effort"], tt.want)
+			}
+			if _, exists := openaiReq["thinking"]; exists {
+				t.Error("thinking field should be removed from request")
+			}
+		})
+	}
+}
+
+func TestClaudeToOpenAI_ServerToolRejected(t *testing.T) {
+	claudeReq := `{
+		"tools": [{"type": "code_execution_20250522", "name": "code_execution"}],
+		"messages": [{"role": "user", "content": "Compute 2^64"}]
+	}`
+
+	_, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4")
+
+	var toolErr *providers.UnsupportedServerToolError
+	if !errors.As(err, &toolErr) {
+		t.Fatalf("error = %v, want UnsupportedServerToolError", err)
+	}
+	if toolErr.ProviderID != "openai" || toolErr.ToolType != "code_execution_20250522" {
+		t.Errorf("error = %+v", toolErr)
+	}
+}
+
+func TestClaudeToOpenAI_SystemStringEscaping(t *testing.T) {
+	claudeReq := `{
+		"system": "Say \"hi\".\nThen stop.\t\\done",
+		"messages": [{"role": "user", "content": "Hi"}]
+	}`
+
+	result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI() error = %v", err)
+	}
+	if !json.Valid(result) {
+		t.Fatalf("invalid JSON output: %s", result)
+	}
+
+	var openaiReq struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(result, &openaiReq); err != nil {
+		t.Fatalf("failed to decode output: %v", err)
+	}
+
+	want := "Say \"hi\".\nThen stop.\t\\done"
+	if len(openaiReq.Messages) != 2 || openaiReq.Messages[0].Role != "system" || openaiReq.Messages[0].Content != want {
+		t.Errorf("messages = %+v, want system content %q", openaiReq.Messages, want)
+	}
+}
+
+func TestClaudeToOpenAI_SystemArrayWithCacheControl(t *testing.T) {
+	claudeReq := `{
+		"system": [
+			{"type": "text", "text": "You are a \"helpful\" assistant."},
+			{"type": "text", "text": "Answer briefly.", "cache_control": {"type": "ephemeral"}}
+		],
+		"messages": [{
+			"role": "user",
+			"content": [{"type": "text", "text": "Hi", "cache_control": {"type": "ephemeral"}}]
+		}]
+	}`
+
+	result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI() error = %v", err)
+	}
+	if !json.Valid(result) {
+		t.Fatalf("invalid JSON output: %s", result)
+	}
+
+	var openaiReq map[string]interface{}
+	json.Unmarshal(result, &openaiReq)
+
+	messages := openaiReq["messages"].([]interface{})
+	if len(messages) != 2 {
+		t.Fatalf("messages count = %d, want 2", len(messages))
+	}
+
+	systemMsg := messages[0].(map[string]interface{})
+	wantSystem := "You are a \"helpful\" assistant.\nAnswer briefly."
+	if systemMsg["role"] != "system" || systemMsg["content"] != wantSystem {
+		t.Errorf("system message = %v, want content %q", systemMsg, wantSystem)
+	}
+
+	userMsg := messages[1].(map[string]interface{})
+	if userMsg["content"] != "Hi" {
+		t.Errorf("user content = %v, want 'Hi'", userMsg["content"])
+	}
+	if _, ok := openaiReq["system"]; ok {
+		t.Error("system field should be removed")
+	}
+}
+
+func TestClaudeToOpenAI_PDFDocument(t *testing.T) {
+	claudeReq := `{
+		"messages": [{
+			"role": "user",
+			"content": [
+				{"type": "document", "title": "report.pdf", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERi0xLjQ="}},
+				{"type": "text", "text": "Summarize this"}
+			]
+		}]
+	}`
+
+	result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI() error = %v", err)
+	}
+
+	var openaiReq map[string]interface{}
+	json.Unmarshal(result, &openaiReq)
+
+	content := openaiReq["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+	if len(content) != 2 {
+		t.Fatalf("content length = %d, want 2", len(content))
+	}
+	filePart := content[0].(map[string]interface{})
+	if filePart["type"] != "file" {
+		t.Fatalf("content[0].type = %v, want 'file'", filePart["type"])
+	}
+	file := filePart["file"].(map[string]interface{})
+	if file["filename"] != "report.pdf" {
+		t.Errorf("file.filename = %v, want 'report.pdf'", file["filename"])
+	}
+	if file["file_data"] != "data:application/pdf;base64,JVBERi0xLjQ=" {
+		t.Errorf("file.file_data = %v", file["file_data"])
+	}
+}
+
+func TestClaudeToOpenAI_PDFDocumentUnsupportedModel(t *testing.T) {
+	claudeReq := `{"messages": [{"role": "user", "content": [
+		{"type": "document", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERi0xLjQ="}}
+	]}]}`
+
+	_, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-3.5-turbo")
+	var docErr *providers.UnsupportedDocumentError
+	if !errors.As(err, &docErr) {
+		t.Fatalf("expected UnsupportedDocumentError, got %v", err)
+	}
+	if docErr.Model != "gpt-3.5-turbo" {
+		t.Errorf("Model = %q, want gpt-3.5-turbo", docErr.Model)
+	}
+}
+
+func TestClaudeToOpenAI_ServiceTier(t *testing.T) {
+	tests := []struct {
+		tier string
+		want string
+	}{
+		{"auto", "auto"},
+		{"standard_only", "default"},
+		{"bogus", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tier, func(t *testing.T) {
+			claudeReq := `{"service_tier": "` + tt.tier + `", "messages": [{"role": "user", "content": "Hi"}]}`
+
+			result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+			if err != nil {
+				t.Fatalf("ClaudeToOpenAI() error = %v", err)
+			}
+
+			var openaiReq map[string]interface{}
+			json.Unmarshal(result, &openaiReq)
+
+			got, _ := openaiReq["service_tier"].(string)
+			if got != tt.want {
+				t.Errorf("service_tier = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaudeToOpenAI_ParallelToolCalls(t *testing.T) {
+	claudeReq := `{
+		"tool_choice": {"type": "auto", "disable_parallel_tool_use": true},
+		"tools": [{"name": "get_weather", "input_schema": {"type": "object"}}],
+		"messages": [{"role": "user", "content": "Hi"}]
+	}`
+
+	result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI() error = %v", err)
+	}
+
+	var req map[string]interface{}
+	if err := json.Unmarshal(result, &req); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if req["parallel_tool_calls"] != false {
+		t.Errorf("parallel_tool_calls = %v, want false", req["parallel_tool_calls"])
+	}
+}
+
+func TestClaudeToOpenAI_ToolChoice(t *testing.T) {
+	tools := `"tools": [{"name": "get_weather", "input_schema": {"type": "object"}}]`
+
+	tests := []struct {
+		name       string
+		toolChoice string
+		want       string // Expected raw tool_choice JSON
+	}{
+		{"auto", `{"type": "auto"}`, `"auto"`},
+		{"any", `{"type": "any"}`, `"required"`},
+		{"named tool", `{"type": "tool", "name": "get_weather"}`, `{"type":"function","function":{"name":"get_weather"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claudeReq := `{"tool_choice": ` + tt.toolChoice + `, ` + tools + `, "messages": [{"role": "user", "content": "Hi"}]}`
+			result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+			if err != nil {
+				t.Fatalf("ClaudeToOpenAI() error = %v", err)
+			}
+
+			var req map[string]json.RawMessage
+			if err := json.Unmarshal(result, &req); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if got := string(req["tool_choice"]); got != tt.want {
+				t.Errorf("tool_choice = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaudeToOpenAI_PreservesResponseFormat(t *testing.T) {
+	responseFormat := `{"type":"json_schema","json_schema":{"name":"weather","strict":true,"schema":{"type":"object"}}}`
+	claudeReq := `{"response_format": ` + responseFormat + `, "messages": [{"role": "user", "content": "Hi"}]}`
+
+	result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI() error = %v", err)
+	}
+
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(result, &req); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got := string(req["response_format"]); got != responseFormat {
+		t.Errorf("response_format = %s, want %s", got, responseFormat)
+	}
+}
+
+func TestClaudeToOpenAI_ProviderParamsCannotOverrideOwnedFields(t *testing.T) {
+	claudeReq := `{
+		"stream": true,
+		"messages": [{"role": "user", "content": "Hi"}],
+		"provider_params": {
+			"openai": {
+				"model": "gpt-other",
+				"stream": false,
+				"stream_options": {"include_usage": false},
+				"tool_choice": "required",
+				"seed": 7
+			}
+		}
+	}`
+
+	result, err := ClaudeToOpenAI([]byte(claudeReq), "gpt-4o")
+	if err != nil {
+		t.Fatalf("ClaudeToOpenAI() error = %v", err)
+	}
+
+	var req map[string]interface{}
+	if err := json.Unmarshal(result, &req); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if req["model"] != "gpt-4o" {
+		t.Errorf("model = %v, want gpt-4o", req["model"])
+	}
+	if req["stream"] != true {
+		t.Errorf("stream = %v, want the client's true", req["stream"])
+	}
+	if _, ok := req["stream_options"]; ok {
+		t.Errorf("stream_options = %v, want none", req["stream_options"])
+	}
+	if _, ok := req["tool_choice"]; ok {
+		t.Errorf("tool_choice = %v, want none", req["tool_choice"])
+	}
+	if req["seed"] != float64(7) {
+		t.Errorf("seed = %v, want 7 from provider_params", req["seed"])
+	}
+}
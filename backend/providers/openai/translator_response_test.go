@@ -2,6 +2,7 @@ package openai
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -177,7 +178,7 @@ func TestMapFinishReason(t *testing.T) {
 		{"stop", "end_turn"},
 		{"length", "max_tokens"},
 		{"tool_calls", "tool_use"},
-		{"content_filter", "stop_sequence"},
+		{"content_filter", "refusal"},
 		{"unknown", "end_turn"},
 		{"", "end_turn"},
 	}
@@ -206,3 +207,26 @@ func TestOpenAIToClaude_EmptyResponse(t *testing.T) {
 		t.Fatalf("Result is not valid JSON: %v", err)
 	}
 }
+
+func TestOpenAIToClaude_UniqueMessageID(t *testing.T) {
+	openaiResp := `{
+		"id": "chatcmpl-123",
+		"choices": [{"message": {"role": "assistant", "content": "Hi"}, "finish_reason": "stop"}]
+	}`
+
+	first, _ := OpenAIToClaude([]byte(openaiResp))
+	second, _ := OpenAIToClaude([]byte(openaiResp))
+
+	var firstResp, secondResp map[string]interface{}
+	json.Unmarshal(first, &firstResp)
+	json.Unmarshal(second, &secondResp)
+
+	firstID, _ := firstResp["id"].(string)
+	secondID, _ := secondResp["id"].(string)
+	if !strings.HasPrefix(firstID, "msg_") || !strings.HasPrefix(secondID, "msg_") {
+		t.Errorf("ids = %q, %q, want msg_ prefix", firstID, secondID)
+	}
+	if firstID == secondID {
+		t.Errorf("ids should be unique, both = %q", firstID)
+	}
+}
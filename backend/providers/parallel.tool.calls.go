@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ParallelToolCalls returns whether the client allows parallel tool calls and whether it said so
+// OpenAI's parallel_tool_calls takes precedence over Claude's tool_choice.disable_parallel_tool_use.
+func ParallelToolCalls(payload []byte) (allowed bool, set bool) {
+	if v := gjson.GetBytes(payload, "parallel_tool_calls"); v.IsBool() {
+		return v.Bool(), true
+	}
+	if v := gjson.GetBytes(payload, "tool_choice.disable_parallel_tool_use"); v.IsBool() {
+		return !v.Bool(), true
+	}
+	return true, false
+}
+
+// ApplyParallelToolCalls sets parallel_tool_calls on an OpenAI-compatible payload
+// The flag is only sent alongside tools, since OpenAI rejects it on tool-less requests.
+func ApplyParallelToolCalls(payload []byte, result string) string {
+	result = DropParallelToolCalls(result)
+
+	allowed, set := ParallelToolCalls(payload)
+	if !set || !gjson.Get(result, "tools").IsArray() {
+		return result
+	}
+	result, _ = sjson.Set(result, "parallel_tool_calls", allowed)
+	return result
+}
+
+// DropParallelToolCalls removes parallel_tool_calls for providers without an equivalent setting
+func DropParallelToolCalls(result string) string {
+	if !gjson.Get(result, "parallel_tool_calls").Exists() {
+		return result
+	}
+	result, _ = sjson.Delete(result, "parallel_tool_calls")
+	return result
+}
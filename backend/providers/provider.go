@@ -2,6 +2,7 @@ package providers
 
 import (
 	"context"
+	"net/http"
 
 	"aigateway-backend/models"
 )
@@ -69,6 +70,10 @@ type ExecuteRequest struct {
 
 	// Token is the authentication token (may be pre-fetched or from Account.AuthData)
 	Token string
+
+	// Format is the client's request format ("claude" or "openai", "" = claude)
+	// Providers that translate their stream emit it in this format.
+	Format string
 }
 
 // ExecuteResponse contains the result of a provider API call
@@ -81,6 +86,13 @@ type ExecuteResponse struct {
 
 	// LatencyMs is the request latency in milliseconds
 	LatencyMs int
+
+	// Headers contains the upstream response headers (e.g. Retry-After on 429)
+	Headers http.Header
+
+	// SentPayload is the body sent upstream when the provider translated Payload itself
+	// (nil = Payload was sent unchanged); request taps log it instead of the inbound payload
+	SentPayload []byte
 }
 
 // StreamResponse contains channels for streaming API responses
@@ -99,4 +111,8 @@ type StreamResponse struct {
 
 	// Done signals when the stream is complete
 	Done <-chan struct{}
+
+	// SentPayload is the body sent upstream when the provider translated Payload itself
+	// (nil = Payload was sent unchanged)
+	SentPayload []byte
 }
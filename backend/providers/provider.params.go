@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ProviderParamsField is the reserved request field for provider-specific passthrough parameters
+// Format: {"provider_params": {"<provider_id>": {...}}}
+const ProviderParamsField = "provider_params"
+
+// ChatCompletionsOwnedParams are the Chat Completions fields the gateway builds itself
+// Passthrough params can't replace them: they decide what is sent, how it streams and how the
+// response is read back.
+var ChatCompletionsOwnedParams = []string{"model", "messages", "stream", "stream_options", "tools", "tool_choice"}
+
+// ApplyProviderParams merges the provider's entry from provider_params into the translated payload
+// Values are deep-merged under namespace ("" = payload root), overriding translated fields except
+// the top-level keys in owned, which are dropped from the params.
+// The provider_params field itself is always removed so it never reaches the upstream.
+func ApplyProviderParams(payload []byte, result string, providerID, namespace string, owned ...string) string {
+	result, _ = sjson.Delete(result, ProviderParamsField)
+
+	params := gjson.GetBytes(payload, ProviderParamsField+"."+gjson.Escape(providerID))
+	if !params.IsObject() {
+		return result
+	}
+
+	if len(owned) > 0 {
+		raw := params.Raw
+		for _, key := range owned {
+			raw, _ = sjson.Delete(raw, gjson.Escape(key))
+		}
+		params = gjson.Parse(raw)
+	}
+
+	return mergeObject(result, namespace, params)
+}
+
+// mergeObject recursively merges obj into result at path
+func mergeObject(result, path string, obj gjson.Result) string {
+	obj.ForEach(func(key, value gjson.Result) bool {
+		fieldPath := gjson.Escape(key.String())
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+
+		if value.IsObject() && gjson.Get(result, fieldPath).IsObject() {
+			result = mergeObject(result, fieldPath, value)
+		} else {
+			result, _ = sjson.SetRaw(result, fieldPath, value.Raw)
+		}
+		return true
+	})
+	return result
+}
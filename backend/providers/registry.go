@@ -22,13 +22,15 @@ type ResolvedMapping struct {
 type Registry struct {
 	mu              sync.RWMutex
 	providers       map[string]Provider
+	modelRoutes     map[string]string // Lowercased model name -> provider ID, checked before prefixes
 	mappingResolver MappingResolver
 }
 
 // NewRegistry creates a new provider registry
 func NewRegistry() *Registry {
 	return &Registry{
-		providers: make(map[string]Provider),
+		providers:   make(map[string]Provider),
+		modelRoutes: make(map[string]string),
 	}
 }
 
@@ -44,6 +46,15 @@ func (r *Registry) Register(id string, provider Provider) {
 	r.providers[id] = provider
 }
 
+// RegisterModels routes the exact model names to a provider (e.g. models of an openai_compatible upstream)
+func (r *Registry) RegisterModels(id string, models []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, model := range models {
+		r.modelRoutes[strings.ToLower(model)] = id
+	}
+}
+
 // Get retrieves a provider by ID
 func (r *Registry) Get(id string) (Provider, error) {
 	r.mu.RLock()
@@ -85,7 +96,7 @@ func (r *Registry) GetByModel(model string) (Provider, string, error) {
 		}
 	}
 
-	// 2. Fallback to prefix matching
+	// 2. Fallback to registered model names, then prefix matching
 	providerID := r.routeModel(model)
 	if providerID == "" {
 		return nil, "", fmt.Errorf("no provider found for model: %s", model)
@@ -98,11 +109,18 @@ func (r *Registry) GetByModel(model string) (Provider, string, error) {
 	return provider, model, nil
 }
 
-// routeModel maps model names to provider IDs based on prefix matching
+// routeModel maps model names to provider IDs based on registered names, then prefix matching
 func (r *Registry) routeModel(model string) string {
 	// Normalize model to lowercase for matching
 	modelLower := strings.ToLower(model)
 
+	r.mu.RLock()
+	providerID, exists := r.modelRoutes[modelLower]
+	r.mu.RUnlock()
+	if exists {
+		return providerID
+	}
+
 	// Model routing logic
 	switch {
 	case strings.HasPrefix(modelLower, "gemini-"):
@@ -127,6 +145,11 @@ func (r *Registry) Remove(id string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.providers, id)
+	for model, providerID := range r.modelRoutes {
+		if providerID == id {
+			delete(r.modelRoutes, model)
+		}
+	}
 }
 
 // Update updates an existing provider in the registry
@@ -167,4 +190,5 @@ func (r *Registry) Clear() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.providers = make(map[string]Provider)
+	r.modelRoutes = make(map[string]string)
 }
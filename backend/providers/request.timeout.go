@@ -0,0 +1,14 @@
+package providers
+
+import "time"
+
+// DefaultRequestTimeout bounds an upstream request when the provider has no configured timeout
+const DefaultRequestTimeout = 120 * time.Second
+
+// RequestTimeout converts a configured timeout in seconds; zero or negative keeps DefaultRequestTimeout
+func RequestTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return DefaultRequestTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,75 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Claude server-side tool kinds (executed by the upstream, not the client)
+const (
+	ServerToolCodeExecution = "code_execution"
+	ServerToolWebSearch     = "web_search"
+	ServerToolWebFetch      = "web_fetch"
+)
+
+// serverToolKinds lists known server tool type prefixes
+// Claude versions them by date suffix, e.g. "code_execution_20250522"
+var serverToolKinds = []string{
+	ServerToolCodeExecution,
+	ServerToolWebSearch,
+	ServerToolWebFetch,
+}
+
+// UnsupportedServerToolError is returned when a request uses a server tool the target provider cannot run
+type UnsupportedServerToolError struct {
+	ProviderID string
+	ToolType   string
+}
+
+func (e *UnsupportedServerToolError) Error() string {
+	return fmt.Sprintf("unsupported server tool %s for provider %s", e.ToolType, e.ProviderID)
+}
+
+// ServerToolKind returns the server tool kind of a Claude tool definition,
+// or an empty string for regular client (function) tools
+func ServerToolKind(tool gjson.Result) string {
+	toolType := tool.Get("type").String()
+	if toolType == "" || toolType == "custom" {
+		return ""
+	}
+
+	for _, kind := range serverToolKinds {
+		if toolType == kind || strings.HasPrefix(toolType, kind+"_") {
+			return kind
+		}
+	}
+	return ""
+}
+
+// CheckServerTools validates that every server tool in a Claude payload is
+// one of the supported kinds for the provider
+func CheckServerTools(payload []byte, providerID string, supported ...string) error {
+	for _, tool := range gjson.GetBytes(payload, "tools").Array() {
+		kind := ServerToolKind(tool)
+		if kind == "" {
+			continue
+		}
+
+		allowed := false
+		for _, s := range supported {
+			if s == kind {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &UnsupportedServerToolError{
+				ProviderID: providerID,
+				ToolType:   tool.Get("type").String(),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Claude service_tier values
+const (
+	// ServiceTierAuto uses priority capacity when available
+	ServiceTierAuto = "auto"
+	// ServiceTierStandardOnly never uses priority capacity
+	ServiceTierStandardOnly = "standard_only"
+)
+
+// ServiceTier returns the request's Claude service_tier, or "" when absent or unrecognized
+func ServiceTier(payload []byte) string {
+	switch tier := strings.ToLower(gjson.GetBytes(payload, "service_tier").String()); tier {
+	case ServiceTierAuto, ServiceTierStandardOnly:
+		return tier
+	default:
+		return ""
+	}
+}
+
+// DropServiceTier removes service_tier from a translated payload for providers without tier selection
+// The tier still reaches the router through the original request for pool selection.
+func DropServiceTier(result string) string {
+	if !gjson.Get(result, "service_tier").Exists() {
+		return result
+	}
+	result, _ = sjson.Delete(result, "service_tier")
+	return result
+}
@@ -0,0 +1,33 @@
+package providers
+
+import (
+	"context"
+	"strings"
+)
+
+// SystemHintsHeader lets a client opt out of gateway-injected system prompt hints for one request
+// "off", "false", "0" or "none" leaves the system prompt exactly as sent.
+const SystemHintsHeader = "X-Gateway-System-Hints"
+
+type systemHintsDisabledKey struct{}
+
+// SystemHintsDisabledByHeader reports whether an X-Gateway-System-Hints value opts out of injection
+func SystemHintsDisabledByHeader(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "off", "false", "0", "none":
+		return true
+	default:
+		return false
+	}
+}
+
+// WithSystemHintsDisabled marks ctx so providers pass the system prompt through untouched
+func WithSystemHintsDisabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemHintsDisabledKey{}, true)
+}
+
+// SystemHintsDisabled reports whether the request carried by ctx opted out of hint injection
+func SystemHintsDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(systemHintsDisabledKey{}).(bool)
+	return disabled
+}
@@ -0,0 +1,33 @@
+package providers
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// PrependSystemMessage moves Claude's system prompt into an OpenAI-style system message
+// at the head of the already-converted messages array. The content is set with sjson so
+// quotes, newlines and other JSON-breaking characters are escaped; string and block-array
+// system prompts are both accepted. The top-level system field is always removed.
+func PrependSystemMessage(payload []byte, result string) string {
+	system := gjson.GetBytes(payload, "system")
+	if !system.Exists() {
+		return result
+	}
+
+	result, _ = sjson.Delete(result, "system")
+
+	content := SystemText(system)
+	messages := gjson.Get(result, "messages")
+	if content == "" || !messages.IsArray() {
+		return result
+	}
+
+	systemMsg, _ := sjson.Set(`{"role":"system"}`, "content", content)
+	newMessages := "[" + systemMsg + "]"
+	for _, msg := range messages.Array() {
+		newMessages, _ = sjson.SetRaw(newMessages, "-1", msg.Raw)
+	}
+	result, _ = sjson.SetRaw(result, "messages", newMessages)
+	return result
+}
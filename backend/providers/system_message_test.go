@@ -0,0 +1,60 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPrependSystemMessage_EscapesAndRoundTrips(t *testing.T) {
+	// Quotes, backslashes, newlines, tabs, a JSON fragment and a control character
+	want := "Reply with {\"ok\": true}.\nPaths look like C:\\tmp\\x\t\"quoted\" \u0001 done"
+
+	systemString, _ := json.Marshal(want)
+	systemBlocks, _ := json.Marshal([]map[string]string{{"type": "text", "text": want}})
+
+	tests := []struct {
+		name   string
+		system string
+	}{
+		{"string", string(systemString)},
+		{"blocks", string(systemBlocks)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(`{"system":` + tt.system + `,"messages":[{"role":"user","content":"Hi"}]}`)
+			result := PrependSystemMessage(payload, string(payload))
+
+			var out struct {
+				System   *json.RawMessage `json:"system"`
+				Messages []struct {
+					Role    string `json:"role"`
+					Content string `json:"content"`
+				} `json:"messages"`
+			}
+			if err := json.Unmarshal([]byte(result), &out); err != nil {
+				t.Fatalf("output is not valid JSON: %v\n%s", err, result)
+			}
+
+			if len(out.Messages) != 2 || out.Messages[0].Role != "system" || out.Messages[1].Content != "Hi" {
+				t.Fatalf("messages = %+v, want system followed by the user message", out.Messages)
+			}
+			if out.Messages[0].Content != want {
+				t.Errorf("system content = %q, want %q", out.Messages[0].Content, want)
+			}
+			if out.System != nil {
+				t.Error("top-level system field should be removed")
+			}
+		})
+	}
+}
+
+func TestPrependSystemMessage_EmptySystemDropped(t *testing.T) {
+	payload := []byte(`{"system":[],"messages":[{"role":"user","content":"Hi"}]}`)
+
+	result := PrependSystemMessage(payload, string(payload))
+
+	if result != `{"messages":[{"role":"user","content":"Hi"}]}` {
+		t.Errorf("result = %s, want system removed without a system message", result)
+	}
+}
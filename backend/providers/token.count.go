@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/tidwall/gjson"
+)
+
+// TokenCounter is implemented by providers with a native token-count API
+// The request payload is in Claude format; the provider translates it like Execute does.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req *ExecuteRequest) (int64, error)
+}
+
+// Local estimate tuning: ~4 characters per token for English text and JSON,
+// a fixed cost per message for role markers, and Anthropic's upper bound per image.
+const (
+	charsPerToken        = 4
+	messageOverheadToken = 3
+	imageTokens          = 1600
+)
+
+// EstimateInputTokens approximates the input tokens of a Claude-format request
+// Used when the routed provider has no token-count API; counts system, messages and tools.
+func EstimateInputTokens(payload []byte) int64 {
+	var chars, tokens int64
+
+	system := gjson.GetBytes(payload, "system")
+	if system.Type == gjson.String {
+		chars += int64(len(system.String()))
+	} else {
+		for _, block := range system.Array() {
+			chars += int64(len(block.Get("text").String()))
+		}
+	}
+
+	for _, message := range gjson.GetBytes(payload, "messages").Array() {
+		tokens += messageOverheadToken
+		c, t := estimateContent(message.Get("content"))
+		chars += c
+		tokens += t
+	}
+
+	for _, tool := range gjson.GetBytes(payload, "tools").Array() {
+		chars += int64(len(tool.Raw))
+	}
+
+	return tokens + (chars+charsPerToken-1)/charsPerToken
+}
+
+// estimateContent returns the text characters and fixed-cost tokens of a content value
+func estimateContent(content gjson.Result) (chars, tokens int64) {
+	if content.Type == gjson.String {
+		return int64(len(content.String())), 0
+	}
+
+	for _, block := range content.Array() {
+		switch block.Get("type").String() {
+		case "text":
+			chars += int64(len(block.Get("text").String()))
+		case "thinking":
+			chars += int64(len(block.Get("thinking").String()))
+		case "tool_use":
+			chars += int64(len(block.Get("name").String()) + len(block.Get("input").Raw))
+		case "tool_result":
+			c, t := estimateContent(block.Get("content"))
+			chars += c
+			tokens += t
+		case "document":
+			if block.Get("source.type").String() == "text" {
+				chars += int64(len(block.Get("source.data").String()))
+			} else {
+				tokens += imageTokens
+			}
+		case "image":
+			tokens += imageTokens
+		}
+	}
+	return chars, tokens
+}
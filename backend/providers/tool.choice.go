@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Claude tool_choice types
+const (
+	ToolChoiceAuto = "auto"
+	ToolChoiceAny  = "any"
+	ToolChoiceTool = "tool"
+	ToolChoiceNone = "none"
+)
+
+// ToolChoice returns the client's tool_choice as a Claude type and, for "tool", the forced tool name
+// Claude objects ({"type":"tool","name":...}) and OpenAI values ("required",
+// {"type":"function","function":{"name":...}}) are both accepted. Returns "" when unset or unrecognized.
+func ToolChoice(payload []byte) (choiceType string, name string) {
+	choice := gjson.GetBytes(payload, "tool_choice")
+
+	if choice.Type == gjson.String {
+		switch choice.String() {
+		case "auto":
+			return ToolChoiceAuto, ""
+		case "required":
+			return ToolChoiceAny, ""
+		case "none":
+			return ToolChoiceNone, ""
+		}
+		return "", ""
+	}
+
+	switch t := choice.Get("type").String(); t {
+	case ToolChoiceAuto, ToolChoiceAny, ToolChoiceNone:
+		return t, ""
+	case ToolChoiceTool:
+		if name := choice.Get("name").String(); name != "" {
+			return ToolChoiceTool, name
+		}
+	case "function":
+		if name := choice.Get("function.name").String(); name != "" {
+			return ToolChoiceTool, name
+		}
+	}
+	return "", ""
+}
+
+// ApplyToolChoice sets tool_choice on an OpenAI-compatible payload
+// auto/none map to the same strings, any to "required" and a named tool to a function choice.
+// Like parallel_tool_calls, it is only sent alongside tools.
+func ApplyToolChoice(payload []byte, result string) string {
+	if gjson.Get(result, "tool_choice").Exists() {
+		result, _ = sjson.Delete(result, "tool_choice")
+	}
+
+	choiceType, name := ToolChoice(payload)
+	if choiceType == "" || !gjson.Get(result, "tools").IsArray() {
+		return result
+	}
+
+	switch choiceType {
+	case ToolChoiceAny:
+		result, _ = sjson.Set(result, "tool_choice", "required")
+	case ToolChoiceTool:
+		result, _ = sjson.SetRaw(result, "tool_choice", `{"type":"function","function":{"name":""}}`)
+		result, _ = sjson.Set(result, "tool_choice.function.name", name)
+	default:
+		result, _ = sjson.Set(result, "tool_choice", choiceType)
+	}
+	return result
+}
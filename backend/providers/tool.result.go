@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// toolResultTruncationMarker is appended where an oversized tool_result was cut
+const toolResultTruncationMarker = "\n\n[... truncated %d characters]"
+
+// toolErrorPrefix marks a failed tool's output for upstreams without an error flag
+const toolErrorPrefix = "[Tool error] "
+
+// ToolResultIsError reports whether a Claude tool_result block has "is_error": true
+func ToolResultIsError(block gjson.Result) bool {
+	return block.Get("is_error").Bool()
+}
+
+// AnnotateToolError prefixes a failed tool's output so chat-completions models see the failure
+func AnnotateToolError(text string) string {
+	return toolErrorPrefix + text
+}
+
+// TruncateToolResults caps the text of each Claude tool_result at maxChars characters
+// String content and text blocks share one budget per tool_result; text beyond it is replaced
+// by a marker stating how much was dropped. Non-text blocks pass through. 0 disables truncation.
+func TruncateToolResults(payload []byte, maxChars int) []byte {
+	if maxChars <= 0 {
+		return payload
+	}
+
+	result := payload
+	for i, msg := range gjson.GetBytes(payload, "messages").Array() {
+		content := msg.Get("content")
+		if !content.IsArray() {
+			continue
+		}
+		for j, block := range content.Array() {
+			if block.Get("type").String() != "tool_result" {
+				continue
+			}
+
+			path := fmt.Sprintf("messages.%d.content.%d.content", i, j)
+			toolContent := block.Get("content")
+			remaining := maxChars
+
+			if toolContent.Type == gjson.String {
+				if text, truncated := truncateText(toolContent.String(), &remaining); truncated {
+					result, _ = sjson.SetBytes(result, path, text)
+				}
+				continue
+			}
+
+			for k, part := range toolContent.Array() {
+				if part.Get("type").String() != "text" {
+					continue
+				}
+				if text, truncated := truncateText(part.Get("text").String(), &remaining); truncated {
+					result, _ = sjson.SetBytes(result, fmt.Sprintf("%s.%d.text", path, k), text)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// truncateText keeps at most *remaining characters of text and deducts what it kept
+// Returns the (possibly marked) text and whether anything was cut.
+func truncateText(text string, remaining *int) (string, bool) {
+	length := utf8.RuneCountInString(text)
+	if length <= *remaining {
+		*remaining -= length
+		return text, false
+	}
+
+	kept := []rune(text)[:*remaining]
+	*remaining = 0
+	return string(kept) + fmt.Sprintf(toolResultTruncationMarker, length-len(kept)), true
+}
@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestTruncateToolResults(t *testing.T) {
+	long := strings.Repeat("x", 50)
+	payload := []byte(`{"messages":[
+		{"role":"user","content":"hi"},
+		{"role":"user","content":[
+			{"type":"tool_result","tool_use_id":"a","content":"` + long + `"},
+			{"type":"tool_result","tool_use_id":"b","content":"short"},
+			{"type":"tool_result","tool_use_id":"c","content":[
+				{"type":"text","text":"` + strings.Repeat("y", 6) + `"},
+				{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}},
+				{"type":"text","text":"` + strings.Repeat("z", 8) + `"}
+			]}
+		]}
+	]}`)
+
+	result := TruncateToolResults(payload, 10)
+	blocks := gjson.GetBytes(result, "messages.1.content").Array()
+
+	if got := blocks[0].Get("content").String(); got != strings.Repeat("x", 10)+"\n\n[... truncated 40 characters]" {
+		t.Errorf("oversized string content = %q, want 10 chars plus marker", got)
+	}
+	if got := blocks[1].Get("content").String(); got != "short" {
+		t.Errorf("normal tool_result = %q, want it untouched", got)
+	}
+
+	parts := blocks[2].Get("content").Array()
+	if got := parts[0].Get("text").String(); got != "yyyyyy" {
+		t.Errorf("first text block = %q, want it untouched within budget", got)
+	}
+	if got := parts[1].Get("source.data").String(); got != "AAAA" {
+		t.Errorf("image block data = %q, want non-text blocks untouched", got)
+	}
+	if got := parts[2].Get("text").String(); got != "zzzz\n\n[... truncated 4 characters]" {
+		t.Errorf("second text block = %q, want the remaining 4 chars plus marker", got)
+	}
+	if got := gjson.GetBytes(result, "messages.0.content").String(); got != "hi" {
+		t.Errorf("plain message = %q, want untouched", got)
+	}
+}
+
+func TestTruncateToolResults_DisabledAndMultibyte(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"a","content":"héllo wörld"}]}]}`)
+
+	if got := TruncateToolResults(payload, 0); string(got) != string(payload) {
+		t.Errorf("limit 0 should leave the payload unchanged, got %s", got)
+	}
+
+	got := gjson.GetBytes(TruncateToolResults(payload, 4), "messages.0.content.0.content").String()
+	if got != "héll\n\n[... truncated 7 characters]" {
+		t.Errorf("content = %q, want truncation on character boundaries", got)
+	}
+}
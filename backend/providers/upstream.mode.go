@@ -0,0 +1,31 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UpstreamMode selects which upstream endpoint serves non-streaming client requests
+// Streaming client requests always use the streaming endpoint.
+type UpstreamMode string
+
+const (
+	// UpstreamModeAuto keeps the provider's built-in choice per model
+	UpstreamModeAuto UpstreamMode = "auto"
+	// UpstreamModeStream always uses the streaming endpoint and aggregates
+	UpstreamModeStream UpstreamMode = "stream"
+	// UpstreamModeNonStream prefers the non-streaming endpoint, falling back to streaming if rejected
+	UpstreamModeNonStream UpstreamMode = "non_stream"
+)
+
+// ParseUpstreamMode validates a configured mode; empty means auto
+func ParseUpstreamMode(value string) (UpstreamMode, error) {
+	switch mode := UpstreamMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", UpstreamModeAuto:
+		return UpstreamModeAuto, nil
+	case UpstreamModeStream, UpstreamModeNonStream:
+		return mode, nil
+	default:
+		return UpstreamModeAuto, fmt.Errorf("unknown upstream mode %q (valid: auto, stream, non_stream)", value)
+	}
+}
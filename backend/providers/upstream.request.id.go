@@ -0,0 +1,29 @@
+package providers
+
+import "net/http"
+
+// UpstreamRequestIDHeader is the gateway response header carrying the provider's request ID
+const UpstreamRequestIDHeader = "X-Upstream-Request-ID"
+
+// upstreamRequestIDHeaders are the headers providers report request IDs in, checked in order
+// Anthropic uses request-id; Google and OpenAI-compatible APIs use x-request-id.
+var upstreamRequestIDHeaders = []string{"Request-Id", "X-Request-Id", "X-Goog-Request-Id"}
+
+// UpstreamRequestID returns the provider's request ID from response headers, or "" when absent
+func UpstreamRequestID(headers http.Header) string {
+	for _, name := range upstreamRequestIDHeaders {
+		if id := headers.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// StreamUpstreamRequestID returns the provider's request ID from a StreamResponse header map
+func StreamUpstreamRequestID(headers map[string]string) string {
+	h := make(http.Header, len(headers))
+	for name, value := range headers {
+		h.Set(name, value)
+	}
+	return UpstreamRequestID(h)
+}
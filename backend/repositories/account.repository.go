@@ -39,6 +39,19 @@ func (r *AccountRepository) GetByProvider(providerID string) ([]*models.Account,
 	return accounts, err
 }
 
+// GetByProviderAndLabel finds the oldest account for a provider with the given label (email)
+// Returns nil, nil when no account matches.
+func (r *AccountRepository) GetByProviderAndLabel(providerID, label string) (*models.Account, error) {
+	var account models.Account
+	err := r.db.Where("provider_id = ? AND LOWER(label) = LOWER(?)", providerID, label).
+		Order("created_at ASC").
+		First(&account).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &account, err
+}
+
 func (r *AccountRepository) List(limit, offset int) ([]*models.Account, int64, error) {
 	var accounts []*models.Account
 	var total int64
@@ -154,6 +167,13 @@ func (r *AccountRepository) UpdateHealthFailure(accountID string, errorMsg strin
 		}).Error
 }
 
+// UpdateActive sets whether the account is eligible for selection
+func (r *AccountRepository) UpdateActive(accountID string, active bool) error {
+	return r.db.Model(&models.Account{}).
+		Where("id = ?", accountID).
+		Update("is_active", active).Error
+}
+
 // UpdateHealthStatus sets health status based on failure count
 func (r *AccountRepository) UpdateHealthStatus(accountID string, status string) error {
 	return r.db.Model(&models.Account{}).
@@ -65,6 +65,22 @@ func (r *ModelMappingRepository) ListForUser(userID string, limit, offset int) (
 	return mappings, total, err
 }
 
+// ListEnabled returns every enabled mapping
+func (r *ModelMappingRepository) ListEnabled() ([]*models.ModelMapping, error) {
+	var mappings []*models.ModelMapping
+	err := r.db.Where("enabled = ?", true).Order("priority DESC, alias ASC").Find(&mappings).Error
+	return mappings, err
+}
+
+// ListEnabledForUser returns enabled global mappings plus the user's own (global only when userID is empty)
+func (r *ModelMappingRepository) ListEnabledForUser(userID string) ([]*models.ModelMapping, error) {
+	var mappings []*models.ModelMapping
+	err := r.db.Where("enabled = ? AND (owner_id IS NULL OR owner_id = ?)", true, userID).
+		Order("priority DESC, alias ASC").
+		Find(&mappings).Error
+	return mappings, err
+}
+
 func (r *ModelMappingRepository) GetByAliasWithOwner(alias string) (*models.ModelMapping, error) {
 	var mapping models.ModelMapping
 	err := r.db.Where("alias = ?", alias).First(&mapping).Error
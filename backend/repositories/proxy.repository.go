@@ -83,14 +83,24 @@ func (r *ProxyRepository) DecrementAccountCount(id int) error {
 		Update("current_accounts", gorm.Expr("current_accounts - 1")).Error
 }
 
+// latencyEWMAWeight is how many parts of the previous average are kept per new sample (1/5 = 20% new)
+const latencyEWMAWeight = 4
+
 func (r *ProxyRepository) UpdateHealth(id int, status models.HealthStatus, latencyMs int) error {
 	now := time.Now()
 	updates := map[string]interface{}{
 		"health_status":   status,
-		"avg_latency_ms":  latencyMs,
 		"last_checked_at": &now,
 	}
 
+	// Rolling average so one slow request doesn't reorder latency-based selection; 0 = no sample
+	if latencyMs > 0 {
+		updates["avg_latency_ms"] = gorm.Expr(
+			"CASE WHEN avg_latency_ms > 0 THEN (avg_latency_ms * ? + ?) / ? ELSE ? END",
+			latencyEWMAWeight, latencyMs, latencyEWMAWeight+1, latencyMs,
+		)
+	}
+
 	if status == models.HealthStatusHealthy {
 		updates["consecutive_failures"] = 0
 	} else {
@@ -52,7 +52,7 @@ func (r *QuotaPatternRepository) Save(pattern *models.AccountQuotaPattern) error
 func (r *QuotaPatternRepository) Upsert(pattern *models.AccountQuotaPattern) error {
 	return r.db.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "account_id"}, {Name: "model"}},
-		DoUpdates: clause.AssignmentColumns([]string{"est_request_limit", "est_token_limit", "confidence", "sample_count", "last_exhausted_at", "last_reset_at", "updated_at"}),
+		DoUpdates: clause.AssignmentColumns([]string{"est_request_limit", "est_token_limit", "max_observed_requests", "max_observed_tokens", "confidence", "sample_count", "last_exhausted_at", "last_reset_at", "updated_at"}),
 	}).Create(pattern).Error
 }
 
@@ -97,6 +97,16 @@ func (r *QuotaPatternRepository) ListByAccount(accountID string) ([]*models.Acco
 	return patterns, err
 }
 
+// ListByAccounts returns the patterns of every account in accountIDs
+func (r *QuotaPatternRepository) ListByAccounts(accountIDs []string) ([]*models.AccountQuotaPattern, error) {
+	var patterns []*models.AccountQuotaPattern
+	if len(accountIDs) == 0 {
+		return patterns, nil
+	}
+	err := r.db.Where("account_id IN ?", accountIDs).Find(&patterns).Error
+	return patterns, err
+}
+
 func (r *QuotaPatternRepository) ListByProvider(providerID string) ([]*models.AccountQuotaPattern, error) {
 	var patterns []*models.AccountQuotaPattern
 	err := r.db.
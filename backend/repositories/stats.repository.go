@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"aigateway-backend/models"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -89,8 +90,61 @@ func (r *StatsRepository) GetRequestLogsByAccount(accountID string, limit int) (
 	return logs, err
 }
 
-func (r *StatsRepository) DeleteOldLogs(before time.Time) error {
-	return r.db.Where("created_at < ?", before).Delete(&models.RequestLog{}).Error
+// LatencyFilter selects the request logs latency percentiles are computed over
+// Empty AccountID/Model match every account/model.
+type LatencyFilter struct {
+	AccountID string
+	Model     string
+	Since     time.Time
+}
+
+// GetLatencyPercentiles computes nearest-rank p50/p95/p99 latency of matching request logs
+// Each percentile is one ordered OFFSET query, so logs are never loaded into memory.
+func (r *StatsRepository) GetLatencyPercentiles(filter LatencyFilter) (*models.LatencyPercentiles, error) {
+	scope := func() *gorm.DB {
+		q := r.db.Model(&models.RequestLog{}).Where("created_at >= ?", filter.Since)
+		if filter.AccountID != "" {
+			q = q.Where("account_id = ?", filter.AccountID)
+		}
+		if filter.Model != "" {
+			q = q.Where("model = ?", filter.Model)
+		}
+		return q
+	}
+
+	var result models.LatencyPercentiles
+	err := scope().
+		Select("COUNT(*) AS count, COALESCE(AVG(latency_ms), 0) AS avg_ms, COALESCE(MAX(latency_ms), 0) AS max_ms").
+		Scan(&result).Error
+	if err != nil || result.Count == 0 {
+		return &result, err
+	}
+
+	for _, p := range []struct {
+		percentile float64
+		dest       *int
+	}{
+		{50, &result.P50Ms},
+		{95, &result.P95Ms},
+		{99, &result.P99Ms},
+	} {
+		rank := int(math.Ceil(p.percentile / 100 * float64(result.Count)))
+		var latencies []int
+		err := scope().Order("latency_ms ASC").Offset(rank-1).Limit(1).Pluck("latency_ms", &latencies).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(latencies) > 0 {
+			*p.dest = latencies[0]
+		}
+	}
+
+	return &result, nil
+}
+
+func (r *StatsRepository) DeleteOldLogs(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.RequestLog{})
+	return result.RowsAffected, result.Error
 }
 
 func parseDate(dateStr string) time.Time {
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupStatsRepo creates a StatsRepository over an in-memory SQLite request_logs table
+func setupStatsRepo(t *testing.T) (*StatsRepository, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to test db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.RequestLog{}); err != nil {
+		t.Fatalf("failed to create request_logs table: %v", err)
+	}
+	return NewStatsRepository(db), db
+}
+
+// seedLatencyLogs stores one request log per latency for account+model at createdAt
+func seedLatencyLogs(t *testing.T, db *gorm.DB, accountID, model string, createdAt time.Time, latencies ...int) {
+	for _, latency := range latencies {
+		log := &models.RequestLog{AccountID: &accountID, Model: model, StatusCode: 200, LatencyMs: latency, CreatedAt: createdAt}
+		if err := db.Create(log).Error; err != nil {
+			t.Fatalf("failed to seed request log: %v", err)
+		}
+	}
+}
+
+func TestGetLatencyPercentiles(t *testing.T) {
+	repo, db := setupStatsRepo(t)
+	now := time.Now()
+
+	// acc-1/gemini: latencies 1..100ms, inserted out of order
+	latencies := make([]int, 0, 100)
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, i)
+	}
+	seedLatencyLogs(t, db, "acc-1", "gemini-2.5-pro", now.Add(-time.Minute), latencies...)
+
+	// Other account, other model, and a log outside the window
+	seedLatencyLogs(t, db, "acc-2", "gemini-2.5-pro", now.Add(-time.Minute), 5000)
+	seedLatencyLogs(t, db, "acc-1", "claude-sonnet-4-5", now.Add(-time.Minute), 3000)
+	seedLatencyLogs(t, db, "acc-1", "gemini-2.5-pro", now.Add(-2*time.Hour), 9000)
+
+	since := now.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		filter LatencyFilter
+		want   models.LatencyPercentiles
+	}{
+		{
+			name:   "account and model",
+			filter: LatencyFilter{AccountID: "acc-1", Model: "gemini-2.5-pro", Since: since},
+			want:   models.LatencyPercentiles{Count: 100, AvgMs: 50.5, MaxMs: 100, P50Ms: 50, P95Ms: 95, P99Ms: 99},
+		},
+		{
+			name:   "single log",
+			filter: LatencyFilter{AccountID: "acc-2", Since: since},
+			want:   models.LatencyPercentiles{Count: 1, AvgMs: 5000, MaxMs: 5000, P50Ms: 5000, P95Ms: 5000, P99Ms: 5000},
+		},
+		{
+			// 102 logs: ranks ceil(51)=51, ceil(96.9)=97, ceil(100.98)=101
+			name:   "all accounts and models",
+			filter: LatencyFilter{Since: since},
+			want:   models.LatencyPercentiles{Count: 102, AvgMs: (5050 + 5000 + 3000) / 102.0, MaxMs: 5000, P50Ms: 51, P95Ms: 97, P99Ms: 3000},
+		},
+		{
+			name:   "no matching logs",
+			filter: LatencyFilter{AccountID: "acc-missing", Since: since},
+			want:   models.LatencyPercentiles{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetLatencyPercentiles(tt.filter)
+			if err != nil {
+				t.Fatalf("GetLatencyPercentiles() error = %v", err)
+			}
+			if diff := got.AvgMs - tt.want.AvgMs; diff > 0.001 || diff < -0.001 {
+				t.Errorf("avg_ms = %v, want %v", got.AvgMs, tt.want.AvgMs)
+			}
+			got.AvgMs = tt.want.AvgMs
+			if *got != tt.want {
+				t.Errorf("GetLatencyPercentiles() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
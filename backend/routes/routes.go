@@ -24,10 +24,15 @@ func SetupRoutes(
 	apiKeyHandler *handlers.APIKeyHandler,
 	oauthHandler *handlers.OAuthHandler,
 	quotaHandler *handlers.QuotaHandler,
+	cacheHandler *handlers.CacheHandler,
+	metricsHandler *handlers.MetricsHandler,
+	translateHandler *handlers.TranslateHandler,
+	healthHandler *handlers.HealthHandler,
+	catalogHandler *handlers.CatalogHandler,
 	authMiddleware *middleware.AuthMiddleware,
 ) {
-	// Apply CORS middleware globally
-	r.Use(middleware.CORS())
+	// Apply CORS middleware globally (only whitelisted origins are reflected)
+	r.Use(middleware.CORS(cfg.CORS.AllowedOrigins, cfg.CORS.AllowedMethods, cfg.CORS.AllowedHeaders))
 
 	// Apply global auth extraction
 	r.Use(authMiddleware.ExtractAuth())
@@ -35,13 +40,26 @@ func SetupRoutes(
 	// Health check endpoint (public)
 	r.GET("/health", proxyHandler.HealthCheck)
 
+	// Readiness probe: checks database, Redis and per-provider account health (public)
+	r.GET("/health/ready", healthHandler.Ready)
+
+	// Prometheus metrics (admin; scrape with a bearer API key)
+	r.GET("/metrics", middleware.RequireAdmin(), metricsHandler.Metrics)
+
 	// Public models endpoint
 	r.GET("/v1/models", modelsHandler.GetModels)
 
-
 	// AI model proxy endpoints (require auth with AI access)
-	r.POST("/v1/messages", middleware.RequireAIAccess(), proxyHandler.HandleProxy)
-	r.POST("/v1/chat/completions", middleware.RequireAIAccess(), proxyHandler.HandleProxy)
+	// Streaming requests share a server-wide concurrency cap
+	// Deprecated models are redirected to their successors before routing
+	// Admins may replace the model per request with X-Model-Override
+	streamLimit := middleware.LimitConcurrentStreams(middleware.NewStreamLimiter(cfg.Server.MaxConcurrentStreams))
+	deprecations := middleware.RedirectDeprecatedModels(cfg.ModelDeprecations)
+	override := middleware.OverrideModel() // Admin-only X-Model-Override, applied before deprecation redirects
+	r.POST("/v1/messages", middleware.RequireAIAccess(), authMiddleware.RateLimit(), streamLimit, override, deprecations, proxyHandler.HandleProxy)
+	r.POST("/v1/messages/count_tokens", middleware.RequireAIAccess(), authMiddleware.RateLimit(), override, deprecations, proxyHandler.HandleCountTokens)
+	r.POST("/v1/chat/completions", middleware.RequireAIAccess(), authMiddleware.RateLimit(), streamLimit, override, deprecations, proxyHandler.HandleProxy)
+	r.POST("/v1/completions", middleware.RequireAIAccess(), authMiddleware.RateLimit(), streamLimit, override, deprecations, proxyHandler.HandleCompletions)
 
 	api := r.Group("/api/v1")
 	{
@@ -79,6 +97,9 @@ func SetupRoutes(
 		// Provider endpoints (public for now)
 		api.GET("/providers", proxyHandler.GetProviders)
 
+		// Effective model catalog: provider models + visible aliases with availability
+		api.GET("/catalog", middleware.RequireAuth(), catalogHandler.GetCatalog)
+
 		// Account endpoints (admin + provider)
 		accounts := api.Group("/accounts")
 		accounts.Use(middleware.RequireAccountAccess())
@@ -86,6 +107,7 @@ func SetupRoutes(
 			accounts.GET("", accountHandler.List)
 			accounts.GET("/:id", accountHandler.Get)
 			accounts.POST("", accountHandler.Create)
+			accounts.POST("/import", middleware.RequireAdmin(), oauthHandler.ImportAccounts)
 			accounts.PUT("/:id", accountHandler.Update)
 			accounts.DELETE("/:id", accountHandler.Delete)
 		}
@@ -97,6 +119,7 @@ func SetupRoutes(
 			proxies.GET("", proxyMgmtHandler.List)
 			proxies.GET("/:id", proxyMgmtHandler.Get)
 			proxies.POST("", proxyMgmtHandler.Create)
+			proxies.POST("/validate", proxyMgmtHandler.Validate)
 			proxies.PUT("/:id", proxyMgmtHandler.Update)
 			proxies.DELETE("/:id", proxyMgmtHandler.Delete)
 			proxies.GET("/assignments", proxyMgmtHandler.GetAssignments)
@@ -108,6 +131,7 @@ func SetupRoutes(
 		stats.Use(middleware.RequireRole(models.RoleAdmin, models.RoleUser))
 		{
 			stats.GET("/proxies/:id", statsHandler.GetProxyStats)
+			stats.GET("/latency", statsHandler.GetLatency)
 		}
 
 		// Public logs endpoints (no auth for debugging)
@@ -132,6 +156,16 @@ func SetupRoutes(
 			quota.GET("/providers/:provider/summary", quotaHandler.GetProviderSummary)
 		}
 
+		// Admin maintenance endpoints
+		admin := api.Group("/admin")
+		admin.Use(middleware.RequireAdmin())
+		{
+			admin.POST("/cache/flush", cacheHandler.Flush)
+		}
+
+		// Translation dry-run (admin only)
+		api.POST("/translate/preview", middleware.RequireAdmin(), translateHandler.Preview)
+
 		// Model mapping endpoints (admin + user)
 		mappings := api.Group("/model-mappings")
 		mappings.Use(middleware.RequireRole(models.RoleAdmin, models.RoleUser))
@@ -35,7 +35,7 @@ func (s *AccountService) SelectAccount(providerID, model string) (*models.Accoun
 	healthyAccounts, err := s.repo.GetHealthyAccounts(providerID)
 	if err == nil && len(healthyAccounts) > 0 {
 		// Filter by proxy availability
-		availableAccounts := s.filterAvailableAccounts(healthyAccounts)
+		availableAccounts := s.filterAvailableAccounts(filterByModel(healthyAccounts, model))
 		if len(availableAccounts) > 0 {
 			idx, err := s.redis.Incr(ctx, key).Result()
 			if err != nil {
@@ -54,7 +54,7 @@ func (s *AccountService) SelectAccount(providerID, model string) (*models.Accoun
 	}
 
 	// Filter accounts with available proxies
-	availableAccounts := s.filterAvailableAccounts(accounts)
+	availableAccounts := s.filterAvailableAccounts(filterByModel(accounts, model))
 	if len(availableAccounts) == 0 {
 		return nil, fmt.Errorf("no accounts with available proxies for provider %s", providerID)
 	}
@@ -85,7 +85,7 @@ func (s *AccountService) SelectAccountExcluding(providerID, model, excludeAccoun
 	// Filter accounts with available proxies, excluding the specified account
 	var availableAccounts []*models.Account
 	for _, acc := range accounts {
-		if acc.ID == excludeAccountID {
+		if acc.ID == excludeAccountID || !acc.AllowsModel(model) {
 			continue
 		}
 		if s.isAccountProxyAvailable(acc) {
@@ -109,6 +109,17 @@ func (s *AccountService) SelectAccountExcluding(providerID, model, excludeAccoun
 	return selected, nil
 }
 
+// filterByModel drops accounts whose model policy excludes model
+func filterByModel(accounts []*models.Account, model string) []*models.Account {
+	var allowed []*models.Account
+	for _, acc := range accounts {
+		if acc.AllowsModel(model) {
+			allowed = append(allowed, acc)
+		}
+	}
+	return allowed
+}
+
 // filterAvailableAccounts filters accounts whose proxy is available
 func (s *AccountService) filterAvailableAccounts(accounts []*models.Account) []*models.Account {
 	var available []*models.Account
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// apiKeyRateWindow is the sliding window used for per-key requests-per-minute limits
+const apiKeyRateWindow = time.Minute
+
+// APIKeyRateLimiter enforces per-API-key requests-per-minute with a Redis sliding window
+// Each admitted request is a sorted-set member scored by its timestamp, so the limit holds
+// across gateway instances sharing the same Redis.
+type APIKeyRateLimiter struct {
+	redis *redis.Client
+	now   func() time.Time
+}
+
+// NewAPIKeyRateLimiter creates a limiter backed by redisClient
+func NewAPIKeyRateLimiter(redisClient *redis.Client) *APIKeyRateLimiter {
+	return &APIKeyRateLimiter{redis: redisClient, now: time.Now}
+}
+
+// Allow records a request for keyID and reports whether it fits within limit per minute
+// When denied, retryAfter is how long until the oldest request leaves the window.
+// limit <= 0 means unlimited.
+func (l *APIKeyRateLimiter) Allow(ctx context.Context, keyID string, limit int) (bool, time.Duration, error) {
+	return l.allow(ctx, apiKeyRateKey(keyID), limit)
+}
+
+// AllowUser is Allow for requests a user makes without an API key (access key or login token)
+func (l *APIKeyRateLimiter) AllowUser(ctx context.Context, userID string, limit int) (bool, time.Duration, error) {
+	return l.allow(ctx, userRateKey(userID), limit)
+}
+
+// allow applies the sliding window stored at key
+func (l *APIKeyRateLimiter) allow(ctx context.Context, key string, limit int) (bool, time.Duration, error) {
+	if limit <= 0 {
+		return true, 0, nil
+	}
+
+	now := l.now()
+	member := strconv.FormatInt(now.UnixNano(), 10) + "-" + uuid.NewString()
+	windowStart := now.Add(-apiKeyRateWindow).UnixNano()
+
+	var countCmd *redis.IntCmd
+	_, err := l.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(windowStart, 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixNano()), Member: member})
+		countCmd = pipe.ZCard(ctx, key)
+		pipe.Expire(ctx, key, apiKeyRateWindow)
+		return nil
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("rate limit check failed: %w", err)
+	}
+
+	if countCmd.Val() <= int64(limit) {
+		return true, 0, nil
+	}
+
+	// Over the limit: this request doesn't count against the window
+	l.redis.ZRem(ctx, key, member)
+
+	retryAfter := apiKeyRateWindow
+	oldest, err := l.redis.ZRangeWithScores(ctx, key, 0, 0).Result()
+	if err == nil && len(oldest) > 0 {
+		retryAfter = time.Unix(0, int64(oldest[0].Score)).Add(apiKeyRateWindow).Sub(now)
+	}
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+
+	return false, retryAfter, nil
+}
+
+// apiKeyRateKey returns the Redis key holding the request window for an API key
+func apiKeyRateKey(keyID string) string {
+	return fmt.Sprintf("ratelimit:apikey:%s", keyID)
+}
+
+// userRateKey returns the Redis key holding the request window for a user
+func userRateKey(userID string) string {
+	return fmt.Sprintf("ratelimit:user:%s", userID)
+}
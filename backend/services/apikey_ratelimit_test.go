@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyRateLimiter_AllowDenyAtBoundary(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewAPIKeyRateLimiter(redisClient)
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Requests up to the limit are allowed
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow(ctx, "key-1", 3)
+		if err != nil {
+			t.Fatalf("Allow() error = %v", err)
+		}
+		if !allowed {
+			t.Fatalf("request %d denied, want allowed within limit", i+1)
+		}
+		now = now.Add(10 * time.Second)
+	}
+
+	// The next one in the same minute is denied until the first request leaves the window
+	allowed, retryAfter, err := limiter.Allow(ctx, "key-1", 3)
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if allowed {
+		t.Fatal("request over limit allowed, want denied")
+	}
+	if retryAfter != 30*time.Second {
+		t.Errorf("retryAfter = %v, want 30s", retryAfter)
+	}
+
+	// Other keys have their own window
+	if allowed, _, _ := limiter.Allow(ctx, "key-2", 3); !allowed {
+		t.Error("different key denied, want independent limit")
+	}
+
+	// Denied requests don't count, so the slot opens as soon as the first request expires
+	now = now.Add(30 * time.Second)
+	if allowed, _, _ := limiter.Allow(ctx, "key-1", 3); !allowed {
+		t.Error("request after window slid denied, want allowed")
+	}
+	if allowed, _, _ := limiter.Allow(ctx, "key-1", 3); allowed {
+		t.Error("second request after slide allowed, want denied (window full again)")
+	}
+}
+
+func TestAPIKeyRateLimiter_UnlimitedKey(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	limiter := NewAPIKeyRateLimiter(redisClient)
+	for i := 0; i < 10; i++ {
+		if allowed, _, _ := limiter.Allow(context.Background(), "key-1", 0); !allowed {
+			t.Fatal("key without a limit denied")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// AsyncWrites tracks fire-and-forget persistence (request logs, proxy stats, health and quota
+// updates) so graceful shutdown can wait for them instead of dropping them mid-write.
+// Unlike sync.WaitGroup it is safe to schedule new work while a Drain is in progress.
+type AsyncWrites struct {
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{}
+}
+
+// NewAsyncWrites creates an empty tracker
+func NewAsyncWrites() *AsyncWrites {
+	idle := make(chan struct{})
+	close(idle)
+	return &AsyncWrites{idle: idle}
+}
+
+// asyncWrites is the process-wide tracker used by the stats, quota and router services
+var asyncWrites = NewAsyncWrites()
+
+// goAsync runs fn in a goroutine tracked by the process-wide tracker
+func goAsync(fn func()) {
+	asyncWrites.Go(fn)
+}
+
+// DrainAsyncWrites blocks until all tracked background writes finish or ctx expires
+func DrainAsyncWrites(ctx context.Context) error {
+	return asyncWrites.Drain(ctx)
+}
+
+// Go runs fn in a new goroutine and tracks it until it returns
+func (a *AsyncWrites) Go(fn func()) {
+	a.mu.Lock()
+	if a.pending == 0 {
+		a.idle = make(chan struct{})
+	}
+	a.pending++
+	a.mu.Unlock()
+
+	go func() {
+		defer a.done()
+		fn()
+	}()
+}
+
+// Pending returns the number of writes still in flight
+func (a *AsyncWrites) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.pending
+}
+
+// Drain waits until no writes are pending, including ones scheduled while draining
+func (a *AsyncWrites) Drain(ctx context.Context) error {
+	for {
+		a.mu.Lock()
+		if a.pending == 0 {
+			a.mu.Unlock()
+			return nil
+		}
+		idle := a.idle
+		a.mu.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return fmt.Errorf("%d async writes still pending: %w", a.Pending(), ctx.Err())
+		}
+	}
+}
+
+func (a *AsyncWrites) done() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending--
+	if a.pending == 0 {
+		close(a.idle)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aigateway-backend/repositories"
+)
+
+func TestAsyncWrites_DrainWaitsForPending(t *testing.T) {
+	a := NewAsyncWrites()
+
+	var completed int32
+	for i := 0; i < 5; i++ {
+		a.Go(func() {
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&completed, 1)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := a.Drain(ctx); err != nil {
+		t.Fatalf("Drain returned error: %v", err)
+	}
+	if got := atomic.LoadInt32(&completed); got != 5 {
+		t.Errorf("completed = %d before Drain returned, want 5", got)
+	}
+	if a.Pending() != 0 {
+		t.Errorf("Pending = %d after drain, want 0", a.Pending())
+	}
+}
+
+func TestAsyncWrites_DrainIncludesWritesScheduledDuringDrain(t *testing.T) {
+	a := NewAsyncWrites()
+
+	var chained int32
+	a.Go(func() {
+		time.Sleep(20 * time.Millisecond)
+		// Follow-up write scheduled by an in-flight one (e.g. RecordRequest -> CreateRequestLog)
+		a.Go(func() {
+			time.Sleep(50 * time.Millisecond)
+			atomic.StoreInt32(&chained, 1)
+		})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := a.Drain(ctx); err != nil {
+		t.Fatalf("Drain returned error: %v", err)
+	}
+	if atomic.LoadInt32(&chained) != 1 {
+		t.Error("Drain returned before chained write completed")
+	}
+}
+
+func TestAsyncWrites_DrainTimeout(t *testing.T) {
+	a := NewAsyncWrites()
+	release := make(chan struct{})
+	defer close(release)
+	a.Go(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := a.Drain(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestAsyncWrites_DrainEmpty(t *testing.T) {
+	if err := NewAsyncWrites().Drain(context.Background()); err != nil {
+		t.Fatalf("Drain on empty tracker returned %v", err)
+	}
+}
+
+func TestDrainAsyncWrites_PersistsQuotaLearning(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	service.RecordUsage("acc-drain", "gemini-2.5-pro", 1000)
+	service.MarkExhausted("acc-drain", "gemini-2.5-pro")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := DrainAsyncWrites(ctx); err != nil {
+		t.Fatalf("DrainAsyncWrites returned error: %v", err)
+	}
+
+	pattern, err := repo.GetByAccountModel("acc-drain", "gemini-2.5-pro")
+	if err != nil || pattern == nil {
+		t.Fatalf("expected learned pattern to be persisted before drain returned, got %v (err=%v)", pattern, err)
+	}
+	if pattern.SampleCount != 1 {
+		t.Errorf("SampleCount = %d, want 1", pattern.SampleCount)
+	}
+}
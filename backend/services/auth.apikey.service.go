@@ -26,18 +26,21 @@ func NewAPIKeyService(repo *repositories.APIKeyRepository, redis *redis.Client)
 	return &APIKeyService{repo: repo, redis: redis}
 }
 
-func (s *APIKeyService) Generate(userID, label string) (*models.APIKey, string, error) {
+// Generate creates a new API key; rateLimitRPM caps requests per minute (0 = unlimited)
+func (s *APIKeyService) Generate(userID, label string, rateLimitRPM int, responseProfile string) (*models.APIKey, string, error) {
 	rawKey := s.generateRawKey()
 	hash := s.hashKey(rawKey)
 	prefix := rawKey[:12]
 
 	apiKey := &models.APIKey{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		KeyHash:   hash,
-		KeyPrefix: prefix,
-		Label:     label,
-		IsActive:  true,
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		KeyHash:         hash,
+		KeyPrefix:       prefix,
+		Label:           label,
+		IsActive:        true,
+		RateLimitRPM:    rateLimitRPM,
+		ResponseProfile: responseProfile,
 	}
 
 	if err := s.repo.Create(apiKey); err != nil {
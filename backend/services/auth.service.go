@@ -104,6 +104,15 @@ func (s *AuthService) ValidateJWT(token string) (*models.User, error) {
 }
 
 func (s *AuthService) ValidateAPIKey(rawKey string) (*models.User, error) {
+	apiKey, err := s.AuthenticateAPIKey(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	return apiKey.User, nil
+}
+
+// AuthenticateAPIKey validates an API key and returns it with its active owner loaded
+func (s *AuthService) AuthenticateAPIKey(rawKey string) (*models.APIKey, error) {
 	apiKey, err := s.apiKeyService.Validate(rawKey)
 	if err != nil {
 		return nil, err
@@ -117,7 +126,7 @@ func (s *AuthService) ValidateAPIKey(rawKey string) (*models.User, error) {
 		return nil, errors.New("account disabled")
 	}
 
-	return apiKey.User, nil
+	return apiKey, nil
 }
 
 // ValidateAccessKey validates a user access key (uk_ prefix)
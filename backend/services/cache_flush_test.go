@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+)
+
+func TestFlushTokenCache_RemovesCachedTokens(t *testing.T) {
+	db := setupTestDB(t)
+	createAccountsTable(t, db)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	authData := fmt.Sprintf(`{"access_token":"token","expires_at":"%s"}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	account := &models.Account{ID: "acc-1", ProviderID: "antigravity", Label: "acc-1", AuthData: authData, IsActive: true}
+	if err := db.Create(account).Error; err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+
+	accountRepo := repositories.NewAccountRepository(db)
+	service := NewOAuthService(redisClient, accountRepo, nil, nil)
+
+	if _, err := service.GetAccessToken(account); err != nil {
+		t.Fatalf("GetAccessToken() error = %v", err)
+	}
+	if !mr.Exists("auth:antigravity:acc-1") {
+		t.Fatal("expected token to be cached")
+	}
+
+	// Unrelated auth manager state must survive the flush
+	mr.Set("auth:budget:acc-1", "5")
+
+	cleared, err := service.FlushTokenCache(context.Background())
+	if err != nil {
+		t.Fatalf("FlushTokenCache() error = %v", err)
+	}
+	if cleared != 1 {
+		t.Errorf("cleared = %d, want 1", cleared)
+	}
+	if mr.Exists("auth:antigravity:acc-1") {
+		t.Error("token cache entry should be removed")
+	}
+	if !mr.Exists("auth:budget:acc-1") {
+		t.Error("budget counter should not be flushed")
+	}
+}
+
+func TestFlushMappingCache_ForcesReResolution(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS model_mappings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			alias TEXT NOT NULL UNIQUE,
+			provider_id TEXT NOT NULL,
+			model_name TEXT NOT NULL,
+			description TEXT,
+			enabled BOOLEAN DEFAULT 1,
+			priority INTEGER DEFAULT 0,
+			owner_id TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	service := NewModelMappingService(repositories.NewModelMappingRepository(db), redisClient)
+	ctx := context.Background()
+
+	mapping := &models.ModelMapping{Alias: "fast", ProviderID: "antigravity", ModelName: "gemini-2.5-flash", Enabled: true}
+	if err := db.Create(mapping).Error; err != nil {
+		t.Fatalf("failed to create mapping: %v", err)
+	}
+
+	if got := service.Resolve(ctx, "fast"); got == nil || got.ModelName != "gemini-2.5-flash" {
+		t.Fatalf("Resolve() = %+v, want gemini-2.5-flash", got)
+	}
+
+	// Change DB behind the cache's back; the stale cache still wins
+	if err := db.Model(mapping).Update("model_name", "gemini-2.5-pro").Error; err != nil {
+		t.Fatalf("failed to update mapping: %v", err)
+	}
+	if got := service.Resolve(ctx, "fast"); got.ModelName != "gemini-2.5-flash" {
+		t.Fatalf("Resolve() = %s, want cached gemini-2.5-flash", got.ModelName)
+	}
+
+	cleared, err := service.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("FlushCache() error = %v", err)
+	}
+	if cleared != 1 {
+		t.Errorf("cleared = %d, want 1", cleared)
+	}
+
+	if got := service.Resolve(ctx, "fast"); got == nil || got.ModelName != "gemini-2.5-pro" {
+		t.Errorf("Resolve() after flush = %+v, want gemini-2.5-pro", got)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"sort"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+)
+
+// CatalogModel is one model a caller can request, with its backing provider and current availability
+type CatalogModel struct {
+	ID           string                      `json:"id"`
+	ProviderID   string                      `json:"provider_id"`
+	Model        string                      `json:"model"` // Upstream model the ID routes to
+	Alias        bool                        `json:"alias"`
+	Available    bool                        `json:"available"` // At least one account can serve it right now
+	Capabilities providers.ModelCapabilities `json:"capabilities"`
+}
+
+// CatalogResponse is the gateway's effective model catalog
+type CatalogResponse struct {
+	Models []CatalogModel `json:"models"`
+}
+
+// CatalogService combines registered providers, model mappings and account health into one catalog
+type CatalogService struct {
+	registry    *providers.Registry
+	mappings    *ModelMappingService
+	authManager *manager.Manager
+}
+
+func NewCatalogService(registry *providers.Registry, mappings *ModelMappingService, authManager *manager.Manager) *CatalogService {
+	return &CatalogService{
+		registry:    registry,
+		mappings:    mappings,
+		authManager: authManager,
+	}
+}
+
+// GetCatalog returns every provider model plus the aliases visible to user
+// Aliases pointing at an unregistered provider are listed as unavailable.
+func (s *CatalogService) GetCatalog(user *models.User) (*CatalogResponse, error) {
+	mappings, err := s.mappings.ListEnabledFor(user)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &CatalogResponse{Models: make([]CatalogModel, 0)}
+	available := make(map[string]bool)
+
+	for _, provider := range s.registry.List() {
+		for _, model := range provider.SupportedModels() {
+			catalog.Models = append(catalog.Models, CatalogModel{
+				ID:           model,
+				ProviderID:   provider.ID(),
+				Model:        model,
+				Available:    s.isAvailable(available, provider.ID(), model),
+				Capabilities: providers.CapabilitiesFor(provider, model),
+			})
+		}
+	}
+
+	for _, m := range mappings {
+		entry := CatalogModel{ID: m.Alias, ProviderID: m.ProviderID, Model: m.ModelName, Alias: true}
+		if provider, err := s.registry.Get(m.ProviderID); err == nil {
+			entry.Available = s.isAvailable(available, m.ProviderID, m.ModelName)
+			entry.Capabilities = providers.CapabilitiesFor(provider, m.ModelName)
+		}
+		catalog.Models = append(catalog.Models, entry)
+	}
+
+	sort.SliceStable(catalog.Models, func(i, j int) bool {
+		if catalog.Models[i].ProviderID != catalog.Models[j].ProviderID {
+			return catalog.Models[i].ProviderID < catalog.Models[j].ProviderID
+		}
+		return catalog.Models[i].ID < catalog.Models[j].ID
+	})
+
+	return catalog, nil
+}
+
+// isAvailable reports whether any account is eligible for provider+model, memoized per catalog build
+func (s *CatalogService) isAvailable(seen map[string]bool, providerID, model string) bool {
+	key := providerID + "/" + model
+	if available, ok := seen[key]; ok {
+		return available
+	}
+
+	available := false
+	if s.authManager != nil {
+		for _, candidate := range s.authManager.Candidates(providerID, model) {
+			if candidate.Eligible {
+				available = true
+				break
+			}
+		}
+	}
+	seen[key] = available
+	return available
+}
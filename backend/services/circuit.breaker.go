@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a provider's circuit breaker
+type BreakerState string
+
+const (
+	// BreakerClosed lets requests through normally
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails requests fast until the cooldown elapses
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe request through to test recovery
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// DefaultBreakerCooldown is how long an open breaker rejects requests before probing
+const DefaultBreakerCooldown = 30 * time.Second
+
+// CircuitOpenError is returned while a provider's breaker is failing requests fast
+type CircuitOpenError struct {
+	ProviderID string
+	RetryAt    time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("provider %s unavailable: no usable accounts, retry after %s", e.ProviderID, e.RetryAt.Format(time.RFC3339))
+}
+
+// BreakerStatus is a snapshot of one provider's breaker
+type BreakerStatus struct {
+	ProviderID          string       `json:"provider_id"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"` // When an open breaker starts probing
+}
+
+// CircuitBreaker fails requests fast for providers whose accounts are all unusable
+// After threshold consecutive provider-wide failures (no account could be selected) the breaker
+// opens for the cooldown, then half-opens and admits one probe: a probe that reaches an account
+// closes it, a probe that fails reopens it.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	clock     func() time.Time
+	providers map[string]*providerBreaker
+}
+
+type providerBreaker struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool // A half-open probe is in flight
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold failures (cooldown 0 = DefaultBreakerCooldown)
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     time.Now,
+		providers: make(map[string]*providerBreaker),
+	}
+}
+
+// Allow reports whether a request to providerID may proceed
+// Returns a *CircuitOpenError while the breaker is open or a half-open probe is already in flight.
+func (b *CircuitBreaker) Allow(providerID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pb := b.get(providerID)
+	switch pb.state {
+	case BreakerOpen:
+		retryAt := pb.openedAt.Add(b.cooldown)
+		if b.clock().Before(retryAt) {
+			return &CircuitOpenError{ProviderID: providerID, RetryAt: retryAt}
+		}
+		pb.state = BreakerHalfOpen
+		pb.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if pb.probing {
+			return &CircuitOpenError{ProviderID: providerID, RetryAt: b.clock().Add(b.cooldown)}
+		}
+		pb.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the breaker after a request reached an account
+func (b *CircuitBreaker) RecordSuccess(providerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pb := b.get(providerID)
+	pb.state = BreakerClosed
+	pb.failures = 0
+	pb.probing = false
+}
+
+// RecordFailure counts a provider-wide failure, opening the breaker at the threshold
+// A failed half-open probe reopens the breaker for another cooldown.
+func (b *CircuitBreaker) RecordFailure(providerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pb := b.get(providerID)
+	pb.failures++
+	if pb.state == BreakerHalfOpen || pb.failures >= b.threshold {
+		pb.state = BreakerOpen
+		pb.openedAt = b.clock()
+	}
+	pb.probing = false
+}
+
+// ReleaseProbe ends a half-open probe that neither succeeded nor failed (e.g. the client went away)
+func (b *CircuitBreaker) ReleaseProbe(providerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.get(providerID).probing = false
+}
+
+// Status returns every provider's breaker, sorted by provider ID
+func (b *CircuitBreaker) Status() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := make([]BreakerStatus, 0, len(b.providers))
+	for providerID, pb := range b.providers {
+		status := BreakerStatus{ProviderID: providerID, State: pb.state, ConsecutiveFailures: pb.failures}
+		if pb.state != BreakerClosed {
+			openedAt := pb.openedAt
+			retryAt := openedAt.Add(b.cooldown)
+			status.OpenedAt = &openedAt
+			status.RetryAt = &retryAt
+		}
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProviderID < result[j].ProviderID
+	})
+	return result
+}
+
+// get returns the breaker for providerID, creating it closed; callers hold b.mu
+func (b *CircuitBreaker) get(providerID string) *providerBreaker {
+	pb, ok := b.providers[providerID]
+	if !ok {
+		pb = &providerBreaker{state: BreakerClosed}
+		b.providers[providerID] = pb
+	}
+	return pb
+}
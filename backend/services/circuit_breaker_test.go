@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker whose clock is advanced by the returned function
+func newTestBreaker(threshold int, cooldown time.Duration) (*CircuitBreaker, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(threshold, cooldown)
+	b.clock = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func breakerState(b *CircuitBreaker, providerID string) BreakerState {
+	for _, status := range b.Status() {
+		if status.ProviderID == providerID {
+			return status.State
+		}
+	}
+	return BreakerClosed
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		b.RecordFailure("antigravity")
+	}
+	if err := b.Allow("antigravity"); err != nil {
+		t.Fatalf("Allow() below threshold = %v, want nil", err)
+	}
+
+	b.RecordFailure("antigravity")
+	var open *CircuitOpenError
+	if err := b.Allow("antigravity"); !errors.As(err, &open) {
+		t.Fatalf("Allow() at threshold = %v, want *CircuitOpenError", err)
+	}
+	if breakerState(b, "antigravity") != BreakerOpen {
+		t.Errorf("state = %s, want open", breakerState(b, "antigravity"))
+	}
+	if err := b.Allow("openai"); err != nil {
+		t.Errorf("other providers should be unaffected, Allow() = %v", err)
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsFailureStreak(t *testing.T) {
+	b, _ := newTestBreaker(2, time.Minute)
+
+	b.RecordFailure("antigravity")
+	b.RecordSuccess("antigravity")
+	b.RecordFailure("antigravity")
+
+	if err := b.Allow("antigravity"); err != nil {
+		t.Errorf("Allow() = %v, want nil (streak was reset by the success)", err)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	b, advance := newTestBreaker(1, time.Minute)
+	b.RecordFailure("antigravity")
+
+	advance(time.Minute)
+	if err := b.Allow("antigravity"); err != nil {
+		t.Fatalf("first Allow() after cooldown = %v, want the probe to pass", err)
+	}
+	if breakerState(b, "antigravity") != BreakerHalfOpen {
+		t.Fatalf("state = %s, want half_open", breakerState(b, "antigravity"))
+	}
+	if err := b.Allow("antigravity"); err == nil {
+		t.Fatal("second Allow() while probing should be rejected")
+	}
+
+	// Failed probe reopens for a full cooldown
+	b.RecordFailure("antigravity")
+	if breakerState(b, "antigravity") != BreakerOpen {
+		t.Fatalf("state after failed probe = %s, want open", breakerState(b, "antigravity"))
+	}
+	advance(30 * time.Second)
+	if err := b.Allow("antigravity"); err == nil {
+		t.Fatal("Allow() should be rejected until the new cooldown elapses")
+	}
+
+	// Successful probe closes the breaker
+	advance(30 * time.Second)
+	if err := b.Allow("antigravity"); err != nil {
+		t.Fatalf("probe Allow() = %v, want nil", err)
+	}
+	b.RecordSuccess("antigravity")
+	if breakerState(b, "antigravity") != BreakerClosed {
+		t.Errorf("state after successful probe = %s, want closed", breakerState(b, "antigravity"))
+	}
+	if err := b.Allow("antigravity"); err != nil {
+		t.Errorf("Allow() after recovery = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreaker_ReleaseProbeAdmitsNextProbe(t *testing.T) {
+	b, advance := newTestBreaker(1, time.Minute)
+	b.RecordFailure("antigravity")
+	advance(time.Minute)
+
+	b.Allow("antigravity")
+	b.ReleaseProbe("antigravity")
+	if err := b.Allow("antigravity"); err != nil {
+		t.Errorf("Allow() after a released probe = %v, want another probe admitted", err)
+	}
+}
+
+func TestExecuteStream_CircuitBreakerFailsFastWhenAllAccountsBlocked(t *testing.T) {
+	provider := &streamingProvider{upstreamURL: "http://127.0.0.1:0"}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+	router.SetCircuitBreaker(NewCircuitBreaker(2, time.Minute))
+
+	// Rate limit the only account so selection fails provider-wide
+	router.authManager.MarkResult("acc-1", "gemini-2.5-pro", http.StatusTooManyRequests, nil, nil)
+
+	req := Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}
+	for i := 0; i < 2; i++ {
+		statusCode, err := router.ExecuteStream(context.Background(), req, &flushRecorder{ResponseRecorder: httptest.NewRecorder()})
+		if err == nil || statusCode == http.StatusServiceUnavailable {
+			t.Fatalf("request %d: status = %d, err = %v, want a selection failure before the breaker opens", i+1, statusCode, err)
+		}
+	}
+
+	statusCode, err := router.ExecuteStream(context.Background(), req, &flushRecorder{ResponseRecorder: httptest.NewRecorder()})
+	var open *CircuitOpenError
+	if statusCode != http.StatusServiceUnavailable || !errors.As(err, &open) {
+		t.Fatalf("status = %d, err = %v, want 503 with *CircuitOpenError", statusCode, err)
+	}
+	if open.ProviderID != "antigravity" {
+		t.Errorf("ProviderID = %q, want antigravity", open.ProviderID)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"aigateway-backend/models"
 	"aigateway-backend/providers"
@@ -42,6 +43,10 @@ func (s *ExecutorService) Execute(ctx context.Context, req Request) (Response, e
 		return Response{}, err
 	}
 
+	if err := providers.CheckRequest(provider, req.Payload, resolvedModel); err != nil {
+		return Response{StatusCode: http.StatusBadRequest}, err
+	}
+
 	providerID := provider.ID()
 
 	// Step 2: Select account (override or round-robin)
@@ -94,30 +99,36 @@ func (s *ExecutorService) Execute(ctx context.Context, req Request) (Response, e
 	latencyMs := executeResp.LatencyMs
 
 	providerIDPtr := &providerID
-	go s.statsTrackerService.RecordRequest(
-		&account.ID,
-		account.ProxyID,
-		providerIDPtr,
-		resolvedModel,
-		statusCode,
-		latencyMs,
-	)
+	goAsync(func() {
+		s.statsTrackerService.RecordRequest(
+			&account.ID,
+			account.ProxyID,
+			providerIDPtr,
+			resolvedModel,
+			statusCode,
+			latencyMs,
+			providers.UpstreamRequestID(executeResp.Headers),
+		)
+	})
 
 	// Check if request was successful
 	if statusCode < 200 || statusCode >= 300 {
 		return Response{
 			StatusCode: statusCode,
 			Payload:    executeResp.Payload,
+			Headers:    executeResp.Headers,
 		}, fmt.Errorf("upstream error: %d", statusCode)
 	}
 
 	return Response{
 		StatusCode: statusCode,
 		Payload:    executeResp.Payload,
+		Headers:    executeResp.Headers,
 	}, nil
 }
 
 // ExecuteStream processes a streaming request through the complete pipeline
+// A request the provider rejects outright returns a 400 StreamResponse along with the error.
 func (s *ExecutorService) ExecuteStream(ctx context.Context, req Request) (*providers.StreamResponse, error) {
 	// Step 1: Route to appropriate provider (may resolve alias to actual model)
 	provider, resolvedModel, err := s.routerService.Route(req.Model)
@@ -130,6 +141,10 @@ func (s *ExecutorService) ExecuteStream(ctx context.Context, req Request) (*prov
 		return nil, fmt.Errorf("provider %s does not support streaming", provider.ID())
 	}
 
+	if err := providers.CheckRequest(provider, req.Payload, resolvedModel); err != nil {
+		return &providers.StreamResponse{StatusCode: http.StatusBadRequest}, err
+	}
+
 	providerID := provider.ID()
 
 	// Step 2: Select account (override or round-robin)
@@ -168,6 +183,7 @@ func (s *ExecutorService) ExecuteStream(ctx context.Context, req Request) (*prov
 		Account:  account,
 		ProxyURL: account.ProxyURL,
 		Token:    token,
+		Format:   req.inputFormat(),
 	}
 
 	streamResp, err := provider.ExecuteStream(ctx, executeReq)
@@ -188,6 +204,7 @@ func (s *ExecutorService) ExecuteStream(ctx context.Context, req Request) (*prov
 			resolvedModel,
 			streamResp.StatusCode,
 			0,
+			providers.StreamUpstreamRequestID(streamResp.Headers),
 		)
 	}()
 
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// IdempotencyKeyHeader is the client-supplied key that makes a retried request safe to replay
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader marks a response served from the idempotency cache
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyTTL is how long a completed response can be replayed
+	DefaultIdempotencyTTL = 10 * time.Minute
+
+	// idempotencyLockTTL bounds how long a crashed request can hold its key
+	idempotencyLockTTL = 5 * time.Minute
+)
+
+var (
+	// ErrIdempotencyInProgress means another request with the same key hasn't finished yet
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+
+	// ErrIdempotencyKeyReused means the key was already used with a different request body
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request body")
+)
+
+// releaseIdempotencyLock deletes a lock only while it still holds this request's token,
+// so a request that outlived idempotencyLockTTL can't release a lock another request now holds
+var releaseIdempotencyLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// IdempotentResponse is a completed response stored for replay
+type IdempotentResponse struct {
+	StatusCode        int    `json:"status_code"`
+	Body              []byte `json:"body"`
+	UpstreamRequestID string `json:"upstream_request_id,omitempty"`
+	RequestHash       string `json:"request_hash"`
+}
+
+// IdempotencyStore caches responses by Idempotency-Key so client retries don't re-execute upstream
+// Keys are scoped to the caller and stored hashed; only successful responses are cached,
+// so a retry after an upstream failure executes again.
+type IdempotencyStore struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+// NewIdempotencyStore creates a store that replays responses for ttl (0 = DefaultIdempotencyTTL)
+func NewIdempotencyStore(redisClient *redis.Client, ttl time.Duration) *IdempotencyStore {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &IdempotencyStore{redis: redisClient, ttl: ttl}
+}
+
+// Do runs execute once per scope+key, returning the cached response on replay
+// replayed reports whether the response came from the cache. If Redis is unavailable
+// the request executes without idempotency rather than failing.
+func (s *IdempotencyStore) Do(
+	ctx context.Context,
+	scope, key string,
+	payload []byte,
+	execute func() *IdempotentResponse,
+) (resp *IdempotentResponse, replayed bool, err error) {
+	responseKey, lockKey := idempotencyKeys(scope, key)
+	requestHash := hashIdempotencyPart(string(payload))
+
+	if cached, err := s.get(ctx, responseKey); err != nil {
+		log.Printf("[Idempotency] Lookup failed, executing without replay: %v", err)
+		return execute(), false, nil
+	} else if cached != nil {
+		return replay(cached, requestHash)
+	}
+
+	return s.executeLocked(ctx, responseKey, lockKey, requestHash, execute)
+}
+
+// executeLocked runs execute under the key's lock and stores a successful response for replay
+// The response is looked up again once the lock is held, since a concurrent request may have
+// stored it and released the lock after the caller's first lookup.
+func (s *IdempotencyStore) executeLocked(
+	ctx context.Context,
+	responseKey, lockKey, requestHash string,
+	execute func() *IdempotentResponse,
+) (*IdempotentResponse, bool, error) {
+	token := requestHash + ":" + uuid.NewString()
+	acquired, err := s.redis.SetNX(ctx, lockKey, token, idempotencyLockTTL).Result()
+	if err != nil {
+		log.Printf("[Idempotency] Lock failed, executing without replay: %v", err)
+		return execute(), false, nil
+	}
+	if !acquired {
+		return nil, false, ErrIdempotencyInProgress
+	}
+	defer releaseIdempotencyLock.Run(context.Background(), s.redis, []string{lockKey}, token)
+
+	if cached, err := s.get(ctx, responseKey); err == nil && cached != nil {
+		return replay(cached, requestHash)
+	}
+
+	resp := execute()
+	if resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		resp.RequestHash = requestHash
+		if data, err := json.Marshal(resp); err == nil {
+			if err := s.redis.Set(context.Background(), responseKey, data, s.ttl).Err(); err != nil {
+				log.Printf("[Idempotency] Failed to store response: %v", err)
+			}
+		}
+	}
+	return resp, false, nil
+}
+
+// replay returns a cached response if it was stored for the same request body
+func replay(cached *IdempotentResponse, requestHash string) (*IdempotentResponse, bool, error) {
+	if cached.RequestHash != requestHash {
+		return nil, false, ErrIdempotencyKeyReused
+	}
+	return cached, true, nil
+}
+
+// get returns the cached response for responseKey, or nil if none is stored
+func (s *IdempotencyStore) get(ctx context.Context, responseKey string) (*IdempotentResponse, error) {
+	data, err := s.redis.Get(ctx, responseKey).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cached IdempotentResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+// idempotencyKeys returns the Redis keys for a caller's Idempotency-Key
+func idempotencyKeys(scope, key string) (responseKey, lockKey string) {
+	hash := hashIdempotencyPart(scope + ":" + key)
+	return "idempotency:" + hash, "idempotency:lock:" + hash
+}
+
+func hashIdempotencyPart(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
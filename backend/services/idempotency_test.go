@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"aigateway-backend/repositories"
+)
+
+func TestIdempotencyStore_ReplayDoesNotReExecute(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	tracker := NewQuotaTrackerService(repositories.NewQuotaPatternRepository(setupTestDB(t)), redisClient)
+	store := NewIdempotencyStore(redisClient, 0)
+
+	payload := []byte(`{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hi"}]}`)
+	executions := 0
+	execute := func() *IdempotentResponse {
+		executions++
+		tracker.RecordUsage("acc-1", "gemini-2.5-pro", 100)
+		return &IdempotentResponse{StatusCode: 200, Body: []byte(`{"id":"msg_1"}`), UpstreamRequestID: "req_up_1"}
+	}
+
+	first, replayed, err := store.Do(context.Background(), "key:k1", "idem-1", payload, execute)
+	if err != nil || replayed {
+		t.Fatalf("first Do() replayed = %v, err = %v, want a live execution", replayed, err)
+	}
+
+	second, replayed, err := store.Do(context.Background(), "key:k1", "idem-1", payload, execute)
+	if err != nil {
+		t.Fatalf("replay Do() error = %v", err)
+	}
+	if !replayed {
+		t.Error("second Do() should be served from the cache")
+	}
+	if string(second.Body) != string(first.Body) || second.StatusCode != 200 || second.UpstreamRequestID != "req_up_1" {
+		t.Errorf("replayed = %+v, want the cached response", second)
+	}
+
+	if executions != 1 {
+		t.Errorf("executions = %d, want 1", executions)
+	}
+	reqCount, _ := redisClient.Get(context.Background(), QuotaKeys{}.RequestsKey("acc-1", "gemini-2.5-pro")).Int()
+	if reqCount != 1 {
+		t.Errorf("quota request count = %d, want 1", reqCount)
+	}
+}
+
+func TestIdempotencyStore_FailureIsNotCached(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+	store := NewIdempotencyStore(redisClient, 0)
+
+	statuses := []int{503, 200}
+	executions := 0
+	execute := func() *IdempotentResponse {
+		status := statuses[executions]
+		executions++
+		return &IdempotentResponse{StatusCode: status, Body: []byte(`{}`)}
+	}
+
+	store.Do(context.Background(), "key:k1", "idem-1", []byte(`{}`), execute)
+	resp, replayed, err := store.Do(context.Background(), "key:k1", "idem-1", []byte(`{}`), execute)
+	if err != nil || replayed || resp.StatusCode != 200 {
+		t.Errorf("retry after failure: status = %d, replayed = %v, err = %v, want a fresh 200", resp.StatusCode, replayed, err)
+	}
+	if executions != 2 {
+		t.Errorf("executions = %d, want 2", executions)
+	}
+}
+
+func TestIdempotencyStore_Conflicts(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+	store := NewIdempotencyStore(redisClient, 0)
+
+	ok := func() *IdempotentResponse { return &IdempotentResponse{StatusCode: 200, Body: []byte(`{}`)} }
+
+	// Same key while the first request is still executing
+	store.Do(context.Background(), "key:k1", "idem-1", []byte(`{"a":1}`), func() *IdempotentResponse {
+		if _, _, err := store.Do(context.Background(), "key:k1", "idem-1", []byte(`{"a":1}`), ok); err != ErrIdempotencyInProgress {
+			t.Errorf("concurrent Do() error = %v, want ErrIdempotencyInProgress", err)
+		}
+		return ok()
+	})
+
+	// Same key with a different body
+	if _, _, err := store.Do(context.Background(), "key:k1", "idem-1", []byte(`{"a":2}`), ok); err != ErrIdempotencyKeyReused {
+		t.Errorf("Do() with a different body error = %v, want ErrIdempotencyKeyReused", err)
+	}
+
+	// Same key from another caller is independent
+	if _, replayed, err := store.Do(context.Background(), "key:k2", "idem-1", []byte(`{"a":2}`), ok); err != nil || replayed {
+		t.Errorf("other caller: replayed = %v, err = %v, want a live execution", replayed, err)
+	}
+}
+
+func TestIdempotencyStore_RechecksResponseAfterLocking(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+	store := NewIdempotencyStore(redisClient, 0)
+
+	payload := []byte(`{"a":1}`)
+	responseKey, lockKey := idempotencyKeys("key:k1", "idem-1")
+	ok := func() *IdempotentResponse {
+		return &IdempotentResponse{StatusCode: 200, Body: []byte(`{"id":"msg_1"}`)}
+	}
+
+	// A concurrent request stored its response and released the lock after our first lookup
+	store.Do(context.Background(), "key:k1", "idem-1", payload, ok)
+	if mr.Exists(lockKey) {
+		t.Fatal("lock still held after Do() returned")
+	}
+
+	executed := false
+	resp, replayed, err := store.executeLocked(context.Background(), responseKey, lockKey, hashIdempotencyPart(string(payload)), func() *IdempotentResponse {
+		executed = true
+		return ok()
+	})
+	if err != nil || !replayed || string(resp.Body) != `{"id":"msg_1"}` {
+		t.Errorf("executeLocked() = %+v, replayed = %v, err = %v, want the stored response", resp, replayed, err)
+	}
+	if executed {
+		t.Error("request executed again although its response was stored")
+	}
+}
+
+func TestIdempotencyStore_ReleasesOnlyItsOwnLock(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+	store := NewIdempotencyStore(redisClient, 0)
+
+	_, lockKey := idempotencyKeys("key:k1", "idem-1")
+	store.Do(context.Background(), "key:k1", "idem-1", []byte(`{"a":1}`), func() *IdempotentResponse {
+		// The lock expires mid-request and a retry with the same body takes it over
+		mr.Set(lockKey, hashIdempotencyPart(`{"a":1}`)+":other")
+		return &IdempotentResponse{StatusCode: 200, Body: []byte(`{}`)}
+	})
+
+	if got, _ := mr.Get(lockKey); got != hashIdempotencyPart(`{"a":1}`)+":other" {
+		t.Errorf("lock = %q after the expired holder finished, want the other request's lock kept", got)
+	}
+}
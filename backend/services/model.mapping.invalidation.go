@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// modelMappingInvalidateChannel carries aliases whose cached mapping changed on some instance
+const modelMappingInvalidateChannel = "model:mapping:invalidate"
+
+// invalidateAllMappings is the message that evicts every alias (sent by FlushCache)
+const invalidateAllMappings = "*"
+
+// StartInvalidationListener subscribes to mapping invalidations published by every instance
+// Mutations write Redis before publishing, so receivers only evict their in-process cache and
+// re-read the shared entry. Returns once subscribed; the listener stops when ctx is cancelled.
+func (s *ModelMappingService) StartInvalidationListener(ctx context.Context) error {
+	sub := s.redis.Subscribe(ctx, modelMappingInvalidateChannel)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return fmt.Errorf("failed to subscribe to %s: %w", modelMappingInvalidateChannel, err)
+	}
+
+	s.localMu.Lock()
+	s.listening = true
+	s.localMu.Unlock()
+
+	go func() {
+		defer sub.Close()
+		defer s.stopLocalCache()
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				s.evictLocal(msg.Payload)
+			}
+		}
+	}()
+	return nil
+}
+
+// invalidate evicts alias locally and tells the other instances to do the same
+func (s *ModelMappingService) invalidate(ctx context.Context, alias string) {
+	s.evictLocal(alias)
+	if err := s.redis.Publish(ctx, modelMappingInvalidateChannel, alias).Err(); err != nil {
+		log.Printf("[ModelMapping] Failed to publish invalidation for %s: %v", alias, err)
+	}
+}
+
+// evictLocal drops alias (or every alias for invalidateAllMappings) from the in-process cache
+func (s *ModelMappingService) evictLocal(alias string) {
+	s.localMu.Lock()
+	defer s.localMu.Unlock()
+
+	s.generation++
+	if alias == invalidateAllMappings {
+		s.local = make(map[string]cachedMapping)
+		return
+	}
+	delete(s.local, alias)
+}
+
+// stopLocalCache disables the in-process cache once invalidations can no longer be received
+func (s *ModelMappingService) stopLocalCache() {
+	s.localMu.Lock()
+	defer s.localMu.Unlock()
+
+	s.listening = false
+	s.generation++
+	s.local = make(map[string]cachedMapping)
+}
+
+// getLocal returns the in-process entry for alias and the cache generation it was read at
+func (s *ModelMappingService) getLocal(alias string) (cachedMapping, uint64, bool) {
+	s.localMu.RLock()
+	defer s.localMu.RUnlock()
+
+	cm, ok := s.local[alias]
+	return cm, s.generation, ok && s.listening
+}
+
+// setLocal caches a resolved mapping unless an eviction happened since generation was read
+func (s *ModelMappingService) setLocal(alias string, cm cachedMapping, generation uint64) {
+	s.localMu.Lock()
+	defer s.localMu.Unlock()
+
+	if s.listening && s.generation == generation {
+		s.local[alias] = cm
+	}
+}
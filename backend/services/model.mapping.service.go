@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -16,6 +17,13 @@ const modelMappingKeyPrefix = "model:mapping:"
 type ModelMappingService struct {
 	repo  *repositories.ModelMappingRepository
 	redis *redis.Client
+
+	// In-process cache in front of Redis, evicted by invalidation messages from any instance
+	// Only used while subscribed (see StartInvalidationListener).
+	localMu    sync.RWMutex
+	local      map[string]cachedMapping
+	listening  bool
+	generation uint64 // Bumped on every eviction so in-flight resolves don't cache stale reads
 }
 
 // cachedMapping is the Redis cache format
@@ -28,6 +36,7 @@ func NewModelMappingService(repo *repositories.ModelMappingRepository, redis *re
 	return &ModelMappingService{
 		repo:  repo,
 		redis: redis,
+		local: make(map[string]cachedMapping),
 	}
 }
 
@@ -35,11 +44,18 @@ func NewModelMappingService(repo *repositories.ModelMappingRepository, redis *re
 func (s *ModelMappingService) Resolve(ctx context.Context, alias string) *providers.ResolvedMapping {
 	key := modelMappingKeyPrefix + alias
 
+	// Check in-process cache
+	hit, generation, ok := s.getLocal(alias)
+	if ok {
+		return &providers.ResolvedMapping{ProviderID: hit.ProviderID, ModelName: hit.ModelName}
+	}
+
 	// Check Redis cache
 	cached, err := s.redis.Get(ctx, key).Result()
 	if err == nil {
 		var cm cachedMapping
 		if json.Unmarshal([]byte(cached), &cm) == nil {
+			s.setLocal(alias, cm, generation)
 			return &providers.ResolvedMapping{
 				ProviderID: cm.ProviderID,
 				ModelName:  cm.ModelName,
@@ -54,10 +70,12 @@ func (s *ModelMappingService) Resolve(ctx context.Context, alias string) *provid
 	}
 
 	// Cache result (no expiry - invalidated on write)
-	s.cacheMapping(ctx, alias, &cachedMapping{
+	resolved := cachedMapping{
 		ProviderID: mapping.ProviderID,
 		ModelName:  mapping.ModelName,
-	})
+	}
+	s.cacheMapping(ctx, alias, &resolved)
+	s.setLocal(alias, resolved, generation)
 
 	return &providers.ResolvedMapping{
 		ProviderID: mapping.ProviderID,
@@ -69,6 +87,7 @@ func (s *ModelMappingService) Create(ctx context.Context, mapping *models.ModelM
 	if err := s.repo.Create(mapping); err != nil {
 		return err
 	}
+	defer s.invalidate(ctx, mapping.Alias)
 	return s.cacheMapping(ctx, mapping.Alias, &cachedMapping{
 		ProviderID: mapping.ProviderID,
 		ModelName:  mapping.ModelName,
@@ -87,10 +106,12 @@ func (s *ModelMappingService) Update(ctx context.Context, oldAlias string, mappi
 	if err := s.repo.Update(oldAlias, mapping); err != nil {
 		return err
 	}
+	defer s.invalidate(ctx, mapping.Alias)
 
 	// Invalidate old key if alias changed
 	if oldAlias != mapping.Alias {
 		s.redis.Del(ctx, modelMappingKeyPrefix+oldAlias)
+		defer s.invalidate(ctx, oldAlias)
 	}
 
 	// Cache new mapping
@@ -104,9 +125,31 @@ func (s *ModelMappingService) Delete(ctx context.Context, alias string) error {
 	if err := s.repo.Delete(alias); err != nil {
 		return err
 	}
+	defer s.invalidate(ctx, alias)
 	return s.redis.Del(ctx, modelMappingKeyPrefix+alias).Err()
 }
 
+// FlushCache removes all cached alias resolutions so the next Resolve reads from DB
+// Returns the number of cache entries deleted.
+func (s *ModelMappingService) FlushCache(ctx context.Context) (int64, error) {
+	var cleared int64
+	defer s.invalidate(ctx, invalidateAllMappings)
+
+	iter := s.redis.Scan(ctx, 0, modelMappingKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		n, err := s.redis.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			return cleared, fmt.Errorf("failed to delete mapping cache: %w", err)
+		}
+		cleared += n
+	}
+	if err := iter.Err(); err != nil {
+		return cleared, fmt.Errorf("failed to scan mapping cache: %w", err)
+	}
+
+	return cleared, nil
+}
+
 func (s *ModelMappingService) cacheMapping(ctx context.Context, alias string, resolved *cachedMapping) error {
 	key := modelMappingKeyPrefix + alias
 	val, err := json.Marshal(resolved)
@@ -123,3 +166,16 @@ func (s *ModelMappingService) ListForUser(userID string, limit, offset int) ([]*
 func (s *ModelMappingService) GetByAliasWithOwner(alias string) (*models.ModelMapping, error) {
 	return s.repo.GetByAliasWithOwner(alias)
 }
+
+// ListEnabledFor returns the enabled mappings visible to user
+// Admins see all, users see global + own, anonymous callers see global only.
+func (s *ModelMappingService) ListEnabledFor(user *models.User) ([]*models.ModelMapping, error) {
+	if user != nil && user.Role == models.RoleAdmin {
+		return s.repo.ListEnabled()
+	}
+	userID := ""
+	if user != nil {
+		userID = user.ID
+	}
+	return s.repo.ListEnabledForUser(userID)
+}
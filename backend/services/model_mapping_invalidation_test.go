@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// createModelMappingsTable creates model_mappings directly, matching the migration
+func createModelMappingsTable(t *testing.T, db *gorm.DB) {
+	err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS model_mappings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			alias TEXT NOT NULL UNIQUE,
+			provider_id TEXT NOT NULL,
+			model_name TEXT NOT NULL,
+			description TEXT,
+			enabled BOOLEAN DEFAULT 1,
+			priority INTEGER DEFAULT 0,
+			owner_id TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+}
+
+// waitForModel polls svc until alias resolves to want ("" = unmapped) or the deadline passes
+func waitForModel(t *testing.T, svc *ModelMappingService, alias, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := ""
+		if resolved := svc.Resolve(context.Background(), alias); resolved != nil {
+			got = resolved.ModelName
+		}
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Resolve(%q) = %q, want %q", alias, got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestModelMappingInvalidation_AcrossInstances(t *testing.T) {
+	db := setupTestDB(t)
+	createModelMappingsTable(t, db)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two gateway instances sharing the database and Redis
+	repo := repositories.NewModelMappingRepository(db)
+	instanceA := NewModelMappingService(repo, redisClient)
+	instanceB := NewModelMappingService(repo, redisClient)
+	for _, svc := range []*ModelMappingService{instanceA, instanceB} {
+		if err := svc.StartInvalidationListener(ctx); err != nil {
+			t.Fatalf("StartInvalidationListener() error = %v", err)
+		}
+	}
+
+	mapping := &models.ModelMapping{Alias: "fast", ProviderID: "antigravity", ModelName: "gemini-2.5-flash", Enabled: true}
+	if err := instanceA.Create(ctx, mapping); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// B caches the mapping in-process
+	waitForModel(t, instanceB, "fast", "gemini-2.5-flash")
+
+	// Without invalidation B would keep serving its in-process copy
+	mapping.ModelName = "gemini-2.5-pro"
+	if err := instanceA.Update(ctx, "fast", mapping); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	waitForModel(t, instanceB, "fast", "gemini-2.5-pro")
+
+	if err := instanceA.Delete(ctx, "fast"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	waitForModel(t, instanceB, "fast", "")
+}
+
+func TestModelMappingInvalidation_IgnoresStaleConcurrentResolve(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	svc := NewModelMappingService(repositories.NewModelMappingRepository(db), redisClient)
+	if err := svc.StartInvalidationListener(context.Background()); err != nil {
+		t.Fatalf("StartInvalidationListener() error = %v", err)
+	}
+
+	// A resolve that read the old value before an eviction must not re-cache it
+	_, generation, _ := svc.getLocal("fast")
+	svc.evictLocal("fast")
+	svc.setLocal("fast", cachedMapping{ProviderID: "antigravity", ModelName: "gemini-2.5-flash"}, generation)
+
+	if _, _, ok := svc.getLocal("fast"); ok {
+		t.Error("stale read was cached after an eviction")
+	}
+}
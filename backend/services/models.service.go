@@ -26,8 +26,17 @@ type ProviderModels struct {
 	Models []string `json:"models"`
 }
 
+// AliasedModel is a model mapping alias the caller can route to
+type AliasedModel struct {
+	ID          string `json:"id"`
+	ProviderID  string `json:"provider_id"`
+	AliasedTo   string `json:"aliased_to"`
+	Description string `json:"description,omitempty"`
+}
+
 type ModelsResponse struct {
 	Providers []ProviderModels `json:"providers"`
+	Aliases   []AliasedModel   `json:"aliases,omitempty"` // Per caller, never cached
 }
 
 func NewModelsService(db *gorm.DB, redis *redis.Client) *ModelsService {
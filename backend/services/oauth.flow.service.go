@@ -8,11 +8,10 @@ import (
 	"aigateway-backend/repositories"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,13 +26,20 @@ const (
 	DefaultRedirectURI = "http://localhost:8088/api/v1/oauth/callback"
 )
 
+// ErrOAuthSessionMismatch is returned when a flow is completed by a different user than started it
+var ErrOAuthSessionMismatch = errors.New("oauth session was started by another user")
+
 // OAuthFlowService handles OAuth authorization flow
 type OAuthFlowService struct {
-	redis       *redis.Client
-	accountSvc  *AccountService
-	repo        *repositories.AccountRepository
-	proxySvc    *ProxyService
-	authManager *manager.Manager
+	redis           *redis.Client
+	accountSvc      *AccountService
+	repo            *repositories.AccountRepository
+	proxySvc        *ProxyService
+	authManager     *manager.Manager
+	allowDuplicates bool // Create a new account on every exchange, even for a known provider+email
+
+	// providerOAuth resolves a provider's OAuth client (replaced in tests to mock token endpoints)
+	providerOAuth func(providerID, redirectURI string) (*oauth.ProviderOAuth, error)
 }
 
 // OAuthSession represents an OAuth flow session stored in Redis
@@ -90,6 +96,8 @@ func NewOAuthFlowService(redis *redis.Client, accountSvc *AccountService, repo *
 		accountSvc: accountSvc,
 		repo:       repo,
 		proxySvc:   proxySvc,
+
+		providerOAuth: oauth.GetProviderOAuth,
 	}
 }
 
@@ -98,6 +106,11 @@ func (s *OAuthFlowService) SetAuthManager(m *manager.Manager) {
 	s.authManager = m
 }
 
+// SetAllowDuplicateAccounts disables provider+email deduplication on code exchange
+func (s *OAuthFlowService) SetAllowDuplicateAccounts(allow bool) {
+	s.allowDuplicates = allow
+}
+
 // InitFlow starts OAuth authorization flow
 func (s *OAuthFlowService) InitFlow(ctx context.Context, req *InitFlowRequest) (*InitFlowResponse, error) {
 	if req.FlowType != "auto" && req.FlowType != "manual" {
@@ -114,7 +127,7 @@ func (s *OAuthFlowService) InitFlow(ctx context.Context, req *InitFlowRequest) (
 		redirectURI = DefaultRedirectURI
 	}
 
-	providerOAuth, err := oauth.GetProviderOAuth(req.Provider, redirectURI)
+	providerOAuth, err := s.providerOAuth(req.Provider, redirectURI)
 	if err != nil {
 		return nil, err
 	}
@@ -162,7 +175,10 @@ func (s *OAuthFlowService) InitFlow(ctx context.Context, req *InitFlowRequest) (
 
 
 // ExchangeCode exchanges authorization code from callback URL
-func (s *OAuthFlowService) ExchangeCode(ctx context.Context, callbackURL string) (*ExchangeResponse, error) {
+// requestedBy is the authenticated user completing the flow (nil for the public callback, where
+// the unguessable state is the credential); it must match the user who started the flow.
+// The session is consumed before the code exchange, so a state can only be used once.
+func (s *OAuthFlowService) ExchangeCode(ctx context.Context, callbackURL string, requestedBy *string) (*ExchangeResponse, error) {
 	parsedURL, err := url.Parse(callbackURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid callback URL: %w", err)
@@ -187,7 +203,18 @@ func (s *OAuthFlowService) ExchangeCode(ctx context.Context, callbackURL string)
 		return nil, fmt.Errorf("failed to parse session: %w", err)
 	}
 
-	providerOAuth, err := oauth.GetProviderOAuth(session.Provider, session.RedirectURI)
+	// Checked before consuming so another user can't burn the owner's session
+	if session.CreatedBy != nil && requestedBy != nil && *session.CreatedBy != *requestedBy {
+		log.Printf("[OAuth] Rejected exchange of %s session by user %s (started by %s)", session.Provider, *requestedBy, *session.CreatedBy)
+		return nil, ErrOAuthSessionMismatch
+	}
+
+	// Consume atomically: of concurrent or replayed exchanges, only one gets the session
+	if err := s.redis.GetDel(ctx, sessionKey).Err(); err != nil {
+		return nil, fmt.Errorf("session not found or expired")
+	}
+
+	providerOAuth, err := s.providerOAuth(session.Provider, session.RedirectURI)
 	if err != nil {
 		return nil, err
 	}
@@ -222,11 +249,6 @@ func (s *OAuthFlowService) ExchangeCode(ctx context.Context, callbackURL string)
 		metadata["project_id"] = session.ProjectID
 	}
 
-	metadataJSON, err := json.Marshal(metadata)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
-	}
-
 	// Fetch user info - uses appropriate method per provider
 	userInfo, err := providerOAuth.GetUserInfoFromToken(ctx, tokenResp)
 	if err != nil {
@@ -238,11 +260,41 @@ func (s *OAuthFlowService) ExchangeCode(ctx context.Context, callbackURL string)
 		return nil, fmt.Errorf("email not found in user info")
 	}
 
+	account, err := s.saveAccount(ctx, &session, email, string(authDataJSON), metadata, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangeResponse{
+		Success: true,
+		Account: account,
+	}, nil
+}
+
+// saveAccount persists the account authorized by an OAuth exchange.
+// Re-authenticating a provider+email that already exists refreshes that account's
+// tokens instead of creating a duplicate, unless duplicates are allowed.
+func (s *OAuthFlowService) saveAccount(ctx context.Context, session *OAuthSession, email, authData string, metadata map[string]interface{}, expiresAt time.Time) (*models.Account, error) {
+	if !s.allowDuplicates {
+		existing, err := s.repo.GetByProviderAndLabel(session.Provider, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up existing account: %w", err)
+		}
+		if existing != nil {
+			return s.reauthorizeAccount(ctx, existing, authData, metadata, expiresAt)
+		}
+	}
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
 	account := &models.Account{
 		ID:         uuid.New().String(),
 		ProviderID: session.Provider,
 		Label:      email,
-		AuthData:   string(authDataJSON),
+		AuthData:   authData,
 		Metadata:   string(metadataJSON),
 		IsActive:   true,
 		ExpiresAt:  &expiresAt,
@@ -275,12 +327,46 @@ func (s *OAuthFlowService) ExchangeCode(ctx context.Context, callbackURL string)
 		log.Printf("[OAuth] Hot-reload: Added account %s to AuthManager", account.ID)
 	}
 
-	s.redis.Del(ctx, sessionKey)
+	return account, nil
+}
 
-	return &ExchangeResponse{
-		Success: true,
-		Account: account,
-	}, nil
+// reauthorizeAccount replaces an existing account's tokens, keeping its ID and proxy assignment
+func (s *OAuthFlowService) reauthorizeAccount(ctx context.Context, account *models.Account, authData string, metadata map[string]interface{}, expiresAt time.Time) (*models.Account, error) {
+	// Keep existing metadata keys; values from this exchange win
+	merged := make(map[string]interface{})
+	if account.Metadata != "" {
+		json.Unmarshal([]byte(account.Metadata), &merged)
+	}
+	for k, v := range metadata {
+		merged[k] = v
+	}
+
+	metadataJSON, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	account.AuthData = authData
+	account.Metadata = string(metadataJSON)
+	account.ExpiresAt = &expiresAt
+	account.IsActive = true
+
+	if err := s.repo.Update(account); err != nil {
+		return nil, fmt.Errorf("failed to update account: %w", err)
+	}
+
+	// Drop cached tokens so the next request uses the fresh ones
+	s.redis.Del(ctx,
+		fmt.Sprintf("auth:%s:%s", account.ProviderID, account.ID),
+		fmt.Sprintf("auth:oauth:%s:%s", account.ProviderID, account.ID),
+	)
+
+	if s.authManager != nil {
+		s.authManager.AddAccount(account)
+	}
+	log.Printf("[OAuth] Re-authenticated existing account %s (%s), tokens updated", account.ID, account.Label)
+
+	return account, nil
 }
 
 // GetProviders returns list of available OAuth providers
@@ -315,40 +401,20 @@ func (s *OAuthFlowService) RefreshToken(ctx context.Context, accountID string) e
 		return fmt.Errorf("no refresh token available")
 	}
 
-	providerOAuth, err := oauth.GetProviderOAuth(account.ProviderID, DefaultRedirectURI)
+	providerOAuth, err := s.providerOAuth(account.ProviderID, DefaultRedirectURI)
 	if err != nil {
 		return err
 	}
 
-	data := url.Values{
-		"grant_type":    {"refresh_token"},
-		"refresh_token": {refreshToken},
-		"client_id":     {providerOAuth.ClientID},
-	}
-	if providerOAuth.ClientSecret != "" {
-		data.Set("client_secret", providerOAuth.ClientSecret)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", providerOAuth.TokenURL, strings.NewReader(data.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to create refresh request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	tokenResp, err := providerOAuth.RefreshToken(ctx, refreshToken)
 	if err != nil {
-		return fmt.Errorf("refresh request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("refresh failed with status %d", resp.StatusCode)
-	}
-
-	var tokenResp oauth.TokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return fmt.Errorf("failed to parse token response: %w", err)
+		if errors.Is(err, oauth.ErrNoAccessToken) {
+			deactivateForReauth(s.repo, accountID, err)
+			if s.authManager != nil {
+				s.authManager.RemoveAccount(accountID)
+			}
+		}
+		return err
 	}
 
 	expiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
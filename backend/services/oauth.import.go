@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ImportAccountEntry is one account to import from an exported refresh token
+type ImportAccountEntry struct {
+	ProviderID   string `json:"provider_id"`
+	Label        string `json:"label"`
+	RefreshToken string `json:"refresh_token"`
+	ProjectID    string `json:"project_id"` // Required for antigravity
+}
+
+// ImportAccountResult reports the outcome of importing one entry
+type ImportAccountResult struct {
+	Index     int    `json:"index"`
+	Label     string `json:"label"`
+	Success   bool   `json:"success"`
+	AccountID string `json:"account_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImportAccounts creates accounts from exported refresh tokens
+// Each entry is refreshed immediately to obtain an access token, then saved like an
+// OAuth exchange: proxy assignment, provider+label dedup and AuthManager hot-reload.
+// Entries are independent; a failed entry does not stop the rest.
+func (s *OAuthFlowService) ImportAccounts(ctx context.Context, entries []ImportAccountEntry, createdBy *string) []ImportAccountResult {
+	results := make([]ImportAccountResult, len(entries))
+	imported := 0
+
+	for i, entry := range entries {
+		results[i] = ImportAccountResult{Index: i, Label: entry.Label}
+
+		accountID, err := s.importAccount(ctx, &entry, createdBy)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Success = true
+		results[i].AccountID = accountID
+		imported++
+	}
+
+	log.Printf("[OAuth] Imported %d/%d accounts", imported, len(entries))
+	return results
+}
+
+// importAccount refreshes and saves one entry, returning the account ID
+func (s *OAuthFlowService) importAccount(ctx context.Context, entry *ImportAccountEntry, createdBy *string) (string, error) {
+	entry.Label = strings.TrimSpace(entry.Label)
+	if entry.ProviderID == "" {
+		return "", fmt.Errorf("provider_id is required")
+	}
+	if entry.Label == "" {
+		return "", fmt.Errorf("label is required")
+	}
+	if entry.RefreshToken == "" {
+		return "", fmt.Errorf("refresh_token is required")
+	}
+	if entry.ProviderID == "antigravity" && entry.ProjectID == "" {
+		return "", fmt.Errorf("project_id is required for antigravity provider")
+	}
+
+	providerOAuth, err := s.providerOAuth(entry.ProviderID, DefaultRedirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	tokenResp, err := providerOAuth.RefreshToken(ctx, entry.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	// Providers that rotate refresh tokens return a new one; keep the imported token otherwise
+	refreshToken := tokenResp.RefreshToken
+	if refreshToken == "" {
+		refreshToken = entry.RefreshToken
+	}
+
+	expiresAt := time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	authData := map[string]interface{}{
+		"access_token":  tokenResp.AccessToken,
+		"refresh_token": refreshToken,
+		"token_type":    tokenResp.TokenType,
+		"expires_at":    expiresAt.Format(time.RFC3339),
+		"expires_in":    tokenResp.ExpiresIn,
+	}
+
+	authDataJSON, err := json.Marshal(authData)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal auth data: %w", err)
+	}
+
+	metadata := make(map[string]interface{})
+	if entry.ProjectID != "" {
+		metadata["project_id"] = entry.ProjectID
+	}
+
+	session := &OAuthSession{
+		Provider:  entry.ProviderID,
+		ProjectID: entry.ProjectID,
+		CreatedBy: createdBy,
+	}
+	account, err := s.saveAccount(ctx, session, entry.Label, string(authDataJSON), metadata, expiresAt)
+	if err != nil {
+		return "", err
+	}
+	return account.ID, nil
+}
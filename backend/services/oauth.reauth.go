@@ -0,0 +1,19 @@
+package services
+
+import (
+	"log"
+
+	"aigateway-backend/repositories"
+)
+
+// deactivateForReauth takes an account out of rotation after its refresh grant stopped working
+// The account stays inactive, with the cause in last_error_msg, until it is re-authenticated
+// through the OAuth flow, which reactivates it.
+func deactivateForReauth(repo *repositories.AccountRepository, accountID string, cause error) {
+	if err := repo.UpdateActive(accountID, false); err != nil {
+		log.Printf("[OAuth] Failed to deactivate account %s: %v", accountID, err)
+		return
+	}
+	repo.UpdateHealthFailure(accountID, "re-authentication required: "+cause.Error())
+	log.Printf("[OAuth] Account %s deactivated, re-authentication required: %v", accountID, cause)
+}
@@ -1,11 +1,14 @@
 package services
 
 import (
+	"aigateway-backend/auth/oauth"
 	"aigateway-backend/models"
+	"aigateway-backend/providers"
 	"aigateway-backend/providers/antigravity"
 	"aigateway-backend/repositories"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -85,6 +88,9 @@ func (s *OAuthService) GetAccessToken(account *models.Account) (string, error) {
 
 		newAccessToken, newExpiresAt, err := s.refreshToken(account.ProviderID, refreshToken, account.ProxyURL, account.ID)
 		if err != nil {
+			if errors.Is(err, oauth.ErrNoAccessToken) {
+				deactivateForReauth(s.repo, account.ID, err)
+			}
 			return "", fmt.Errorf("token refresh failed: %w", err)
 		}
 
@@ -117,6 +123,14 @@ func (s *OAuthService) GetAccessToken(account *models.Account) (string, error) {
 	return accessToken, nil
 }
 
+// HasWarmToken reports whether the account's access token is cached and outside the refresh skew
+// Such an account serves without an on-demand refresh, so selection can prefer it.
+func (s *OAuthService) HasWarmToken(account *models.Account) bool {
+	cacheKey := fmt.Sprintf("auth:%s:%s", account.ProviderID, account.ID)
+	ttl, err := s.redis.TTL(context.Background(), cacheKey).Result()
+	return err == nil && ttl > antigravity.RefreshSkew
+}
+
 func (s *OAuthService) refreshToken(providerID string, refreshToken string, proxyURL string, accountID string) (string, time.Time, error) {
 	var clientID, clientSecret, tokenURL string
 
@@ -165,8 +179,8 @@ func (s *OAuthService) refreshToken(providerID string, refreshToken string, prox
 
 	if resp.StatusCode != 200 {
 		logCtx["status_code"] = resp.StatusCode
-		logCtx["response_body"] = string(body)
-		err := fmt.Errorf("token refresh failed: %s", string(body))
+		logCtx["response_body"] = providers.Redact(string(body))
+		err := fmt.Errorf("token refresh failed: %s", providers.Redact(string(body)))
 		s.logError("refresh_token", "bad_status", err, logCtx)
 		return "", time.Time{}, err
 	}
@@ -177,8 +191,14 @@ func (s *OAuthService) refreshToken(providerID string, refreshToken string, prox
 		return "", time.Time{}, err
 	}
 
+	lifetime, err := oauth.ValidateTokenResponse(tokenResp.AccessToken, tokenResp.ExpiresIn)
+	if err != nil {
+		s.logError("refresh_token", "validate_response", err, logCtx)
+		return "", time.Time{}, err
+	}
+
 	// Always use UTC for consistent timezone handling
-	expiresAt := time.Now().UTC().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	expiresAt := time.Now().UTC().Add(lifetime)
 	return tokenResp.AccessToken, expiresAt, nil
 }
 
@@ -193,3 +213,38 @@ func (s *OAuthService) InvalidateCache(account *models.Account) error {
 	ctx := context.Background()
 	return s.redis.Del(ctx, cacheKey).Err()
 }
+
+// FlushTokenCache removes cached access tokens for all accounts
+// Returns the number of cache entries deleted.
+func (s *OAuthService) FlushTokenCache(ctx context.Context) (int64, error) {
+	const pageSize = 500
+	var cleared int64
+
+	for offset := 0; ; offset += pageSize {
+		accounts, _, err := s.repo.List(pageSize, offset)
+		if err != nil {
+			return cleared, fmt.Errorf("failed to list accounts: %w", err)
+		}
+		if len(accounts) == 0 {
+			return cleared, nil
+		}
+
+		keys := make([]string, 0, len(accounts)*2)
+		for _, account := range accounts {
+			keys = append(keys,
+				fmt.Sprintf("auth:%s:%s", account.ProviderID, account.ID),
+				fmt.Sprintf("auth:oauth:%s:%s", account.ProviderID, account.ID),
+			)
+		}
+
+		n, err := s.redis.Del(ctx, keys...).Result()
+		if err != nil {
+			return cleared, fmt.Errorf("failed to delete token cache: %w", err)
+		}
+		cleared += n
+
+		if len(accounts) < pageSize {
+			return cleared, nil
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+
+	"github.com/tidwall/gjson"
+)
+
+func setupOAuthFlowService(t *testing.T) (*OAuthFlowService, *repositories.AccountRepository, func() int64) {
+	db := setupTestDB(t)
+	createAccountsTable(t, db)
+	mr, redisClient := setupTestRedis(t)
+	t.Cleanup(mr.Close)
+
+	repo := repositories.NewAccountRepository(db)
+	count := func() int64 {
+		var n int64
+		db.Model(&models.Account{}).Count(&n)
+		return n
+	}
+	return NewOAuthFlowService(redisClient, nil, repo, nil), repo, count
+}
+
+func TestSaveAccount_ReauthUpdatesExistingAccount(t *testing.T) {
+	svc, repo, count := setupOAuthFlowService(t)
+	ctx := context.Background()
+	session := &OAuthSession{Provider: "antigravity", ProjectID: "proj-1"}
+
+	first, err := svc.saveAccount(ctx, session, "user@example.com",
+		`{"access_token":"old-access","refresh_token":"old-refresh"}`,
+		map[string]interface{}{"project_id": "proj-1"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("first save: %v", err)
+	}
+
+	// Stale cached token for the account must not survive re-authentication
+	svc.redis.Set(ctx, "auth:antigravity:"+first.ID, "old-access", time.Hour)
+
+	second, err := svc.saveAccount(ctx, &OAuthSession{Provider: "antigravity"}, "User@Example.com",
+		`{"access_token":"new-access","refresh_token":"new-refresh"}`,
+		map[string]interface{}{}, time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("second save: %v", err)
+	}
+
+	if second.ID != first.ID {
+		t.Errorf("re-auth created account %s, want existing %s", second.ID, first.ID)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("accounts = %d, want 1", n)
+	}
+
+	stored, err := repo.GetByProviderAndLabel("antigravity", "user@example.com")
+	if err != nil || stored == nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if got := gjson.Get(stored.AuthData, "access_token").String(); got != "new-access" {
+		t.Errorf("access_token = %q, want new-access", got)
+	}
+	if got := gjson.Get(stored.AuthData, "refresh_token").String(); got != "new-refresh" {
+		t.Errorf("refresh_token = %q, want new-refresh", got)
+	}
+	if got := gjson.Get(stored.Metadata, "project_id").String(); got != "proj-1" {
+		t.Errorf("project_id = %q, want it preserved from the original registration", got)
+	}
+	if svc.redis.Exists(ctx, "auth:antigravity:"+first.ID).Val() != 0 {
+		t.Error("expected cached token to be invalidated")
+	}
+}
+
+func TestSaveAccount_DifferentProviderOrEmailCreatesAccount(t *testing.T) {
+	svc, _, count := setupOAuthFlowService(t)
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+
+	svc.saveAccount(ctx, &OAuthSession{Provider: "antigravity"}, "a@example.com", `{}`, nil, expires)
+	svc.saveAccount(ctx, &OAuthSession{Provider: "claude"}, "a@example.com", `{}`, nil, expires)
+	svc.saveAccount(ctx, &OAuthSession{Provider: "antigravity"}, "b@example.com", `{}`, nil, expires)
+
+	if n := count(); n != 3 {
+		t.Errorf("accounts = %d, want 3", n)
+	}
+}
+
+func TestSaveAccount_AllowDuplicates(t *testing.T) {
+	svc, _, count := setupOAuthFlowService(t)
+	svc.SetAllowDuplicateAccounts(true)
+	ctx := context.Background()
+	session := &OAuthSession{Provider: "antigravity"}
+
+	first, _ := svc.saveAccount(ctx, session, "user@example.com", `{}`, nil, time.Now().Add(time.Hour))
+	second, _ := svc.saveAccount(ctx, session, "user@example.com", `{}`, nil, time.Now().Add(time.Hour))
+
+	if first.ID == second.ID {
+		t.Error("expected a new account when duplicates are allowed")
+	}
+	if n := count(); n != 2 {
+		t.Errorf("accounts = %d, want 2", n)
+	}
+}
+
+// initClaudeFlow starts a Claude flow for createdBy and returns its callback URL
+// Claude is used because its user info comes from the token response, not a network call.
+func initClaudeFlow(t *testing.T, svc *OAuthFlowService, createdBy *string) string {
+	t.Helper()
+	resp, err := svc.InitFlow(context.Background(), &InitFlowRequest{Provider: "claude", FlowType: "manual", CreatedBy: createdBy})
+	if err != nil {
+		t.Fatalf("InitFlow() error = %v", err)
+	}
+	return "http://localhost:8088/api/v1/oauth/callback?code=auth-code&state=" + resp.State
+}
+
+func TestExchangeCode_RejectsOtherUser(t *testing.T) {
+	svc, _, count := setupOAuthFlowService(t)
+	mockTokenEndpoint(t, svc)
+
+	owner, other := "user-a", "user-b"
+	callbackURL := initClaudeFlow(t, svc, &owner)
+
+	if _, err := svc.ExchangeCode(context.Background(), callbackURL, &other); !errors.Is(err, ErrOAuthSessionMismatch) {
+		t.Fatalf("ExchangeCode() by another user error = %v, want ErrOAuthSessionMismatch", err)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("accounts = %d, want 0", n)
+	}
+
+	// The rejected attempt must not consume the owner's session
+	resp, err := svc.ExchangeCode(context.Background(), callbackURL, &owner)
+	if err != nil {
+		t.Fatalf("ExchangeCode() by owner error = %v", err)
+	}
+	if resp.Account.CreatedBy == nil || *resp.Account.CreatedBy != owner {
+		t.Errorf("created_by = %v, want %s", resp.Account.CreatedBy, owner)
+	}
+}
+
+func TestExchangeCode_RejectsReplay(t *testing.T) {
+	svc, _, count := setupOAuthFlowService(t)
+	mockTokenEndpoint(t, svc)
+
+	owner := "user-a"
+	callbackURL := initClaudeFlow(t, svc, &owner)
+
+	if _, err := svc.ExchangeCode(context.Background(), callbackURL, nil); err != nil {
+		t.Fatalf("first ExchangeCode() error = %v", err)
+	}
+	if _, err := svc.ExchangeCode(context.Background(), callbackURL, &owner); err == nil {
+		t.Fatal("replayed ExchangeCode() succeeded, want session not found")
+	}
+	if n := count(); n != 1 {
+		t.Errorf("accounts = %d, want 1", n)
+	}
+}
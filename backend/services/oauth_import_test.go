@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/auth/oauth"
+
+	"github.com/tidwall/gjson"
+)
+
+// mockTokenEndpoint answers refresh grants: "revoked" fails, anything else gets a fresh access token
+func mockTokenEndpoint(t *testing.T, svc *OAuthFlowService) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"access-for-` + r.Form.Get("refresh_token") + `","expires_in":3599,"token_type":"Bearer"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	svc.providerOAuth = func(providerID, redirectURI string) (*oauth.ProviderOAuth, error) {
+		p, err := oauth.GetProviderOAuth(providerID, redirectURI)
+		if err != nil {
+			return nil, err
+		}
+		p.TokenURL = server.URL
+		return p, nil
+	}
+}
+
+func TestImportAccounts_PartialFailure(t *testing.T) {
+	svc, repo, count := setupOAuthFlowService(t)
+	mockTokenEndpoint(t, svc)
+	authManager := manager.NewManager(nil, svc.redis)
+	authManager.SetLogging(false)
+	svc.SetAuthManager(authManager)
+
+	entries := []ImportAccountEntry{
+		{ProviderID: "antigravity", Label: "a@example.com", RefreshToken: "rt-a", ProjectID: "proj-a"},
+		{ProviderID: "antigravity", Label: "b@example.com", RefreshToken: "revoked", ProjectID: "proj-b"},
+		{ProviderID: "antigravity", Label: "c@example.com", RefreshToken: "rt-c"},
+		{ProviderID: "unknown", Label: "d@example.com", RefreshToken: "rt-d"},
+		{ProviderID: "codex", Label: "e@example.com", RefreshToken: "rt-e"},
+	}
+
+	results := svc.ImportAccounts(context.Background(), entries, nil)
+	if len(results) != len(entries) {
+		t.Fatalf("results = %d, want %d", len(results), len(entries))
+	}
+
+	wantSuccess := []bool{true, false, false, false, true}
+	for i, r := range results {
+		if r.Index != i || r.Label != entries[i].Label {
+			t.Errorf("result %d = %+v, want index and label of entry %d", i, r, i)
+		}
+		if r.Success != wantSuccess[i] {
+			t.Errorf("entry %d success = %v (error %q), want %v", i, r.Success, r.Error, wantSuccess[i])
+		}
+		if !r.Success && r.Error == "" {
+			t.Errorf("entry %d failed without an error message", i)
+		}
+	}
+	if n := count(); n != 2 {
+		t.Errorf("accounts = %d, want 2", n)
+	}
+
+	stored, err := repo.GetByProviderAndLabel("antigravity", "a@example.com")
+	if err != nil || stored == nil {
+		t.Fatalf("imported account not found: %v", err)
+	}
+	if stored.ID != results[0].AccountID {
+		t.Errorf("stored ID = %s, want %s", stored.ID, results[0].AccountID)
+	}
+	if got := gjson.Get(stored.AuthData, "access_token").String(); got != "access-for-rt-a" {
+		t.Errorf("access_token = %q, want access-for-rt-a", got)
+	}
+	if got := gjson.Get(stored.AuthData, "refresh_token").String(); got != "rt-a" {
+		t.Errorf("refresh_token = %q, want the imported token kept", got)
+	}
+	if got := gjson.Get(stored.Metadata, "project_id").String(); got != "proj-a" {
+		t.Errorf("project_id = %q, want proj-a", got)
+	}
+	if stored.ExpiresAt == nil {
+		t.Error("expires_at should be set from the refresh")
+	}
+
+	if authManager.GetAccount(results[0].AccountID) == nil || authManager.GetAccount(results[4].AccountID) == nil {
+		t.Error("imported accounts should be hot-loaded into AuthManager")
+	}
+}
+
+func TestImportAccounts_ReimportUpdatesExistingAccount(t *testing.T) {
+	svc, _, count := setupOAuthFlowService(t)
+	mockTokenEndpoint(t, svc)
+
+	entry := ImportAccountEntry{ProviderID: "antigravity", Label: "a@example.com", RefreshToken: "rt-1", ProjectID: "proj-a"}
+	first := svc.ImportAccounts(context.Background(), []ImportAccountEntry{entry}, nil)
+
+	entry.RefreshToken = "rt-2"
+	second := svc.ImportAccounts(context.Background(), []ImportAccountEntry{entry}, nil)
+
+	if !first[0].Success || !second[0].Success {
+		t.Fatalf("imports = %+v, %+v, want both to succeed", first[0], second[0])
+	}
+	if second[0].AccountID != first[0].AccountID {
+		t.Errorf("re-import created %s, want existing %s", second[0].AccountID, first[0].AccountID)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("accounts = %d, want 1", n)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"aigateway-backend/auth/oauth"
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+
+	"github.com/tidwall/gjson"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// setupExpiredAccount stores an antigravity account whose access token needs a refresh,
+// with the token endpoint answering 200 and body
+func setupExpiredAccount(t *testing.T, body string) (*OAuthService, *repositories.AccountRepository, *models.Account) {
+	db := setupTestDB(t)
+	createAccountsTable(t, db)
+	mr, redisClient := setupTestRedis(t)
+	t.Cleanup(mr.Close)
+
+	repo := repositories.NewAccountRepository(db)
+	account := &models.Account{
+		ID:         "acc-1",
+		ProviderID: "antigravity",
+		Label:      "expired",
+		AuthData:   `{"access_token":"old-token","refresh_token":"rt-1","expires_at":"` + time.Now().Add(-time.Hour).UTC().Format(time.RFC3339) + `"}`,
+		IsActive:   true,
+	}
+	if err := repo.Create(account); err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+
+	clients := NewHTTPClientService()
+	clients.cache[""] = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})}
+
+	return NewOAuthService(redisClient, repo, clients, nil), repo, account
+}
+
+func TestGetAccessToken_EmptyRefreshIsFailure(t *testing.T) {
+	svc, repo, account := setupExpiredAccount(t, `{"access_token":"","expires_in":3599,"token_type":"Bearer"}`)
+
+	token, err := svc.GetAccessToken(account)
+	if !errors.Is(err, oauth.ErrNoAccessToken) {
+		t.Fatalf("GetAccessToken() = %q, %v, want ErrNoAccessToken", token, err)
+	}
+
+	stored, _ := repo.GetByID(account.ID)
+	if stored.IsActive {
+		t.Error("account should be deactivated until re-authenticated")
+	}
+	if !strings.Contains(stored.LastErrorMsg, "re-authentication required") {
+		t.Errorf("last_error_msg = %q, want a re-authentication flag", stored.LastErrorMsg)
+	}
+	if got := gjson.Get(stored.AuthData, "access_token").String(); got != "old-token" {
+		t.Errorf("stored access_token = %q, want the old token kept rather than an empty one", got)
+	}
+}
+
+func TestGetAccessToken_RefreshWithoutExpiryUsesDefault(t *testing.T) {
+	svc, repo, account := setupExpiredAccount(t, `{"access_token":"new-token","token_type":"Bearer"}`)
+
+	token, err := svc.GetAccessToken(account)
+	if err != nil || token != "new-token" {
+		t.Fatalf("GetAccessToken() = %q, %v, want new-token", token, err)
+	}
+
+	stored, _ := repo.GetByID(account.ID)
+	expiresAt, _ := time.Parse(time.RFC3339, gjson.Get(stored.AuthData, "expires_at").String())
+	if remaining := time.Until(expiresAt); remaining < oauth.DefaultTokenLifetime-time.Minute {
+		t.Errorf("expires in %v, want about %v when expires_in is missing", remaining, oauth.DefaultTokenLifetime)
+	}
+	if !stored.IsActive {
+		t.Error("a successful refresh should leave the account active")
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+)
+
+func TestOAuthService_HasWarmToken(t *testing.T) {
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	service := NewOAuthService(redisClient, nil, nil, nil)
+	account := &models.Account{ID: "acc-1", ProviderID: "antigravity"}
+
+	if service.HasWarmToken(account) {
+		t.Error("HasWarmToken() = true with no cached token")
+	}
+
+	redisClient.Set(context.Background(), "auth:antigravity:acc-1", `{"access_token":"t"}`, 30*time.Second)
+	if service.HasWarmToken(account) {
+		t.Error("HasWarmToken() = true for a token inside the refresh skew")
+	}
+
+	redisClient.Set(context.Background(), "auth:antigravity:acc-1", `{"access_token":"t"}`, time.Hour)
+	if !service.HasWarmToken(account) {
+		t.Error("HasWarmToken() = false for a token cached for an hour")
+	}
+}
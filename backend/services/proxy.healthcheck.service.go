@@ -4,9 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net"
-	"net/http"
-	"net/url"
 	"sync"
 	"time"
 
@@ -142,69 +139,20 @@ func (s *ProxyHealthCheckService) checkProxy(proxy *models.Proxy) {
 
 // quickTCPCheck performs a quick TCP connection test to proxy
 func (s *ProxyHealthCheckService) quickTCPCheck(proxy *models.Proxy) bool {
-	parsed, err := url.Parse(proxy.URL)
+	parsed, err := parseProxyURL(proxy.URL)
 	if err != nil {
 		return false
 	}
-
-	// Extract host:port from proxy URL
-	host := parsed.Host
-	if host == "" {
-		host = parsed.Hostname()
-		if host == "" {
-			return false
-		}
-		if parsed.Port() != "" {
-			host = host + ":" + parsed.Port()
-		} else {
-			// Default to proxy port 44444 if not specified
-			host = host + ":44444"
-		}
-	}
-
-	// Attempt TCP connection with timeout
-	conn, err := net.DialTimeout("tcp", host, 5*time.Second)
-	if err != nil {
-		return false
-	}
-	conn.Close()
-	return true
+	return checkProxyTCP(parsed) == nil
 }
 
 // fullHTTPCheck performs a full HTTP test through the proxy
 func (s *ProxyHealthCheckService) fullHTTPCheck(proxy *models.Proxy) bool {
-	// Build transport with proxy
-	parsed, err := url.Parse(proxy.URL)
+	parsed, err := parseProxyURL(proxy.URL)
 	if err != nil {
 		return false
 	}
-
-	transport := &http.Transport{
-		Proxy: http.ProxyURL(parsed),
-		// Tight timeout for health check
-		IdleConnTimeout:     5 * time.Second,
-		TLSHandshakeTimeout: 5 * time.Second,
-	}
-
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   10 * time.Second,
-	}
-
-	// Test with a simple external service
-	req, err := http.NewRequest("HEAD", "http://httpbin.org/status/200", nil)
-	if err != nil {
-		return false
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-
-	// Accept any 2xx status
-	return resp.StatusCode >= 200 && resp.StatusCode < 300
+	return checkProxyHTTP(parsed) == nil
 }
 
 // CheckProxyManual manually triggers a health check for a specific proxy
@@ -5,16 +5,27 @@ import (
 	"aigateway-backend/models"
 	"aigateway-backend/repositories"
 	"fmt"
+	"log"
+	"sort"
 	"sync"
 	"time"
 )
 
+// Proxy selection strategies (proxy.selection_strategy)
+const (
+	// ProxySelectionFillFirst takes proxies in priority order, then fewest assigned accounts
+	ProxySelectionFillFirst = "fill_first"
+	// ProxySelectionLowestLatency prefers healthy over degraded, then the lowest average latency
+	ProxySelectionLowestLatency = "lowest_latency"
+)
+
 // ProxyService handles proxy assignment and management operations
 type ProxyService struct {
 	repo                 *repositories.ProxyRepository
 	accountRepo          *repositories.AccountRepository
 	mu                   sync.RWMutex
 	downRecoveryDelay    time.Duration
+	selectionStrategy    string
 }
 
 // NewProxyService creates a new proxy service instance
@@ -23,10 +34,21 @@ func NewProxyService(repo *repositories.ProxyRepository, accountRepo *repositori
 	if cfg != nil && cfg.DownRecoveryDelayMin > 0 {
 		recoveryDelay = time.Duration(cfg.DownRecoveryDelayMin) * time.Minute
 	}
+	strategy := ProxySelectionFillFirst
+	if cfg != nil {
+		switch cfg.SelectionStrategy {
+		case "", ProxySelectionFillFirst:
+		case ProxySelectionLowestLatency:
+			strategy = ProxySelectionLowestLatency
+		default:
+			log.Printf("[ProxyService] Unknown selection_strategy %q, using %s", cfg.SelectionStrategy, ProxySelectionFillFirst)
+		}
+	}
 	return &ProxyService{
 		repo:              repo,
 		accountRepo:       accountRepo,
 		downRecoveryDelay: recoveryDelay,
+		selectionStrategy: strategy,
 	}
 }
 
@@ -46,7 +68,7 @@ func (s *ProxyService) AssignProxy(account *models.Account, providerID string) e
 	}
 
 	// Get active proxies for provider
-	proxies, err := s.repo.GetActiveByProvider(providerID)
+	proxies, err := s.activeProxies(providerID)
 	if err != nil || len(proxies) == 0 {
 		// No proxies available, clear proxy assignment
 		account.ProxyURL = ""
@@ -75,6 +97,33 @@ func (s *ProxyService) AssignProxy(account *models.Account, providerID string) e
 	return nil
 }
 
+// activeProxies returns the provider's usable proxies in the configured selection order
+func (s *ProxyService) activeProxies(providerID string) ([]*models.Proxy, error) {
+	proxies, err := s.repo.GetActiveByProvider(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if s.selectionStrategy == ProxySelectionLowestLatency {
+		sortByLatency(proxies)
+	}
+	return proxies, nil
+}
+
+// sortByLatency orders healthy proxies before degraded ones, then by average latency
+// Proxies without a latency sample go after measured ones; ties keep repository order.
+func sortByLatency(proxies []*models.Proxy) {
+	sort.SliceStable(proxies, func(i, j int) bool {
+		a, b := proxies[i], proxies[j]
+		if aHealthy, bHealthy := a.HealthStatus == models.HealthStatusHealthy, b.HealthStatus == models.HealthStatusHealthy; aHealthy != bHealthy {
+			return aHealthy
+		}
+		if aMeasured, bMeasured := a.AvgLatencyMs > 0, b.AvgLatencyMs > 0; aMeasured != bMeasured {
+			return aMeasured
+		}
+		return a.AvgLatencyMs < b.AvgLatencyMs
+	})
+}
+
 // hasCapacity checks if a proxy has available capacity for more accounts
 func (s *ProxyService) hasCapacity(proxy *models.Proxy) bool {
 	if proxy.MaxAccounts <= 0 {
@@ -145,7 +194,7 @@ func (s *ProxyService) SelectProxyForNewAccount(providerID string) (*models.Prox
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	proxies, err := s.repo.GetActiveByProvider(providerID)
+	proxies, err := s.activeProxies(providerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get proxies: %w", err)
 	}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Targets used to exercise a proxy; package vars so tests can point them elsewhere
+var (
+	proxyCheckHTTPURL       = "http://httpbin.org/status/200"
+	proxyCheckConnectTarget = "httpbin.org:443"
+)
+
+const (
+	proxyDialTimeout  = 5 * time.Second
+	proxyCheckTimeout = 10 * time.Second
+)
+
+// ProxyCheckResult is the outcome of a single proxy check
+type ProxyCheckResult struct {
+	Passed    bool   `json:"passed"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProxyValidationResult is the outcome of validating a proxy URL before it is saved
+type ProxyValidationResult struct {
+	URL     string           `json:"proxy_url"`
+	Valid   bool             `json:"valid"`
+	TCP     ProxyCheckResult `json:"tcp"`
+	HTTP    ProxyCheckResult `json:"http"`
+	Connect ProxyCheckResult `json:"connect"`
+}
+
+// ValidateProxyURL runs the TCP, HTTP and CONNECT checks against a proxy URL
+// The HTTP and CONNECT checks are skipped when the proxy isn't reachable over TCP.
+func ValidateProxyURL(rawURL string) *ProxyValidationResult {
+	result := &ProxyValidationResult{URL: rawURL}
+
+	parsed, err := parseProxyURL(rawURL)
+	if err != nil {
+		result.TCP.Error = err.Error()
+		result.HTTP.Error = "skipped: invalid proxy URL"
+		result.Connect.Error = "skipped: invalid proxy URL"
+		return result
+	}
+
+	result.TCP = timeProxyCheck(func() error { return checkProxyTCP(parsed) })
+	if !result.TCP.Passed {
+		result.HTTP.Error = "skipped: TCP check failed"
+		result.Connect.Error = "skipped: TCP check failed"
+		return result
+	}
+
+	result.HTTP = timeProxyCheck(func() error { return checkProxyHTTP(parsed) })
+	result.Connect = timeProxyCheck(func() error { return checkProxyConnect(parsed) })
+	result.Valid = result.HTTP.Passed && result.Connect.Passed
+	return result
+}
+
+// timeProxyCheck runs check and records its latency and error
+func timeProxyCheck(check func() error) ProxyCheckResult {
+	start := time.Now()
+	err := check()
+	result := ProxyCheckResult{Passed: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// parseProxyURL parses a proxy URL and requires a scheme and host
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid proxy URL: scheme and host are required")
+	}
+	return parsed, nil
+}
+
+// proxyDialAddress returns the proxy's host:port, defaulting to port 44444
+func proxyDialAddress(parsed *url.URL) string {
+	if parsed.Port() != "" {
+		return parsed.Host
+	}
+	return net.JoinHostPort(parsed.Hostname(), "44444")
+}
+
+// checkProxyTCP verifies the proxy accepts TCP connections
+func checkProxyTCP(parsed *url.URL) error {
+	conn, err := net.DialTimeout("tcp", proxyDialAddress(parsed), proxyDialTimeout)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	return nil
+}
+
+// checkProxyHTTP sends a plain HTTP request through the proxy with its credentials
+func checkProxyHTTP(parsed *url.URL) error {
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyURL(parsed),
+			IdleConnTimeout:     proxyDialTimeout,
+			TLSHandshakeTimeout: proxyDialTimeout,
+		},
+		Timeout: proxyCheckTimeout,
+	}
+
+	req, err := http.NewRequest(http.MethodHead, proxyCheckHTTPURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkProxyConnect verifies the proxy opens a CONNECT tunnel, which HTTPS upstreams require
+func checkProxyConnect(parsed *url.URL) error {
+	conn, err := net.DialTimeout("tcp", proxyDialAddress(parsed), proxyDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(proxyCheckTimeout))
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: proxyCheckConnectTarget},
+		Host:   proxyCheckConnectTarget,
+		Header: make(http.Header),
+	}
+	if parsed.User != nil {
+		password, _ := parsed.User.Password()
+		req.SetBasicAuth(parsed.User.Username(), password)
+		req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+		req.Header.Del("Authorization")
+	}
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("CONNECT rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"testing"
+
+	"aigateway-backend/internal/config"
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// createProxyPoolTable creates proxy_pool without MySQL enum columns
+func createProxyPoolTable(t *testing.T, db *gorm.DB) {
+	err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS proxy_pool (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT NOT NULL UNIQUE,
+			protocol TEXT DEFAULT 'http',
+			is_active BOOLEAN DEFAULT 1,
+			health_status TEXT DEFAULT 'healthy',
+			max_accounts INTEGER DEFAULT 0,
+			current_accounts INTEGER DEFAULT 0,
+			last_used_at DATETIME,
+			usage_count INTEGER DEFAULT 0,
+			priority INTEGER DEFAULT 0,
+			weight INTEGER DEFAULT 1,
+			consecutive_failures INTEGER DEFAULT 0,
+			max_failures INTEGER DEFAULT 3,
+			success_rate REAL DEFAULT 100,
+			avg_latency_ms INTEGER DEFAULT 0,
+			last_checked_at DATETIME,
+			marked_down_at DATETIME,
+			created_at DATETIME,
+			updated_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("failed to create proxy_pool table: %v", err)
+	}
+}
+
+// seedLatencyProxies creates proxies whose fastest healthy one is fast.example.com
+func seedLatencyProxies(t *testing.T, db *gorm.DB) {
+	proxies := []*models.Proxy{
+		{URL: "http://slow.example.com:8080", HealthStatus: models.HealthStatusHealthy, AvgLatencyMs: 300, Priority: 1},
+		{URL: "http://fast.example.com:8080", HealthStatus: models.HealthStatusHealthy, AvgLatencyMs: 80},
+		{URL: "http://degraded.example.com:8080", HealthStatus: models.HealthStatusDegraded, AvgLatencyMs: 20},
+		{URL: "http://unmeasured.example.com:8080", HealthStatus: models.HealthStatusHealthy},
+		{URL: "http://down.example.com:8080", HealthStatus: models.HealthStatusDown, AvgLatencyMs: 10},
+	}
+	for _, p := range proxies {
+		p.IsActive = true
+		p.Protocol = models.ProxyProtocolHTTP
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("failed to seed proxy: %v", err)
+		}
+	}
+}
+
+func TestSelectProxyForNewAccount_LowestLatency(t *testing.T) {
+	db := setupTestDB(t)
+	createProxyPoolTable(t, db)
+	seedLatencyProxies(t, db)
+
+	service := NewProxyService(repositories.NewProxyRepository(db), nil, &config.ProxyConfig{SelectionStrategy: ProxySelectionLowestLatency})
+	proxy, err := service.SelectProxyForNewAccount("antigravity")
+	if err != nil {
+		t.Fatalf("SelectProxyForNewAccount() error = %v", err)
+	}
+	if proxy.URL != "http://fast.example.com:8080" {
+		t.Errorf("selected %s, want the fastest healthy proxy", proxy.URL)
+	}
+}
+
+func TestSelectProxyForNewAccount_FillFirstByDefault(t *testing.T) {
+	db := setupTestDB(t)
+	createProxyPoolTable(t, db)
+	seedLatencyProxies(t, db)
+
+	service := NewProxyService(repositories.NewProxyRepository(db), nil, &config.ProxyConfig{})
+	proxy, err := service.SelectProxyForNewAccount("antigravity")
+	if err != nil {
+		t.Fatalf("SelectProxyForNewAccount() error = %v", err)
+	}
+	if proxy.URL != "http://slow.example.com:8080" {
+		t.Errorf("selected %s, want the highest-priority proxy", proxy.URL)
+	}
+}
+
+func TestSortByLatency(t *testing.T) {
+	proxies := []*models.Proxy{
+		{ID: 1, HealthStatus: models.HealthStatusDegraded, AvgLatencyMs: 5},
+		{ID: 2, HealthStatus: models.HealthStatusHealthy},
+		{ID: 3, HealthStatus: models.HealthStatusHealthy, AvgLatencyMs: 200},
+		{ID: 4, HealthStatus: models.HealthStatusHealthy, AvgLatencyMs: 50},
+	}
+	sortByLatency(proxies)
+
+	want := []int{4, 3, 2, 1}
+	for i, p := range proxies {
+		if p.ID != want[i] {
+			t.Fatalf("order[%d] = %d, want %v", i, p.ID, want)
+		}
+	}
+}
+
+func TestProxyRepository_UpdateHealthRollingLatency(t *testing.T) {
+	db := setupTestDB(t)
+	createProxyPoolTable(t, db)
+	repo := repositories.NewProxyRepository(db)
+
+	proxy := &models.Proxy{URL: "http://p.example.com:8080", IsActive: true, Protocol: models.ProxyProtocolHTTP, HealthStatus: models.HealthStatusHealthy}
+	if err := db.Create(proxy).Error; err != nil {
+		t.Fatalf("failed to seed proxy: %v", err)
+	}
+
+	steps := []struct {
+		latencyMs int
+		want      int
+	}{
+		{100, 100}, // First sample is taken as-is
+		{600, 200}, // (100*4 + 600) / 5
+		{0, 200},   // No sample (e.g. marked down) keeps the average
+	}
+	for _, step := range steps {
+		if err := repo.UpdateHealth(proxy.ID, models.HealthStatusHealthy, step.latencyMs); err != nil {
+			t.Fatalf("UpdateHealth() error = %v", err)
+		}
+		got, _ := repo.GetByID(proxy.ID)
+		if got.AvgLatencyMs != step.want {
+			t.Errorf("after %dms sample avg_latency_ms = %d, want %d", step.latencyMs, got.AvgLatencyMs, step.want)
+		}
+	}
+}
@@ -10,7 +10,7 @@ const (
 	// Key prefixes
 	quotaKeyPrefix = "quota"
 
-	// TTL for quota window (5 hours for Antigravity)
+	// Default TTL for quota window (5 hours for Antigravity); see SetProviderWindow
 	QuotaWindowTTL = 5 * time.Hour
 )
 
@@ -6,6 +6,9 @@ import (
 	"context"
 	"log"
 	"math"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -17,8 +20,39 @@ type QuotaTrackerService struct {
 	redis     *redis.Client
 	keys      QuotaKeys
 	windowTTL time.Duration
+
+	// staleGrace keeps decayed learned limits in effect instead of ignoring them
+	staleGrace bool
+
+	// Per-provider and per-model window overrides of windowTTL (see quota.window.go)
+	providerWindows map[string]time.Duration
+	modelWindows    map[string]map[string]time.Duration
+	providerOf      func(accountID string) string
+	providerCache   sync.Map // account ID -> provider ID
+
+	webhook *QuotaWebhook // Exhaustion push notifications (nil = disabled)
+
+	// In-memory copy of learned request limits read on every account selection
+	learnedLimits sync.Map // QuotaStatusKey -> cachedLimit
+}
+
+// learnedLimitTTL bounds how long a cached learned request limit is used before MySQL is re-read
+const learnedLimitTTL = time.Minute
+
+// cachedLimit holds the learned limits in effect as of expiresAt (nil pattern = none)
+type cachedLimit struct {
+	pattern   *models.AccountQuotaPattern
+	enforced  bool // confident enough to mark the account exhausted before upstream does
+	expiresAt time.Time
 }
 
+// MinLearnedConfidence is the decayed confidence below which learned limits are considered stale
+const MinLearnedConfidence = 0.05
+
+// MinEnforcedConfidence is the decayed confidence learned limits need before they act as a hard cap
+// Below it they only steer selection, and upstream quota errors decide when an account is exhausted.
+const MinEnforcedConfidence = 0.3
+
 // NewQuotaTrackerService creates a new quota tracker service
 func NewQuotaTrackerService(
 	repo *repositories.QuotaPatternRepository,
@@ -32,28 +66,58 @@ func NewQuotaTrackerService(
 	}
 }
 
+// SetStaleLimitGrace keeps applying learned limits whose confidence has decayed
+// Without it, stale limits are ignored until the account hits its quota again.
+func (s *QuotaTrackerService) SetStaleLimitGrace(enabled bool) {
+	s.staleGrace = enabled
+}
+
 // RecordUsage records successful request usage (requests + tokens)
 func (s *QuotaTrackerService) RecordUsage(accountID, model string, tokens int64) {
 	ctx := context.Background()
+	window := s.windowFor(accountID, model)
 
 	// Increment request counter
 	reqKey := s.keys.RequestsKey(accountID, model)
 	pipe := s.redis.Pipeline()
-	pipe.Incr(ctx, reqKey)
-	pipe.Expire(ctx, reqKey, s.windowTTL)
+	reqCmd := pipe.Incr(ctx, reqKey)
+	pipe.Expire(ctx, reqKey, window)
 
 	// Increment token counter
 	tokenKey := s.keys.TokensKey(accountID, model)
-	pipe.IncrBy(ctx, tokenKey, tokens)
-	pipe.Expire(ctx, tokenKey, s.windowTTL)
+	tokenCmd := pipe.IncrBy(ctx, tokenKey, tokens)
+	pipe.Expire(ctx, tokenKey, window)
 
 	// Set window start if not exists
 	windowKey := s.keys.WindowStartKey(accountID, model)
-	pipe.SetNX(ctx, windowKey, time.Now().Unix(), s.windowTTL)
+	pipe.SetNX(ctx, windowKey, time.Now().Unix(), window)
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		log.Printf("[QuotaTracker] Failed to record usage: %v", err)
+		return
+	}
+
+	// Check learned limits against the new totals (async, reads MySQL on a cache miss)
+	requests, totalTokens := int(reqCmd.Val()), tokenCmd.Val()
+	goAsync(func() { s.checkLearnedLimits(accountID, model, requests, totalTokens) })
+}
+
+// checkLearnedLimits marks account+model exhausted once usage reaches the binding learned limit
+// Token-heavy traffic can hit the token limit long before the request limit, or vice versa.
+func (s *QuotaTrackerService) checkLearnedLimits(accountID, model string, requests int, tokens int64) {
+	learned := s.learnedLimitsFor(accountID, model)
+	if learned.pattern == nil || !learned.enforced {
+		return
 	}
+
+	ratio, constraint := bindingUsage(learned.pattern, requests, tokens)
+	if ratio < 1 {
+		return
+	}
+
+	ctx := context.Background()
+	s.redis.Set(ctx, s.keys.ExhaustedKey(accountID, model), true, s.windowFor(accountID, model))
+	log.Printf("[QuotaTracker] %s/%s reached learned %s limit (%.0f%%), marking exhausted", accountID, model, constraint, ratio*100)
 }
 
 // MarkExhausted marks account+model as exhausted and learns from the pattern
@@ -66,10 +130,11 @@ func (s *QuotaTrackerService) MarkExhausted(accountID, model string) {
 
 	// Mark as exhausted in Redis
 	exhaustedKey := s.keys.ExhaustedKey(accountID, model)
-	s.redis.Set(ctx, exhaustedKey, true, s.windowTTL)
+	s.redis.Set(ctx, exhaustedKey, true, s.windowFor(accountID, model))
 
 	// Learn from this exhaustion event (async)
-	go s.learnFromExhaustion(accountID, model, requests, tokens)
+	goAsync(func() { s.learnFromExhaustion(accountID, model, requests, tokens) })
+	s.notifyExhausted(accountID, model, time.Now())
 }
 
 // learnFromExhaustion updates learned limits based on exhaustion event
@@ -86,15 +151,34 @@ func (s *QuotaTrackerService) learnFromExhaustion(accountID, model string, reque
 
 	now := time.Now()
 
-	if pattern.EstRequestLimit == nil {
+	if pattern.EstRequestLimit == nil || pattern.EstTokenLimit == nil {
 		// First time hitting limit - set directly
 		pattern.EstRequestLimit = &requests
 		pattern.EstTokenLimit = &tokens
+		pattern.MaxObservedRequests = &requests
+		pattern.MaxObservedTokens = &tokens
 		pattern.Confidence = 0.1
 	} else {
-		// Update with weighted average
-		pattern.EstRequestLimit = weightedAvgInt(*pattern.EstRequestLimit, requests, pattern.Confidence)
-		pattern.EstTokenLimit = weightedAvgInt64(*pattern.EstTokenLimit, tokens, pattern.Confidence)
+		// Only refine the binding constraint(s): exhausting tokens at low request count
+		// says nothing new about the request limit, and vice versa
+		requestRatio, tokenRatio := usageRatios(pattern, requests, tokens)
+		if requestRatio >= tokenRatio {
+			maxObserved := int64(*pattern.EstRequestLimit)
+			if pattern.MaxObservedRequests != nil {
+				maxObserved = int64(*pattern.MaxObservedRequests)
+			}
+			estimate, newMax := learnLimit(int64(*pattern.EstRequestLimit), maxObserved, int64(requests), pattern.Confidence)
+			estRequests, maxRequests := int(estimate), int(newMax)
+			pattern.EstRequestLimit, pattern.MaxObservedRequests = &estRequests, &maxRequests
+		}
+		if tokenRatio >= requestRatio {
+			maxObserved := *pattern.EstTokenLimit
+			if pattern.MaxObservedTokens != nil {
+				maxObserved = *pattern.MaxObservedTokens
+			}
+			estimate, newMax := learnLimit(*pattern.EstTokenLimit, maxObserved, tokens, pattern.Confidence)
+			pattern.EstTokenLimit, pattern.MaxObservedTokens = &estimate, &newMax
+		}
 		pattern.Confidence = math.Min(1.0, float64(pattern.SampleCount+1)/10.0)
 	}
 
@@ -104,6 +188,7 @@ func (s *QuotaTrackerService) learnFromExhaustion(accountID, model string, reque
 	if err := s.repo.Save(pattern); err != nil {
 		log.Printf("[QuotaTracker] Failed to save pattern: %v", err)
 	}
+	s.learnedLimits.Delete(QuotaStatusKey{AccountID: accountID, Model: model})
 }
 
 // IsAvailable checks if account+model has available quota
@@ -125,42 +210,170 @@ func (s *QuotaTrackerService) IsAvailable(accountID, model string) bool {
 	return !exhausted
 }
 
+// RemainingHeadroom returns requests left before the learned request limit for account+model
+// Returns false when no request limit has been learned yet. Called for every selection, so the
+// learned limit comes from memory and only the request counter is read from Redis.
+func (s *QuotaTrackerService) RemainingHeadroom(accountID, model string) (int, bool) {
+	limit := s.learnedRequestLimit(accountID, model)
+	if limit <= 0 {
+		return 0, false
+	}
+
+	requests, _ := s.redis.Get(context.Background(), s.keys.RequestsKey(accountID, model)).Int()
+	headroom := limit - requests
+	if headroom < 0 {
+		headroom = 0
+	}
+	return headroom, true
+}
+
+// learnedRequestLimit returns the learned request limit in effect for account+model (0 = none)
+func (s *QuotaTrackerService) learnedRequestLimit(accountID, model string) int {
+	learned := s.learnedLimitsFor(accountID, model)
+	if learned.pattern == nil || learned.pattern.EstRequestLimit == nil {
+		return 0
+	}
+	return *learned.pattern.EstRequestLimit
+}
+
+// learnedLimitsFor returns the learned limits in effect for account+model
+// Cached for learnedLimitTTL; learning a new limit invalidates the entry.
+func (s *QuotaTrackerService) learnedLimitsFor(accountID, model string) cachedLimit {
+	key := QuotaStatusKey{AccountID: accountID, Model: model}
+	now := time.Now()
+	if cached, ok := s.learnedLimits.Load(key); ok && now.Before(cached.(cachedLimit).expiresAt) {
+		return cached.(cachedLimit)
+	}
+
+	pattern, err := s.repo.GetByAccountModel(accountID, model)
+	if err != nil {
+		return cachedLimit{} // Don't cache lookup failures
+	}
+
+	learned := cachedLimit{expiresAt: now.Add(learnedLimitTTL)}
+	if pattern != nil && s.limitsInEffect(pattern) {
+		learned.pattern = pattern
+		learned.enforced = s.limitsEnforced(pattern)
+	}
+	s.learnedLimits.Store(key, learned)
+	return learned
+}
+
+// QuotaStatusKey identifies the counters of one account+model
+type QuotaStatusKey struct {
+	AccountID string
+	Model     string
+}
+
+// quotaCounterCmds holds the pipelined reads for one account+model
+type quotaCounterCmds struct {
+	requests, tokens, exhausted, windowStart *redis.StringCmd
+}
+
 // GetQuotaStatus returns current quota status for account+model
 func (s *QuotaTrackerService) GetQuotaStatus(accountID, model string) *models.QuotaStatus {
 	ctx := context.Background()
 
-	status := &models.QuotaStatus{
-		AccountID: accountID,
-		Model:     model,
-	}
-
 	// Get current usage from Redis
 	requests, _ := s.redis.Get(ctx, s.keys.RequestsKey(accountID, model)).Int()
 	tokens, _ := s.redis.Get(ctx, s.keys.TokensKey(accountID, model)).Int64()
-	status.RequestsUsed = requests
-	status.TokensUsed = tokens
-
-	// Check exhausted status
 	exhausted, _ := s.redis.Get(ctx, s.keys.ExhaustedKey(accountID, model)).Bool()
-	status.IsExhausted = exhausted
+	windowStart, _ := s.redis.Get(ctx, s.keys.WindowStartKey(accountID, model)).Int64()
+
+	// Get learned limits from MySQL
+	pattern, err := s.repo.GetByAccountModel(accountID, model)
+	if err != nil {
+		pattern = nil
+	}
 
-	// Get window reset time
-	windowStart, err := s.redis.Get(ctx, s.keys.WindowStartKey(accountID, model)).Int64()
-	if err == nil && windowStart > 0 {
-		resetAt := time.Unix(windowStart, 0).Add(s.windowTTL)
+	return s.buildQuotaStatus(QuotaStatusKey{accountID, model}, requests, tokens, exhausted, windowStart, pattern)
+}
+
+// GetQuotaStatusBatch returns quota status for many account+model pairs
+// All Redis counters are read in one pipeline and learned limits in one query,
+// instead of four GETs and a lookup per pair as with GetQuotaStatus.
+func (s *QuotaTrackerService) GetQuotaStatusBatch(keys []QuotaStatusKey) map[QuotaStatusKey]*models.QuotaStatus {
+	result := make(map[QuotaStatusKey]*models.QuotaStatus, len(keys))
+	if len(keys) == 0 {
+		return result
+	}
+	ctx := context.Background()
+
+	cmds := make(map[QuotaStatusKey]quotaCounterCmds, len(keys))
+	pipe := s.redis.Pipeline()
+	for _, key := range keys {
+		cmds[key] = quotaCounterCmds{
+			requests:    pipe.Get(ctx, s.keys.RequestsKey(key.AccountID, key.Model)),
+			tokens:      pipe.Get(ctx, s.keys.TokensKey(key.AccountID, key.Model)),
+			exhausted:   pipe.Get(ctx, s.keys.ExhaustedKey(key.AccountID, key.Model)),
+			windowStart: pipe.Get(ctx, s.keys.WindowStartKey(key.AccountID, key.Model)),
+		}
+	}
+	// Missing counters come back as redis.Nil per command and read as zero below
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		log.Printf("[QuotaTracker] Batch status read failed: %v", err)
+	}
+
+	// Learned limits for every account involved
+	accountIDs := make([]string, 0, len(keys))
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if !seen[key.AccountID] {
+			seen[key.AccountID] = true
+			accountIDs = append(accountIDs, key.AccountID)
+		}
+	}
+	patterns := make(map[QuotaStatusKey]*models.AccountQuotaPattern)
+	if list, err := s.repo.ListByAccounts(accountIDs); err == nil {
+		for _, p := range list {
+			patterns[QuotaStatusKey{p.AccountID, p.Model}] = p
+		}
+	}
+
+	for key, c := range cmds {
+		requests, _ := c.requests.Int()
+		tokens, _ := c.tokens.Int64()
+		exhausted, _ := c.exhausted.Bool()
+		windowStart, _ := c.windowStart.Int64()
+		result[key] = s.buildQuotaStatus(key, requests, tokens, exhausted, windowStart, patterns[key])
+	}
+	return result
+}
+
+// buildQuotaStatus assembles a status from raw counters and the learned pattern (may be nil)
+func (s *QuotaTrackerService) buildQuotaStatus(key QuotaStatusKey, requests int, tokens int64, exhausted bool, windowStart int64, pattern *models.AccountQuotaPattern) *models.QuotaStatus {
+	status := &models.QuotaStatus{
+		AccountID:    key.AccountID,
+		Model:        key.Model,
+		RequestsUsed: requests,
+		TokensUsed:   tokens,
+		IsExhausted:  exhausted,
+	}
+
+	// Window reset time
+	if windowStart > 0 {
+		resetAt := time.Unix(windowStart, 0).Add(s.windowFor(key.AccountID, key.Model))
 		status.ResetsAt = &resetAt
 	}
 
-	// Get learned limits from MySQL
-	pattern, err := s.repo.GetByAccountModel(accountID, model)
-	if err == nil && pattern != nil {
+	if pattern != nil {
 		status.EstRequestLimit = pattern.EstRequestLimit
 		status.EstTokenLimit = pattern.EstTokenLimit
 		status.Confidence = s.getDecayedConfidence(pattern)
+		status.LowConfidence = s.staleGrace && s.isStale(pattern)
 
-		// Calculate percent used if we have learned limits
+		// Report utilization of each learned limit; PercentUsed follows the binding one
+		requestRatio, tokenRatio := usageRatios(pattern, requests, tokens)
 		if pattern.EstRequestLimit != nil && *pattern.EstRequestLimit > 0 {
-			pct := float64(requests) / float64(*pattern.EstRequestLimit) * 100
+			pct := requestRatio * 100
+			status.RequestPercentUsed = &pct
+		}
+		if pattern.EstTokenLimit != nil && *pattern.EstTokenLimit > 0 {
+			pct := tokenRatio * 100
+			status.TokenPercentUsed = &pct
+		}
+		if ratio, constraint := bindingUsage(pattern, requests, tokens); constraint != "" {
+			pct := ratio * 100
 			status.PercentUsed = &pct
 		}
 	}
@@ -168,6 +381,40 @@ func (s *QuotaTrackerService) GetQuotaStatus(accountID, model string) *models.Qu
 	return status
 }
 
+// TrackedModels returns models with live usage counters or learned patterns for an account
+func (s *QuotaTrackerService) TrackedModels(accountID string) []string {
+	ctx := context.Background()
+	seen := make(map[string]bool)
+	var result []string
+
+	add := func(model string) {
+		if model != "" && !seen[model] {
+			seen[model] = true
+			result = append(result, model)
+		}
+	}
+
+	// Live counters: quota:{account_id}:{model}:{field}
+	prefix := quotaKeyPrefix + ":" + accountID + ":"
+	iter := s.redis.Scan(ctx, 0, s.keys.AccountPattern(accountID), 100).Iterator()
+	for iter.Next(ctx) {
+		rest := strings.TrimPrefix(iter.Val(), prefix)
+		if idx := strings.LastIndex(rest, ":"); idx > 0 {
+			add(rest[:idx])
+		}
+	}
+
+	// Learned limits persist after counters expire
+	if patterns, err := s.repo.ListByAccount(accountID); err == nil {
+		for _, p := range patterns {
+			add(p.Model)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
 // GetEarliestReset returns the earliest reset time among exhausted accounts for a provider+model
 func (s *QuotaTrackerService) GetEarliestReset(accountIDs []string, model string) *time.Time {
 	ctx := context.Background()
@@ -179,7 +426,7 @@ func (s *QuotaTrackerService) GetEarliestReset(accountIDs []string, model string
 			continue
 		}
 
-		resetAt := time.Unix(windowStart, 0).Add(s.windowTTL)
+		resetAt := time.Unix(windowStart, 0).Add(s.windowFor(accID, model))
 		if earliest == nil || resetAt.Before(*earliest) {
 			earliest = &resetAt
 		}
@@ -199,6 +446,7 @@ func (s *QuotaTrackerService) ClearQuota(accountID, model string) error {
 		s.keys.WindowStartKey(accountID, model),
 	}
 
+	s.learnedLimits.Delete(QuotaStatusKey{AccountID: accountID, Model: model})
 	return s.redis.Del(ctx, keys...).Err()
 }
 
@@ -220,14 +468,77 @@ func (s *QuotaTrackerService) getDecayedConfidence(pattern *models.AccountQuotaP
 	return confidence
 }
 
-// Helper: weighted average for int
-func weightedAvgInt(old, new int, weight float64) *int {
-	result := int((float64(old)*weight + float64(new)) / (weight + 1))
-	return &result
+// isStale reports whether the learned limits have decayed below MinLearnedConfidence
+// Patterns that were never exhausted (e.g. seeded manually) carry no age and never go stale.
+func (s *QuotaTrackerService) isStale(pattern *models.AccountQuotaPattern) bool {
+	return pattern.LastExhaustedAt != nil && s.getDecayedConfidence(pattern) < MinLearnedConfidence
+}
+
+// limitsInEffect reports whether learned limits should influence selection
+func (s *QuotaTrackerService) limitsInEffect(pattern *models.AccountQuotaPattern) bool {
+	return s.staleGrace || !s.isStale(pattern)
+}
+
+// limitsEnforced reports whether learned limits are trusted as a hard cap
+// Patterns that were never exhausted (e.g. seeded manually) are always enforced.
+func (s *QuotaTrackerService) limitsEnforced(pattern *models.AccountQuotaPattern) bool {
+	return pattern.LastExhaustedAt == nil || s.getDecayedConfidence(pattern) >= MinEnforcedConfidence
 }
 
-// Helper: weighted average for int64
-func weightedAvgInt64(old, new int64, weight float64) *int64 {
-	result := int64((float64(old)*weight + float64(new)) / (weight + 1))
-	return &result
+// Quota constraint kinds
+const (
+	quotaConstraintRequests = "requests"
+	quotaConstraintTokens   = "tokens"
+)
+
+// usageRatios returns usage as a fraction of the learned request and token limits
+// A ratio is 0 when the corresponding limit is unknown.
+func usageRatios(pattern *models.AccountQuotaPattern, requests int, tokens int64) (float64, float64) {
+	var requestRatio, tokenRatio float64
+	if pattern.EstRequestLimit != nil && *pattern.EstRequestLimit > 0 {
+		requestRatio = float64(requests) / float64(*pattern.EstRequestLimit)
+	}
+	if pattern.EstTokenLimit != nil && *pattern.EstTokenLimit > 0 {
+		tokenRatio = float64(tokens) / float64(*pattern.EstTokenLimit)
+	}
+	return requestRatio, tokenRatio
+}
+
+// bindingUsage returns the usage ratio of whichever learned limit is closest to exhaustion
+// constraint is empty when no limits have been learned.
+func bindingUsage(pattern *models.AccountQuotaPattern, requests int, tokens int64) (float64, string) {
+	hasRequestLimit := pattern.EstRequestLimit != nil && *pattern.EstRequestLimit > 0
+	hasTokenLimit := pattern.EstTokenLimit != nil && *pattern.EstTokenLimit > 0
+	requestRatio, tokenRatio := usageRatios(pattern, requests, tokens)
+
+	switch {
+	case hasTokenLimit && (!hasRequestLimit || tokenRatio > requestRatio):
+		return tokenRatio, quotaConstraintTokens
+	case hasRequestLimit:
+		return requestRatio, quotaConstraintRequests
+	default:
+		return 0, ""
+	}
+}
+
+const (
+	// maxObservedDecay shrinks the running max per sample so a genuinely lowered limit is relearned
+	maxObservedDecay = 0.95
+	// limitFloorRatio keeps the estimate near the top of the observed distribution
+	limitFloorRatio = 0.9
+)
+
+// learnLimit folds one usage-at-exhaustion observation into a learned limit.
+// Observations are lower bounds on the true limit: a higher one is adopted outright, while a
+// lower one (e.g. a run of token-heavy requests) only blends in and cannot pull the estimate
+// below limitFloorRatio of the decaying running max. Returns the new estimate and running max.
+func learnLimit(estimate, maxObserved, observed int64, weight float64) (int64, int64) {
+	maxObserved = int64(math.Max(float64(observed), float64(maxObserved)*maxObservedDecay))
+	if observed >= estimate {
+		return observed, maxObserved
+	}
+
+	blended := (float64(estimate)*weight + float64(observed)) / (weight + 1)
+	floor := float64(maxObserved) * limitFloorRatio
+	return int64(math.Max(blended, floor)), maxObserved
 }
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"aigateway-backend/models"
 	"aigateway-backend/repositories"
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -29,6 +31,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			model TEXT NOT NULL,
 			est_request_limit INTEGER,
 			est_token_limit INTEGER,
+			max_observed_requests INTEGER,
+			max_observed_tokens INTEGER,
 			confidence REAL DEFAULT 0,
 			sample_count INTEGER DEFAULT 0,
 			last_exhausted_at DATETIME,
@@ -189,7 +193,7 @@ func TestMarkExhausted_LearningAlgorithm(t *testing.T) {
 	// Clear Redis for second test
 	mr.FlushAll()
 
-	// Second exhaustion: 120 requests (weighted average should adjust)
+	// Second exhaustion: 120 requests (a higher lower bound is adopted)
 	for i := 0; i < 120; i++ {
 		service.RecordUsage(accountID, model, 100)
 	}
@@ -202,13 +206,11 @@ func TestMarkExhausted_LearningAlgorithm(t *testing.T) {
 		t.Errorf("expected SampleCount 2, got %d", pattern.SampleCount)
 	}
 
-	// Weighted average: (100 * 0.1 + 120) / 1.1 ≈ 118
-	// But first hit sets directly, so second uses weighted avg
+	// First hit sets directly; exhausting later at 120 proves the limit is at least 120
 	if pattern.EstRequestLimit != nil {
 		limit := *pattern.EstRequestLimit
-		// Should be somewhere between 100 and 120 due to weighted average
-		if limit < 100 || limit > 120 {
-			t.Errorf("expected EstRequestLimit between 100-120, got %d", limit)
+		if limit != 120 {
+			t.Errorf("expected EstRequestLimit 120, got %d", limit)
 		}
 	}
 
@@ -218,6 +220,59 @@ func TestMarkExhausted_LearningAlgorithm(t *testing.T) {
 	}
 }
 
+func TestMarkExhausted_SmallExhaustionDoesNotCollapseLimit(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	accountID := "test-account-anomaly"
+	model := "gemini-2.5-pro"
+	exhaustAt := func(requests int) int {
+		mr.FlushAll()
+		for i := 0; i < requests; i++ {
+			service.RecordUsage(accountID, model, 100)
+		}
+		service.MarkExhausted(accountID, model)
+		time.Sleep(100 * time.Millisecond)
+
+		pattern, _ := repo.GetByAccountModel(accountID, model)
+		return *pattern.EstRequestLimit
+	}
+
+	for i := 0; i < 3; i++ {
+		exhaustAt(100)
+	}
+
+	// One run exhausts early (e.g. token-heavy requests): the estimate dips but stays near the max
+	if limit := exhaustAt(10); limit < 85 || limit >= 100 {
+		t.Errorf("expected EstRequestLimit in [85, 100) after an anomalous exhaustion, got %d", limit)
+	}
+
+	// Exhaustion counts are lower bounds, so a normal run restores the estimate
+	if limit := exhaustAt(100); limit != 100 {
+		t.Errorf("expected EstRequestLimit back at 100, got %d", limit)
+	}
+}
+
+func TestLearnLimit(t *testing.T) {
+	// A higher observation is adopted outright
+	if estimate, maxObserved := learnLimit(100, 100, 120, 0.5); estimate != 120 || maxObserved != 120 {
+		t.Errorf("learnLimit(higher) = %d, %d, want 120, 120", estimate, maxObserved)
+	}
+
+	// A genuinely lowered limit is relearned as the running max decays
+	estimate, maxObserved := int64(100), int64(100)
+	for i := 0; i < 30; i++ {
+		estimate, maxObserved = learnLimit(estimate, maxObserved, 50, 1.0)
+	}
+	if estimate < 50 || estimate > 55 {
+		t.Errorf("estimate after repeated exhaustion at 50 = %d, want ~50", estimate)
+	}
+}
+
 func TestIsAvailable(t *testing.T) {
 	db := setupTestDB(t)
 	mr, redisClient := setupTestRedis(t)
@@ -412,3 +467,466 @@ func TestGetEarliestReset(t *testing.T) {
 		t.Errorf("reset time off by %v", diff)
 	}
 }
+
+func TestIsAvailable_TokenLimitBinding(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	accountID := "test-account-tokens"
+	model := "gemini-2.5-pro"
+
+	requestLimit := 100
+	tokenLimit := int64(10000)
+	if err := repo.Upsert(&models.AccountQuotaPattern{
+		AccountID:       accountID,
+		Model:           model,
+		EstRequestLimit: &requestLimit,
+		EstTokenLimit:   &tokenLimit,
+	}); err != nil {
+		t.Fatalf("failed to seed pattern: %v", err)
+	}
+
+	// Large prompts: 2 requests use 80% of the token limit
+	service.RecordUsage(accountID, model, 4000)
+	service.RecordUsage(accountID, model, 4000)
+	time.Sleep(100 * time.Millisecond)
+
+	if !service.IsAvailable(accountID, model) {
+		t.Fatal("expected account available below the token limit")
+	}
+
+	// Third request crosses the token limit while requests are at 3%
+	service.RecordUsage(accountID, model, 4000)
+	time.Sleep(100 * time.Millisecond)
+
+	if service.IsAvailable(accountID, model) {
+		t.Error("expected account exhausted once token usage reaches the learned token limit")
+	}
+
+	status := service.GetQuotaStatus(accountID, model)
+	if status.PercentUsed == nil || *status.PercentUsed != 120 {
+		t.Errorf("expected PercentUsed 120 (token-bound), got %v", status.PercentUsed)
+	}
+}
+
+func TestGetQuotaStatus_TokenPressure(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	accountID := "test-account-token-pressure"
+	model := "gemini-2.5-pro"
+
+	requestLimit := 200
+	tokenLimit := int64(10000)
+	if err := repo.Upsert(&models.AccountQuotaPattern{
+		AccountID:       accountID,
+		Model:           model,
+		EstRequestLimit: &requestLimit,
+		EstTokenLimit:   &tokenLimit,
+		Confidence:      1.0,
+		SampleCount:     10,
+	}); err != nil {
+		t.Fatalf("failed to seed pattern: %v", err)
+	}
+
+	// Two long-context requests: 1% of the request limit, 90% of the token limit
+	service.RecordUsage(accountID, model, 4500)
+	service.RecordUsage(accountID, model, 4500)
+
+	status := service.GetQuotaStatus(accountID, model)
+	if status.RequestPercentUsed == nil || *status.RequestPercentUsed != 1 {
+		t.Errorf("expected RequestPercentUsed 1, got %v", status.RequestPercentUsed)
+	}
+	if status.TokenPercentUsed == nil || *status.TokenPercentUsed != 90 {
+		t.Errorf("expected TokenPercentUsed 90, got %v", status.TokenPercentUsed)
+	}
+	if status.PercentUsed == nil || *status.PercentUsed != 90 {
+		t.Errorf("expected PercentUsed 90 (token pressure), got %v", status.PercentUsed)
+	}
+}
+
+func TestMarkExhausted_LearnsOnlyBindingLimit(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	accountID := "test-account-binding"
+	model := "gemini-2.5-pro"
+
+	requestLimit := 100
+	tokenLimit := int64(20000)
+	if err := repo.Upsert(&models.AccountQuotaPattern{
+		AccountID:       accountID,
+		Model:           model,
+		EstRequestLimit: &requestLimit,
+		EstTokenLimit:   &tokenLimit,
+		Confidence:      0.5,
+		SampleCount:     5,
+	}); err != nil {
+		t.Fatalf("failed to seed pattern: %v", err)
+	}
+
+	// Upstream exhausts at 5 requests / 15000 tokens: tokens are the binding constraint
+	for i := 0; i < 5; i++ {
+		service.RecordUsage(accountID, model, 3000)
+	}
+	service.MarkExhausted(accountID, model)
+	time.Sleep(100 * time.Millisecond)
+
+	pattern, _ := repo.GetByAccountModel(accountID, model)
+	if *pattern.EstRequestLimit != 100 {
+		t.Errorf("expected EstRequestLimit unchanged at 100, got %d", *pattern.EstRequestLimit)
+	}
+	if *pattern.EstTokenLimit >= 20000 {
+		t.Errorf("expected EstTokenLimit to drop below 20000, got %d", *pattern.EstTokenLimit)
+	}
+}
+
+func TestRemainingHeadroom(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	accountID := "test-account-headroom"
+	model := "gemini-2.5-pro"
+
+	// No learned limit yet
+	if _, ok := service.RemainingHeadroom(accountID, model); ok {
+		t.Error("expected headroom to be unknown before a limit is learned")
+	}
+
+	pattern, err := repo.GetOrCreate(accountID, model)
+	if err != nil {
+		t.Fatalf("GetOrCreate() error = %v", err)
+	}
+	limit := 10
+	pattern.EstRequestLimit = &limit
+	if err := repo.Save(pattern); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	service.learnedLimits.Clear() // Seeded behind the tracker's back
+
+	for i := 0; i < 3; i++ {
+		service.RecordUsage(accountID, model, 100)
+	}
+
+	headroom, ok := service.RemainingHeadroom(accountID, model)
+	if !ok || headroom != 7 {
+		t.Errorf("RemainingHeadroom() = (%d, %v), want (7, true)", headroom, ok)
+	}
+}
+
+func TestRemainingHeadroom_CachesLearnedLimit(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	accountID := "test-account-cached"
+	model := "gemini-2.5-pro"
+
+	limit := 10
+	pattern, _ := repo.GetOrCreate(accountID, model)
+	pattern.EstRequestLimit = &limit
+	repo.Save(pattern)
+
+	if headroom, _ := service.RemainingHeadroom(accountID, model); headroom != 10 {
+		t.Fatalf("RemainingHeadroom() = %d, want 10", headroom)
+	}
+
+	// A changed row isn't re-read while the cached limit is fresh
+	other := 50
+	pattern.EstRequestLimit = &other
+	repo.Save(pattern)
+	if headroom, _ := service.RemainingHeadroom(accountID, model); headroom != 10 {
+		t.Errorf("RemainingHeadroom() = %d, want cached 10", headroom)
+	}
+
+	// Learning from an exhaustion drops the cached limit
+	service.learnFromExhaustion(accountID, model, 60, 1000)
+	if headroom, _ := service.RemainingHeadroom(accountID, model); headroom == 10 {
+		t.Errorf("RemainingHeadroom() = %d, want the newly learned limit", headroom)
+	}
+}
+
+func TestGetQuotaStatusBatch_MatchesPerKey(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	// acc-1/pro learns a limit, then starts a new window
+	for i := 0; i < 20; i++ {
+		service.RecordUsage("acc-1", "gemini-2.5-pro", 100)
+	}
+	service.MarkExhausted("acc-1", "gemini-2.5-pro")
+	if err := DrainAsyncWrites(context.Background()); err != nil {
+		t.Fatalf("DrainAsyncWrites() error = %v", err)
+	}
+	service.ClearQuota("acc-1", "gemini-2.5-pro")
+	service.RecordUsage("acc-1", "gemini-2.5-pro", 300)
+
+	// acc-1/flash has usage only, acc-2/pro is exhausted, acc-3/pro has nothing
+	service.RecordUsage("acc-1", "gemini-2.5-flash", 50)
+	service.RecordUsage("acc-2", "gemini-2.5-pro", 1000)
+	service.MarkExhausted("acc-2", "gemini-2.5-pro")
+	if err := DrainAsyncWrites(context.Background()); err != nil {
+		t.Fatalf("DrainAsyncWrites() error = %v", err)
+	}
+
+	keys := []QuotaStatusKey{
+		{"acc-1", "gemini-2.5-pro"},
+		{"acc-1", "gemini-2.5-flash"},
+		{"acc-2", "gemini-2.5-pro"},
+		{"acc-3", "gemini-2.5-pro"},
+	}
+
+	batch := service.GetQuotaStatusBatch(keys)
+	if len(batch) != len(keys) {
+		t.Fatalf("batch returned %d statuses, want %d", len(batch), len(keys))
+	}
+
+	for _, key := range keys {
+		want := service.GetQuotaStatus(key.AccountID, key.Model)
+		if got := batch[key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s/%s: batch = %+v, per-key = %+v", key.AccountID, key.Model, got, want)
+		}
+	}
+
+	if status := batch[QuotaStatusKey{"acc-1", "gemini-2.5-pro"}]; status.EstRequestLimit == nil || status.PercentUsed == nil {
+		t.Errorf("acc-1/pro = %+v, want learned limits and percent used", status)
+	}
+	if !batch[QuotaStatusKey{"acc-2", "gemini-2.5-pro"}].IsExhausted {
+		t.Error("acc-2/pro should be exhausted")
+	}
+}
+
+func TestStaleLimitGrace(t *testing.T) {
+	for _, grace := range []bool{false, true} {
+		db := setupTestDB(t)
+		mr, redisClient := setupTestRedis(t)
+
+		repo := repositories.NewQuotaPatternRepository(db)
+		service := NewQuotaTrackerService(repo, redisClient)
+		service.SetStaleLimitGrace(grace)
+
+		accountID := "test-account-stale"
+		model := "gemini-2.5-pro"
+
+		// Limit learned two months ago: confidence has decayed to almost nothing
+		limit := 3
+		tokenLimit := int64(1000000)
+		lastHit := time.Now().AddDate(0, -2, 0)
+		if err := repo.Upsert(&models.AccountQuotaPattern{
+			AccountID:       accountID,
+			Model:           model,
+			EstRequestLimit: &limit,
+			EstTokenLimit:   &tokenLimit,
+			Confidence:      0.5,
+			SampleCount:     5,
+			LastExhaustedAt: &lastHit,
+		}); err != nil {
+			t.Fatalf("failed to seed pattern: %v", err)
+		}
+
+		for i := 0; i < 3; i++ {
+			service.RecordUsage(accountID, model, 100)
+		}
+		if err := DrainAsyncWrites(context.Background()); err != nil {
+			t.Fatalf("DrainAsyncWrites() error = %v", err)
+		}
+
+		_, learned := service.RemainingHeadroom(accountID, model)
+		if learned != grace {
+			t.Errorf("grace=%v: RemainingHeadroom learned = %v, want %v", grace, learned, grace)
+		}
+		// Stale limits only steer selection; they never cap usage, even with grace
+		if !service.IsAvailable(accountID, model) {
+			t.Errorf("grace=%v: IsAvailable() = false after reaching the stale limit", grace)
+		}
+
+		status := service.GetQuotaStatus(accountID, model)
+		if status.Confidence >= MinLearnedConfidence {
+			t.Errorf("grace=%v: Confidence = %f, want decayed below %f", grace, status.Confidence, MinLearnedConfidence)
+		}
+		if status.LowConfidence != grace {
+			t.Errorf("grace=%v: LowConfidence = %v, want %v", grace, status.LowConfidence, grace)
+		}
+
+		mr.Close()
+	}
+}
+
+func TestCheckLearnedLimits_EnforcedOnlyWhenConfident(t *testing.T) {
+	tests := []struct {
+		name       string
+		confidence float64
+		wantCapped bool
+	}{
+		{"first estimate", 0.1, false},
+		{"below threshold", 0.2, false},
+		{"at threshold", MinEnforcedConfidence, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			mr, redisClient := setupTestRedis(t)
+			defer mr.Close()
+
+			repo := repositories.NewQuotaPatternRepository(db)
+			service := NewQuotaTrackerService(repo, redisClient)
+
+			accountID := "test-account-confidence"
+			model := "gemini-2.5-pro"
+
+			limit := 3
+			tokenLimit := int64(1000000)
+			lastHit := time.Now()
+			if err := repo.Upsert(&models.AccountQuotaPattern{
+				AccountID:       accountID,
+				Model:           model,
+				EstRequestLimit: &limit,
+				EstTokenLimit:   &tokenLimit,
+				Confidence:      tt.confidence,
+				SampleCount:     1,
+				LastExhaustedAt: &lastHit,
+			}); err != nil {
+				t.Fatalf("failed to seed pattern: %v", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				service.RecordUsage(accountID, model, 100)
+			}
+			if err := DrainAsyncWrites(context.Background()); err != nil {
+				t.Fatalf("DrainAsyncWrites() error = %v", err)
+			}
+
+			if available := service.IsAvailable(accountID, model); available == tt.wantCapped {
+				t.Errorf("IsAvailable() = %v at the learned limit with confidence %.2f", available, tt.confidence)
+			}
+			// The limit still steers selection either way
+			if headroom, learned := service.RemainingHeadroom(accountID, model); !learned || headroom != 0 {
+				t.Errorf("RemainingHeadroom() = %d, %v, want 0, true", headroom, learned)
+			}
+		})
+	}
+}
+
+func TestCheckLearnedLimits_UsesCachedLimits(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	repo := repositories.NewQuotaPatternRepository(db)
+	service := NewQuotaTrackerService(repo, redisClient)
+
+	accountID := "test-account-check-cached"
+	model := "gemini-2.5-pro"
+
+	limit := 2
+	tokenLimit := int64(1000000)
+	if err := repo.Upsert(&models.AccountQuotaPattern{
+		AccountID:       accountID,
+		Model:           model,
+		EstRequestLimit: &limit,
+		EstTokenLimit:   &tokenLimit,
+	}); err != nil {
+		t.Fatalf("failed to seed pattern: %v", err)
+	}
+
+	service.RecordUsage(accountID, model, 100)
+	if err := DrainAsyncWrites(context.Background()); err != nil {
+		t.Fatalf("DrainAsyncWrites() error = %v", err)
+	}
+
+	// With the pattern cached, later checks don't go back to MySQL
+	if err := db.Exec("DELETE FROM account_quota_pattern").Error; err != nil {
+		t.Fatalf("failed to delete pattern: %v", err)
+	}
+	service.RecordUsage(accountID, model, 100)
+	if err := DrainAsyncWrites(context.Background()); err != nil {
+		t.Fatalf("DrainAsyncWrites() error = %v", err)
+	}
+
+	if service.IsAvailable(accountID, model) {
+		t.Error("expected account exhausted at the cached learned limit")
+	}
+}
+
+func TestProviderWindows_ExpirationsAndResets(t *testing.T) {
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	service := NewQuotaTrackerService(repositories.NewQuotaPatternRepository(db), redisClient)
+	service.SetProviderWindow("antigravity", 24*time.Hour, nil)
+	service.SetProviderWindow("glm", time.Minute, map[string]time.Duration{"glm-4.6": 10 * time.Minute})
+
+	providers := map[string]string{"acc-ag": "antigravity", "acc-glm": "glm", "acc-claude": "claude"}
+	service.SetAccountProviderResolver(func(accountID string) string { return providers[accountID] })
+
+	tests := []struct {
+		accountID string
+		model     string
+		want      time.Duration
+	}{
+		{"acc-ag", "gemini-2.5-pro", 24 * time.Hour},
+		{"acc-glm", "glm-4.5", time.Minute},
+		{"acc-glm", "glm-4.6", 10 * time.Minute}, // Per-model override
+		{"acc-claude", "claude-sonnet-4-5", QuotaWindowTTL},
+		{"acc-unknown", "gemini-2.5-pro", QuotaWindowTTL},
+	}
+
+	for _, tt := range tests {
+		service.RecordUsage(tt.accountID, tt.model, 100)
+		service.MarkExhausted(tt.accountID, tt.model)
+
+		keys := service.keys
+		for _, key := range []string{
+			keys.RequestsKey(tt.accountID, tt.model),
+			keys.TokensKey(tt.accountID, tt.model),
+			keys.WindowStartKey(tt.accountID, tt.model),
+			keys.ExhaustedKey(tt.accountID, tt.model),
+		} {
+			if ttl := mr.TTL(key); ttl != tt.want {
+				t.Errorf("%s TTL = %v, want %v", key, ttl, tt.want)
+			}
+		}
+
+		resetAt := service.GetEarliestReset([]string{tt.accountID}, tt.model)
+		if resetAt == nil {
+			t.Fatalf("%s/%s: expected resetAt to be set", tt.accountID, tt.model)
+		}
+		if diff := time.Until(*resetAt) - tt.want; diff < -2*time.Second || diff > time.Second {
+			t.Errorf("%s/%s reset off by %v", tt.accountID, tt.model, diff)
+		}
+
+		status := service.GetQuotaStatus(tt.accountID, tt.model)
+		if status.ResetsAt == nil || !status.ResetsAt.Equal(*resetAt) {
+			t.Errorf("%s/%s ResetsAt = %v, want %v", tt.accountID, tt.model, status.ResetsAt, resetAt)
+		}
+	}
+
+	if err := DrainAsyncWrites(context.Background()); err != nil {
+		t.Fatalf("DrainAsyncWrites() error = %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// quotaWebhookTimeout bounds each delivery attempt
+	quotaWebhookTimeout = 5 * time.Second
+	// quotaWebhookAttempts is the number of deliveries tried before giving up
+	quotaWebhookAttempts = 3
+	// quotaWebhookBackoff is the delay before the first retry, doubled on each further retry
+	quotaWebhookBackoff = time.Second
+)
+
+// QuotaExhaustedEvent is the JSON body POSTed when an account+model hits its quota
+type QuotaExhaustedEvent struct {
+	AccountID     string     `json:"account_id"`
+	Model         string     `json:"model"`
+	Provider      string     `json:"provider,omitempty"`
+	ExhaustedAt   time.Time  `json:"exhausted_at"`
+	EarliestReset *time.Time `json:"earliest_reset"`
+}
+
+// QuotaWebhook pushes quota exhaustion events to an operator-configured URL
+// Deliveries retry with backoff and are run in the background by the quota tracker.
+type QuotaWebhook struct {
+	url     string
+	client  *http.Client
+	backoff time.Duration
+	sleep   func(time.Duration)
+}
+
+// NewQuotaWebhook creates a webhook notifier posting to url
+func NewQuotaWebhook(url string) *QuotaWebhook {
+	return &QuotaWebhook{
+		url:     url,
+		client:  &http.Client{Timeout: quotaWebhookTimeout},
+		backoff: quotaWebhookBackoff,
+		sleep:   time.Sleep,
+	}
+}
+
+// deliver POSTs the event, retrying transport errors, 429 and 5xx responses with exponential backoff
+func (w *QuotaWebhook) deliver(event QuotaExhaustedEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		err = w.post(body)
+		if err == nil || !isRetryableWebhookError(err) || attempt == quotaWebhookAttempts {
+			break
+		}
+		w.sleep(backoff)
+		backoff *= 2
+	}
+
+	if err != nil {
+		log.Printf("[QuotaTracker] Exhaustion webhook for %s/%s failed: %v", event.AccountID, event.Model, err)
+	}
+	return err
+}
+
+// webhookStatusError is a non-2xx webhook response
+type webhookStatusError struct {
+	statusCode int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned HTTP %d", e.statusCode)
+}
+
+// isRetryableWebhookError reports whether a failed delivery is worth another attempt
+func isRetryableWebhookError(err error) bool {
+	statusErr, ok := err.(*webhookStatusError)
+	if !ok {
+		return true // Transport error or timeout
+	}
+	return statusErr.statusCode == http.StatusTooManyRequests || statusErr.statusCode >= 500
+}
+
+func (w *QuotaWebhook) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &webhookStatusError{statusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// SetExhaustionWebhook registers a webhook notified whenever MarkExhausted fires (nil = disabled)
+func (s *QuotaTrackerService) SetExhaustionWebhook(webhook *QuotaWebhook) {
+	s.webhook = webhook
+}
+
+// notifyExhausted sends the exhaustion event for account+model to the configured webhook
+// The event is built in the background too, since its reset and provider lookups hit Redis and the database.
+func (s *QuotaTrackerService) notifyExhausted(accountID, model string, exhaustedAt time.Time) {
+	if s.webhook == nil {
+		return
+	}
+
+	webhook := s.webhook
+	go func() {
+		webhook.deliver(s.exhaustionEvent(accountID, model, exhaustedAt))
+	}()
+}
+
+// exhaustionEvent builds the webhook event for account+model
+func (s *QuotaTrackerService) exhaustionEvent(accountID, model string, exhaustedAt time.Time) QuotaExhaustedEvent {
+	event := QuotaExhaustedEvent{
+		AccountID:     accountID,
+		Model:         model,
+		ExhaustedAt:   exhaustedAt,
+		EarliestReset: s.GetEarliestReset([]string{accountID}, model),
+	}
+	if s.providerOf != nil {
+		event.Provider = s.accountProvider(accountID)
+	}
+	if event.EarliestReset == nil {
+		// No usage window recorded; the exhausted flag lapses after one window
+		resetAt := exhaustedAt.Add(s.windowFor(accountID, model))
+		event.EarliestReset = &resetAt
+	}
+	return event
+}
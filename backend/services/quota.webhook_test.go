@@ -0,0 +1,141 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"aigateway-backend/repositories"
+)
+
+func TestMarkExhausted_PostsWebhookEvent(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- body
+	}))
+	defer server.Close()
+
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	service := NewQuotaTrackerService(repositories.NewQuotaPatternRepository(db), redisClient)
+	service.SetAccountProviderResolver(func(string) string { return "antigravity" })
+	service.SetExhaustionWebhook(NewQuotaWebhook(server.URL))
+
+	service.RecordUsage("acc-1", "gemini-2.5-pro", 100)
+	before := time.Now()
+	service.MarkExhausted("acc-1", "gemini-2.5-pro")
+
+	var body []byte
+	select {
+	case body = <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+
+	var event QuotaExhaustedEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("webhook body %s is not an event: %v", body, err)
+	}
+	if event.AccountID != "acc-1" || event.Model != "gemini-2.5-pro" || event.Provider != "antigravity" {
+		t.Errorf("event = %+v, want acc-1/gemini-2.5-pro on antigravity", event)
+	}
+	if event.ExhaustedAt.Before(before.Add(-time.Second)) {
+		t.Errorf("exhausted_at = %v, want around %v", event.ExhaustedAt, before)
+	}
+	if event.EarliestReset == nil || !event.EarliestReset.After(event.ExhaustedAt) {
+		t.Errorf("earliest_reset = %v, want a time after exhausted_at", event.EarliestReset)
+	}
+}
+
+func TestMarkExhausted_BuildsWebhookEventInBackground(t *testing.T) {
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	db := setupTestDB(t)
+	mr, redisClient := setupTestRedis(t)
+	defer mr.Close()
+
+	// The provider lookup blocks until released, like a slow database query
+	release := make(chan struct{})
+	service := NewQuotaTrackerService(repositories.NewQuotaPatternRepository(db), redisClient)
+	service.SetAccountProviderResolver(func(string) string {
+		<-release
+		return "antigravity"
+	})
+	service.SetExhaustionWebhook(NewQuotaWebhook(server.URL))
+
+	marked := make(chan struct{})
+	go func() {
+		service.MarkExhausted("acc-1", "gemini-2.5-pro")
+		close(marked)
+	}()
+
+	select {
+	case <-marked:
+	case <-time.After(2 * time.Second):
+		t.Fatal("MarkExhausted waited for the webhook event lookups")
+	}
+
+	close(release)
+	select {
+	case <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestQuotaWebhook_RetriesWithBackoff(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	webhook := NewQuotaWebhook(server.URL)
+	var sleeps []time.Duration
+	webhook.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	if err := webhook.deliver(QuotaExhaustedEvent{AccountID: "acc-1"}); err != nil {
+		t.Fatalf("deliver() error = %v, want success on the third attempt", err)
+	}
+	if calls != 3 {
+		t.Errorf("webhook called %d times, want 3", calls)
+	}
+	if len(sleeps) != 2 || sleeps[0] != quotaWebhookBackoff || sleeps[1] != 2*quotaWebhookBackoff {
+		t.Errorf("backoff sleeps = %v, want [%v %v]", sleeps, quotaWebhookBackoff, 2*quotaWebhookBackoff)
+	}
+}
+
+func TestQuotaWebhook_NoRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhook := NewQuotaWebhook(server.URL)
+	webhook.sleep = func(time.Duration) {}
+
+	if err := webhook.deliver(QuotaExhaustedEvent{AccountID: "acc-1"}); err == nil {
+		t.Error("deliver() should fail on HTTP 400")
+	}
+	if calls != 1 {
+		t.Errorf("webhook called %d times, want 1 (4xx is not retried)", calls)
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"time"
+)
+
+// SetProviderWindow sets the quota window length for a provider's accounts
+// modelTTLs optionally overrides it per model; zero values keep the next fallback.
+// Claude uses 5-hour windows, Gemini daily quotas and GLM per-minute limits.
+func (s *QuotaTrackerService) SetProviderWindow(providerID string, ttl time.Duration, modelTTLs map[string]time.Duration) {
+	if s.providerWindows == nil {
+		s.providerWindows = make(map[string]time.Duration)
+		s.modelWindows = make(map[string]map[string]time.Duration)
+	}
+	if ttl > 0 {
+		s.providerWindows[providerID] = ttl
+	}
+	if len(modelTTLs) > 0 {
+		s.modelWindows[providerID] = modelTTLs
+	}
+}
+
+// SetAccountProviderResolver sets how an account ID maps to its provider for window lookups
+// Resolved providers are cached, since an account never changes provider.
+// Without a resolver every account uses the default window.
+func (s *QuotaTrackerService) SetAccountProviderResolver(resolve func(accountID string) string) {
+	s.providerOf = resolve
+}
+
+// accountProvider returns the account's provider, resolving it once per account
+func (s *QuotaTrackerService) accountProvider(accountID string) string {
+	if cached, ok := s.providerCache.Load(accountID); ok {
+		return cached.(string)
+	}
+	providerID := s.providerOf(accountID)
+	if providerID != "" {
+		s.providerCache.Store(accountID, providerID)
+	}
+	return providerID
+}
+
+// windowFor returns the quota window for account+model: model override, then provider, then default
+func (s *QuotaTrackerService) windowFor(accountID, model string) time.Duration {
+	if s.providerOf == nil || s.providerWindows == nil {
+		return s.windowTTL
+	}
+
+	providerID := s.accountProvider(accountID)
+	if ttl := s.modelWindows[providerID][model]; ttl > 0 {
+		return ttl
+	}
+	if ttl := s.providerWindows[providerID]; ttl > 0 {
+		return ttl
+	}
+	return s.windowTTL
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// retryBackoffBase is the wait before the first same-account retry
+// Each further retry doubles it, capped at RouterConfig.MaxRetryWait.
+var retryBackoffBase = 100 * time.Millisecond
+
+// retryBackoff returns the wait before the retry-th retry (0-based) on the same account
+func (s *RouterService) retryBackoff(retry int) time.Duration {
+	return s.backoff(retryBackoffBase, retry)
+}
+
+// backoff returns the wait before the retry-th retry (0-based) starting from base
+// The exponential delay is capped at RouterConfig.MaxRetryWait, then jittered down to
+// [delay/2, delay] so that requests throttled together don't retry in lockstep.
+func (s *RouterService) backoff(base time.Duration, retry int) time.Duration {
+	delay := base
+	for i := 0; i < retry && (s.config.MaxRetryWait <= 0 || delay < s.config.MaxRetryWait); i++ {
+		delay *= 2
+	}
+	if s.config.MaxRetryWait > 0 && delay > s.config.MaxRetryWait {
+		delay = s.config.MaxRetryWait
+	}
+
+	half := delay / 2
+	return half + s.jitter(delay-half)
+}
+
+// randomJitter returns a uniformly random duration in [0, max]
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max + 1)
+}
+
+// sleepContext waits for d, returning early with the context error if ctx ends first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
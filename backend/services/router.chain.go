@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// AttemptRecord is one upstream attempt in a request's retry/switch chain
+type AttemptRecord struct {
+	AccountID  string `json:"account_id"`
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+// switchChainSummary is the structured log entry written once per routed request
+type switchChainSummary struct {
+	RequestID  string          `json:"request_id,omitempty"`
+	Provider   string          `json:"provider"`
+	Model      string          `json:"model"`
+	Attempts   []AttemptRecord `json:"attempts"`
+	Switched   bool            `json:"switched"`
+	Outcome    string          `json:"outcome"`
+	StatusCode int             `json:"status_code"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// recordAttempt appends an upstream attempt to the chain
+func (r *RetryContext) recordAttempt(accountID string, statusCode int, err error) {
+	attempt := AttemptRecord{AccountID: accountID, StatusCode: statusCode}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	r.Attempts = append(r.Attempts, attempt)
+}
+
+// switched reports whether more than one account was tried
+func (r *RetryContext) switched() bool {
+	for _, attempt := range r.Attempts {
+		if attempt.AccountID != r.Attempts[0].AccountID {
+			return true
+		}
+	}
+	return false
+}
+
+// logSwitchChain writes a single JSON line summarizing every attempt made for the request
+func (s *RouterService) logSwitchChain(req Request, providerID, model string, retryCtx *RetryContext, statusCode int, finalErr error) {
+	summary := switchChainSummary{
+		RequestID:  req.RequestID,
+		Provider:   providerID,
+		Model:      model,
+		Attempts:   retryCtx.Attempts,
+		Switched:   retryCtx.switched(),
+		Outcome:    "success",
+		StatusCode: statusCode,
+	}
+	if summary.Attempts == nil {
+		summary.Attempts = []AttemptRecord{}
+	}
+	if finalErr != nil {
+		summary.Outcome = "failure"
+		summary.Error = finalErr.Error()
+	}
+
+	data, _ := json.Marshal(summary)
+	log.Printf("[Router] Switch chain: %s", data)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"aigateway-backend/providers"
+)
+
+// Token count sources reported by CountTokens
+const (
+	TokenCountSourceProvider = "provider"
+	TokenCountSourceEstimate = "estimate"
+)
+
+// TokenCount is the input token count of a request and how it was obtained
+type TokenCount struct {
+	InputTokens int64
+	Source      string
+}
+
+// CountTokens counts the input tokens of a Claude-format request
+// Providers implementing providers.TokenCounter are asked natively with any active account;
+// otherwise, or when the native call fails, the count is estimated locally.
+// Counting does not consume quota or affect account health and stats.
+func (s *RouterService) CountTokens(ctx context.Context, req Request) (TokenCount, error) {
+	provider, resolvedModel, err := s.Route(req.Model)
+	if err != nil {
+		return TokenCount{}, err
+	}
+
+	if counter, ok := provider.(providers.TokenCounter); ok {
+		n, err := s.countNative(ctx, counter, provider.ID(), resolvedModel, req.Payload)
+		if err == nil {
+			return TokenCount{InputTokens: n, Source: TokenCountSourceProvider}, nil
+		}
+		log.Printf("[Router] Native token count failed for %s/%s, estimating: %v", provider.ID(), resolvedModel, err)
+	}
+
+	return TokenCount{InputTokens: providers.EstimateInputTokens(req.Payload), Source: TokenCountSourceEstimate}, nil
+}
+
+// countNative asks the provider's token-count API using a selected account
+func (s *RouterService) countNative(ctx context.Context, counter providers.TokenCounter, providerID, model string, payload []byte) (int64, error) {
+	if s.accountService == nil || s.oauthService == nil {
+		return 0, fmt.Errorf("account selection unavailable")
+	}
+
+	account, err := s.accountService.SelectAccount(providerID, model)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select account: %w", err)
+	}
+
+	token, err := s.oauthService.GetAccessToken(account)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return counter.CountTokens(ctx, &providers.ExecuteRequest{
+		Model:    model,
+		Payload:  payload,
+		Account:  account,
+		ProxyURL: account.ProxyURL,
+		Token:    token,
+	})
+}
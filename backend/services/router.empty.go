@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// EmptyResponseAction is how the router handles a 200 response with no assistant content
+type EmptyResponseAction string
+
+const (
+	// EmptyResponsePassThrough returns the empty message unchanged
+	EmptyResponsePassThrough EmptyResponseAction = "pass_through"
+	// EmptyResponseRetry retries once on another account, passing through if that fails too
+	EmptyResponseRetry EmptyResponseAction = "retry"
+	// EmptyResponseRefusal replaces the empty content with a synthesized refusal message
+	EmptyResponseRefusal EmptyResponseAction = "refusal"
+)
+
+// EmptyResponseAnyReason is the policy key matching any stop_reason without its own entry
+const EmptyResponseAnyReason = "*"
+
+// ParseEmptyResponsePolicy validates a stop_reason → action map from config
+func ParseEmptyResponsePolicy(raw map[string]string) (map[string]EmptyResponseAction, error) {
+	policy := make(map[string]EmptyResponseAction, len(raw))
+	for stopReason, action := range raw {
+		switch a := EmptyResponseAction(strings.ToLower(action)); a {
+		case EmptyResponsePassThrough, EmptyResponseRetry, EmptyResponseRefusal:
+			policy[stopReason] = a
+		default:
+			return nil, fmt.Errorf("invalid empty response action %q for %q (want pass_through, retry or refusal)", action, stopReason)
+		}
+	}
+	return policy, nil
+}
+
+// SetEmptyResponsePolicy sets the per-stop_reason handling of empty responses
+// Only non-streaming requests on the AuthManager path are affected; nil passes everything through.
+func (s *RouterService) SetEmptyResponsePolicy(policy map[string]EmptyResponseAction) {
+	s.config.EmptyResponsePolicy = policy
+}
+
+// emptyResponseAction returns the configured action for stop_reason
+func (s *RouterService) emptyResponseAction(stopReason string) EmptyResponseAction {
+	if action, ok := s.config.EmptyResponsePolicy[stopReason]; ok {
+		return action
+	}
+	if action, ok := s.config.EmptyResponsePolicy[EmptyResponseAnyReason]; ok {
+		return action
+	}
+	return EmptyResponsePassThrough
+}
+
+// emptyResponse reports whether a Claude message has no text or tool_use content, with its stop_reason
+// Thinking-only messages count as empty since agent loops have nothing to act on.
+func emptyResponse(payload []byte) (string, bool) {
+	if !gjson.GetBytes(payload, "type").Exists() && !gjson.GetBytes(payload, "content").Exists() {
+		return "", false // Not a Claude message
+	}
+
+	for _, block := range gjson.GetBytes(payload, "content").Array() {
+		switch block.Get("type").String() {
+		case "text":
+			if strings.TrimSpace(block.Get("text").String()) != "" {
+				return "", false
+			}
+		case "tool_use", "image":
+			return "", false
+		}
+	}
+	return gjson.GetBytes(payload, "stop_reason").String(), true
+}
+
+// synthesizeRefusal replaces the content of an empty message with an explanatory text block
+func synthesizeRefusal(payload []byte, stopReason string) []byte {
+	text := "The provider returned an empty response."
+	if stopReason == "refusal" {
+		text = "The response was blocked by the provider's content filter."
+	}
+
+	result, err := sjson.SetRawBytes(payload, "content", []byte(`[{"type":"text","text":""}]`))
+	if err != nil {
+		return payload
+	}
+	result, _ = sjson.SetBytes(result, "content.0.text", text)
+	result, _ = sjson.SetBytes(result, "stop_reason", "refusal")
+	return result
+}
+
+// applyEmptyResponsePolicy handles a successful response that carries no assistant content
+func (s *RouterService) applyEmptyResponsePolicy(
+	ctx context.Context,
+	provider providers.Provider,
+	accountID string,
+	resolvedModel string,
+	req Request,
+	retryCtx *RetryContext,
+	resp Response,
+) (Response, error) {
+	stopReason, empty := emptyResponse(resp.Payload)
+	if !empty {
+		return resp, nil
+	}
+
+	switch s.emptyResponseAction(stopReason) {
+	case EmptyResponseRetry:
+		if req.AccountID != "" {
+			log.Printf("[Router] Empty response (stop_reason=%s) from pinned account %s, not switching", stopReason, accountID)
+			return resp, nil
+		}
+		altState, err := s.selectAlternative(ctx, provider.ID(), resolvedModel, req, accountID)
+		if err != nil {
+			log.Printf("[Router] Empty response (stop_reason=%s) from account %s, no alternative account", stopReason, accountID)
+			return resp, nil
+		}
+
+		log.Printf("[Router] Empty response (stop_reason=%s) from account %s, retrying on %s", stopReason, accountID, altState.Account.ID)
+		retryCtx.SwitchedFromAccID = &accountID
+		retryCtx.RetryCount = 0
+		return s.executeWithSwitchedAccount(ctx, provider, altState.Account, resolvedModel, req, retryCtx)
+
+	case EmptyResponseRefusal:
+		resp.Payload = synthesizeRefusal(resp.Payload, stopReason)
+	}
+
+	return resp, nil
+}
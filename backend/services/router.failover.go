@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/sjson"
+)
+
+// SetFailover configures fallback models per requested model (nil = disabled)
+// Fallbacks are tried in order when every account of the routed provider is blocked or
+// quota-exhausted, or its circuit is open. Each fallback is routed like a request for that model, so it may be served
+// by another provider; the payload is re-translated from its inbound format for providers
+// that need it.
+func (s *RouterService) SetFailover(failover map[string][]string) {
+	s.failover = failover
+}
+
+// FailoverProviderIDs returns providerIDs plus the providers serving failover models
+// Fallback providers need their accounts loaded into the AuthManager for failover to select one.
+func (s *RouterService) FailoverProviderIDs(providerIDs []string) []string {
+	seen := make(map[string]bool, len(providerIDs))
+	for _, id := range providerIDs {
+		seen[id] = true
+	}
+
+	for _, fallbacks := range s.failover {
+		for _, model := range fallbacks {
+			provider, _, err := s.Route(model)
+			if err != nil || seen[provider.ID()] {
+				continue
+			}
+			seen[provider.ID()] = true
+			providerIDs = append(providerIDs, provider.ID())
+		}
+	}
+	return providerIDs
+}
+
+// hasFailover reports whether req may fail over; pinned-account requests never do
+func (s *RouterService) hasFailover(req Request) bool {
+	return req.AccountID == "" && len(s.failover[req.Model]) > 0
+}
+
+// providerUnavailable reports whether err means no account of the provider could take the request
+func providerUnavailable(err error) bool {
+	var (
+		allBlocked   *manager.AllBlockedError
+		allExhausted *manager.AllExhaustedError
+		circuitOpen  *CircuitOpenError
+	)
+	return errors.As(err, &allBlocked) || errors.As(err, &allExhausted) || errors.As(err, &circuitOpen)
+}
+
+// executeFailover tries the fallback models of req.Model in order, returning the first result
+// that isn't another unavailable provider. Fallbacks don't chain into their own failover lists.
+func (s *RouterService) executeFailover(ctx context.Context, req Request, resp Response, err error) (Response, error) {
+	for _, model := range s.failover[req.Model] {
+		provider, resolvedModel, routeErr := s.Route(model)
+		if routeErr != nil {
+			log.Printf("[Router] Skipping failover model %s: %v", model, routeErr)
+			continue
+		}
+
+		fallbackReq, translateErr := failoverRequest(provider, model, resolvedModel, req)
+		if translateErr != nil {
+			log.Printf("[Router] Skipping failover model %s: %v", model, translateErr)
+			continue
+		}
+
+		log.Printf("[Router] No usable account for %s, failing over to %s/%s", req.Model, provider.ID(), resolvedModel)
+		resp, err = s.executeOnProvider(ctx, fallbackReq, 0)
+		if err == nil && req.inputFormat() == FormatClaude && !providers.ExecutesClaudeFormat(provider) {
+			translated, translateErr := provider.TranslateResponse(resp.Payload)
+			if translateErr != nil {
+				return resp, fmt.Errorf("failed to translate %s response: %w", provider.ID(), translateErr)
+			}
+			resp.Payload = translated
+		}
+		if !providerUnavailable(err) {
+			return resp, err
+		}
+	}
+	return resp, err
+}
+
+// streamFailover is executeFailover for streams
+// Chunks are forwarded as the provider emits them, so only fallbacks whose stream is in the
+// client's format are tried.
+func (s *RouterService) streamFailover(ctx context.Context, req Request, w http.ResponseWriter, flusher http.Flusher, statusCode int, err error) (int, error) {
+	for _, model := range s.failover[req.Model] {
+		provider, resolvedModel, routeErr := s.Route(model)
+		if routeErr != nil {
+			log.Printf("[Router] Skipping failover model %s: %v", model, routeErr)
+			continue
+		}
+		if providers.StreamFormat(provider, req.inputFormat()) != req.inputFormat() {
+			log.Printf("[Router] Skipping failover model %s: %s doesn't stream in %s format", model, provider.ID(), req.inputFormat())
+			continue
+		}
+
+		fallbackReq, translateErr := failoverRequest(provider, model, resolvedModel, req)
+		if translateErr != nil {
+			log.Printf("[Router] Skipping failover model %s: %v", model, translateErr)
+			continue
+		}
+
+		log.Printf("[Router] No usable account for %s, failing over stream to %s/%s", req.Model, provider.ID(), resolvedModel)
+		statusCode, err = s.streamOnProvider(ctx, fallbackReq, w, flusher)
+		if !providerUnavailable(err) {
+			return statusCode, err
+		}
+	}
+	return statusCode, err
+}
+
+// failoverRequest rewrites req for a fallback model, translating the payload from its inbound
+// format when the fallback provider doesn't execute that format itself
+func failoverRequest(provider providers.Provider, model, resolvedModel string, req Request) (Request, error) {
+	payload, err := sjson.SetBytes(req.Payload, "model", resolvedModel)
+	if err != nil {
+		return req, err
+	}
+
+	format := req.inputFormat()
+	if providers.ExecutesClaudeFormat(provider) {
+		// There is no translation into Claude format, so only Claude requests can go here
+		if format != FormatClaude {
+			return req, fmt.Errorf("%s executes Claude format and can't take a %s request", provider.ID(), format)
+		}
+	} else if payload, err = provider.TranslateRequest(format, payload, resolvedModel); err != nil {
+		return req, err
+	}
+
+	req.Model = model
+	req.Payload = payload
+	return req, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	autherrors "aigateway-backend/auth/errors"
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+)
+
+// overloadBackoffBase is the first wait before retrying an overloaded (529) upstream
+// Each further retry doubles it, capped at RouterConfig.MaxRetryWait and jittered like other retries.
+var overloadBackoffBase = 500 * time.Millisecond
+
+// retryOverloaded re-executes on the same account with exponential backoff while the upstream reports 529
+// Takes the first overloaded attempt's result and returns the last attempt's.
+func (s *RouterService) retryOverloaded(
+	ctx context.Context,
+	provider providers.Provider,
+	account *models.Account,
+	resolvedModel string,
+	req Request,
+	retryCtx *RetryContext,
+	resp Response,
+	statusCode int,
+	payload []byte,
+	execErr error,
+) (Response, int, []byte, error) {
+	for i := 0; i < s.config.MaxRetries && autherrors.IsOverloadedStatus(statusCode); i++ {
+		wait := s.backoff(overloadBackoffBase, i)
+		log.Printf("[Router] Upstream overloaded on account %s, retrying in %v", account.ID, wait)
+
+		if err := s.sleep(ctx, wait); err != nil {
+			return resp, statusCode, payload, err
+		}
+
+		retryCtx.RetryCount++
+		resp, statusCode, payload, execErr = s.executeWithPermanentProxy(ctx, provider, account, resolvedModel, req, retryCtx)
+		retryCtx.recordAttempt(account.ID, statusCode, execErr)
+	}
+
+	return resp, statusCode, payload, execErr
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	autherrors "aigateway-backend/auth/errors"
@@ -18,12 +19,73 @@ type RetryContext struct {
 	RetryCount         int
 	SwitchedFromAccID  *string
 	ProxyMarkedDown    bool
+	Attempts           []AttemptRecord // Every upstream attempt, kept across all-blocked waits
 }
 
 // executeWithAuthManager executes request with health-aware account selection and retry
+// Fails over to the model's configured fallbacks when its provider has no usable account.
 func (s *RouterService) executeWithAuthManager(ctx context.Context, req Request, attempt int) (Response, error) {
+	resp, err := s.executeOnProvider(ctx, req, attempt)
+	if s.hasFailover(req) && providerUnavailable(err) {
+		return s.executeFailover(ctx, req, resp, err)
+	}
+	return resp, err
+}
+
+// executeOnProvider executes request on the provider req.Model routes to
+// A summary of the attempted accounts is logged once the request finishes.
+func (s *RouterService) executeOnProvider(ctx context.Context, req Request, attempt int) (Response, error) {
+	provider, resolvedModel, err := s.Route(req.Model)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if err := providers.CheckRequest(provider, req.Payload, resolvedModel); err != nil {
+		return Response{StatusCode: http.StatusBadRequest}, err
+	}
+
+	if err := s.allowProvider(provider.ID()); err != nil {
+		return Response{StatusCode: http.StatusServiceUnavailable}, err
+	}
+
 	retryCtx := &RetryContext{}
-	return s.executeWithRetry(ctx, req, attempt, retryCtx)
+	resp, err := s.executeWithRetry(ctx, req, attempt, retryCtx)
+	s.recordBreakerOutcome(ctx, provider.ID(), retryCtx, err)
+	s.logSwitchChain(req, provider.ID(), resolvedModel, retryCtx, resp.StatusCode, err)
+	return resp, err
+}
+
+// selectForRequest picks the account for req: the pinned req.AccountID when set, otherwise load-balanced
+func (s *RouterService) selectForRequest(ctx context.Context, providerID, resolvedModel string, req Request) (*manager.AccountState, error) {
+	if req.AccountID != "" {
+		return s.authManager.SelectPinned(providerID, req.AccountID, resolvedModel)
+	}
+	return s.authManager.SelectForTier(ctx, providerID, resolvedModel, req.ServiceTier)
+}
+
+// allowProvider checks the provider's circuit breaker before any account selection
+func (s *RouterService) allowProvider(providerID string) error {
+	if s.breaker == nil {
+		return nil
+	}
+	return s.breaker.Allow(providerID)
+}
+
+// recordBreakerOutcome reports a finished request to the provider's circuit breaker
+// Reaching any account counts as success; failing without a single upstream attempt means
+// no account was usable, which is the provider-wide failure the breaker counts.
+func (s *RouterService) recordBreakerOutcome(ctx context.Context, providerID string, retryCtx *RetryContext, err error) {
+	if s.breaker == nil {
+		return
+	}
+	switch {
+	case len(retryCtx.Attempts) > 0:
+		s.breaker.RecordSuccess(providerID)
+	case err != nil && ctx.Err() == nil:
+		s.breaker.RecordFailure(providerID)
+	default:
+		s.breaker.ReleaseProbe(providerID)
+	}
 }
 
 // executeWithRetry handles retry logic with same account before switching
@@ -39,11 +101,15 @@ func (s *RouterService) executeWithRetry(ctx context.Context, req Request, attem
 
 	providerID := provider.ID()
 
-	// Select account using AuthManager
-	accState, err := s.authManager.Select(ctx, providerID, resolvedModel)
+	// Select account using AuthManager (or the pinned account)
+	accState, err := s.selectForRequest(ctx, providerID, resolvedModel, req)
 	if err != nil {
 		if allBlocked, ok := err.(*manager.AllBlockedError); ok {
-			return s.handleAllBlocked(ctx, req, attempt, allBlocked)
+			// Fail over right away rather than waiting for an account to unblock
+			if s.hasFailover(req) {
+				return Response{StatusCode: http.StatusTooManyRequests}, allBlocked
+			}
+			return s.handleAllBlocked(ctx, req, attempt, allBlocked, retryCtx)
 		}
 		return Response{}, fmt.Errorf("failed to select account: %w", err)
 	}
@@ -56,16 +122,39 @@ func (s *RouterService) executeWithRetry(ctx context.Context, req Request, attem
 
 	// Execute and track result
 	resp, statusCode, payload, execErr := s.executeWithPermanentProxy(ctx, provider, accState.Account, resolvedModel, req, retryCtx)
+	retryCtx.recordAttempt(accState.Account.ID, statusCode, execErr)
+
+	// Overloaded upstream: back off on the same account instead of switching
+	overloaded := autherrors.IsOverloadedStatus(statusCode)
+	if overloaded {
+		resp, statusCode, payload, execErr = s.retryOverloaded(ctx, provider, accState.Account, resolvedModel, req, retryCtx, resp, statusCode, payload, execErr)
+		overloaded = autherrors.IsOverloadedStatus(statusCode)
+	}
 
 	// Mark result in AuthManager
-	s.authManager.MarkResult(accState.Account.ID, resolvedModel, statusCode, payload)
+	s.authManager.MarkResult(accState.Account.ID, resolvedModel, statusCode, payload, resp.Headers)
+
+	// Still overloaded after backing off: other accounts hit the same upstream, so don't switch
+	if overloaded {
+		return resp, execErr
+	}
 
 	// Handle retry logic
-	if execErr != nil && s.shouldRetry(statusCode, attempt) {
+	if execErr != nil && s.shouldRetry(statusCode, execErr, attempt) {
 		retryCtx.RetryCount++
 
+		// Transport errors point at the connection path (proxy/network), so switch
+		// right away instead of retrying the same account
+		transportErr := statusCode == 0
+
 		// Check if we've exhausted retries for current account
-		if retryCtx.RetryCount >= s.config.MaxRetries {
+		exhausted := retryCtx.RetryCount >= s.config.MaxRetries
+		if req.AccountID != "" {
+			// A pinned account is never switched; give up once its retries are spent
+			if exhausted {
+				return resp, execErr
+			}
+		} else if exhausted || transportErr {
 			// Mark proxy as down if we have one
 			if accState.Account.ProxyID != nil && !retryCtx.ProxyMarkedDown {
 				s.proxyService.MarkProxyDown(*accState.Account.ProxyID)
@@ -73,28 +162,45 @@ func (s *RouterService) executeWithRetry(ctx context.Context, req Request, attem
 			}
 
 			// Try to switch to a different account
-			altAccount, switchErr := s.accountService.SelectAccountExcluding(providerID, resolvedModel, accState.Account.ID)
+			altState, switchErr := s.selectAlternative(ctx, providerID, resolvedModel, req, accState.Account.ID)
 			if switchErr == nil {
 				// Track that we switched accounts
 				retryCtx.SwitchedFromAccID = &accState.Account.ID
 				retryCtx.RetryCount = 0 // Reset retry count for new account
 
 				// Execute with new account
-				return s.executeWithSwitchedAccount(ctx, provider, altAccount, resolvedModel, req, retryCtx)
+				return s.executeWithSwitchedAccount(ctx, provider, altState.Account, resolvedModel, req, retryCtx)
+			}
+			// No alternative account: retry transport errors on the same account, otherwise give up
+			if !transportErr {
+				return resp, execErr
 			}
-			// No alternative account available, return the error
-			return resp, execErr
 		}
 
-		// Retry with same account after delay
-		time.Sleep(time.Duration(s.config.MaxRetries*100) * time.Millisecond)
+		// Retry with same account after an exponential, jittered delay
+		wait := s.retryBackoff(retryCtx.RetryCount - 1)
+		if err := s.sleep(ctx, wait); err != nil {
+			return resp, err
+		}
 		return s.executeWithRetry(ctx, req, attempt+1, retryCtx)
 	}
 
+	if execErr == nil {
+		return s.applyEmptyResponsePolicy(ctx, provider, accState.Account.ID, resolvedModel, req, retryCtx, resp)
+	}
 	return resp, execErr
 }
 
+// selectAlternative picks an account other than current through the AuthManager
+// The request's own exclusions still apply, and the pick holds an in-flight slot that the
+// MarkResult in executeWithSwitchedAccount releases.
+func (s *RouterService) selectAlternative(ctx context.Context, providerID, resolvedModel string, req Request, current string) (*manager.AccountState, error) {
+	ctx = manager.WithExcludedAccounts(ctx, []string{current})
+	return s.authManager.SelectForTier(ctx, providerID, resolvedModel, req.ServiceTier)
+}
+
 // executeWithSwitchedAccount executes with a different account after retry failure
+// account must come from selectAlternative, since its in-flight slot is released here.
 func (s *RouterService) executeWithSwitchedAccount(
 	ctx context.Context,
 	provider providers.Provider,
@@ -106,24 +212,27 @@ func (s *RouterService) executeWithSwitchedAccount(
 	retryCtx.CurrentAccountID = account.ID
 
 	resp, statusCode, payload, execErr := s.executeWithPermanentProxy(ctx, provider, account, resolvedModel, req, retryCtx)
+	retryCtx.recordAttempt(account.ID, statusCode, execErr)
 
 	// Mark result
-	s.authManager.MarkResult(account.ID, resolvedModel, statusCode, payload)
+	s.authManager.MarkResult(account.ID, resolvedModel, statusCode, payload, resp.Headers)
 
 	return resp, execErr
 }
 
 // handleAllBlocked handles the case when all accounts are blocked
+// Retry state is reset for the next round; the attempt chain is kept for the summary log.
 func (s *RouterService) handleAllBlocked(
 	ctx context.Context,
 	req Request,
 	attempt int,
 	allBlocked *manager.AllBlockedError,
+	retryCtx *RetryContext,
 ) (Response, error) {
 	waitDur := time.Until(allBlocked.WaitDuration)
 	if waitDur <= 0 {
 		// Retry immediately
-		retryCtx := &RetryContext{}
+		*retryCtx = RetryContext{Attempts: retryCtx.Attempts}
 		return s.executeWithRetry(ctx, req, attempt+1, retryCtx)
 	}
 
@@ -136,7 +245,7 @@ func (s *RouterService) handleAllBlocked(
 	case <-ctx.Done():
 		return Response{}, ctx.Err()
 	case <-time.After(waitDur):
-		retryCtx := &RetryContext{}
+		*retryCtx = RetryContext{Attempts: retryCtx.Attempts}
 		return s.executeWithRetry(ctx, req, attempt+1, retryCtx)
 	}
 }
@@ -172,16 +281,17 @@ func (s *RouterService) executeWithPermanentProxy(
 
 	// Handle connection errors
 	if err != nil && executeResp == nil {
+		s.tapRequest(providerID, resolvedModel, req.Payload, 0, nil)
 		s.statsTrackerService.RecordFailureWithRetry(&account.ID, account.ProxyID, 0, err, retryCtx.RetryCount, retryCtx.SwitchedFromAccID)
 		// Track health failure (defensive: check accountRepo exists)
 		if s.accountRepo != nil {
-			go func() {
+			goAsync(func() {
 				if dbErr := s.accountRepo.UpdateHealthFailure(account.ID, err.Error()); dbErr != nil {
 					fmt.Printf("[ERROR] Failed to update health failure for account %s: %v\n", account.ID, dbErr)
 				} else {
-					fmt.Printf("[DEBUG] Updated health failure for account %s (connection error)\n", account.ID)
+					providers.Debugf("Updated health failure for account %s (connection error)", account.ID)
 				}
-			}()
+			})
 		} else {
 			fmt.Printf("[WARN] accountRepo is nil, cannot track health failure (connection error)\n")
 		}
@@ -190,61 +300,78 @@ func (s *RouterService) executeWithPermanentProxy(
 
 	statusCode := executeResp.StatusCode
 	payload := executeResp.Payload
+	s.tapRequest(providerID, resolvedModel, sentPayload(req.Payload, executeResp.SentPayload), statusCode, payload)
 
-	// Record stats async with retry info
+	// Record stats async with retry info, captured now since later attempts keep updating retryCtx
 	providerIDPtr := &providerID
-	go s.statsTrackerService.RecordRequestWithRetry(
-		&account.ID,
-		account.ProxyID,
-		providerIDPtr,
-		resolvedModel,
-		statusCode,
-		executeResp.LatencyMs,
-		retryCtx.RetryCount,
-		retryCtx.SwitchedFromAccID,
-	)
+	retryCount := retryCtx.RetryCount
+	switchedFrom := retryCtx.SwitchedFromAccID
+	goAsync(func() {
+		s.statsTrackerService.RecordRequestWithRetry(
+			&account.ID,
+			account.ProxyID,
+			providerIDPtr,
+			resolvedModel,
+			statusCode,
+			executeResp.LatencyMs,
+			retryCount,
+			switchedFrom,
+			providers.UpstreamRequestID(executeResp.Headers),
+		)
+	})
 
 	// Check success
 	if statusCode < 200 || statusCode >= 300 {
 		// Track health failure for non-2xx (defensive: check accountRepo exists)
 		if s.accountRepo != nil {
-			go func() {
+			goAsync(func() {
 				if err := s.accountRepo.UpdateHealthFailure(account.ID, fmt.Sprintf("HTTP %d", statusCode)); err != nil {
 					fmt.Printf("[ERROR] Failed to update health failure for account %s: %v\n", account.ID, err)
 				} else {
-					fmt.Printf("[DEBUG] Updated health failure for account %s (HTTP %d)\n", account.ID, statusCode)
+					providers.Debugf("Updated health failure for account %s (HTTP %d)", account.ID, statusCode)
 				}
-			}()
+			})
 		} else {
 			fmt.Printf("[WARN] accountRepo is nil, cannot track health failure\n")
 		}
 		return Response{
 			StatusCode: statusCode,
 			Payload:    payload,
+			Headers:    executeResp.Headers,
 		}, statusCode, payload, fmt.Errorf("upstream error: %d", statusCode)
 	}
 
 	// Track health success (defensive: check accountRepo exists)
 	if s.accountRepo != nil {
-		go func() {
+		goAsync(func() {
 			if err := s.accountRepo.UpdateHealthSuccess(account.ID); err != nil {
 				fmt.Printf("[ERROR] Failed to update health success for account %s: %v\n", account.ID, err)
 			} else {
-				fmt.Printf("[DEBUG] Updated health success for account %s\n", account.ID)
+				providers.Debugf("Updated health success for account %s", account.ID)
 			}
-		}()
+		})
 	}
 
 	return Response{
 		StatusCode: statusCode,
 		Payload:    payload,
+		Headers:    executeResp.Headers,
 	}, statusCode, payload, nil
 }
 
+// SetRetryOnTransportErrors toggles retrying timeouts and connection resets/refusals (enabled by default)
+func (s *RouterService) SetRetryOnTransportErrors(enabled bool) {
+	s.config.RetryOnTransportErrors = enabled
+}
+
 // shouldRetry determines if request should be retried based on status code
-func (s *RouterService) shouldRetry(statusCode int, attempt int) bool {
+// Transport failures (statusCode 0) are classified by the underlying network error.
+func (s *RouterService) shouldRetry(statusCode int, err error, attempt int) bool {
 	if attempt >= s.config.MaxRetries-1 {
 		return false
 	}
+	if statusCode == 0 {
+		return s.config.RetryOnTransportErrors && autherrors.IsRetryableNetError(err)
+	}
 	return autherrors.IsRetryableStatus(statusCode)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
 	"aigateway-backend/auth/manager"
@@ -14,32 +15,57 @@ import (
 
 // Request represents a unified request structure for the router
 type Request struct {
-	ProviderID string
-	Model      string
-	Payload    []byte
-	Stream     bool
-	AccountID  string // Optional: override account selection for testing
+	ProviderID  string
+	Model       string
+	Payload     []byte
+	Stream      bool
+	AccountID   string // Optional: override account selection for testing
+	ServiceTier string // Claude service_tier ("auto"/"standard_only"), used for pool selection
+	RequestID   string // Correlates the router's switch-chain log with the client request
+	Format      string // Inbound payload format (FormatClaude or FormatOpenAI), "" = FormatClaude
+}
+
+// Inbound payload formats, as passed to Provider.TranslateRequest
+const (
+	FormatClaude = "claude"
+	FormatOpenAI = "openai"
+)
+
+// inputFormat returns the format the client sent the payload in
+func (r Request) inputFormat() string {
+	if r.Format == "" {
+		return FormatClaude
+	}
+	return r.Format
 }
 
 // Response represents a unified response structure from the router
 type Response struct {
 	StatusCode int
 	Payload    []byte
+	Headers    http.Header // Upstream response headers (e.g. Retry-After)
 }
 
 // RouterConfig holds configuration for the router
 type RouterConfig struct {
-	UseAuthManager bool
-	MaxRetries     int
-	MaxRetryWait   time.Duration
+	UseAuthManager         bool
+	MaxRetries             int
+	MaxRetryWait           time.Duration
+	RetryOnTransportErrors bool          // Retry timeouts and connection resets/refusals (no HTTP status)
+	RequestTapEnabled      bool          // Send upstream payloads to the RequestTap (debug only)
+	StreamPingInterval     time.Duration // Idle time before a keep-alive ping event is streamed, 0 = disabled
+
+	// Handling of 200 responses without assistant content, keyed by Claude stop_reason ("*" = any)
+	EmptyResponsePolicy map[string]EmptyResponseAction
 }
 
 // DefaultRouterConfig returns default configuration
 func DefaultRouterConfig() RouterConfig {
 	return RouterConfig{
-		UseAuthManager: false,
-		MaxRetries:     3,
-		MaxRetryWait:   30 * time.Second,
+		UseAuthManager:         false,
+		MaxRetries:             3,
+		MaxRetryWait:           30 * time.Second,
+		RetryOnTransportErrors: true,
 	}
 }
 
@@ -56,6 +82,22 @@ type RouterService struct {
 	// Auth manager for health-aware selection
 	authManager *manager.Manager
 	config      RouterConfig
+
+	// Optional upstream traffic observer
+	requestTap RequestTap
+
+	// Optional per-provider fail-fast when no account is usable
+	breaker *CircuitBreaker
+
+	// Fallback models per requested model, tried when its provider has no usable account
+	failover map[string][]string
+
+	// Weighted alias -> model splits for A/B testing (nil = disabled)
+	splitter *ModelSplitter
+
+	// Retry backoff hooks, replaceable in tests for determinism
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(max time.Duration) time.Duration
 }
 
 // NewRouterService creates a new router service instance
@@ -77,6 +119,8 @@ func NewRouterService(
 		oauthService:        oauthService,
 		statsTrackerService: statsTrackerService,
 		config:              DefaultRouterConfig(),
+		sleep:               sleepContext,
+		jitter:              randomJitter,
 	}
 }
 
@@ -85,6 +129,11 @@ func (s *RouterService) SetAuthManager(m *manager.Manager) {
 	s.authManager = m
 }
 
+// SetCircuitBreaker enables fail-fast for providers whose accounts are all unusable (nil = disabled)
+func (s *RouterService) SetCircuitBreaker(breaker *CircuitBreaker) {
+	s.breaker = breaker
+}
+
 // SetConfig sets the router configuration
 func (s *RouterService) SetConfig(config RouterConfig) {
 	s.config = config
@@ -136,6 +185,10 @@ func (s *RouterService) executeLegacy(ctx context.Context, req Request) (Respons
 		return Response{}, err
 	}
 
+	if err := providers.CheckRequest(provider, req.Payload, resolvedModel); err != nil {
+		return Response{StatusCode: http.StatusBadRequest}, err
+	}
+
 	providerID := provider.ID()
 
 	account, err := s.accountService.SelectAccount(providerID, resolvedModel)
@@ -173,45 +226,52 @@ func (s *RouterService) executeWithAccount(
 
 	executeResp, err := provider.Execute(ctx, executeReq)
 	if err != nil {
+		s.tapRequest(providerID, resolvedModel, req.Payload, 0, nil)
 		s.statsTrackerService.RecordFailure(&account.ID, account.ProxyID, 0, err)
 		// Track health failure (defensive: check accountRepo exists)
 		if s.accountRepo != nil {
-			go s.accountRepo.UpdateHealthFailure(account.ID, err.Error())
+			goAsync(func() { s.accountRepo.UpdateHealthFailure(account.ID, err.Error()) })
 		}
 		return Response{}, fmt.Errorf("provider execution failed: %w", err)
 	}
 
 	statusCode := executeResp.StatusCode
+	s.tapRequest(providerID, resolvedModel, sentPayload(req.Payload, executeResp.SentPayload), statusCode, executeResp.Payload)
 	providerIDPtr := &providerID
 
-	go s.statsTrackerService.RecordRequest(
-		&account.ID,
-		account.ProxyID,
-		providerIDPtr,
-		resolvedModel,
-		statusCode,
-		executeResp.LatencyMs,
-	)
+	goAsync(func() {
+		s.statsTrackerService.RecordRequest(
+			&account.ID,
+			account.ProxyID,
+			providerIDPtr,
+			resolvedModel,
+			statusCode,
+			executeResp.LatencyMs,
+			providers.UpstreamRequestID(executeResp.Headers),
+		)
+	})
 
 	if statusCode < 200 || statusCode >= 300 {
 		// Track health failure for non-2xx (defensive: check accountRepo exists)
 		if s.accountRepo != nil {
-			go s.accountRepo.UpdateHealthFailure(account.ID, fmt.Sprintf("HTTP %d", statusCode))
+			goAsync(func() { s.accountRepo.UpdateHealthFailure(account.ID, fmt.Sprintf("HTTP %d", statusCode)) })
 		}
 		return Response{
 			StatusCode: statusCode,
 			Payload:    executeResp.Payload,
+			Headers:    executeResp.Headers,
 		}, fmt.Errorf("upstream error: %d", statusCode)
 	}
 
 	// Track health success (defensive: check accountRepo exists)
 	if s.accountRepo != nil {
-		go s.accountRepo.UpdateHealthSuccess(account.ID)
+		goAsync(func() { s.accountRepo.UpdateHealthSuccess(account.ID) })
 	}
 
 	return Response{
 		StatusCode: statusCode,
 		Payload:    executeResp.Payload,
+		Headers:    executeResp.Headers,
 	}, nil
 }
 
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SplitTarget is one weighted destination of a model split
+type SplitTarget struct {
+	Model  string
+	Weight int
+}
+
+// ModelSplitter sends a percentage of an alias's traffic to other models (A/B testing)
+// Picks come from a seeded source, so a fixed seed yields a reproducible sequence.
+type ModelSplitter struct {
+	mu     sync.Mutex
+	splits map[string][]SplitTarget
+	rng    *rand.Rand
+}
+
+// NewModelSplitter creates a splitter over alias -> weighted targets (seed 0 = time-based)
+// Targets with a non-positive weight are never picked.
+func NewModelSplitter(splits map[string][]SplitTarget, seed int64) *ModelSplitter {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ModelSplitter{
+		splits: splits,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Resolve returns the model to serve for model: a weighted pick for split aliases, otherwise model itself
+func (s *ModelSplitter) Resolve(model string) string {
+	targets := s.splits[model]
+
+	total := 0
+	for _, target := range targets {
+		if target.Weight > 0 {
+			total += target.Weight
+		}
+	}
+	if total == 0 {
+		return model
+	}
+
+	s.mu.Lock()
+	n := s.rng.Intn(total)
+	s.mu.Unlock()
+
+	for _, target := range targets {
+		if target.Weight <= 0 {
+			continue
+		}
+		if n < target.Weight {
+			return target.Model
+		}
+		n -= target.Weight
+	}
+	return model
+}
+
+// SetModelSplitter enables weighted model splits (nil = disabled)
+// Splits are resolved once per request by ResolveModelSplit, before routing, rather than inside
+// Route: Route runs again on every retry and would re-roll the pick, and a target may be the
+// alias itself. Targets are routed like direct requests for that model.
+func (s *RouterService) SetModelSplitter(splitter *ModelSplitter) {
+	s.splitter = splitter
+}
+
+// ResolveModelSplit picks the split target for a requested model; models without a split are returned unchanged
+func (s *RouterService) ResolveModelSplit(model string) string {
+	if s.splitter == nil {
+		return model
+	}
+	return s.splitter.Resolve(model)
+}
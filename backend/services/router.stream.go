@@ -0,0 +1,416 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/internal/apierror"
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
+)
+
+// ExecuteStream streams a request through the AuthManager path, flushing each chunk to w as it arrives
+// Retries only happen before the first chunk is written; once streaming starts the response is committed.
+// MarkResult, stats and health tracking run when the stream completes. Returns the upstream status code,
+// or 503 with a *CircuitOpenError while the provider's circuit breaker is open. Fails over to the model's
+// configured fallbacks when its provider has no usable account.
+func (s *RouterService) ExecuteStream(ctx context.Context, req Request, w http.ResponseWriter) (int, error) {
+	if s.authManager == nil {
+		return 0, fmt.Errorf("streaming requires the auth manager")
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return 0, fmt.Errorf("response writer does not support flushing")
+	}
+
+	statusCode, err := s.streamOnProvider(ctx, req, w, flusher)
+	if s.hasFailover(req) && providerUnavailable(err) {
+		return s.streamFailover(ctx, req, w, flusher, statusCode, err)
+	}
+	return statusCode, err
+}
+
+// streamOnProvider streams req from the provider req.Model routes to
+func (s *RouterService) streamOnProvider(ctx context.Context, req Request, w http.ResponseWriter, flusher http.Flusher) (int, error) {
+	provider, resolvedModel, err := s.Route(req.Model)
+	if err != nil {
+		return 0, err
+	}
+	if !provider.SupportsStreaming() {
+		return 0, fmt.Errorf("provider %s does not support streaming", provider.ID())
+	}
+
+	if err := providers.CheckRequest(provider, req.Payload, resolvedModel); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if err := s.allowProvider(provider.ID()); err != nil {
+		return http.StatusServiceUnavailable, err
+	}
+
+	retryCtx := &RetryContext{}
+	statusCode, err := s.streamWithRetry(ctx, provider, resolvedModel, req, w, flusher, retryCtx)
+	s.recordBreakerOutcome(ctx, provider.ID(), retryCtx, err)
+	s.logSwitchChain(req, provider.ID(), resolvedModel, retryCtx, statusCode, err)
+	return statusCode, err
+}
+
+// streamWithRetry selects accounts until a stream opens, then forwards it
+func (s *RouterService) streamWithRetry(
+	ctx context.Context,
+	provider providers.Provider,
+	resolvedModel string,
+	req Request,
+	w http.ResponseWriter,
+	flusher http.Flusher,
+	retryCtx *RetryContext,
+) (int, error) {
+	for attempt := 0; ; attempt++ {
+		accState, err := s.selectForRequest(ctx, provider.ID(), resolvedModel, req)
+		if err != nil {
+			var allBlocked *manager.AllBlockedError
+			if errors.As(err, &allBlocked) {
+				return http.StatusTooManyRequests, err
+			}
+			return 0, fmt.Errorf("failed to select account: %w", err)
+		}
+
+		if retryCtx.OriginalAccountID == "" {
+			retryCtx.OriginalAccountID = accState.Account.ID
+		} else if retryCtx.CurrentAccountID != accState.Account.ID {
+			switchedFrom := retryCtx.CurrentAccountID
+			retryCtx.SwitchedFromAccID = &switchedFrom
+		}
+		retryCtx.CurrentAccountID = accState.Account.ID
+
+		streamResp, statusCode, startErr := s.startStream(ctx, provider, accState.Account, resolvedModel, req)
+		if startErr != nil {
+			retryCtx.recordAttempt(accState.Account.ID, statusCode, startErr)
+			s.authManager.MarkResult(accState.Account.ID, resolvedModel, statusCode, []byte(startErr.Error()), nil)
+			s.recordStreamResult(provider.ID(), accState.Account, resolvedModel, req.Payload, statusCode, 0, nil, startErr, retryCtx, "")
+
+			if s.shouldRetry(statusCode, startErr, attempt) {
+				retryCtx.RetryCount++
+				if err := s.sleep(ctx, s.retryBackoff(retryCtx.RetryCount-1)); err != nil {
+					return statusCode, err
+				}
+				continue
+			}
+			return statusCode, startErr
+		}
+
+		return s.forwardStream(ctx, provider.ID(), accState.Account, resolvedModel, req, streamResp, w, flusher, retryCtx)
+	}
+}
+
+// startStream gets a token and opens the upstream stream for the selected account
+// Returns the upstream status code (0 on transport errors) alongside any error.
+func (s *RouterService) startStream(
+	ctx context.Context,
+	provider providers.Provider,
+	account *models.Account,
+	resolvedModel string,
+	req Request,
+) (*providers.StreamResponse, int, error) {
+	token, err := s.oauthService.GetAccessToken(account)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	streamResp, err := provider.ExecuteStream(ctx, &providers.ExecuteRequest{
+		Model:    resolvedModel,
+		Payload:  req.Payload,
+		Stream:   true,
+		Account:  account,
+		ProxyURL: account.ProxyURL,
+		Token:    token,
+		Format:   req.inputFormat(),
+	})
+	if err != nil {
+		statusCode := 0
+		if streamResp != nil {
+			statusCode = streamResp.StatusCode
+		}
+		return nil, statusCode, fmt.Errorf("provider streaming execution failed: %w", err)
+	}
+	if streamResp.StatusCode != 0 && (streamResp.StatusCode < 200 || streamResp.StatusCode >= 300) {
+		return nil, streamResp.StatusCode, fmt.Errorf("upstream error: %d", streamResp.StatusCode)
+	}
+
+	return streamResp, http.StatusOK, nil
+}
+
+// streamPingEvent is Anthropic's keep-alive event; clients ignore it
+var streamPingEvent = []byte("event: ping\ndata: {\"type\": \"ping\"}\n\n")
+
+// streamPingComment is the keep-alive for other formats: an SSE comment, which OpenAI clients
+// skip where they would fail to parse Anthropic's ping as a chunk
+var streamPingComment = []byte(": ping\n\n")
+
+// streamPing returns the keep-alive written to clients streaming in format
+func streamPing(format string) []byte {
+	if format == FormatClaude {
+		return streamPingEvent
+	}
+	return streamPingComment
+}
+
+// SetStreamPingInterval enables keep-alive pings after interval without upstream chunks (0 = disabled)
+// Keeps idle-timeout proxies and clients from closing streams while a model thinks silently.
+func (s *RouterService) SetStreamPingInterval(interval time.Duration) {
+	s.config.StreamPingInterval = interval
+}
+
+// forwardStream writes upstream chunks to the client and records the result once the stream ends
+// While the upstream is silent for StreamPingInterval, pings in the client's format are written between chunks.
+func (s *RouterService) forwardStream(
+	ctx context.Context,
+	providerID string,
+	account *models.Account,
+	resolvedModel string,
+	req Request,
+	streamResp *providers.StreamResponse,
+	w http.ResponseWriter,
+	flusher http.Flusher,
+	retryCtx *RetryContext,
+) (int, error) {
+	startTime := time.Now()
+	usage := &streamUsage{}
+	var tapped []byte
+	tapping := s.config.RequestTapEnabled && s.requestTap != nil
+
+	upstreamRequestID := providers.StreamUpstreamRequestID(streamResp.Headers)
+
+	header := w.Header()
+	if upstreamRequestID != "" {
+		header.Set(providers.UpstreamRequestIDHeader, upstreamRequestID)
+	}
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Idle timer, restarted by every chunk; nil channel when pings are disabled
+	ping := streamPing(req.inputFormat())
+	var idle <-chan time.Time
+	var pingTimer *time.Timer
+	if s.config.StreamPingInterval > 0 {
+		pingTimer = time.NewTimer(s.config.StreamPingInterval)
+		defer pingTimer.Stop()
+		idle = pingTimer.C
+	}
+
+	var streamErr error
+	clientGone := false // Disconnected or cancelled client, not an upstream failure
+forward:
+	for {
+		select {
+		case chunk, ok := <-streamResp.DataCh:
+			if !ok {
+				break forward
+			}
+			if pingTimer != nil {
+				if !pingTimer.Stop() {
+					select {
+					case <-pingTimer.C:
+					default:
+					}
+				}
+				pingTimer.Reset(s.config.StreamPingInterval)
+			}
+
+			chunk = frameSSE(chunk)
+			usage.observe(chunk)
+			if tapping {
+				tapped = append(tapped, chunk...)
+			}
+
+			if _, err := w.Write(chunk); err != nil {
+				streamErr = fmt.Errorf("failed to write chunk: %w", err)
+				clientGone = true
+				break forward
+			}
+			flusher.Flush()
+
+		case <-idle:
+			if _, err := w.Write(ping); err != nil {
+				streamErr = fmt.Errorf("failed to write ping: %w", err)
+				clientGone = true
+				break forward
+			}
+			flusher.Flush()
+			pingTimer.Reset(s.config.StreamPingInterval)
+
+		case <-ctx.Done():
+			streamErr = ctx.Err()
+			clientGone = true
+			break forward
+		}
+	}
+
+	// The client went away mid-stream: that says nothing about the account, so free its
+	// slot without recording a failure against its health or stats
+	if clientGone {
+		retryCtx.recordAttempt(account.ID, 0, streamErr)
+		s.authManager.Release(account.ID)
+		return http.StatusOK, streamErr
+	}
+
+	// The error channel is closed before the data channel, so this never blocks on a finished stream
+	if streamErr == nil && streamResp.ErrCh != nil {
+		if err, ok := <-streamResp.ErrCh; ok && err != nil {
+			streamErr = fmt.Errorf("stream error: %w", err)
+			w.Write(apierror.StreamEvent(ctx, apierror.TypeAPI, err.Error()))
+			flusher.Flush()
+		}
+	}
+
+	statusCode := http.StatusOK
+	body := usage.summary()
+	if streamErr != nil {
+		statusCode = 0
+		body = []byte(streamErr.Error())
+	}
+
+	retryCtx.recordAttempt(account.ID, statusCode, streamErr)
+	s.authManager.MarkResult(account.ID, resolvedModel, statusCode, body, nil)
+	s.recordStreamResult(providerID, account, resolvedModel, sentPayload(req.Payload, streamResp.SentPayload), statusCode, int(time.Since(startTime).Milliseconds()), tapped, streamErr, retryCtx, upstreamRequestID)
+
+	return http.StatusOK, streamErr
+}
+
+// recordStreamResult records tap, stats and health for a finished (or failed) stream
+// request is the body sent upstream, as passed to the tap.
+func (s *RouterService) recordStreamResult(
+	providerID string,
+	account *models.Account,
+	resolvedModel string,
+	request []byte,
+	statusCode int,
+	latencyMs int,
+	response []byte,
+	streamErr error,
+	retryCtx *RetryContext,
+	upstreamRequestID string,
+) {
+	s.tapRequest(providerID, resolvedModel, request, statusCode, response)
+
+	retryCount := retryCtx.RetryCount
+	switchedFrom := retryCtx.SwitchedFromAccID
+	if statusCode == 0 {
+		s.statsTrackerService.RecordFailureWithRetry(&account.ID, account.ProxyID, 0, streamErr, retryCount, switchedFrom)
+	} else {
+		goAsync(func() {
+			s.statsTrackerService.RecordRequestWithRetry(
+				&account.ID,
+				account.ProxyID,
+				&providerID,
+				resolvedModel,
+				statusCode,
+				latencyMs,
+				retryCount,
+				switchedFrom,
+				upstreamRequestID,
+			)
+		})
+	}
+
+	if s.accountRepo == nil {
+		return
+	}
+	goAsync(func() {
+		var err error
+		if streamErr != nil {
+			err = s.accountRepo.UpdateHealthFailure(account.ID, streamErr.Error())
+		} else {
+			err = s.accountRepo.UpdateHealthSuccess(account.ID)
+		}
+		if err != nil {
+			fmt.Printf("[ERROR] Failed to update health for account %s: %v\n", account.ID, err)
+		}
+	})
+}
+
+// frameSSE wraps bare JSON chunks that a provider left untranslated as SSE data events
+// Chunks already translated into SSE events (e.g. Antigravity's Claude events) pass through unchanged.
+func frameSSE(chunk []byte) []byte {
+	trimmed := bytes.TrimSpace(chunk)
+	if bytes.HasPrefix(trimmed, []byte("event:")) || bytes.HasPrefix(trimmed, []byte("data:")) {
+		return chunk
+	}
+
+	framed := make([]byte, 0, len(trimmed)+8)
+	framed = append(framed, "data: "...)
+	framed = append(framed, trimmed...)
+	return append(framed, "\n\n"...)
+}
+
+// streamUsage accumulates token usage reported in streamed events
+// Claude events report input tokens on message_start and output tokens on message_delta;
+// OpenAI-style chunks report both in a final usage object, and untranslated Antigravity
+// chunks carry cumulative usageMetadata (optionally wrapped in "response") on the last event.
+type streamUsage struct {
+	inputTokens  int64
+	outputTokens int64
+}
+
+// observe scans the data lines of an SSE chunk for usage fields
+func (u *streamUsage) observe(chunk []byte) {
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+
+		if v := gjson.GetBytes(data, "message.usage.input_tokens"); v.Exists() {
+			u.inputTokens = v.Int()
+		}
+		if v := gjson.GetBytes(data, "usage.input_tokens"); v.Exists() {
+			u.inputTokens = v.Int()
+		}
+		if v := gjson.GetBytes(data, "usage.output_tokens"); v.Exists() {
+			u.outputTokens = v.Int()
+		}
+		if v := gjson.GetBytes(data, "usage.prompt_tokens"); v.Exists() {
+			u.inputTokens = v.Int()
+		}
+		if v := gjson.GetBytes(data, "usage.completion_tokens"); v.Exists() {
+			u.outputTokens = v.Int()
+		}
+
+		metadata := gjson.GetBytes(data, "usageMetadata")
+		if !metadata.Exists() {
+			metadata = gjson.GetBytes(data, "response.usageMetadata")
+		}
+		if v := metadata.Get("promptTokenCount"); v.Exists() {
+			u.inputTokens = v.Int()
+		}
+		if v := metadata.Get("candidatesTokenCount"); v.Exists() {
+			u.outputTokens = v.Int()
+		}
+	}
+}
+
+// summary renders the accumulated usage in both formats the TokenExtractor understands
+// Returns nil when the stream reported no usage so the extractor doesn't estimate from the summary itself.
+func (u *streamUsage) summary() []byte {
+	total := u.inputTokens + u.outputTokens
+	if total == 0 {
+		return nil
+	}
+	return []byte(fmt.Sprintf(
+		`{"usage":{"prompt_tokens":%d,"completion_tokens":%d,"total_tokens":%d},"usageMetadata":{"promptTokenCount":%d,"candidatesTokenCount":%d,"totalTokenCount":%d}}`,
+		u.inputTokens, u.outputTokens, total, u.inputTokens, u.outputTokens, total,
+	))
+}
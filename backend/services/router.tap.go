@@ -0,0 +1,68 @@
+package services
+
+import (
+	"log"
+
+	"aigateway-backend/providers"
+)
+
+// RequestTap observes upstream traffic for debugging translation issues
+// Implementations run on their own goroutine and must be safe for concurrent use.
+type RequestTap interface {
+	// Tap receives the outbound payload sent to the provider and the raw upstream response
+	// statusCode is 0 and response is nil when the request failed before a response arrived.
+	Tap(providerID, model string, request []byte, statusCode int, response []byte)
+}
+
+// SetRequestTap sets the request tap (called only when enabled via EnableRequestTap)
+func (s *RouterService) SetRequestTap(tap RequestTap) {
+	s.requestTap = tap
+}
+
+// EnableRequestTap toggles request tapping without removing the configured tap
+func (s *RouterService) EnableRequestTap(enabled bool) {
+	s.config.RequestTapEnabled = enabled
+}
+
+// tapRequest forwards a request/response pair to the tap without blocking the pipeline
+func (s *RouterService) tapRequest(providerID, model string, request []byte, statusCode int, response []byte) {
+	if !s.config.RequestTapEnabled || s.requestTap == nil {
+		return
+	}
+
+	tap := s.requestTap
+	go tap.Tap(providerID, model, request, statusCode, response)
+}
+
+// sentPayload returns the body the provider sent upstream: its own translation when it
+// reported one, otherwise the payload the router passed to it
+func sentPayload(payload, sent []byte) []byte {
+	if sent != nil {
+		return sent
+	}
+	return payload
+}
+
+// LogRequestTap logs upstream payloads, truncated to MaxBodyBytes
+type LogRequestTap struct {
+	MaxBodyBytes int
+}
+
+// NewLogRequestTap creates a logging tap that truncates bodies to maxBodyBytes (0 = unlimited)
+func NewLogRequestTap(maxBodyBytes int) *LogRequestTap {
+	return &LogRequestTap{MaxBodyBytes: maxBodyBytes}
+}
+
+// Tap logs the request and response bodies
+func (t *LogRequestTap) Tap(providerID, model string, request []byte, statusCode int, response []byte) {
+	log.Printf("[RequestTap] %s/%s status=%d request=%s response=%s",
+		providerID, model, statusCode, t.truncate(request), t.truncate(response))
+}
+
+// truncate shortens body to MaxBodyBytes, masking credentials
+func (t *LogRequestTap) truncate(body []byte) string {
+	if t.MaxBodyBytes > 0 && len(body) > t.MaxBodyBytes {
+		return providers.Redact(string(body[:t.MaxBodyBytes])) + "...(truncated)"
+	}
+	return providers.Redact(string(body))
+}
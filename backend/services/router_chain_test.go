@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+// captureSwitchChain runs fn with the standard logger redirected and returns the decoded switch-chain entry
+func captureSwitchChain(t *testing.T, fn func()) switchChainSummary {
+	t.Helper()
+
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	fn()
+	log.SetOutput(prev)
+
+	const marker = "[Router] Switch chain: "
+	var entries []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if i := strings.Index(line, marker); i >= 0 {
+			entries = append(entries, line[i+len(marker):])
+		}
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d switch chain entries, want 1:\n%s", len(entries), buf.String())
+	}
+
+	var summary switchChainSummary
+	if err := json.Unmarshal([]byte(entries[0]), &summary); err != nil {
+		t.Fatalf("switch chain entry is not JSON: %v\n%s", err, entries[0])
+	}
+	return summary
+}
+
+func TestSwitchChain_LogsEachAttemptedAccount(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-1": {connResetError()},
+	}}
+	router := setupRetryRouter(t, provider, []string{"acc-1", "acc-2"}, []string{"acc-1"})
+
+	summary := captureSwitchChain(t, func() {
+		if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`), RequestID: "req-123"}); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	})
+
+	if summary.RequestID != "req-123" {
+		t.Errorf("request_id = %q, want req-123", summary.RequestID)
+	}
+	if len(summary.Attempts) != 2 {
+		t.Fatalf("attempts = %+v, want 2", summary.Attempts)
+	}
+	if first := summary.Attempts[0]; first.AccountID != "acc-1" || first.StatusCode != 0 || !strings.Contains(first.Error, "connection reset") {
+		t.Errorf("attempts[0] = %+v, want acc-1 transport failure", first)
+	}
+	if second := summary.Attempts[1]; second.AccountID != "acc-2" || second.StatusCode != 200 || second.Error != "" {
+		t.Errorf("attempts[1] = %+v, want acc-2 success", second)
+	}
+	if !summary.Switched || summary.Outcome != "success" || summary.StatusCode != 200 {
+		t.Errorf("summary = %+v, want switched success with status 200", summary)
+	}
+}
+
+func TestSwitchChain_LogsFinalFailure(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-1": {errors.New("invalid request")},
+	}}
+	router := setupRetryRouter(t, provider, []string{"acc-1"}, []string{"acc-1"})
+
+	summary := captureSwitchChain(t, func() {
+		if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`), RequestID: "req-456"}); err == nil {
+			t.Fatal("Execute() expected an error")
+		}
+	})
+
+	if len(summary.Attempts) != 1 || summary.Attempts[0].AccountID != "acc-1" {
+		t.Fatalf("attempts = %+v, want a single acc-1 attempt", summary.Attempts)
+	}
+	if summary.Switched || summary.Outcome != "failure" || !strings.Contains(summary.Error, "invalid request") {
+		t.Errorf("summary = %+v, want unswitched failure carrying the error", summary)
+	}
+}
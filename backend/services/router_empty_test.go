@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"aigateway-backend/providers"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	safetyFilteredResponse = `{"type":"message","role":"assistant","content":[],"stop_reason":"refusal"}`
+	textResponse           = `{"type":"message","role":"assistant","content":[{"type":"text","text":"hello"}],"stop_reason":"end_turn"}`
+)
+
+// payloadProvider returns a fixed Claude payload per account
+type payloadProvider struct {
+	fakeProvider
+	payloads map[string]string
+}
+
+func (p *payloadProvider) Execute(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = append(p.calls, req.Account.ID)
+	return &providers.ExecuteResponse{StatusCode: 200, Payload: []byte(p.payloads[req.Account.ID])}, nil
+}
+
+func setupEmptyResponseRouter(t *testing.T, policy map[string]EmptyResponseAction) (*RouterService, *payloadProvider) {
+	provider := &payloadProvider{
+		fakeProvider: fakeProvider{failures: map[string][]error{}},
+		payloads: map[string]string{
+			"acc-1": safetyFilteredResponse,
+			"acc-2": textResponse,
+		},
+	}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1", "acc-2"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+	router.SetEmptyResponsePolicy(policy)
+	return router, provider
+}
+
+func TestEmptyResponse_RetryOnAnotherAccount(t *testing.T) {
+	router, provider := setupEmptyResponseRouter(t, map[string]EmptyResponseAction{"refusal": EmptyResponseRetry})
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if want := []string{"acc-1", "acc-2"}; fmt.Sprint(provider.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", provider.calls, want)
+	}
+	if string(resp.Payload) != textResponse {
+		t.Errorf("payload = %s, want the retried account's response", resp.Payload)
+	}
+}
+
+func TestEmptyResponse_SynthesizedRefusal(t *testing.T) {
+	router, provider := setupEmptyResponseRouter(t, map[string]EmptyResponseAction{EmptyResponseAnyReason: EmptyResponseRefusal})
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if len(provider.calls) != 1 {
+		t.Errorf("calls = %v, want a single attempt", provider.calls)
+	}
+	if got := gjson.GetBytes(resp.Payload, "content.0.text").String(); got == "" {
+		t.Errorf("payload = %s, want a synthesized refusal text block", resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "stop_reason").String(); got != "refusal" {
+		t.Errorf("stop_reason = %q, want refusal", got)
+	}
+}
+
+func TestEmptyResponse_PassThroughByDefault(t *testing.T) {
+	router, provider := setupEmptyResponseRouter(t, nil)
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if len(provider.calls) != 1 || string(resp.Payload) != safetyFilteredResponse {
+		t.Errorf("calls = %v, payload = %s, want the empty response unchanged", provider.calls, resp.Payload)
+	}
+}
+
+func TestEmptyResponse_PolicyMatchesStopReason(t *testing.T) {
+	// Only end_turn is configured, so a safety refusal passes through
+	router, provider := setupEmptyResponseRouter(t, map[string]EmptyResponseAction{"end_turn": EmptyResponseRetry})
+
+	if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(provider.calls) != 1 {
+		t.Errorf("calls = %v, want no retry for an unconfigured stop_reason", provider.calls)
+	}
+}
+
+func TestParseEmptyResponsePolicy(t *testing.T) {
+	policy, err := ParseEmptyResponsePolicy(map[string]string{"refusal": "Retry", "*": "pass_through"})
+	if err != nil {
+		t.Fatalf("ParseEmptyResponsePolicy() error = %v", err)
+	}
+	if policy["refusal"] != EmptyResponseRetry || policy["*"] != EmptyResponsePassThrough {
+		t.Errorf("policy = %v", policy)
+	}
+
+	if _, err := ParseEmptyResponsePolicy(map[string]string{"refusal": "drop"}); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+}
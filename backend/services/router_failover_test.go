@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+	"aigateway-backend/providers/glm"
+
+	"github.com/tidwall/gjson"
+)
+
+const failoverClaudePayload = `{
+	"model": "claude-sonnet-4-5",
+	"max_tokens": 256,
+	"system": "You are terse.",
+	"messages": [{"role": "user", "content": [{"type": "text", "text": "Hello"}]}]
+}`
+
+// glmFallbackProvider translates like the real GLM provider and records what Execute receives
+type glmFallbackProvider struct {
+	*glm.Provider
+
+	mu       sync.Mutex
+	accounts []string
+	payloads [][]byte
+	formats  []string // ExecuteRequest.Format of each stream
+}
+
+func (p *glmFallbackProvider) Execute(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.accounts = append(p.accounts, req.Account.ID)
+	p.payloads = append(p.payloads, req.Payload)
+	return &providers.ExecuteResponse{
+		StatusCode: 200,
+		Payload:    []byte(`{"id":"chatcmpl-1","model":"glm-4-plus","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":8,"completion_tokens":1}}`),
+	}, nil
+}
+
+// ExecuteStream records the request and streams one chunk; the real GLM provider's StreamFormat
+// still decides which clients it may serve
+func (p *glmFallbackProvider) ExecuteStream(ctx context.Context, req *providers.ExecuteRequest) (*providers.StreamResponse, error) {
+	p.mu.Lock()
+	p.accounts = append(p.accounts, req.Account.ID)
+	p.payloads = append(p.payloads, req.Payload)
+	p.formats = append(p.formats, req.Format)
+	p.mu.Unlock()
+
+	dataCh := make(chan []byte, 1)
+	errCh := make(chan error)
+	done := make(chan struct{})
+	dataCh <- []byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	close(dataCh)
+	close(errCh)
+	close(done)
+	return &providers.StreamResponse{StatusCode: http.StatusOK, DataCh: dataCh, ErrCh: errCh, Done: done}, nil
+}
+
+// setupFailoverRouter routes claude-sonnet-4-5 to the scripted antigravity provider, failing
+// over to GLM; glm-1 is the only GLM account
+func setupFailoverRouter(t *testing.T) (*RouterService, *fakeProvider, *glmFallbackProvider) {
+	primary := &fakeProvider{}
+	router, db := setupRetryRouterDB(t, primary, []string{"acc-1"}, []string{"acc-1"})
+
+	fallback := &glmFallbackProvider{Provider: glm.NewProvider()}
+	router.registry.Register("glm", fallback)
+	err := db.Create(&models.Account{
+		ID:         "glm-1",
+		ProviderID: "glm",
+		Label:      "glm-1",
+		AuthData:   fmt.Sprintf(`{"api_key":"key","access_token":"key","expires_at":"%s"}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339)),
+		Metadata:   "{}",
+		IsActive:   true,
+	}).Error
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+
+	// Load accounts like main.go: only antigravity is served directly, GLM through failover
+	router.SetFailover(map[string][]string{"claude-sonnet-4-5": {"glm-4-plus"}})
+	if err := router.authManager.LoadAccounts(context.Background(), router.FailoverProviderIDs([]string{"antigravity"})...); err != nil {
+		t.Fatalf("LoadAccounts() error = %v", err)
+	}
+	return router, primary, fallback
+}
+
+func TestExecute_FailsOverWhenAllAccountsBlocked(t *testing.T) {
+	router, primary, fallback := setupFailoverRouter(t)
+
+	// Rate limit the only antigravity account so selection fails provider-wide
+	router.authManager.MarkResult("acc-1", "claude-sonnet-4-5", http.StatusTooManyRequests, nil, nil)
+
+	resp, err := router.Execute(context.Background(), Request{Model: "claude-sonnet-4-5", Payload: []byte(failoverClaudePayload)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(primary.calls) != 0 {
+		t.Errorf("primary calls = %v, want none", primary.calls)
+	}
+	if fmt.Sprint(fallback.accounts) != "[glm-1]" {
+		t.Fatalf("fallback accounts = %v, want [glm-1]", fallback.accounts)
+	}
+
+	// The Claude request reaches GLM in chat-completions format for the fallback model
+	sent := fallback.payloads[0]
+	if got := gjson.GetBytes(sent, "model").String(); got != "glm-4-plus" {
+		t.Errorf("fallback model = %q, want glm-4-plus", got)
+	}
+	if got := gjson.GetBytes(sent, "messages.0.role").String(); got != "system" {
+		t.Errorf("messages[0].role = %q, want system prompt as a system message", got)
+	}
+	if got := gjson.GetBytes(sent, "messages.1.content").String(); got != "Hello" {
+		t.Errorf("messages[1].content = %q, want Hello", got)
+	}
+
+	// The GLM response comes back in Claude format
+	if got := gjson.GetBytes(resp.Payload, "content.0.text").String(); got != "Hi" {
+		t.Errorf("response text = %q, want Hi (payload %s)", got, resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "stop_reason").String(); got != "end_turn" {
+		t.Errorf("stop_reason = %q, want end_turn", got)
+	}
+}
+
+// exhaustedQuotaTracker reports the listed accounts as quota-exhausted
+type exhaustedQuotaTracker struct {
+	recordingQuotaTracker
+	exhausted map[string]bool
+}
+
+func (e *exhaustedQuotaTracker) IsAvailable(accountID, model string) bool {
+	return !e.exhausted[accountID]
+}
+
+func TestExecute_FailsOverWhenAllAccountsExhausted(t *testing.T) {
+	router, primary, fallback := setupFailoverRouter(t)
+	router.authManager.SetQuotaTracker(&exhaustedQuotaTracker{exhausted: map[string]bool{"acc-1": true}}, nil)
+
+	resp, err := router.Execute(context.Background(), Request{Model: "claude-sonnet-4-5", Payload: []byte(failoverClaudePayload)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(primary.calls) != 0 {
+		t.Errorf("primary calls = %v, want none", primary.calls)
+	}
+	if fmt.Sprint(fallback.accounts) != "[glm-1]" {
+		t.Fatalf("fallback accounts = %v, want [glm-1]", fallback.accounts)
+	}
+	if got := gjson.GetBytes(resp.Payload, "content.0.text").String(); got != "Hi" {
+		t.Errorf("response text = %q, want Hi (payload %s)", got, resp.Payload)
+	}
+}
+
+func TestExecute_NoFailoverWhilePrimaryAvailable(t *testing.T) {
+	router, primary, fallback := setupFailoverRouter(t)
+
+	resp, err := router.Execute(context.Background(), Request{Model: "claude-sonnet-4-5", Payload: []byte(failoverClaudePayload)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != `{"ok":true}` {
+		t.Errorf("payload = %s, want the primary response", resp.Payload)
+	}
+	if fmt.Sprint(primary.calls) != "[acc-1]" || len(fallback.accounts) != 0 {
+		t.Errorf("primary calls = %v, fallback accounts = %v, want primary only", primary.calls, fallback.accounts)
+	}
+}
+
+func TestExecute_FailoverKeepsOpenAIFormat(t *testing.T) {
+	router, _, fallback := setupFailoverRouter(t)
+	router.authManager.MarkResult("acc-1", "claude-sonnet-4-5", http.StatusTooManyRequests, nil, nil)
+
+	payload := `{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"Hello"}]}`
+	resp, err := router.Execute(context.Background(), Request{Model: "claude-sonnet-4-5", Payload: []byte(payload), Format: FormatOpenAI})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// The chat-completions request is translated as OpenAI, not as Claude
+	sent := fallback.payloads[0]
+	if got := gjson.GetBytes(sent, "model").String(); got != "glm-4-plus" {
+		t.Errorf("fallback model = %q, want glm-4-plus", got)
+	}
+	if got := gjson.GetBytes(sent, "messages.0.content").String(); got != "Hello" {
+		t.Errorf("messages[0].content = %q, want Hello (payload %s)", got, sent)
+	}
+
+	// ...and the client gets the chat-completions response it asked for
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "Hi" {
+		t.Errorf("response = %s, want the OpenAI-format fallback response", resp.Payload)
+	}
+}
+
+func TestFailoverRequest_OpenAIRequestNeedsNonClaudeProvider(t *testing.T) {
+	req := Request{Model: "gpt-4o", Payload: []byte(`{"messages":[]}`), Format: FormatOpenAI}
+	if _, err := failoverRequest(&streamingProvider{}, "gemini-2.5-pro", "gemini-2.5-pro", req); err == nil {
+		t.Error("failoverRequest() should refuse an OpenAI request for a Claude-format provider")
+	}
+}
+
+func TestExecuteStream_FailsOverWhenAllAccountsBlocked(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"type":"message_stop"}`+"\n\n")
+	}))
+	defer upstream.Close()
+
+	provider := &streamingProvider{upstreamURL: upstream.URL}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+	router.SetFailover(map[string][]string{"claude-sonnet-4-5": {"gemini-2.5-pro"}})
+
+	// acc-1 is rate limited for the requested model only, so the fallback model can use it
+	router.authManager.MarkResult("acc-1", "claude-sonnet-4-5", http.StatusTooManyRequests, nil, nil)
+
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	statusCode, err := router.ExecuteStream(context.Background(), Request{Model: "claude-sonnet-4-5", Payload: []byte(failoverClaudePayload), Stream: true}, rec)
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if statusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", statusCode)
+	}
+	if !strings.Contains(rec.Body.String(), "message_stop") {
+		t.Errorf("body = %q, want the fallback stream", rec.Body.String())
+	}
+}
+
+func TestExecuteStream_FailsOverToGLMInClientFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		payload string
+	}{
+		{FormatClaude, failoverClaudePayload},
+		{FormatOpenAI, `{"model":"claude-sonnet-4-5","stream":true,"messages":[{"role":"user","content":"Hello"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			router, _, fallback := setupFailoverRouter(t)
+			router.registry.Register("antigravity", &streamingProvider{})
+			router.authManager.MarkResult("acc-1", "claude-sonnet-4-5", http.StatusTooManyRequests, nil, nil)
+
+			rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+			req := Request{Model: "claude-sonnet-4-5", Payload: []byte(tt.payload), Stream: true, Format: tt.format}
+			if _, err := router.ExecuteStream(context.Background(), req, rec); err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+
+			if fmt.Sprint(fallback.formats) != fmt.Sprintf("[%s]", tt.format) {
+				t.Fatalf("fallback stream formats = %v, want [%s]", fallback.formats, tt.format)
+			}
+			if got := gjson.GetBytes(fallback.payloads[0], "messages.#(role==\"user\").content").String(); got != "Hello" {
+				t.Errorf("fallback payload = %s, want the user message translated for GLM", fallback.payloads[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"aigateway-backend/providers"
+)
+
+// statusProvider returns queued HTTP statuses per account before succeeding
+type statusProvider struct {
+	fakeProvider
+	statuses map[string][]int
+}
+
+func (p *statusProvider) Execute(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = append(p.calls, req.Account.ID)
+	if queued := p.statuses[req.Account.ID]; len(queued) > 0 {
+		p.statuses[req.Account.ID] = queued[1:]
+		return &providers.ExecuteResponse{StatusCode: queued[0], Payload: []byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)}, nil
+	}
+	return &providers.ExecuteResponse{StatusCode: 200, Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func setupOverloadRouter(t *testing.T, statuses []int) (*RouterService, *statusProvider) {
+	prev := overloadBackoffBase
+	overloadBackoffBase = time.Millisecond
+	t.Cleanup(func() { overloadBackoffBase = prev })
+
+	provider := &statusProvider{statuses: map[string][]int{"acc-1": statuses}}
+	// acc-2 is available for a switch, but only acc-1 is managed so it is always picked first
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1", "acc-2"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+	return router, provider
+}
+
+func TestExecuteWithRetry_OverloadedBacksOffOnSameAccount(t *testing.T) {
+	router, provider := setupOverloadRouter(t, []int{529, 529})
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+
+	want := []string{"acc-1", "acc-1", "acc-1"}
+	if fmt.Sprint(provider.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v (no account switch)", provider.calls, want)
+	}
+
+	acc := router.authManager.GetAccount("acc-1")
+	if blocked, reason := acc.IsBlockedFor("gemini-2.5-pro", time.Now()); blocked || acc.Disabled {
+		t.Errorf("acc-1 blocked = %v (%s), disabled = %v, want an overload to leave the account usable", blocked, reason, acc.Disabled)
+	}
+}
+
+func TestExecuteWithRetry_PersistentOverloadDoesNotSwitch(t *testing.T) {
+	router, provider := setupOverloadRouter(t, []int{529, 529, 529, 529, 529})
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("Execute() should fail while the upstream stays overloaded")
+	}
+	if resp.StatusCode != 529 {
+		t.Errorf("StatusCode = %d, want 529", resp.StatusCode)
+	}
+
+	// One attempt plus MaxRetries backoff retries, all on acc-1
+	for _, id := range provider.calls {
+		if id != "acc-1" {
+			t.Fatalf("calls = %v, want every attempt on acc-1", provider.calls)
+		}
+	}
+	if want := 1 + router.config.MaxRetries; len(provider.calls) != want {
+		t.Errorf("attempts = %d, want %d", len(provider.calls), want)
+	}
+}
+
+func TestExecuteWithRetry_OverloadBackoffIsJittered(t *testing.T) {
+	router, _ := setupOverloadRouter(t, []int{529, 529})
+
+	var waits []time.Duration
+	router.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	router.jitter = func(time.Duration) time.Duration { return 0 }
+
+	if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// No jitter leaves half of each doubled delay
+	want := []time.Duration{overloadBackoffBase / 2, overloadBackoffBase}
+	if fmt.Sprint(waits) != fmt.Sprint(want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"aigateway-backend/auth/manager"
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+	"aigateway-backend/repositories"
+
+	"gorm.io/gorm"
+)
+
+// fakeProvider returns scripted results per account and records execution order
+type fakeProvider struct {
+	mu       sync.Mutex
+	calls    []string
+	failures map[string][]error // Errors returned for each account before succeeding
+	reject   error              // Returned by CheckRequest, nil = every request accepted
+	sent     []byte             // Reported as the translated body sent upstream, nil = none
+}
+
+func (p *fakeProvider) ID() string                { return "antigravity" }
+func (p *fakeProvider) Name() string              { return "Fake" }
+func (p *fakeProvider) AuthStrategy() string      { return "oauth" }
+func (p *fakeProvider) SupportedModels() []string { return []string{"gemini-2.5-pro"} }
+func (p *fakeProvider) SupportsStreaming() bool   { return false }
+
+func (p *fakeProvider) TranslateRequest(format string, payload []byte, model string) ([]byte, error) {
+	return payload, nil
+}
+
+func (p *fakeProvider) TranslateResponse(payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func (p *fakeProvider) CheckRequest(payload []byte, model string) error {
+	return p.reject
+}
+
+func (p *fakeProvider) Execute(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls = append(p.calls, req.Account.ID)
+	if errs := p.failures[req.Account.ID]; len(errs) > 0 {
+		p.failures[req.Account.ID] = errs[1:]
+		return nil, errs[0]
+	}
+	return &providers.ExecuteResponse{StatusCode: 200, Payload: []byte(`{"ok":true}`), SentPayload: p.sent}, nil
+}
+
+func (p *fakeProvider) ExecuteStream(ctx context.Context, req *providers.ExecuteRequest) (*providers.StreamResponse, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+// connResetError mimics the error returned by net/http when the peer resets the connection
+func connResetError() error {
+	return fmt.Errorf("HTTP request failed: %w", &net.OpError{
+		Op:  "read",
+		Net: "tcp",
+		Err: os.NewSyscallError("read", syscall.ECONNRESET),
+	})
+}
+
+// createAccountsTable creates the accounts table plus the tables it preloads
+// Created directly since SQLite doesn't support the ENUM columns on related tables.
+func createAccountsTable(t *testing.T, db *gorm.DB) {
+	err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS accounts (
+			id TEXT PRIMARY KEY,
+			provider_id TEXT NOT NULL,
+			label TEXT NOT NULL,
+			auth_data TEXT NOT NULL,
+			metadata TEXT,
+			is_active BOOLEAN DEFAULT 1,
+			proxy_url TEXT,
+			proxy_id INTEGER,
+			expires_at DATETIME,
+			last_used_at DATETIME,
+			usage_count INTEGER DEFAULT 0,
+			allowed_models TEXT,
+			denied_models TEXT,
+			health_status TEXT DEFAULT 'healthy',
+			failure_count INTEGER DEFAULT 0,
+			last_error_at DATETIME,
+			last_error_msg TEXT,
+			last_success_at DATETIME,
+			created_at DATETIME,
+			updated_at DATETIME,
+			created_by TEXT
+		)
+	`).Error
+	if err == nil {
+		err = db.Exec(`CREATE TABLE IF NOT EXISTS providers (id TEXT PRIMARY KEY, name TEXT)`).Error
+	}
+	if err != nil {
+		t.Fatalf("failed to create accounts table: %v", err)
+	}
+}
+
+// setupRetryRouter builds a router using the AuthManager path with a scripted provider
+// Every account is registered with the AuthManager; accounts outside preferred get a lower
+// priority, so they are only picked once the preferred ones are unavailable or excluded.
+func setupRetryRouter(t *testing.T, provider *fakeProvider, accountIDs []string, preferred []string) *RouterService {
+	router, _ := setupRetryRouterDB(t, provider, accountIDs, preferred)
+	return router
+}
+
+// setupRetryRouterDB is setupRetryRouter that also returns the accounts database
+// The AuthManager reads accounts from it, so more can be loaded with LoadAccounts.
+func setupRetryRouterDB(t *testing.T, provider *fakeProvider, accountIDs []string, preferred []string) (*RouterService, *gorm.DB) {
+	db := setupTestDB(t)
+	createAccountsTable(t, db)
+	mr, redisClient := setupTestRedis(t)
+	t.Cleanup(mr.Close)
+
+	isPreferred := make(map[string]bool, len(preferred))
+	for _, id := range preferred {
+		isPreferred[id] = true
+	}
+
+	authData := fmt.Sprintf(`{"access_token":"token","expires_at":"%s"}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	accounts := make([]*models.Account, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		metadata := "{}"
+		if !isPreferred[id] {
+			metadata = `{"priority":-1}`
+		}
+		acc := &models.Account{ID: id, ProviderID: "antigravity", Label: id, AuthData: authData, Metadata: metadata, IsActive: true}
+		if err := db.Create(acc).Error; err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+		accounts = append(accounts, acc)
+	}
+
+	registry := providers.NewRegistry()
+	registry.Register("antigravity", provider)
+
+	accountRepo := repositories.NewAccountRepository(db)
+	authManager := manager.NewManager(accountRepo, redisClient)
+	authManager.SetLogging(false)
+	for _, acc := range accounts {
+		authManager.AddAccount(acc)
+	}
+
+	router := NewRouterService(
+		registry,
+		nil,
+		NewAccountService(accountRepo, redisClient),
+		nil,
+		nil,
+		NewOAuthService(redisClient, accountRepo, nil, nil),
+		NewStatsTrackerService(repositories.NewStatsRepository(db), nil, redisClient, nil),
+	)
+	router.SetAuthManager(authManager)
+	router.EnableAuthManager(true)
+	return router, db
+}
+
+func TestExecuteWithRetry_ConnectionResetSwitchesAccount(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-1": {connResetError()},
+	}}
+	router := setupRetryRouter(t, provider, []string{"acc-1", "acc-2"}, []string{"acc-1"})
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+
+	want := []string{"acc-1", "acc-2"}
+	if fmt.Sprint(provider.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", provider.calls, want)
+	}
+}
+
+func TestExecuteWithRetry_SwitchReleasesOnlyAcquiredSlots(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-1": {connResetError()},
+	}}
+	router := setupRetryRouter(t, provider, []string{"acc-1", "acc-2"}, []string{"acc-1"})
+
+	// Another request is in flight on acc-2 throughout
+	if _, err := router.authManager.SelectPinned("antigravity", "acc-2", "gemini-2.5-pro"); err != nil {
+		t.Fatalf("SelectPinned() error = %v", err)
+	}
+
+	if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	inFlight := router.authManager.GetMetrics().GetInFlight()
+	if inFlight["acc-1"] != 0 || inFlight["acc-2"] != 1 {
+		t.Errorf("in-flight = %v, want acc-1=0 and acc-2=1 (the other request's slot kept)", inFlight)
+	}
+}
+
+func TestExecuteWithRetry_SwitchHonorsExcludedAccounts(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-1": {connResetError()},
+	}}
+	router := setupRetryRouter(t, provider, []string{"acc-1", "acc-2", "acc-3"}, []string{"acc-1"})
+
+	ctx := manager.WithExcludedAccounts(context.Background(), []string{"acc-2"})
+	if _, err := router.Execute(ctx, Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if want := []string{"acc-1", "acc-3"}; fmt.Sprint(provider.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v (acc-2 excluded from the switch)", provider.calls, want)
+	}
+}
+
+func TestExecuteWithRetry_ConnectionResetRetriesWithoutAlternative(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-1": {connResetError()},
+	}}
+	router := setupRetryRouter(t, provider, []string{"acc-1"}, []string{"acc-1"})
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+	}
+	if len(provider.calls) != 2 {
+		t.Errorf("calls = %v, want 2 attempts on acc-1", provider.calls)
+	}
+}
+
+func TestExecute_RejectedRequestFailsBeforeSelection(t *testing.T) {
+	provider := &fakeProvider{reject: errors.New("server tool web_fetch is not supported")}
+	router := setupRetryRouter(t, provider, []string{"acc-1", "acc-2"}, []string{"acc-1"})
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("Execute() error = nil, want the provider's rejection")
+	}
+	if resp.StatusCode != 400 {
+		t.Errorf("StatusCode = %d, want 400", resp.StatusCode)
+	}
+	if len(provider.calls) != 0 {
+		t.Errorf("calls = %v, want none", provider.calls)
+	}
+	for _, id := range []string{"acc-1", "acc-2"} {
+		if inFlight := router.authManager.GetAccount(id).InFlight(); inFlight != 0 {
+			t.Errorf("%s: in-flight = %d, want no account acquired", id, inFlight)
+		}
+	}
+}
+
+func TestShouldRetry_TransportErrors(t *testing.T) {
+	router := &RouterService{config: DefaultRouterConfig()}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection reset", connResetError(), true},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{"timeout", fmt.Errorf("HTTP request failed: %w", context.DeadlineExceeded), true},
+		{"canceled", fmt.Errorf("HTTP request failed: %w", context.Canceled), false},
+		{"other", errors.New("failed to get access token: no refresh token"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := router.shouldRetry(0, tt.err, 0); got != tt.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	router.SetRetryOnTransportErrors(false)
+	if router.shouldRetry(0, connResetError(), 0) {
+		t.Error("shouldRetry() should be false when transport retries are disabled")
+	}
+}
+
+func TestExecuteWithRetry_PinnedAccountBypassesBalancing(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-2": {connResetError()},
+	}}
+	accounts := []string{"acc-1", "acc-2", "acc-3"}
+	router := setupRetryRouter(t, provider, accounts, accounts)
+
+	for i := 0; i < 3; i++ {
+		resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`), AccountID: "acc-2"})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if resp.StatusCode != 200 {
+			t.Errorf("StatusCode = %d, want 200", resp.StatusCode)
+		}
+	}
+
+	// The connection reset is retried on the pinned account instead of switching
+	want := []string{"acc-2", "acc-2", "acc-2", "acc-2"}
+	if fmt.Sprint(provider.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", provider.calls, want)
+	}
+}
+
+func TestExecuteWithRetry_PinnedAccountMustBeUsable(t *testing.T) {
+	provider := &fakeProvider{}
+	router := setupRetryRouter(t, provider, []string{"acc-1"}, []string{"acc-1"})
+
+	if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`), AccountID: "missing"}); err == nil {
+		t.Error("Execute() pinned to an unknown account should fail")
+	}
+	if len(provider.calls) != 0 {
+		t.Errorf("calls = %v, want no upstream call", provider.calls)
+	}
+}
+
+func TestRetryBackoff_GrowsWithinCap(t *testing.T) {
+	router := &RouterService{config: RouterConfig{MaxRetryWait: time.Second}}
+
+	// Upper bound of the jitter range: the plain exponential delay
+	router.jitter = func(max time.Duration) time.Duration { return max }
+	want := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for retry, w := range want {
+		if got := router.retryBackoff(retry); got != w*time.Millisecond {
+			t.Errorf("retryBackoff(%d) = %v, want %v", retry, got, w*time.Millisecond)
+		}
+	}
+
+	// Lower bound: half the delay
+	router.jitter = func(time.Duration) time.Duration { return 0 }
+	if got := router.retryBackoff(10); got != 500*time.Millisecond {
+		t.Errorf("retryBackoff(10) with no jitter = %v, want 500ms", got)
+	}
+
+	// Random jitter always stays within [delay/2, cap]
+	router.jitter = randomJitter
+	for retry := 0; retry < 20; retry++ {
+		if got := router.retryBackoff(retry); got < retryBackoffBase/2 || got > time.Second {
+			t.Errorf("retryBackoff(%d) = %v, outside [%v, 1s]", retry, got, retryBackoffBase/2)
+		}
+	}
+}
+
+func TestExecuteWithRetry_SleepsWithBackoff(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{
+		"acc-1": {connResetError(), connResetError()},
+	}}
+	router := setupRetryRouter(t, provider, []string{"acc-1"}, []string{"acc-1"})
+
+	var waits []time.Duration
+	router.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	router.jitter = func(max time.Duration) time.Duration { return max }
+
+	if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`), AccountID: "acc-1"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := []time.Duration{retryBackoffBase, 2 * retryBackoffBase}
+	if fmt.Sprint(waits) != fmt.Sprint(want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+}
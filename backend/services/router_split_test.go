@@ -0,0 +1,61 @@
+package services
+
+import "testing"
+
+func TestModelSplitter_SameSeedSameSequence(t *testing.T) {
+	splits := map[string][]SplitTarget{
+		"claude-sonnet-4-5": {{Model: "claude-sonnet-4-5", Weight: 70}, {Model: "gpt-5", Weight: 30}},
+	}
+	a := NewModelSplitter(splits, 42)
+	b := NewModelSplitter(splits, 42)
+
+	for i := 0; i < 50; i++ {
+		got, want := a.Resolve("claude-sonnet-4-5"), b.Resolve("claude-sonnet-4-5")
+		if got != want {
+			t.Fatalf("pick %d = %q, want %q for the same seed", i, got, want)
+		}
+	}
+}
+
+func TestModelSplitter_RespectsWeights(t *testing.T) {
+	splits := map[string][]SplitTarget{
+		"alias": {{Model: "a", Weight: 80}, {Model: "b", Weight: 20}, {Model: "never", Weight: 0}},
+	}
+	s := NewModelSplitter(splits, 7)
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[s.Resolve("alias")]++
+	}
+
+	if counts["never"] != 0 {
+		t.Errorf("zero-weight target picked %d times", counts["never"])
+	}
+	if counts["a"] < 7500 || counts["a"] > 8500 {
+		t.Errorf("target a picked %d/10000 times, want ~8000", counts["a"])
+	}
+	if counts["a"]+counts["b"] != 10000 {
+		t.Errorf("picks = %v, want only a and b", counts)
+	}
+}
+
+func TestModelSplitter_UnsplitModelUnchanged(t *testing.T) {
+	s := NewModelSplitter(map[string][]SplitTarget{"alias": {{Model: "a", Weight: 1}}}, 1)
+
+	if got := s.Resolve("claude-opus-4-5"); got != "claude-opus-4-5" {
+		t.Errorf("Resolve = %q, want model unchanged", got)
+	}
+}
+
+func TestResolveModelSplit_DisabledByDefault(t *testing.T) {
+	router := &RouterService{}
+
+	if got := router.ResolveModelSplit("alias"); got != "alias" {
+		t.Errorf("ResolveModelSplit = %q, want alias without a splitter", got)
+	}
+
+	router.SetModelSplitter(NewModelSplitter(map[string][]SplitTarget{"alias": {{Model: "a", Weight: 1}}}, 1))
+	if got := router.ResolveModelSplit("alias"); got != "a" {
+		t.Errorf("ResolveModelSplit = %q, want a", got)
+	}
+}
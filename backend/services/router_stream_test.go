@@ -0,0 +1,378 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aigateway-backend/providers"
+)
+
+// streamingProvider streams SSE events from a fake upstream server line by line
+type streamingProvider struct {
+	fakeProvider
+	upstreamURL string
+}
+
+func (p *streamingProvider) SupportsStreaming() bool    { return true }
+func (p *streamingProvider) ExecutesClaudeFormat() bool { return true }
+
+func (p *streamingProvider) ExecuteStream(ctx context.Context, req *providers.ExecuteRequest) (*providers.StreamResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.upstreamURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	dataCh := make(chan []byte, 10)
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(dataCh)
+		defer close(errCh)
+		defer close(done)
+		defer httpResp.Body.Close()
+
+		scanner := bufio.NewScanner(httpResp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				dataCh <- []byte(line + "\n\n")
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return &providers.StreamResponse{StatusCode: httpResp.StatusCode, DataCh: dataCh, ErrCh: errCh, Done: done}, nil
+}
+
+// flushRecorder reports the body written so far on every flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes chan string
+}
+
+func (r *flushRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.flushes <- r.Body.String()
+}
+
+func TestExecuteStream_ForwardsChunksIncrementally(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"type":"message_start","message":{"usage":{"input_tokens":7}}}`+"\n\n")
+		w.(http.Flusher).Flush()
+
+		<-release
+		fmt.Fprint(w, `data: {"type":"message_delta","usage":{"output_tokens":5}}`+"\n\n")
+	}))
+	defer upstream.Close()
+
+	provider := &streamingProvider{upstreamURL: upstream.URL}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	result := make(chan error, 1)
+	go func() {
+		_, err := router.ExecuteStream(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}, rec)
+		result <- err
+	}()
+
+	// The first event must reach the client while the upstream is still holding the second one
+	deadline := time.After(5 * time.Second)
+	for firstSeen := false; !firstSeen; {
+		select {
+		case body := <-rec.flushes:
+			firstSeen = strings.Contains(body, "message_start")
+			if strings.Contains(body, "message_delta") {
+				t.Fatal("second chunk arrived before the upstream sent it")
+			}
+		case <-deadline:
+			t.Fatal("first chunk was not flushed before the stream completed")
+		}
+	}
+	close(release)
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("ExecuteStream() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not complete")
+	}
+
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	if !strings.Contains(rec.Body.String(), "message_delta") {
+		t.Errorf("body missing second chunk: %s", rec.Body.String())
+	}
+
+	state := router.authManager.GetAccount("acc-1").GetModelState("gemini-2.5-pro")
+	if state.SuccessCount != 1 {
+		t.Errorf("SuccessCount = %d, want 1 (MarkResult on completion)", state.SuccessCount)
+	}
+}
+
+// recordingQuotaTracker captures RecordUsage calls; every account is available with unlearned limits
+type recordingQuotaTracker struct {
+	mu     sync.Mutex
+	tokens map[string]int64
+}
+
+func (r *recordingQuotaTracker) RecordUsage(accountID, model string, tokens int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[accountID+"/"+model] += tokens
+}
+func (r *recordingQuotaTracker) MarkExhausted(accountID, model string)    {}
+func (r *recordingQuotaTracker) IsAvailable(accountID, model string) bool { return true }
+func (r *recordingQuotaTracker) GetEarliestReset(accountIDs []string, model string) *time.Time {
+	return nil
+}
+func (r *recordingQuotaTracker) RemainingHeadroom(accountID, model string) (int, bool) {
+	return 0, false
+}
+
+func TestExecuteStream_RecordsUsageMetadataFromFinalChunk(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"response":{"candidates":[{"content":{"parts":[{"text":"Hel"}]}}]}}`+"\n\n")
+		fmt.Fprint(w, `data: {"response":{"candidates":[{"content":{"parts":[{"text":"lo"}]}}]}}`+"\n\n")
+		fmt.Fprint(w, `data: {"response":{"candidates":[{"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":30}}}`+"\n\n")
+	}))
+	defer upstream.Close()
+
+	provider := &streamingProvider{upstreamURL: upstream.URL}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+
+	tracker := &recordingQuotaTracker{tokens: make(map[string]int64)}
+	router.authManager.SetQuotaTracker(tracker, NewTokenExtractor())
+
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	if _, err := router.ExecuteStream(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}, rec); err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	if got := tracker.tokens["acc-1/gemini-2.5-pro"]; got != 42 {
+		t.Errorf("RecordUsage tokens = %d, want 42 (prompt 12 + candidates 30)", got)
+	}
+}
+
+func TestStreamUsage_Summary(t *testing.T) {
+	usage := &streamUsage{}
+	usage.observe([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":7}}}\n\n"))
+	usage.observe(frameSSE([]byte(`{"usage":{"completion_tokens":5}}`)))
+
+	tokens := NewTokenExtractor().ExtractTokens("antigravity", usage.summary())
+	if tokens != 12 {
+		t.Errorf("ExtractTokens() = %d, want 12", tokens)
+	}
+
+	if (&streamUsage{}).summary() != nil {
+		t.Error("summary() should be nil when no usage was reported")
+	}
+}
+
+func TestExecuteStream_PingsDuringUpstreamPause(t *testing.T) {
+	tests := []struct {
+		format string
+		ping   string
+	}{
+		{FormatClaude, "event: ping\ndata: {\"type\": \"ping\"}\n\n"},
+		{FormatOpenAI, ": ping\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			release := make(chan struct{})
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, `data: {"type":"message_start","message":{"usage":{"input_tokens":7}}}`+"\n\n")
+				w.(http.Flusher).Flush()
+
+				// Silent while the model "thinks"
+				<-release
+				fmt.Fprint(w, `data: {"type":"message_delta","usage":{"output_tokens":5}}`+"\n\n")
+			}))
+			defer upstream.Close()
+
+			provider := &streamingProvider{upstreamURL: upstream.URL}
+			router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+			router.registry.Register("antigravity", provider)
+			router.SetStreamPingInterval(10 * time.Millisecond)
+
+			rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 64)}
+			result := make(chan error, 1)
+			go func() {
+				_, err := router.ExecuteStream(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`), Format: tt.format}, rec)
+				result <- err
+			}()
+
+			// Wait for two pings after message_start while the upstream is paused
+			deadline := time.After(5 * time.Second)
+			for pinged := false; !pinged; {
+				select {
+				case body := <-rec.flushes:
+					if strings.Contains(body, "message_delta") {
+						t.Fatal("upstream resumed before it was released")
+					}
+					_, afterStart, _ := strings.Cut(body, "message_start")
+					pinged = strings.Count(afterStart, tt.ping) >= 2
+				case <-deadline:
+					t.Fatal("no ping events were written during the upstream pause")
+				}
+			}
+			close(release)
+
+			// Drain flushes so the recorder never blocks the stream
+			go func() {
+				for range rec.flushes {
+				}
+			}()
+
+			select {
+			case err := <-result:
+				if err != nil {
+					t.Fatalf("ExecuteStream() error = %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("stream did not complete")
+			}
+
+			body := rec.Body.String()
+			if !strings.HasSuffix(strings.TrimSpace(body), `data: {"type":"message_delta","usage":{"output_tokens":5}}`) {
+				t.Errorf("stream should end with the upstream's last chunk, got: %s", body)
+			}
+			if tt.format != FormatClaude && strings.Contains(body, "event: ping") {
+				t.Errorf("%s stream got Anthropic ping events: %s", tt.format, body)
+			}
+		})
+	}
+}
+
+func TestExecuteStream_NoPingsWhenDisabled(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"type":"message_start","message":{"usage":{"input_tokens":7}}}`+"\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, `data: {"type":"message_delta","usage":{"output_tokens":5}}`+"\n\n")
+	}))
+	defer upstream.Close()
+
+	provider := &streamingProvider{upstreamURL: upstream.URL}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 64)}
+	if _, err := router.ExecuteStream(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}, rec); err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	if strings.Contains(rec.Body.String(), "ping") {
+		t.Errorf("ping written with pings disabled: %s", rec.Body.String())
+	}
+}
+
+func TestExecuteStream_ClientDisconnectIsNeutral(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"type":"message_start","message":{"usage":{"input_tokens":7}}}`+"\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	provider := &streamingProvider{upstreamURL: upstream.URL}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	result := make(chan error, 1)
+	go func() {
+		_, err := router.ExecuteStream(ctx, Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}, rec)
+		result <- err
+	}()
+
+	// Disconnect once the first chunk reached the client
+	for body := ""; !strings.Contains(body, "message_start"); body = <-rec.flushes {
+	}
+	cancel()
+
+	select {
+	case <-result:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after the client disconnected")
+	}
+
+	if ms := router.authManager.GetAccount("acc-1").GetModelState("gemini-2.5-pro"); ms.FailureCount != 0 {
+		t.Errorf("FailureCount = %d after a client disconnect, want 0", ms.FailureCount)
+	}
+	for _, status := range router.authManager.Candidates("antigravity", "gemini-2.5-pro") {
+		if !status.Eligible || status.InFlight != 0 {
+			t.Errorf("account %s after disconnect: eligible=%v reason=%q in-flight=%d, want eligible with no in-flight",
+				status.AccountID, status.Eligible, status.Reason, status.InFlight)
+		}
+	}
+}
+
+func TestExecuteStream_RetryBacksOff(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"type":"message_stop"}`+"\n\n")
+	}))
+	defer upstream.Close()
+
+	provider := &streamingProvider{upstreamURL: upstream.URL}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1", "acc-2"}, []string{"acc-1", "acc-2"})
+	router.registry.Register("antigravity", provider)
+
+	var waits []time.Duration
+	router.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	router.jitter = func(max time.Duration) time.Duration { return max }
+
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	if _, err := router.ExecuteStream(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}, rec); err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+
+	if want := []time.Duration{retryBackoffBase}; fmt.Sprint(waits) != fmt.Sprint(want) {
+		t.Errorf("waits = %v, want %v before retrying the stream", waits, want)
+	}
+}
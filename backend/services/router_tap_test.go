@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// tapEvent captures a single RequestTap call
+type tapEvent struct {
+	providerID string
+	model      string
+	request    string
+	statusCode int
+	response   string
+}
+
+// chanTap forwards tap calls to a channel
+type chanTap chan tapEvent
+
+func (c chanTap) Tap(providerID, model string, request []byte, statusCode int, response []byte) {
+	c <- tapEvent{providerID, model, string(request), statusCode, string(response)}
+}
+
+func TestRequestTap_ReceivesPayloads(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{}}
+	router := setupRetryRouter(t, provider, []string{"acc-1"}, []string{"acc-1"})
+
+	tap := make(chanTap, 1)
+	router.SetRequestTap(tap)
+	router.EnableRequestTap(true)
+
+	_, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{"messages":[]}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	select {
+	case ev := <-tap:
+		if ev.providerID != "antigravity" || ev.model != "gemini-2.5-pro" {
+			t.Errorf("provider/model = %s/%s, want antigravity/gemini-2.5-pro", ev.providerID, ev.model)
+		}
+		if ev.request != `{"messages":[]}` {
+			t.Errorf("request = %s", ev.request)
+		}
+		if ev.statusCode != 200 || ev.response != `{"ok":true}` {
+			t.Errorf("status/response = %d/%s, want 200/{\"ok\":true}", ev.statusCode, ev.response)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tap was not called")
+	}
+}
+
+func TestRequestTap_ReceivesTranslatedPayload(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{}, sent: []byte(`{"request":{"contents":[]}}`)}
+	router := setupRetryRouter(t, provider, []string{"acc-1"}, []string{"acc-1"})
+
+	tap := make(chanTap, 1)
+	router.SetRequestTap(tap)
+	router.EnableRequestTap(true)
+
+	if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{"messages":[]}`)}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	select {
+	case ev := <-tap:
+		if ev.request != `{"request":{"contents":[]}}` {
+			t.Errorf("request = %s, want the body the provider sent upstream", ev.request)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tap was not called")
+	}
+}
+
+func TestRequestTap_DisabledByDefault(t *testing.T) {
+	provider := &fakeProvider{failures: map[string][]error{}}
+	router := setupRetryRouter(t, provider, []string{"acc-1"}, []string{"acc-1"})
+
+	tap := make(chanTap, 1)
+	router.SetRequestTap(tap)
+
+	if _, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	select {
+	case ev := <-tap:
+		t.Errorf("tap called while disabled: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
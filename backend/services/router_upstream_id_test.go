@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+	"aigateway-backend/providers"
+	"aigateway-backend/repositories"
+)
+
+// headerProvider returns a successful response carrying fixed upstream headers
+type headerProvider struct {
+	fakeProvider
+	headers http.Header
+}
+
+func (p *headerProvider) Execute(ctx context.Context, req *providers.ExecuteRequest) (*providers.ExecuteResponse, error) {
+	return &providers.ExecuteResponse{StatusCode: 200, Payload: []byte(`{"ok":true}`), Headers: p.headers}, nil
+}
+
+func TestExecute_CapturesUpstreamRequestID(t *testing.T) {
+	provider := &headerProvider{headers: http.Header{"Request-Id": []string{"req_011CabcXYZ"}}}
+	router := setupRetryRouter(t, &provider.fakeProvider, []string{"acc-1"}, []string{"acc-1"})
+	router.registry.Register("antigravity", provider)
+
+	db := setupTestDB(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1) // Keep async writes on the same in-memory database
+	if err := db.AutoMigrate(&models.RequestLog{}); err != nil {
+		t.Fatalf("failed to migrate request_logs: %v", err)
+	}
+	router.statsTrackerService = NewStatsTrackerService(repositories.NewStatsRepository(db), nil, nil, nil)
+
+	resp, err := router.Execute(context.Background(), Request{Model: "gemini-2.5-pro", Payload: []byte(`{}`)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := providers.UpstreamRequestID(resp.Headers); got != "req_011CabcXYZ" {
+		t.Errorf("response upstream request ID = %q, want req_011CabcXYZ", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := DrainAsyncWrites(ctx); err != nil {
+		t.Fatalf("DrainAsyncWrites() error = %v", err)
+	}
+
+	var logs []models.RequestLog
+	db.Find(&logs)
+	if len(logs) != 1 || logs[0].UpstreamRequestID != "req_011CabcXYZ" {
+		t.Errorf("request logs = %+v, want one entry with upstream_request_id req_011CabcXYZ", logs)
+	}
+}
+
+func TestUpstreamRequestID_Headers(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		want    string
+	}{
+		{"anthropic", http.Header{"Request-Id": []string{"req_1"}}, "req_1"},
+		{"google", http.Header{"X-Request-Id": []string{"g-2"}}, "g-2"},
+		{"none", http.Header{"Content-Type": []string{"application/json"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := providers.UpstreamRequestID(tt.headers); got != tt.want {
+				t.Errorf("UpstreamRequestID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Stream responses carry headers as a plain map with arbitrary casing
+	if got := providers.StreamUpstreamRequestID(map[string]string{"x-request-id": "s-3"}); got != "s-3" {
+		t.Errorf("StreamUpstreamRequestID() = %q, want s-3", got)
+	}
+}
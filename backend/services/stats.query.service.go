@@ -30,6 +30,16 @@ func (s *StatsQueryService) GetProxyStatsRange(proxyID int, startDate, endDate s
 	return s.repo.GetProxyStatsRange(proxyID, startDate, endDate)
 }
 
+// GetLatencyPercentiles computes request latency percentiles over the last window
+// Empty accountID or model aggregate across all accounts or models.
+func (s *StatsQueryService) GetLatencyPercentiles(accountID, model string, window time.Duration) (*models.LatencyPercentiles, error) {
+	return s.repo.GetLatencyPercentiles(repositories.LatencyFilter{
+		AccountID: accountID,
+		Model:     model,
+		Since:     time.Now().Add(-window),
+	})
+}
+
 // GetRecentLogs retrieves the most recent request logs up to the specified limit
 func (s *StatsQueryService) GetRecentLogs(limit int) ([]*models.RequestLog, error) {
 	return s.repo.GetRecentRequestLogs(limit)
@@ -5,11 +5,15 @@ import (
 	"aigateway-backend/repositories"
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// DefaultLogRetentionDays is how long request logs are kept when retention isn't configured
+const DefaultLogRetentionDays = 30
+
 // StatsTrackerService handles recording and tracking of request statistics
 type StatsTrackerService struct {
 	repo        *repositories.StatsRepository
@@ -34,35 +38,38 @@ func NewStatsTrackerService(
 }
 
 // RecordRequest records a successful or failed request with all relevant metrics
-func (s *StatsTrackerService) RecordRequest(accountID *string, proxyID *int, providerID *string, model string, statusCode, latencyMs int) {
+// upstreamRequestID is the provider's request ID from the response headers ("" if none).
+func (s *StatsTrackerService) RecordRequest(accountID *string, proxyID *int, providerID *string, model string, statusCode, latencyMs int, upstreamRequestID string) {
 	// Create request log
 	log := &models.RequestLog{
-		AccountID:  accountID,
-		ProxyID:    proxyID,
-		ProviderID: providerID,
-		Model:      model,
-		StatusCode: statusCode,
-		LatencyMs:  latencyMs,
-		CreatedAt:  time.Now(),
+		AccountID:         accountID,
+		ProxyID:           proxyID,
+		ProviderID:        providerID,
+		Model:             model,
+		StatusCode:        statusCode,
+		LatencyMs:         latencyMs,
+		UpstreamRequestID: upstreamRequestID,
+		CreatedAt:         time.Now(),
 	}
 
 	// Store log in database
-	go s.repo.CreateRequestLog(log)
+	goAsync(func() { s.repo.CreateRequestLog(log) })
 
 	// Update proxy stats if proxy was used
 	if proxyID != nil {
+		id := *proxyID
 		success := statusCode >= 200 && statusCode < 300
-		go s.repo.IncrementProxyStats(*proxyID, providerID, success, latencyMs)
+		goAsync(func() { s.repo.IncrementProxyStats(id, providerID, success, latencyMs) })
 
 		// Update proxy health status
 		if success {
-			go s.healthService.MarkHealthy(*proxyID, latencyMs)
+			goAsync(func() { s.healthService.MarkHealthy(id, latencyMs) })
 		} else {
-			go s.healthService.MarkDegraded(*proxyID, latencyMs)
+			goAsync(func() { s.healthService.MarkDegraded(id, latencyMs) })
 		}
 
 		// Update Redis counters for real-time stats
-		s.updateRedisCounters(*proxyID, success)
+		s.updateRedisCounters(id, success)
 	}
 }
 
@@ -77,11 +84,12 @@ func (s *StatsTrackerService) RecordFailure(accountID *string, proxyID *int, lat
 		CreatedAt: time.Now(),
 	}
 
-	go s.repo.CreateRequestLog(log)
+	goAsync(func() { s.repo.CreateRequestLog(log) })
 
 	// Mark proxy as down if failure occurred
 	if proxyID != nil {
-		go s.healthService.MarkDown(*proxyID, latencyMs)
+		id := *proxyID
+		goAsync(func() { s.healthService.MarkDown(id, latencyMs) })
 	}
 }
 
@@ -125,11 +133,35 @@ func (s *StatsTrackerService) GetTodayErrorCount(proxyID int) (int64, error) {
 }
 
 // CleanupOldLogs removes request logs older than the specified number of days
-func (s *StatsTrackerService) CleanupOldLogs(days int) error {
+// Returns the number of deleted rows.
+func (s *StatsTrackerService) CleanupOldLogs(days int) (int64, error) {
 	before := time.Now().AddDate(0, 0, -days)
 	return s.repo.DeleteOldLogs(before)
 }
 
+// StartLogCleanupRoutine purges request logs older than retentionDays once at startup and then hourly
+// retentionDays <= 0 falls back to DefaultLogRetentionDays.
+func (s *StatsTrackerService) StartLogCleanupRoutine(retentionDays int) {
+	if retentionDays <= 0 {
+		retentionDays = DefaultLogRetentionDays
+	}
+
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			purged, err := s.CleanupOldLogs(retentionDays)
+			if err != nil {
+				log.Printf("[StatsTracker] Failed to purge request logs: %v", err)
+			} else if purged > 0 {
+				log.Printf("[StatsTracker] Purged %d request logs older than %d days", purged, retentionDays)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
 // RecordRequestWithRetry records a request with retry and account switch information
 func (s *StatsTrackerService) RecordRequestWithRetry(
 	accountID *string,
@@ -139,6 +171,7 @@ func (s *StatsTrackerService) RecordRequestWithRetry(
 	statusCode, latencyMs int,
 	retryCount int,
 	switchedFromAccountID *string,
+	upstreamRequestID string,
 ) {
 	log := &models.RequestLog{
 		AccountID:             accountID,
@@ -149,22 +182,24 @@ func (s *StatsTrackerService) RecordRequestWithRetry(
 		LatencyMs:             latencyMs,
 		RetryCount:            retryCount,
 		SwitchedFromAccountID: switchedFromAccountID,
+		UpstreamRequestID:     upstreamRequestID,
 		CreatedAt:             time.Now(),
 	}
 
-	go s.repo.CreateRequestLog(log)
+	goAsync(func() { s.repo.CreateRequestLog(log) })
 
 	if proxyID != nil {
+		id := *proxyID
 		success := statusCode >= 200 && statusCode < 300
-		go s.repo.IncrementProxyStats(*proxyID, providerID, success, latencyMs)
+		goAsync(func() { s.repo.IncrementProxyStats(id, providerID, success, latencyMs) })
 
 		if success {
-			go s.healthService.MarkHealthy(*proxyID, latencyMs)
+			goAsync(func() { s.healthService.MarkHealthy(id, latencyMs) })
 		} else {
-			go s.healthService.MarkDegraded(*proxyID, latencyMs)
+			goAsync(func() { s.healthService.MarkDegraded(id, latencyMs) })
 		}
 
-		s.updateRedisCounters(*proxyID, success)
+		s.updateRedisCounters(id, success)
 	}
 
 	// Track retry and switch metrics in Redis
@@ -196,10 +231,11 @@ func (s *StatsTrackerService) RecordFailureWithRetry(
 		CreatedAt:             time.Now(),
 	}
 
-	go s.repo.CreateRequestLog(log)
+	goAsync(func() { s.repo.CreateRequestLog(log) })
 
 	if proxyID != nil {
-		go s.healthService.MarkDown(*proxyID, latencyMs)
+		id := *proxyID
+		goAsync(func() { s.healthService.MarkDown(id, latencyMs) })
 	}
 
 	if retryCount > 0 {
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"aigateway-backend/models"
+	"aigateway-backend/repositories"
+)
+
+func TestCleanupOldLogs_DeletesOnlyLogsPastRetention(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&models.RequestLog{}); err != nil {
+		t.Fatalf("failed to migrate request_logs: %v", err)
+	}
+
+	now := time.Now()
+	seed := []models.RequestLog{
+		{Model: "old-1", StatusCode: 200, CreatedAt: now.AddDate(0, 0, -45)},
+		{Model: "old-2", StatusCode: 500, CreatedAt: now.AddDate(0, 0, -31)},
+		{Model: "recent-1", StatusCode: 200, CreatedAt: now.AddDate(0, 0, -29)},
+		{Model: "recent-2", StatusCode: 200, CreatedAt: now},
+	}
+	if err := db.Create(&seed).Error; err != nil {
+		t.Fatalf("failed to seed logs: %v", err)
+	}
+
+	service := NewStatsTrackerService(repositories.NewStatsRepository(db), nil, nil, nil)
+
+	purged, err := service.CleanupOldLogs(30)
+	if err != nil {
+		t.Fatalf("CleanupOldLogs() error = %v", err)
+	}
+	if purged != 2 {
+		t.Errorf("purged = %d, want 2", purged)
+	}
+
+	var remaining []models.RequestLog
+	db.Order("model").Find(&remaining)
+	if len(remaining) != 2 || remaining[0].Model != "recent-1" || remaining[1].Model != "recent-2" {
+		t.Errorf("remaining logs = %+v, want recent-1 and recent-2", remaining)
+	}
+}
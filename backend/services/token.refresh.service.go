@@ -1,11 +1,13 @@
 package services
 
 import (
+	"aigateway-backend/auth/oauth"
 	"aigateway-backend/models"
 	"aigateway-backend/providers/antigravity"
 	"aigateway-backend/repositories"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -104,6 +106,11 @@ func (s *TokenRefreshService) refreshAccount(account *models.Account) error {
 		}
 
 		newToken, expiresAt, err := s.doRefresh(refreshToken)
+		if errors.Is(err, oauth.ErrNoAccessToken) {
+			// Not transient: retrying returns the same empty grant
+			deactivateForReauth(s.accountRepo, account.ID, err)
+			return err
+		}
 		if err != nil {
 			lastErr = err
 			continue
@@ -163,8 +170,13 @@ func (s *TokenRefreshService) doRefresh(refreshToken string) (string, time.Time,
 		return "", time.Time{}, err
 	}
 
+	lifetime, err := oauth.ValidateTokenResponse(tokenResp.AccessToken, tokenResp.ExpiresIn)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
 	// Always use UTC for consistent timezone handling
-	expiresAt := time.Now().UTC().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	expiresAt := time.Now().UTC().Add(lifetime)
 	return tokenResp.AccessToken, expiresAt, nil
 }
 